@@ -0,0 +1,52 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCommand returns the cobra command for "config".
+func NewConfigCommand() *cobra.Command {
+	cc := &cobra.Command{
+		Use:   "config <subcommand>",
+		Short: "Server configuration related commands",
+	}
+
+	cc.AddCommand(newConfigReloadCommand())
+
+	return cc
+}
+
+func newConfigReloadCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reload",
+		Short: "Reloads server configuration file, applying all reloadable changes",
+		Run:   configReloadCommandFunc,
+	}
+}
+
+// configReloadCommandFunc executes the "config reload" command.
+func configReloadCommandFunc(cmd *cobra.Command, _ []string) {
+	cc, ctx, cancel := mustConfigClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.ReloadConfig(ctx, &adminpb.ReloadConfigRequest{})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.ReloadConfig(resp)
+}
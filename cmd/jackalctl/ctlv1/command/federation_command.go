@@ -0,0 +1,186 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+// NewFederationCommand returns the cobra command for "federation".
+func NewFederationCommand() *cobra.Command {
+	fc := &cobra.Command{
+		Use:   "federation <subcommand>",
+		Short: "S2S federation policy related commands",
+	}
+
+	fc.AddCommand(newFederationShowCommand())
+	fc.AddCommand(newFederationAllowCommand())
+	fc.AddCommand(newFederationDisallowCommand())
+	fc.AddCommand(newFederationDenyCommand())
+	fc.AddCommand(newFederationUndenyCommand())
+	fc.AddCommand(newFederationShapeCommand())
+	fc.AddCommand(newFederationUnshapeCommand())
+
+	return fc
+}
+
+func newFederationShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Shows current federation policy",
+		Run:   federationShowCommandFunc,
+	}
+}
+
+func newFederationAllowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "allow <domain pattern>",
+		Short: "Adds a domain pattern to the federation allow list",
+		Run: federationUpdateCommandFunc("allow", 1, func(p *adminpb.FederationPolicy, args []string) {
+			p.Allow = appendUnique(p.Allow, args[0])
+		}),
+	}
+}
+
+func newFederationDisallowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "disallow <domain pattern>",
+		Short: "Removes a domain pattern from the federation allow list",
+		Run: federationUpdateCommandFunc("disallow", 1, func(p *adminpb.FederationPolicy, args []string) {
+			p.Allow = remove(p.Allow, args[0])
+		}),
+	}
+}
+
+func newFederationDenyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "deny <domain pattern>",
+		Short: "Adds a domain pattern to the federation deny list",
+		Run: federationUpdateCommandFunc("deny", 1, func(p *adminpb.FederationPolicy, args []string) {
+			p.Deny = appendUnique(p.Deny, args[0])
+		}),
+	}
+}
+
+func newFederationUndenyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undeny <domain pattern>",
+		Short: "Removes a domain pattern from the federation deny list",
+		Run: federationUpdateCommandFunc("undeny", 1, func(p *adminpb.FederationPolicy, args []string) {
+			p.Deny = remove(p.Deny, args[0])
+		}),
+	}
+}
+
+func newFederationShapeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "shape <domain pattern> <shaper name>",
+		Short: "Applies a shaper to a domain pattern connections",
+		Run: federationUpdateCommandFunc("shape", 2, func(p *adminpb.FederationPolicy, args []string) {
+			for _, rule := range p.Shaping {
+				if rule.Domain == args[0] {
+					rule.Shaper = args[1]
+					return
+				}
+			}
+			p.Shaping = append(p.Shaping, &adminpb.FederationShapingRule{Domain: args[0], Shaper: args[1]})
+		}),
+	}
+}
+
+func newFederationUnshapeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unshape <domain pattern>",
+		Short: "Removes a domain pattern shaping rule",
+		Run: federationUpdateCommandFunc("unshape", 1, func(p *adminpb.FederationPolicy, args []string) {
+			var shaping []*adminpb.FederationShapingRule
+			for _, rule := range p.Shaping {
+				if rule.Domain != args[0] {
+					shaping = append(shaping, rule)
+				}
+			}
+			p.Shaping = shaping
+		}),
+	}
+}
+
+// federationShowCommandFunc executes the "federation show" command.
+func federationShowCommandFunc(cmd *cobra.Command, _ []string) {
+	cc, ctx, cancel := mustFederationClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.GetFederationPolicy(ctx, &adminpb.GetFederationPolicyRequest{})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.FederationPolicy(resp.GetPolicy())
+}
+
+// federationUpdateCommandFunc returns a command function that updates current federation policy by means of updateFn.
+func federationUpdateCommandFunc(name string, nArgs int, updateFn func(p *adminpb.FederationPolicy, args []string)) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if len(args) != nArgs {
+			ExitWithError(ExitBadArgs, fmt.Errorf("federation %s command requires %d argument(s)", name, nArgs))
+		}
+		cc, ctx, cancel := mustFederationClientFromCmd(cmd)
+		defer cancel()
+
+		policy, err := getFederationPolicy(ctx, cc)
+		if err != nil {
+			ExitWithError(ExitError, err)
+		}
+		updateFn(policy, args)
+
+		resp, err := cc.UpdateFederationPolicy(ctx, &adminpb.UpdateFederationPolicyRequest{Policy: policy})
+		if err != nil {
+			ExitWithError(ExitError, err)
+		}
+		display.UpdateFederationPolicy(resp)
+	}
+}
+
+func getFederationPolicy(ctx context.Context, cc adminpb.FederationClient) (*adminpb.FederationPolicy, error) {
+	resp, err := cc.GetFederationPolicy(ctx, &adminpb.GetFederationPolicyRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetPolicy() == nil {
+		return &adminpb.FederationPolicy{}, nil
+	}
+	return resp.GetPolicy(), nil
+}
+
+func appendUnique(ss []string, s string) []string {
+	for _, v := range ss {
+		if v == s {
+			return ss
+		}
+	}
+	return append(ss, s)
+}
+
+func remove(ss []string, s string) []string {
+	var ret []string
+	for _, v := range ss {
+		if v != s {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
@@ -57,6 +57,24 @@ func mustUsersClientFromCmd(cmd *cobra.Command) (adminpb.UsersClient, context.Co
 	return adminpb.NewUsersClient(conn), ctx, cancel
 }
 
+func mustFederationClientFromCmd(cmd *cobra.Command) (adminpb.FederationClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewFederationClient(conn), ctx, cancel
+}
+
+func mustHostsClientFromCmd(cmd *cobra.Command) (adminpb.HostsClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewHostsClient(conn), ctx, cancel
+}
+
+func mustConfigClientFromCmd(cmd *cobra.Command) (adminpb.ConfigClient, context.Context, context.CancelFunc) {
+	conn := connFromCmd(cmd)
+	ctx, cancel := commandCtx(cmd)
+	return adminpb.NewConfigClient(conn), ctx, cancel
+}
+
 func initDisplayFromCmd(cmd *cobra.Command) {
 	display = &simplePrinter{}
 }
@@ -0,0 +1,169 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hostCertFile string
+	hostKeyFile  string
+)
+
+// NewHostCommand returns the cobra command for "host".
+func NewHostCommand() *cobra.Command {
+	hc := &cobra.Command{
+		Use:   "host <subcommand>",
+		Short: "Virtual host related commands",
+	}
+
+	hc.AddCommand(newHostListCommand())
+	hc.AddCommand(newHostAddCommand())
+	hc.AddCommand(newHostUpdateCommand())
+	hc.AddCommand(newHostRemoveCommand())
+
+	return hc
+}
+
+func newHostListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists all virtual hosts",
+		Run:   hostListCommandFunc,
+	}
+}
+
+func newHostAddCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "add <domain> --cert <file> --key <file>",
+		Short: "Adds a new virtual host",
+		Run:   hostAddCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&hostCertFile, "cert", "", "PEM encoded certificate chain file")
+	cmd.Flags().StringVar(&hostKeyFile, "key", "", "PEM encoded private key file")
+
+	return &cmd
+}
+
+func newHostUpdateCommand() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "update <domain> --cert <file> --key <file>",
+		Short: "Replaces the certificate of a virtual host",
+		Run:   hostUpdateCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&hostCertFile, "cert", "", "PEM encoded certificate chain file")
+	cmd.Flags().StringVar(&hostKeyFile, "key", "", "PEM encoded private key file")
+
+	return &cmd
+}
+
+func newHostRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <domain>",
+		Short: "Removes a virtual host",
+		Run:   hostRemoveCommandFunc,
+	}
+}
+
+// hostListCommandFunc executes the "host list" command.
+func hostListCommandFunc(cmd *cobra.Command, _ []string) {
+	cc, ctx, cancel := mustHostsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.ListHosts(ctx, &adminpb.ListHostsRequest{})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.ListHosts(resp)
+}
+
+// hostAddCommandFunc executes the "host add" command.
+func hostAddCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("host add command requires domain as its argument"))
+	}
+	certPEM, keyPEM := mustReadHostCertificate()
+
+	cc, ctx, cancel := mustHostsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.AddHost(ctx, &adminpb.AddHostRequest{
+		Domain:        args[0],
+		CertPem:       certPEM,
+		PrivateKeyPem: keyPEM,
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.AddHost(args[0], resp)
+}
+
+// hostUpdateCommandFunc executes the "host update" command.
+func hostUpdateCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("host update command requires domain as its argument"))
+	}
+	certPEM, keyPEM := mustReadHostCertificate()
+
+	cc, ctx, cancel := mustHostsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.UpdateHost(ctx, &adminpb.UpdateHostRequest{
+		Domain:        args[0],
+		CertPem:       certPEM,
+		PrivateKeyPem: keyPEM,
+	})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.UpdateHost(args[0], resp)
+}
+
+// hostRemoveCommandFunc executes the "host remove" command.
+func hostRemoveCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("host remove command requires domain as its argument"))
+	}
+	cc, ctx, cancel := mustHostsClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.RemoveHost(ctx, &adminpb.RemoveHostRequest{Domain: args[0]})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.RemoveHost(args[0], resp)
+}
+
+func mustReadHostCertificate() (certPEM, keyPEM []byte) {
+	if len(hostCertFile) == 0 || len(hostKeyFile) == 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("both --cert and --key flags must be specified"))
+	}
+	certPEM, err := os.ReadFile(hostCertFile)
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	keyPEM, err = os.ReadFile(hostKeyFile)
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	return certPEM, keyPEM
+}
@@ -16,6 +16,7 @@ package command
 
 import (
 	"fmt"
+	"strings"
 
 	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
 )
@@ -24,6 +25,14 @@ type printer interface {
 	CreateUser(name string, _ *adminpb.CreateUserResponse)
 	ChangeUserPassword(*adminpb.ChangeUserPasswordResponse)
 	DeleteUser(string, *adminpb.DeleteUserResponse)
+	PepperReport(*adminpb.GetPepperReportResponse)
+	FederationPolicy(*adminpb.FederationPolicy)
+	UpdateFederationPolicy(*adminpb.UpdateFederationPolicyResponse)
+	ListHosts(*adminpb.ListHostsResponse)
+	AddHost(string, *adminpb.AddHostResponse)
+	UpdateHost(string, *adminpb.UpdateHostResponse)
+	RemoveHost(string, *adminpb.RemoveHostResponse)
+	ReloadConfig(*adminpb.ReloadConfigResponse)
 }
 
 type simplePrinter struct{}
@@ -39,3 +48,77 @@ func (p *simplePrinter) ChangeUserPassword(*adminpb.ChangeUserPasswordResponse)
 func (p *simplePrinter) DeleteUser(user string, _ *adminpb.DeleteUserResponse) {
 	fmt.Printf("User %s deleted\n", user)
 }
+
+func (p *simplePrinter) PepperReport(resp *adminpb.GetPepperReportResponse) {
+	for _, usage := range resp.GetPeppers() {
+		var flags []string
+		if usage.GetIsActive() {
+			flags = append(flags, "active")
+		}
+		if usage.GetPepperId() == "none" {
+			flags = append(flags, "unpeppered")
+		} else if !usage.GetIsConfigured() {
+			flags = append(flags, "unknown")
+		} else if !usage.GetIsActive() && usage.GetUserCount() == 0 {
+			flags = append(flags, "retirable")
+		}
+		fmt.Printf("%s\t%d\t%s\n", usage.GetPepperId(), usage.GetUserCount(), strings.Join(flags, ","))
+	}
+	fmt.Printf("iteration count: %d\n", resp.GetIterationCount())
+}
+
+func (p *simplePrinter) FederationPolicy(policy *adminpb.FederationPolicy) {
+	for _, domain := range policy.GetAllow() {
+		fmt.Printf("allow\t%s\n", domain)
+	}
+	for _, domain := range policy.GetDeny() {
+		fmt.Printf("deny\t%s\n", domain)
+	}
+	for _, rule := range policy.GetShaping() {
+		fmt.Printf("shape\t%s\t%s\n", rule.GetDomain(), rule.GetShaper())
+	}
+}
+
+func (p *simplePrinter) UpdateFederationPolicy(*adminpb.UpdateFederationPolicyResponse) {
+	fmt.Println("Federation policy updated")
+}
+
+func (p *simplePrinter) ListHosts(resp *adminpb.ListHostsResponse) {
+	for _, h := range resp.GetHosts() {
+		var flags []string
+		if h.GetIsDefault() {
+			flags = append(flags, "default")
+		}
+		if h.GetIsDynamic() {
+			flags = append(flags, "dynamic")
+		} else {
+			flags = append(flags, "static")
+		}
+		fmt.Printf("%s\t%s\n", h.GetDomain(), strings.Join(flags, ","))
+	}
+}
+
+func (p *simplePrinter) AddHost(domain string, _ *adminpb.AddHostResponse) {
+	fmt.Printf("Host %s added\n", domain)
+}
+
+func (p *simplePrinter) UpdateHost(domain string, _ *adminpb.UpdateHostResponse) {
+	fmt.Printf("Host %s updated\n", domain)
+}
+
+func (p *simplePrinter) RemoveHost(domain string, _ *adminpb.RemoveHostResponse) {
+	fmt.Printf("Host %s removed\n", domain)
+}
+
+func (p *simplePrinter) ReloadConfig(resp *adminpb.ReloadConfigResponse) {
+	if len(resp.GetApplied()) == 0 && len(resp.GetNonReloadable()) == 0 {
+		fmt.Println("Configuration unchanged")
+		return
+	}
+	for _, section := range resp.GetApplied() {
+		fmt.Printf("applied\t%s\n", section)
+	}
+	for _, section := range resp.GetNonReloadable() {
+		fmt.Printf("restart required\t%s\n", section)
+	}
+}
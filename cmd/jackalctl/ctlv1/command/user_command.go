@@ -40,6 +40,7 @@ func NewUserCommand() *cobra.Command {
 	ac.AddCommand(newUserAddCommand())
 	ac.AddCommand(newUserChangePasswordCommand())
 	ac.AddCommand(newUserDeleteCommand())
+	ac.AddCommand(newUserPeppersCommand())
 
 	return ac
 }
@@ -77,6 +78,14 @@ func newUserDeleteCommand() *cobra.Command {
 	}
 }
 
+func newUserPeppersCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "peppers",
+		Short: "Shows how many users are bound to each pepper key",
+		Run:   userPeppersCommandFunc,
+	}
+}
+
 // userAddCommandFunc executes the "user add" command.
 func userAddCommandFunc(cmd *cobra.Command, args []string) {
 	if len(args) != 1 {
@@ -163,6 +172,21 @@ func userDeleteCommandFunc(cmd *cobra.Command, args []string) {
 	display.DeleteUser(username, resp)
 }
 
+// userPeppersCommandFunc executes the "user peppers" command.
+func userPeppersCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("user peppers command does not accept any argument"))
+	}
+	cc, ctx, cancel := mustUsersClientFromCmd(cmd)
+	defer cancel()
+
+	resp, err := cc.GetPepperReport(ctx, &adminpb.GetPepperReportRequest{})
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.PepperReport(resp)
+}
+
 func readPasswordInteractive(name string) string {
 	prompt1 := fmt.Sprintf("Password of %s: ", name)
 	password1, err1 := speakeasy.Ask(prompt1)
@@ -51,6 +51,9 @@ func init() {
 
 	rootCmd.AddCommand(
 		command.NewUserCommand(),
+		command.NewFederationCommand(),
+		command.NewHostCommand(),
+		command.NewConfigCommand(),
 		command.NewVersionCommand(),
 	)
 }
@@ -0,0 +1,521 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.26.0
+// 	protoc        v3.21.5
+// source: proto/admin/v1/federation.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// FederationPolicy represents the set of rules applied to federated domains.
+type FederationPolicy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// allow, if not empty, restricts federation to the matching remote domains (wildcards allowed).
+	Allow []string `protobuf:"bytes,1,rep,name=allow,proto3" json:"allow,omitempty"`
+	// deny contains the set of blocked remote domains (wildcards allowed).
+	Deny []string `protobuf:"bytes,2,rep,name=deny,proto3" json:"deny,omitempty"`
+	// shaping contains per remote domain traffic shaping rules.
+	Shaping []*FederationShapingRule `protobuf:"bytes,3,rep,name=shaping,proto3" json:"shaping,omitempty"`
+}
+
+func (x *FederationPolicy) Reset() {
+	*x = FederationPolicy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_federation_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FederationPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FederationPolicy) ProtoMessage() {}
+
+func (x *FederationPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_federation_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FederationPolicy.ProtoReflect.Descriptor instead.
+func (*FederationPolicy) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_federation_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FederationPolicy) GetAllow() []string {
+	if x != nil {
+		return x.Allow
+	}
+	return nil
+}
+
+func (x *FederationPolicy) GetDeny() []string {
+	if x != nil {
+		return x.Deny
+	}
+	return nil
+}
+
+func (x *FederationPolicy) GetShaping() []*FederationShapingRule {
+	if x != nil {
+		return x.Shaping
+	}
+	return nil
+}
+
+// FederationShapingRule associates a shaper to a set of remote domains.
+type FederationShapingRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// domain is the remote domain name (wildcards allowed).
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	// shaper is the name of the shaper applied to domain connections.
+	Shaper string `protobuf:"bytes,2,opt,name=shaper,proto3" json:"shaper,omitempty"`
+}
+
+func (x *FederationShapingRule) Reset() {
+	*x = FederationShapingRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_federation_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FederationShapingRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FederationShapingRule) ProtoMessage() {}
+
+func (x *FederationShapingRule) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_federation_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FederationShapingRule.ProtoReflect.Descriptor instead.
+func (*FederationShapingRule) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_federation_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FederationShapingRule) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *FederationShapingRule) GetShaper() string {
+	if x != nil {
+		return x.Shaper
+	}
+	return ""
+}
+
+// GetFederationPolicyRequest is the parameter message for GetFederationPolicy rpc.
+type GetFederationPolicyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetFederationPolicyRequest) Reset() {
+	*x = GetFederationPolicyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_federation_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetFederationPolicyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFederationPolicyRequest) ProtoMessage() {}
+
+func (x *GetFederationPolicyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_federation_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFederationPolicyRequest.ProtoReflect.Descriptor instead.
+func (*GetFederationPolicyRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_federation_proto_rawDescGZIP(), []int{2}
+}
+
+// GetFederationPolicyResponse is the response returned by GetFederationPolicy rpc.
+type GetFederationPolicyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// policy is the current federation policy.
+	Policy *FederationPolicy `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+}
+
+func (x *GetFederationPolicyResponse) Reset() {
+	*x = GetFederationPolicyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_federation_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetFederationPolicyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFederationPolicyResponse) ProtoMessage() {}
+
+func (x *GetFederationPolicyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_federation_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFederationPolicyResponse.ProtoReflect.Descriptor instead.
+func (*GetFederationPolicyResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_federation_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetFederationPolicyResponse) GetPolicy() *FederationPolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+// UpdateFederationPolicyRequest is the parameter message for UpdateFederationPolicy rpc.
+type UpdateFederationPolicyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// policy is the new federation policy.
+	Policy *FederationPolicy `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+}
+
+func (x *UpdateFederationPolicyRequest) Reset() {
+	*x = UpdateFederationPolicyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_federation_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateFederationPolicyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateFederationPolicyRequest) ProtoMessage() {}
+
+func (x *UpdateFederationPolicyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_federation_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateFederationPolicyRequest.ProtoReflect.Descriptor instead.
+func (*UpdateFederationPolicyRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_federation_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateFederationPolicyRequest) GetPolicy() *FederationPolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+// UpdateFederationPolicyResponse is the response returned by UpdateFederationPolicy rpc.
+type UpdateFederationPolicyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *UpdateFederationPolicyResponse) Reset() {
+	*x = UpdateFederationPolicyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_federation_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateFederationPolicyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateFederationPolicyResponse) ProtoMessage() {}
+
+func (x *UpdateFederationPolicyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_federation_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateFederationPolicyResponse.ProtoReflect.Descriptor instead.
+func (*UpdateFederationPolicyResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_federation_proto_rawDescGZIP(), []int{5}
+}
+
+var File_proto_admin_v1_federation_proto protoreflect.FileDescriptor
+
+var file_proto_admin_v1_federation_proto_rawDesc = []byte{
+	0x0a, 0x1f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2f, 0x76, 0x31,
+	0x2f, 0x66, 0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x08, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x22, 0x77, 0x0a, 0x10, 0x46,
+	0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05,
+	0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x65, 0x6e, 0x79, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x04, 0x64, 0x65, 0x6e, 0x79, 0x12, 0x39, 0x0a, 0x07, 0x73, 0x68, 0x61,
+	0x70, 0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x61, 0x64, 0x6d,
+	0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x53, 0x68, 0x61, 0x70, 0x69, 0x6e, 0x67, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x07, 0x73, 0x68, 0x61,
+	0x70, 0x69, 0x6e, 0x67, 0x22, 0x47, 0x0a, 0x15, 0x46, 0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x53, 0x68, 0x61, 0x70, 0x69, 0x6e, 0x67, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64,
+	0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x68, 0x61, 0x70, 0x65, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x68, 0x61, 0x70, 0x65, 0x72, 0x22, 0x1c, 0x0a,
+	0x1a, 0x47, 0x65, 0x74, 0x46, 0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f,
+	0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x51, 0x0a, 0x1b, 0x47,
+	0x65, 0x74, 0x46, 0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69,
+	0x63, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x06, 0x70, 0x6f,
+	0x6c, 0x69, 0x63, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x61, 0x64, 0x6d,
+	0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x22, 0x53,
+	0x0a, 0x1d, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x46, 0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x32, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x65, 0x64, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x06, 0x70, 0x6f, 0x6c,
+	0x69, 0x63, 0x79, 0x22, 0x20, 0x0a, 0x1e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x46, 0x65, 0x64,
+	0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xdd, 0x01, 0x0a, 0x0a, 0x46, 0x65, 0x64, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x62, 0x0a, 0x13, 0x47, 0x65, 0x74, 0x46, 0x65, 0x64, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x24, 0x2e, 0x61, 0x64,
+	0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x65, 0x64, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x25, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74,
+	0x46, 0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x6b, 0x0a, 0x16, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x46, 0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69,
+	0x63, 0x79, 0x12, 0x27, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x46, 0x65, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f,
+	0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x61, 0x64,
+	0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x46, 0x65, 0x64,
+	0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0e, 0x5a, 0x0c, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x64, 0x6d,
+	0x69, 0x6e, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_admin_v1_federation_proto_rawDescOnce sync.Once
+	file_proto_admin_v1_federation_proto_rawDescData = file_proto_admin_v1_federation_proto_rawDesc
+)
+
+func file_proto_admin_v1_federation_proto_rawDescGZIP() []byte {
+	file_proto_admin_v1_federation_proto_rawDescOnce.Do(func() {
+		file_proto_admin_v1_federation_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_admin_v1_federation_proto_rawDescData)
+	})
+	return file_proto_admin_v1_federation_proto_rawDescData
+}
+
+var file_proto_admin_v1_federation_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_admin_v1_federation_proto_goTypes = []interface{}{
+	(*FederationPolicy)(nil),               // 0: admin.v1.FederationPolicy
+	(*FederationShapingRule)(nil),          // 1: admin.v1.FederationShapingRule
+	(*GetFederationPolicyRequest)(nil),     // 2: admin.v1.GetFederationPolicyRequest
+	(*GetFederationPolicyResponse)(nil),    // 3: admin.v1.GetFederationPolicyResponse
+	(*UpdateFederationPolicyRequest)(nil),  // 4: admin.v1.UpdateFederationPolicyRequest
+	(*UpdateFederationPolicyResponse)(nil), // 5: admin.v1.UpdateFederationPolicyResponse
+}
+var file_proto_admin_v1_federation_proto_depIdxs = []int32{
+	1, // 0: admin.v1.FederationPolicy.shaping:type_name -> admin.v1.FederationShapingRule
+	0, // 1: admin.v1.GetFederationPolicyResponse.policy:type_name -> admin.v1.FederationPolicy
+	0, // 2: admin.v1.UpdateFederationPolicyRequest.policy:type_name -> admin.v1.FederationPolicy
+	2, // 3: admin.v1.Federation.GetFederationPolicy:input_type -> admin.v1.GetFederationPolicyRequest
+	4, // 4: admin.v1.Federation.UpdateFederationPolicy:input_type -> admin.v1.UpdateFederationPolicyRequest
+	3, // 5: admin.v1.Federation.GetFederationPolicy:output_type -> admin.v1.GetFederationPolicyResponse
+	5, // 6: admin.v1.Federation.UpdateFederationPolicy:output_type -> admin.v1.UpdateFederationPolicyResponse
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_proto_admin_v1_federation_proto_init() }
+func file_proto_admin_v1_federation_proto_init() {
+	if File_proto_admin_v1_federation_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_admin_v1_federation_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FederationPolicy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_federation_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FederationShapingRule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_federation_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetFederationPolicyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_federation_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetFederationPolicyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_federation_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdateFederationPolicyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_federation_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdateFederationPolicyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_admin_v1_federation_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_admin_v1_federation_proto_goTypes,
+		DependencyIndexes: file_proto_admin_v1_federation_proto_depIdxs,
+		MessageInfos:      file_proto_admin_v1_federation_proto_msgTypes,
+	}.Build()
+	File_proto_admin_v1_federation_proto = out.File
+	file_proto_admin_v1_federation_proto_rawDesc = nil
+	file_proto_admin_v1_federation_proto_goTypes = nil
+	file_proto_admin_v1_federation_proto_depIdxs = nil
+}
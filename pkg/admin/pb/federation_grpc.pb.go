@@ -0,0 +1,157 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// FederationClient is the client API for Federation service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FederationClient interface {
+	// GetFederationPolicy returns the S2S federation policy currently in use.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - UNAVAILABLE(14): When S2S federation is not enabled.
+	GetFederationPolicy(ctx context.Context, in *GetFederationPolicyRequest, opts ...grpc.CallOption) (*GetFederationPolicyResponse, error)
+	// UpdateFederationPolicy replaces the S2S federation policy, closing all existing
+	// connections with remote domains no longer allowed.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When policy contains a malformed domain pattern or references an unknown shaper.
+	// - UNAVAILABLE(14): When S2S federation is not enabled.
+	UpdateFederationPolicy(ctx context.Context, in *UpdateFederationPolicyRequest, opts ...grpc.CallOption) (*UpdateFederationPolicyResponse, error)
+}
+
+type federationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFederationClient(cc grpc.ClientConnInterface) FederationClient {
+	return &federationClient{cc}
+}
+
+func (c *federationClient) GetFederationPolicy(ctx context.Context, in *GetFederationPolicyRequest, opts ...grpc.CallOption) (*GetFederationPolicyResponse, error) {
+	out := new(GetFederationPolicyResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Federation/GetFederationPolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *federationClient) UpdateFederationPolicy(ctx context.Context, in *UpdateFederationPolicyRequest, opts ...grpc.CallOption) (*UpdateFederationPolicyResponse, error) {
+	out := new(UpdateFederationPolicyResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Federation/UpdateFederationPolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FederationServer is the server API for Federation service.
+// All implementations must embed UnimplementedFederationServer
+// for forward compatibility
+type FederationServer interface {
+	// GetFederationPolicy returns the S2S federation policy currently in use.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - UNAVAILABLE(14): When S2S federation is not enabled.
+	GetFederationPolicy(context.Context, *GetFederationPolicyRequest) (*GetFederationPolicyResponse, error)
+	// UpdateFederationPolicy replaces the S2S federation policy, closing all existing
+	// connections with remote domains no longer allowed.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When policy contains a malformed domain pattern or references an unknown shaper.
+	// - UNAVAILABLE(14): When S2S federation is not enabled.
+	UpdateFederationPolicy(context.Context, *UpdateFederationPolicyRequest) (*UpdateFederationPolicyResponse, error)
+	mustEmbedUnimplementedFederationServer()
+}
+
+// UnimplementedFederationServer must be embedded to have forward compatible implementations.
+type UnimplementedFederationServer struct {
+}
+
+func (UnimplementedFederationServer) GetFederationPolicy(context.Context, *GetFederationPolicyRequest) (*GetFederationPolicyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFederationPolicy not implemented")
+}
+func (UnimplementedFederationServer) UpdateFederationPolicy(context.Context, *UpdateFederationPolicyRequest) (*UpdateFederationPolicyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateFederationPolicy not implemented")
+}
+func (UnimplementedFederationServer) mustEmbedUnimplementedFederationServer() {}
+
+// UnsafeFederationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FederationServer will
+// result in compilation errors.
+type UnsafeFederationServer interface {
+	mustEmbedUnimplementedFederationServer()
+}
+
+func RegisterFederationServer(s grpc.ServiceRegistrar, srv FederationServer) {
+	s.RegisterService(&Federation_ServiceDesc, srv)
+}
+
+func _Federation_GetFederationPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFederationPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FederationServer).GetFederationPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Federation/GetFederationPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FederationServer).GetFederationPolicy(ctx, req.(*GetFederationPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Federation_UpdateFederationPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateFederationPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FederationServer).UpdateFederationPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Federation/UpdateFederationPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FederationServer).UpdateFederationPolicy(ctx, req.(*UpdateFederationPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Federation_ServiceDesc is the grpc.ServiceDesc for Federation service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Federation_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.v1.Federation",
+	HandlerType: (*FederationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetFederationPolicy",
+			Handler:    _Federation_GetFederationPolicy_Handler,
+		},
+		{
+			MethodName: "UpdateFederationPolicy",
+			Handler:    _Federation_UpdateFederationPolicy_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin/v1/federation.proto",
+}
@@ -0,0 +1,243 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// HostsClient is the client API for Hosts service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type HostsClient interface {
+	// ListHosts returns all local virtual hosts.
+	ListHosts(ctx context.Context, in *ListHostsRequest, opts ...grpc.CallOption) (*ListHostsResponse, error)
+	// AddHost registers a new virtual host on every cluster member.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When domain is malformed or certificate is not valid for it.
+	// - ALREADY_EXISTS(6): When host is already registered.
+	AddHost(ctx context.Context, in *AddHostRequest, opts ...grpc.CallOption) (*AddHostResponse, error)
+	// UpdateHost replaces the certificate of a virtual host defined at runtime.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When certificate is not valid for host domain.
+	// - NOT_FOUND(5): When host is not registered.
+	// - FAILED_PRECONDITION(9): When host is defined in configuration file.
+	UpdateHost(ctx context.Context, in *UpdateHostRequest, opts ...grpc.CallOption) (*UpdateHostResponse, error)
+	// RemoveHost unregisters a virtual host defined at runtime from every cluster member.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5): When host is not registered.
+	// - FAILED_PRECONDITION(9): When host is defined in configuration file.
+	RemoveHost(ctx context.Context, in *RemoveHostRequest, opts ...grpc.CallOption) (*RemoveHostResponse, error)
+}
+
+type hostsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHostsClient(cc grpc.ClientConnInterface) HostsClient {
+	return &hostsClient{cc}
+}
+
+func (c *hostsClient) ListHosts(ctx context.Context, in *ListHostsRequest, opts ...grpc.CallOption) (*ListHostsResponse, error) {
+	out := new(ListHostsResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Hosts/ListHosts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostsClient) AddHost(ctx context.Context, in *AddHostRequest, opts ...grpc.CallOption) (*AddHostResponse, error) {
+	out := new(AddHostResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Hosts/AddHost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostsClient) UpdateHost(ctx context.Context, in *UpdateHostRequest, opts ...grpc.CallOption) (*UpdateHostResponse, error) {
+	out := new(UpdateHostResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Hosts/UpdateHost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostsClient) RemoveHost(ctx context.Context, in *RemoveHostRequest, opts ...grpc.CallOption) (*RemoveHostResponse, error) {
+	out := new(RemoveHostResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Hosts/RemoveHost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HostsServer is the server API for Hosts service.
+// All implementations must embed UnimplementedHostsServer
+// for forward compatibility
+type HostsServer interface {
+	// ListHosts returns all local virtual hosts.
+	ListHosts(context.Context, *ListHostsRequest) (*ListHostsResponse, error)
+	// AddHost registers a new virtual host on every cluster member.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When domain is malformed or certificate is not valid for it.
+	// - ALREADY_EXISTS(6): When host is already registered.
+	AddHost(context.Context, *AddHostRequest) (*AddHostResponse, error)
+	// UpdateHost replaces the certificate of a virtual host defined at runtime.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INVALID_ARGUMENT(3): When certificate is not valid for host domain.
+	// - NOT_FOUND(5): When host is not registered.
+	// - FAILED_PRECONDITION(9): When host is defined in configuration file.
+	UpdateHost(context.Context, *UpdateHostRequest) (*UpdateHostResponse, error)
+	// RemoveHost unregisters a virtual host defined at runtime from every cluster member.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - NOT_FOUND(5): When host is not registered.
+	// - FAILED_PRECONDITION(9): When host is defined in configuration file.
+	RemoveHost(context.Context, *RemoveHostRequest) (*RemoveHostResponse, error)
+	mustEmbedUnimplementedHostsServer()
+}
+
+// UnimplementedHostsServer must be embedded to have forward compatible implementations.
+type UnimplementedHostsServer struct {
+}
+
+func (UnimplementedHostsServer) ListHosts(context.Context, *ListHostsRequest) (*ListHostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListHosts not implemented")
+}
+func (UnimplementedHostsServer) AddHost(context.Context, *AddHostRequest) (*AddHostResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddHost not implemented")
+}
+func (UnimplementedHostsServer) UpdateHost(context.Context, *UpdateHostRequest) (*UpdateHostResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateHost not implemented")
+}
+func (UnimplementedHostsServer) RemoveHost(context.Context, *RemoveHostRequest) (*RemoveHostResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveHost not implemented")
+}
+func (UnimplementedHostsServer) mustEmbedUnimplementedHostsServer() {}
+
+// UnsafeHostsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HostsServer will
+// result in compilation errors.
+type UnsafeHostsServer interface {
+	mustEmbedUnimplementedHostsServer()
+}
+
+func RegisterHostsServer(s grpc.ServiceRegistrar, srv HostsServer) {
+	s.RegisterService(&Hosts_ServiceDesc, srv)
+}
+
+func _Hosts_ListHosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListHostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostsServer).ListHosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Hosts/ListHosts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostsServer).ListHosts(ctx, req.(*ListHostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hosts_AddHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostsServer).AddHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Hosts/AddHost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostsServer).AddHost(ctx, req.(*AddHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hosts_UpdateHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostsServer).UpdateHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Hosts/UpdateHost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostsServer).UpdateHost(ctx, req.(*UpdateHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hosts_RemoveHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostsServer).RemoveHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Hosts/RemoveHost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostsServer).RemoveHost(ctx, req.(*RemoveHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Hosts_ServiceDesc is the grpc.ServiceDesc for Hosts service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Hosts_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.v1.Hosts",
+	HandlerType: (*HostsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListHosts",
+			Handler:    _Hosts_ListHosts_Handler,
+		},
+		{
+			MethodName: "AddHost",
+			Handler:    _Hosts_AddHost_Handler,
+		},
+		{
+			MethodName: "UpdateHost",
+			Handler:    _Hosts_UpdateHost_Handler,
+		},
+		{
+			MethodName: "RemoveHost",
+			Handler:    _Hosts_RemoveHost_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/admin/v1/hosts.proto",
+}
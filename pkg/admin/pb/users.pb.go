@@ -316,6 +316,179 @@ func (*DeleteUserResponse) Descriptor() ([]byte, []int) {
 	return file_proto_admin_v1_users_proto_rawDescGZIP(), []int{5}
 }
 
+// PepperUsage describes how many users are bound to a pepper key.
+type PepperUsage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// pepper_id is the pepper key identifier, or "none" for credentials derived without a pepper key.
+	PepperId string `protobuf:"bytes,1,opt,name=pepper_id,json=pepperId,proto3" json:"pepper_id,omitempty"`
+	// user_count is the number of users whose credentials were derived using this pepper key.
+	UserCount int64 `protobuf:"varint,2,opt,name=user_count,json=userCount,proto3" json:"user_count,omitempty"`
+	// is_active tells whether this is the pepper key used to derive new credentials.
+	IsActive bool `protobuf:"varint,3,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	// is_configured tells whether this pepper key is present in the server configuration.
+	IsConfigured bool `protobuf:"varint,4,opt,name=is_configured,json=isConfigured,proto3" json:"is_configured,omitempty"`
+}
+
+func (x *PepperUsage) Reset() {
+	*x = PepperUsage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_users_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PepperUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PepperUsage) ProtoMessage() {}
+
+func (x *PepperUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_users_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PepperUsage.ProtoReflect.Descriptor instead.
+func (*PepperUsage) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_users_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PepperUsage) GetPepperId() string {
+	if x != nil {
+		return x.PepperId
+	}
+	return ""
+}
+
+func (x *PepperUsage) GetUserCount() int64 {
+	if x != nil {
+		return x.UserCount
+	}
+	return 0
+}
+
+func (x *PepperUsage) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *PepperUsage) GetIsConfigured() bool {
+	if x != nil {
+		return x.IsConfigured
+	}
+	return false
+}
+
+// GetPepperReportRequest is the parameter message for GetPepperReport rpc.
+type GetPepperReportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetPepperReportRequest) Reset() {
+	*x = GetPepperReportRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_users_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPepperReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPepperReportRequest) ProtoMessage() {}
+
+func (x *GetPepperReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_users_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPepperReportRequest.ProtoReflect.Descriptor instead.
+func (*GetPepperReportRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_users_proto_rawDescGZIP(), []int{7}
+}
+
+// GetPepperReportResponse is the response returned by GetPepperReport rpc.
+type GetPepperReportResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// peppers contains the usage of every configured or referenced pepper key.
+	Peppers []*PepperUsage `protobuf:"bytes,1,rep,name=peppers,proto3" json:"peppers,omitempty"`
+	// iteration_count is the iteration count used to derive new credentials.
+	IterationCount int64 `protobuf:"varint,2,opt,name=iteration_count,json=iterationCount,proto3" json:"iteration_count,omitempty"`
+}
+
+func (x *GetPepperReportResponse) Reset() {
+	*x = GetPepperReportResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_admin_v1_users_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPepperReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPepperReportResponse) ProtoMessage() {}
+
+func (x *GetPepperReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_v1_users_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPepperReportResponse.ProtoReflect.Descriptor instead.
+func (*GetPepperReportResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_v1_users_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetPepperReportResponse) GetPeppers() []*PepperUsage {
+	if x != nil {
+		return x.Peppers
+	}
+	return nil
+}
+
+func (x *GetPepperReportResponse) GetIterationCount() int64 {
+	if x != nil {
+		return x.IterationCount
+	}
+	return 0
+}
+
 var File_proto_admin_v1_users_proto protoreflect.FileDescriptor
 
 var file_proto_admin_v1_users_proto_rawDesc = []byte{
@@ -339,24 +512,48 @@ var file_proto_admin_v1_users_proto_rawDesc = []byte{
 	0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72,
 	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72,
 	0x6e, 0x61, 0x6d, 0x65, 0x22, 0x14, 0x0a, 0x12, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x55, 0x73,
-	0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xfa, 0x01, 0x0a, 0x05, 0x55,
-	0x73, 0x65, 0x72, 0x73, 0x12, 0x47, 0x0a, 0x0a, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x55, 0x73,
-	0x65, 0x72, 0x12, 0x1b, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72,
-	0x65, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x1c, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74,
-	0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5f, 0x0a,
-	0x12, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x55, 0x73, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77,
-	0x6f, 0x72, 0x64, 0x12, 0x23, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43,
-	0x68, 0x61, 0x6e, 0x67, 0x65, 0x55, 0x73, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
-	0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e,
-	0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x55, 0x73, 0x65, 0x72, 0x50, 0x61,
-	0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x47,
-	0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x1b, 0x2e, 0x61,
-	0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x55, 0x73,
-	0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x61, 0x64, 0x6d, 0x69,
+	0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x8b, 0x01, 0x0a, 0x0b, 0x50,
+	0x65, 0x70, 0x70, 0x65, 0x72, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x65,
+	0x70, 0x70, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70,
+	0x65, 0x70, 0x70, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x75, 0x73, 0x65, 0x72, 0x5f,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x75, 0x73, 0x65,
+	0x72, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x73, 0x5f, 0x61, 0x63, 0x74,
+	0x69, 0x76, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x69, 0x73, 0x41, 0x63, 0x74,
+	0x69, 0x76, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x69, 0x73, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x75, 0x72, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x69, 0x73, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x65, 0x64, 0x22, 0x18, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x50,
+	0x65, 0x70, 0x70, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x22, 0x73, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x50, 0x65, 0x70, 0x70, 0x65, 0x72, 0x52,
+	0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2f, 0x0a,
+	0x07, 0x70, 0x65, 0x70, 0x70, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x65, 0x70, 0x70, 0x65, 0x72,
+	0x55, 0x73, 0x61, 0x67, 0x65, 0x52, 0x07, 0x70, 0x65, 0x70, 0x70, 0x65, 0x72, 0x73, 0x12, 0x27,
+	0x0a, 0x0f, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x32, 0xd2, 0x02, 0x0a, 0x05, 0x55, 0x73, 0x65, 0x72,
+	0x73, 0x12, 0x47, 0x0a, 0x0a, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12,
+	0x1b, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x61,
+	0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x55, 0x73,
+	0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5f, 0x0a, 0x12, 0x43, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x55, 0x73, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64,
+	0x12, 0x23, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68, 0x61, 0x6e,
+	0x67, 0x65, 0x55, 0x73, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76, 0x31,
+	0x2e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x55, 0x73, 0x65, 0x72, 0x50, 0x61, 0x73, 0x73, 0x77,
+	0x6f, 0x72, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x47, 0x0a, 0x0a, 0x44,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x1b, 0x2e, 0x61, 0x64, 0x6d, 0x69,
 	0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0e, 0x5a, 0x0c, 0x70, 0x6b, 0x67, 0x2f, 0x61,
-	0x64, 0x6d, 0x69, 0x6e, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x56, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x50, 0x65, 0x70, 0x70, 0x65,
+	0x72, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x20, 0x2e, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2e,
+	0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x65, 0x70, 0x70, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6f,
+	0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x61, 0x64, 0x6d, 0x69,
+	0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x65, 0x70, 0x70, 0x65, 0x72, 0x52, 0x65,
+	0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0e, 0x5a, 0x0c,
+	0x70, 0x6b, 0x67, 0x2f, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -371,7 +568,7 @@ func file_proto_admin_v1_users_proto_rawDescGZIP() []byte {
 	return file_proto_admin_v1_users_proto_rawDescData
 }
 
-var file_proto_admin_v1_users_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_admin_v1_users_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_proto_admin_v1_users_proto_goTypes = []interface{}{
 	(*CreateUserRequest)(nil),          // 0: admin.v1.CreateUserRequest
 	(*CreateUserResponse)(nil),         // 1: admin.v1.CreateUserResponse
@@ -379,19 +576,25 @@ var file_proto_admin_v1_users_proto_goTypes = []interface{}{
 	(*ChangeUserPasswordResponse)(nil), // 3: admin.v1.ChangeUserPasswordResponse
 	(*DeleteUserRequest)(nil),          // 4: admin.v1.DeleteUserRequest
 	(*DeleteUserResponse)(nil),         // 5: admin.v1.DeleteUserResponse
+	(*PepperUsage)(nil),                // 6: admin.v1.PepperUsage
+	(*GetPepperReportRequest)(nil),     // 7: admin.v1.GetPepperReportRequest
+	(*GetPepperReportResponse)(nil),    // 8: admin.v1.GetPepperReportResponse
 }
 var file_proto_admin_v1_users_proto_depIdxs = []int32{
-	0, // 0: admin.v1.Users.CreateUser:input_type -> admin.v1.CreateUserRequest
-	2, // 1: admin.v1.Users.ChangeUserPassword:input_type -> admin.v1.ChangeUserPasswordRequest
-	4, // 2: admin.v1.Users.DeleteUser:input_type -> admin.v1.DeleteUserRequest
-	1, // 3: admin.v1.Users.CreateUser:output_type -> admin.v1.CreateUserResponse
-	3, // 4: admin.v1.Users.ChangeUserPassword:output_type -> admin.v1.ChangeUserPasswordResponse
-	5, // 5: admin.v1.Users.DeleteUser:output_type -> admin.v1.DeleteUserResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	6, // 0: admin.v1.GetPepperReportResponse.peppers:type_name -> admin.v1.PepperUsage
+	0, // 1: admin.v1.Users.CreateUser:input_type -> admin.v1.CreateUserRequest
+	2, // 2: admin.v1.Users.ChangeUserPassword:input_type -> admin.v1.ChangeUserPasswordRequest
+	4, // 3: admin.v1.Users.DeleteUser:input_type -> admin.v1.DeleteUserRequest
+	7, // 4: admin.v1.Users.GetPepperReport:input_type -> admin.v1.GetPepperReportRequest
+	1, // 5: admin.v1.Users.CreateUser:output_type -> admin.v1.CreateUserResponse
+	3, // 6: admin.v1.Users.ChangeUserPassword:output_type -> admin.v1.ChangeUserPasswordResponse
+	5, // 7: admin.v1.Users.DeleteUser:output_type -> admin.v1.DeleteUserResponse
+	8, // 8: admin.v1.Users.GetPepperReport:output_type -> admin.v1.GetPepperReportResponse
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_proto_admin_v1_users_proto_init() }
@@ -472,6 +675,42 @@ func file_proto_admin_v1_users_proto_init() {
 				return nil
 			}
 		}
+		file_proto_admin_v1_users_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PepperUsage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_users_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPepperReportRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_admin_v1_users_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPepperReportResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -479,7 +718,7 @@ func file_proto_admin_v1_users_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_admin_v1_users_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
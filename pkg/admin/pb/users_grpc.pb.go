@@ -36,6 +36,12 @@ type UsersClient interface {
 	// - NOT_FOUND(5):  When user does not exist.
 	// - INTERNAL(13): When an internal problem happens.
 	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error)
+	// GetPepperReport returns how many users have their credentials derived using each pepper key,
+	// so that keys no longer referenced by any user can be safely retired.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INTERNAL(13): When an internal problem happens.
+	GetPepperReport(ctx context.Context, in *GetPepperReportRequest, opts ...grpc.CallOption) (*GetPepperReportResponse, error)
 }
 
 type usersClient struct {
@@ -73,6 +79,15 @@ func (c *usersClient) DeleteUser(ctx context.Context, in *DeleteUserRequest, opt
 	return out, nil
 }
 
+func (c *usersClient) GetPepperReport(ctx context.Context, in *GetPepperReportRequest, opts ...grpc.CallOption) (*GetPepperReportResponse, error) {
+	out := new(GetPepperReportResponse)
+	err := c.cc.Invoke(ctx, "/admin.v1.Users/GetPepperReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UsersServer is the server API for Users service.
 // All implementations must embed UnimplementedUsersServer
 // for forward compatibility
@@ -95,6 +110,12 @@ type UsersServer interface {
 	// - NOT_FOUND(5):  When user does not exist.
 	// - INTERNAL(13): When an internal problem happens.
 	DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error)
+	// GetPepperReport returns how many users have their credentials derived using each pepper key,
+	// so that keys no longer referenced by any user can be safely retired.
+	//
+	// Return status codes (https://github.com/grpc/grpc/blob/master/doc/statuscodes.md):
+	// - INTERNAL(13): When an internal problem happens.
+	GetPepperReport(context.Context, *GetPepperReportRequest) (*GetPepperReportResponse, error)
 	mustEmbedUnimplementedUsersServer()
 }
 
@@ -111,6 +132,9 @@ func (UnimplementedUsersServer) ChangeUserPassword(context.Context, *ChangeUserP
 func (UnimplementedUsersServer) DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
 }
+func (UnimplementedUsersServer) GetPepperReport(context.Context, *GetPepperReportRequest) (*GetPepperReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPepperReport not implemented")
+}
 func (UnimplementedUsersServer) mustEmbedUnimplementedUsersServer() {}
 
 // UnsafeUsersServer may be embedded to opt out of forward compatibility for this service.
@@ -178,6 +202,24 @@ func _Users_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Users_GetPepperReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPepperReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).GetPepperReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.v1.Users/GetPepperReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).GetPepperReport(ctx, req.(*GetPepperReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Users_ServiceDesc is the grpc.ServiceDesc for Users service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -197,6 +239,10 @@ var Users_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUser",
 			Handler:    _Users_DeleteUser_Handler,
 		},
+		{
+			MethodName: "GetPepperReport",
+			Handler:    _Users_GetPepperReport_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/admin/v1/users.proto",
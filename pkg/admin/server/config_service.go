@@ -0,0 +1,58 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+
+	kitlog "github.com/go-kit/log"
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConfigReloader defines the interface in charge of reloading server configuration.
+type ConfigReloader interface {
+	// ReloadConfig re-reads configuration file, applying all reloadable changes.
+	// Names of the changed sections are returned split by whether they could be applied or not.
+	ReloadConfig(ctx context.Context) (applied, nonReloadable []string, err error)
+}
+
+type configService struct {
+	adminpb.UnimplementedConfigServer
+	reloader ConfigReloader
+	logger   kitlog.Logger
+}
+
+func newConfigService(reloader ConfigReloader, logger kitlog.Logger) adminpb.ConfigServer {
+	return &configService{
+		reloader: reloader,
+		logger:   logger,
+	}
+}
+
+func (s *configService) ReloadConfig(ctx context.Context, _ *adminpb.ReloadConfigRequest) (*adminpb.ReloadConfigResponse, error) {
+	if s.reloader == nil {
+		return nil, status.Error(codes.Unavailable, "configuration reload not available")
+	}
+	applied, nonReloadable, err := s.reloader.ReloadConfig(ctx)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &adminpb.ReloadConfigResponse{
+		Applied:       applied,
+		NonReloadable: nonReloadable,
+	}, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/s2s"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type federationService struct {
+	adminpb.UnimplementedFederationServer
+	fedPolicy *s2s.FederationPolicy
+	logger    kitlog.Logger
+}
+
+func newFederationService(fedPolicy *s2s.FederationPolicy, logger kitlog.Logger) adminpb.FederationServer {
+	return &federationService{
+		fedPolicy: fedPolicy,
+		logger:    logger,
+	}
+}
+
+func (s *federationService) GetFederationPolicy(_ context.Context, _ *adminpb.GetFederationPolicyRequest) (*adminpb.GetFederationPolicyResponse, error) {
+	if s.fedPolicy == nil {
+		return nil, status.Error(codes.Unavailable, "federation not enabled")
+	}
+	cfg := s.fedPolicy.Config()
+
+	policy := &adminpb.FederationPolicy{
+		Allow: cfg.Allow,
+		Deny:  cfg.Deny,
+	}
+	for _, shapingCfg := range cfg.Shaping {
+		policy.Shaping = append(policy.Shaping, &adminpb.FederationShapingRule{
+			Domain: shapingCfg.Domain,
+			Shaper: shapingCfg.Shaper,
+		})
+	}
+	return &adminpb.GetFederationPolicyResponse{Policy: policy}, nil
+}
+
+func (s *federationService) UpdateFederationPolicy(_ context.Context, req *adminpb.UpdateFederationPolicyRequest) (*adminpb.UpdateFederationPolicyResponse, error) {
+	if s.fedPolicy == nil {
+		return nil, status.Error(codes.Unavailable, "federation not enabled")
+	}
+	policy := req.GetPolicy()
+
+	cfg := s2s.FederationConfig{
+		Allow: policy.GetAllow(),
+		Deny:  policy.GetDeny(),
+	}
+	for _, rule := range policy.GetShaping() {
+		cfg.Shaping = append(cfg.Shaping, s2s.FederationShapingConfig{
+			Domain: rule.GetDomain(),
+			Shaper: rule.GetShaper(),
+		})
+	}
+	if err := s.fedPolicy.Override(cfg); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	level.Info(s.logger).Log("msg", "federation policy updated",
+		"allow", len(cfg.Allow),
+		"deny", len(cfg.Deny),
+		"shaping", len(cfg.Shaping),
+	)
+	return &adminpb.UpdateFederationPolicyResponse{}, nil
+}
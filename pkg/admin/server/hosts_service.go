@@ -0,0 +1,92 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"errors"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/host"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type hostsService struct {
+	adminpb.UnimplementedHostsServer
+	hostMng *host.KVManager
+	logger  kitlog.Logger
+}
+
+func newHostsService(hostMng *host.KVManager, logger kitlog.Logger) adminpb.HostsServer {
+	return &hostsService{
+		hostMng: hostMng,
+		logger:  logger,
+	}
+}
+
+func (s *hostsService) ListHosts(_ context.Context, _ *adminpb.ListHostsRequest) (*adminpb.ListHostsResponse, error) {
+	var resp adminpb.ListHostsResponse
+	for _, desc := range s.hostMng.Hosts() {
+		resp.Hosts = append(resp.Hosts, &adminpb.Host{
+			Domain:    desc.Domain,
+			IsDefault: desc.IsDefault,
+			IsDynamic: desc.IsDynamic,
+		})
+	}
+	return &resp, nil
+}
+
+func (s *hostsService) AddHost(ctx context.Context, req *adminpb.AddHostRequest) (*adminpb.AddHostResponse, error) {
+	if err := s.hostMng.AddHost(ctx, req.GetDomain(), req.GetCertPem(), req.GetPrivateKeyPem()); err != nil {
+		return nil, hostError(err)
+	}
+	level.Info(s.logger).Log("msg", "virtual host added", "domain", req.GetDomain())
+	return &adminpb.AddHostResponse{}, nil
+}
+
+func (s *hostsService) UpdateHost(ctx context.Context, req *adminpb.UpdateHostRequest) (*adminpb.UpdateHostResponse, error) {
+	if err := s.hostMng.UpdateHost(ctx, req.GetDomain(), req.GetCertPem(), req.GetPrivateKeyPem()); err != nil {
+		return nil, hostError(err)
+	}
+	level.Info(s.logger).Log("msg", "virtual host updated", "domain", req.GetDomain())
+	return &adminpb.UpdateHostResponse{}, nil
+}
+
+func (s *hostsService) RemoveHost(ctx context.Context, req *adminpb.RemoveHostRequest) (*adminpb.RemoveHostResponse, error) {
+	if err := s.hostMng.RemoveHost(ctx, req.GetDomain()); err != nil {
+		return nil, hostError(err)
+	}
+	level.Info(s.logger).Log("msg", "virtual host removed", "domain", req.GetDomain())
+	return &adminpb.RemoveHostResponse{}, nil
+}
+
+func hostError(err error) error {
+	var invalidErr *host.InvalidHostError
+	switch {
+	case errors.As(err, &invalidErr):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, host.ErrHostExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, host.ErrHostNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, host.ErrStaticHost):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
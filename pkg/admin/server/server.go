@@ -28,6 +28,8 @@ import (
 	adminpb "github.com/ortuman/jackal/pkg/admin/pb"
 	"github.com/ortuman/jackal/pkg/auth/pepper"
 	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/s2s"
 	"github.com/ortuman/jackal/pkg/storage/repository"
 	"google.golang.org/grpc"
 )
@@ -41,10 +43,13 @@ type Server struct {
 	ln       net.Listener
 	active   int32
 
-	rep     repository.Repository
-	peppers *pepper.Keys
-	hk      *hook.Hooks
-	logger  kitlog.Logger
+	rep       repository.Repository
+	peppers   *pepper.Keys
+	fedPolicy *s2s.FederationPolicy
+	hostMng   *host.KVManager
+	reloader  ConfigReloader
+	hk        *hook.Hooks
+	logger    kitlog.Logger
 }
 
 // Config contains Server configuration parameters.
@@ -59,6 +64,9 @@ func New(
 	cfg Config,
 	rep repository.Repository,
 	peppers *pepper.Keys,
+	fedPolicy *s2s.FederationPolicy,
+	hostMng *host.KVManager,
+	reloader ConfigReloader,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) *Server {
@@ -66,12 +74,15 @@ func New(
 		return nil
 	}
 	return &Server{
-		bindAddr: cfg.BindAddr,
-		port:     cfg.Port,
-		rep:      rep,
-		peppers:  peppers,
-		hk:       hk,
-		logger:   logger,
+		bindAddr:  cfg.BindAddr,
+		port:      cfg.Port,
+		rep:       rep,
+		peppers:   peppers,
+		fedPolicy: fedPolicy,
+		hostMng:   hostMng,
+		reloader:  reloader,
+		hk:        hk,
+		logger:    logger,
 	}
 }
 
@@ -94,6 +105,9 @@ func (s *Server) Start(_ context.Context) error {
 			grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
 		)
 		adminpb.RegisterUsersServer(grpcServer, newUsersService(s.rep, s.peppers, s.hk, s.logger))
+		adminpb.RegisterFederationServer(grpcServer, newFederationService(s.fedPolicy, s.logger))
+		adminpb.RegisterHostsServer(grpcServer, newHostsService(s.hostMng, s.logger))
+		adminpb.RegisterConfigServer(grpcServer, newConfigService(s.reloader, s.logger))
 		if err := grpcServer.Serve(s.ln); err != nil {
 			if atomic.LoadInt32(&s.active) == 1 {
 				level.Error(s.logger).Log("msg", "admin server error", "err", err)
@@ -15,32 +15,26 @@
 package adminserver
 
 import (
-	"bytes"
 	"context"
-	"crypto/rand"
-	"crypto/sha1"
-	"crypto/sha256"
-	"crypto/sha512"
-	"encoding/base64"
 	"fmt"
-	"hash"
+	"sort"
 
 	kitlog "github.com/go-kit/log"
 
 	"github.com/go-kit/log/level"
 
 	userspb "github.com/ortuman/jackal/pkg/admin/pb"
+	"github.com/ortuman/jackal/pkg/auth"
 	"github.com/ortuman/jackal/pkg/auth/pepper"
 	"github.com/ortuman/jackal/pkg/hook"
 	usermodel "github.com/ortuman/jackal/pkg/model/user"
 	"github.com/ortuman/jackal/pkg/storage/repository"
-	"golang.org/x/crypto/pbkdf2"
-	"golang.org/x/crypto/sha3"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-const iterationCount = 15_000
+// noPepperID identifies credentials derived without a pepper key in pepper reports.
+const noPepperID = "none"
 
 type usersService struct {
 	userspb.UnimplementedUsersServer
@@ -117,6 +111,42 @@ func (s *usersService) DeleteUser(ctx context.Context, req *userspb.DeleteUserRe
 	return &userspb.DeleteUserResponse{}, nil
 }
 
+func (s *usersService) GetPepperReport(ctx context.Context, _ *userspb.GetPepperReportRequest) (*userspb.GetPepperReportResponse, error) {
+	counts, err := s.rep.CountUsersByPepper(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	activeID := s.peppers.GetActiveID()
+
+	usages := make(map[string]*userspb.PepperUsage)
+	for _, pepperID := range s.peppers.GetIDs() {
+		usages[pepperID] = &userspb.PepperUsage{PepperId: pepperID, IsConfigured: true}
+	}
+	for pepperID, count := range counts {
+		if len(pepperID) == 0 {
+			pepperID = noPepperID // credentials derived without a pepper key
+		}
+		usage, ok := usages[pepperID]
+		if !ok {
+			// referenced by stored credentials, but missing from configuration
+			usage = &userspb.PepperUsage{PepperId: pepperID, IsConfigured: false}
+			usages[pepperID] = usage
+		}
+		usage.UserCount = int64(count)
+	}
+	resp := &userspb.GetPepperReportResponse{
+		IterationCount: int64(s.peppers.IterationCount()),
+	}
+	for _, usage := range usages {
+		usage.IsActive = usage.PepperId == activeID
+		resp.Peppers = append(resp.Peppers, usage)
+	}
+	sort.Slice(resp.Peppers, func(i, j int) bool {
+		return resp.Peppers[i].PepperId < resp.Peppers[j].PepperId
+	})
+	return resp, nil
+}
+
 func (s *usersService) ensureUserNotFound(ctx context.Context, username string) error {
 	exists, err := s.rep.UserExists(ctx, username)
 	if err != nil {
@@ -140,40 +170,16 @@ func (s *usersService) ensureUserAlreadyExists(ctx context.Context, username str
 }
 
 func (s *usersService) upsertUser(ctx context.Context, username, password string) error {
-	salt := make([]byte, 32)
-	_, err := rand.Read(salt)
+	scram, err := auth.NewScramCredentials(password, s.peppers)
 	if err != nil {
 		return status.Error(codes.Internal, err.Error())
 	}
-	buf := bytes.NewBuffer(salt)
-	pp := s.peppers.GetActiveKey()
-	buf.WriteString(pp)
-	pepperedSalt := buf.Bytes()
-
-	// generate password hashes
-	hSHA1 := hashPassword([]byte(password), pepperedSalt, iterationCount, sha1.Size, sha1.New)
-	hSHA256 := hashPassword([]byte(password), pepperedSalt, iterationCount, sha256.Size, sha256.New)
-	hSHA512 := hashPassword([]byte(password), pepperedSalt, iterationCount, sha512.Size, sha512.New)
-	hSHA3512 := hashPassword([]byte(password), pepperedSalt, iterationCount, sha512.Size, sha3.New512)
-
 	usr := usermodel.User{
 		Username: username,
-		Scram:    &usermodel.Scram{},
+		Scram:    scram,
 	}
-	usr.Scram.Sha1 = base64.RawURLEncoding.EncodeToString(hSHA1)
-	usr.Scram.Sha256 = base64.RawURLEncoding.EncodeToString(hSHA256)
-	usr.Scram.Sha512 = base64.RawURLEncoding.EncodeToString(hSHA512)
-	usr.Scram.Sha3512 = base64.RawURLEncoding.EncodeToString(hSHA3512)
-	usr.Scram.Salt = base64.RawURLEncoding.EncodeToString(salt)
-	usr.Scram.IterationCount = iterationCount
-	usr.Scram.PepperId = s.peppers.GetActiveID()
-
 	if err := s.rep.UpsertUser(ctx, &usr); err != nil {
 		return status.Error(codes.Internal, err.Error())
 	}
 	return nil
 }
-
-func hashPassword(password, salt []byte, iterations int, hKeyLen int, h func() hash.Hash) []byte {
-	return pbkdf2.Key(password, salt, iterations, hKeyLen, h)
-}
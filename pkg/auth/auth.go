@@ -23,6 +23,18 @@ import (
 
 const saslNamespace = "urn:ietf:params:xml:ns:xmpp-sasl"
 
+type domainCtxKey struct{}
+
+// WithDomain returns a copy of ctx carrying the local domain an entity is authenticating against.
+func WithDomain(ctx context.Context, domain string) context.Context {
+	return context.WithValue(ctx, domainCtxKey{}, domain)
+}
+
+func domainFromContext(ctx context.Context) string {
+	domain, _ := ctx.Value(domainCtxKey{}).(string)
+	return domain
+}
+
 // Authenticator defines a generic authenticator state machine.
 type Authenticator interface {
 
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"fmt"
 	"time"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -28,22 +29,108 @@ import (
 	"google.golang.org/grpc/keepalive"
 )
 
+// ExternalClient represents a connection to an external authenticator gRPC service.
+// A single instance is meant to be shared across all authenticators making use of the same service.
+type ExternalClient struct {
+	address  string
+	isSecure bool
+	cc       *grpc.ClientConn
+	cl       extGrpcClient
+}
+
+// NewExternalClient returns a new external authenticator client.
+func NewExternalClient(address string, isSecure bool) *ExternalClient {
+	return &ExternalClient{
+		address:  address,
+		isSecure: isSecure,
+	}
+}
+
+// Address returns external authenticator service address.
+func (c *ExternalClient) Address() string {
+	return c.address
+}
+
+// UserExists tells whether or not a user exists according to the external authenticator.
+func (c *ExternalClient) UserExists(ctx context.Context, username string) (bool, error) {
+	resp, err := c.cl.UserExists(ctx, &authpb.UserExistsRequest{
+		Username: username,
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Exists, nil
+}
+
+// Start dials external authenticator gRPC connection.
+func (c *ExternalClient) Start(ctx context.Context) error {
+	var opts = []grpc.DialOption{
+		grpc.WithBalancerName(roundrobin.Name),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Second * 10,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithUnaryInterceptor(grpc_prometheus.UnaryClientInterceptor),
+		grpc.WithStreamInterceptor(grpc_prometheus.StreamClientInterceptor),
+	}
+	if !c.isSecure {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	cc, err := grpc.DialContext(ctx, c.address, opts...)
+	if err != nil {
+		return err
+	}
+	c.cc = cc
+	c.cl = authpb.NewAuthenticatorClient(cc)
+	return nil
+}
+
+// Stop closes underlying gRPC connection.
+func (c *ExternalClient) Stop(_ context.Context) error {
+	return c.cc.Close()
+}
+
+func (c *ExternalClient) scramCredentials(ctx context.Context, username string, tp ScramType) (*scramCredentials, error) {
+	resp, err := c.cl.GetScramCredentials(ctx, &authpb.GetScramCredentialsRequest{
+		Username:  username,
+		Mechanism: scramMechanisms[tp],
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Found {
+		return nil, nil
+	}
+	// keys must be as long as mechanism hash output
+	hashSize := scramHash(tp)().Size()
+	if len(resp.StoredKey) != hashSize || len(resp.ServerKey) != hashSize {
+		return nil, fmt.Errorf("auth: invalid %s credentials key length for user %s", scramMechanisms[tp], username)
+	}
+	return &scramCredentials{
+		salt:           resp.Salt,
+		iterationCount: int(resp.IterationCount),
+		storedKey:      resp.StoredKey,
+		serverKey:      resp.ServerKey,
+	}, nil
+}
+
+var scramMechanisms = map[ScramType]authpb.ScramMechanism{
+	ScramSHA1:    authpb.ScramMechanism_SCRAM_MECHANISM_SHA_1,
+	ScramSHA256:  authpb.ScramMechanism_SCRAM_MECHANISM_SHA_256,
+	ScramSHA512:  authpb.ScramMechanism_SCRAM_MECHANISM_SHA_512,
+	ScramSHA3512: authpb.ScramMechanism_SCRAM_MECHANISM_SHA3_512,
+}
+
 // External represents external authentication mechanism (PLAIN).
 type External struct {
-	address       string
-	isSecure      bool
+	cl            extGrpcClient
 	username      string
 	authenticated bool
-	cc            *grpc.ClientConn
-	cl            authpb.AuthenticatorClient
 }
 
 // NewExternal returns a new external authenticator.
-func NewExternal(address string, isSecure bool) *External {
-	return &External{
-		address:  address,
-		isSecure: isSecure,
-	}
+func NewExternal(cl *ExternalClient) *External {
+	return &External{cl: cl.cl}
 }
 
 // Mechanism returns authenticator mechanism name.
@@ -103,36 +190,8 @@ func (e *External) ProcessElement(ctx context.Context, elem stravaganza.Element)
 		Build(), nil
 }
 
-// Reset resets scram internal state.
+// Reset resets external authenticator internal state.
 func (e *External) Reset() {
 	e.username = ""
 	e.authenticated = false
 }
-
-// Start dials external authenticator gRPC connection.
-func (e *External) Start(ctx context.Context) error {
-	var opts = []grpc.DialOption{
-		grpc.WithBalancerName(roundrobin.Name),
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:                time.Second * 10,
-			PermitWithoutStream: true,
-		}),
-		grpc.WithUnaryInterceptor(grpc_prometheus.UnaryClientInterceptor),
-		grpc.WithStreamInterceptor(grpc_prometheus.StreamClientInterceptor),
-	}
-	if !e.isSecure {
-		opts = append(opts, grpc.WithInsecure())
-	}
-	cc, err := grpc.DialContext(ctx, e.address, opts...)
-	if err != nil {
-		return err
-	}
-	e.cc = cc
-	e.cl = authpb.NewAuthenticatorClient(cc)
-	return nil
-}
-
-// Stop closes underlying gRPC connection.
-func (e *External) Stop(_ context.Context) error {
-	return e.cc.Close()
-}
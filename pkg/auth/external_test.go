@@ -110,3 +110,63 @@ func TestExternal_AuthenticateInvalidCredentials(t *testing.T) {
 	require.False(t, e.Authenticated())
 	require.Equal(t, "", e.Username())
 }
+
+func TestExternalClient_UserExists(t *testing.T) {
+	// given
+	clMock := &extGrpcClientMock{}
+	clMock.UserExistsFunc = func(ctx context.Context, in *authpb.UserExistsRequest, opts ...grpc.CallOption) (*authpb.UserExistsResponse, error) {
+		return &authpb.UserExistsResponse{Exists: in.Username == "ortuman"}, nil
+	}
+	cl := &ExternalClient{cl: clMock}
+
+	// when
+	ok1, err1 := cl.UserExists(context.Background(), "ortuman")
+	ok2, err2 := cl.UserExists(context.Background(), "noelia")
+
+	// then
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.True(t, ok1)
+	require.False(t, ok2)
+}
+
+func TestExternalClient_ScramCredentials(t *testing.T) {
+	var tests = []struct {
+		name      string
+		storedKey []byte
+		serverKey []byte
+		wantErr   bool
+	}{
+		{name: "valid", storedKey: make([]byte, 32), serverKey: make([]byte, 32)},
+		{name: "short stored key", storedKey: make([]byte, 20), serverKey: make([]byte, 32), wantErr: true},
+		{name: "long server key", storedKey: make([]byte, 32), serverKey: make([]byte, 64), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			clMock := &extGrpcClientMock{}
+			clMock.GetScramCredentialsFunc = func(ctx context.Context, in *authpb.GetScramCredentialsRequest, opts ...grpc.CallOption) (*authpb.GetScramCredentialsResponse, error) {
+				return &authpb.GetScramCredentialsResponse{
+					Found:          true,
+					Salt:           []byte("salt"),
+					IterationCount: 4096,
+					StoredKey:      tt.storedKey,
+					ServerKey:      tt.serverKey,
+				}, nil
+			}
+			cl := &ExternalClient{cl: clMock}
+
+			// when
+			creds, err := cl.scramCredentials(context.Background(), "ortuman", ScramSHA256)
+
+			// then
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, creds)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.storedKey, creds.storedKey)
+			}
+		})
+	}
+}
@@ -15,7 +15,10 @@
 package auth
 
 import (
+	"context"
+
 	authpb "github.com/ortuman/jackal/pkg/auth/pb"
+	"github.com/ortuman/jackal/pkg/ldap"
 	"github.com/ortuman/jackal/pkg/storage/repository"
 	"github.com/ortuman/jackal/pkg/transport"
 )
@@ -34,3 +37,14 @@ type authUserRepository interface {
 type extGrpcClient interface {
 	authpb.AuthenticatorClient
 }
+
+//go:generate moq -out ldap_client.mock_test.go . ldapClient
+type ldapClient interface {
+	Authenticate(ctx context.Context, domain, username, password string) (*ldap.Entry, error)
+}
+
+//go:generate moq -out jwt_verifier.mock_test.go . jwtVerifier
+type jwtVerifier interface {
+	Verify(token string) (string, error)
+	AutoProvision() bool
+}
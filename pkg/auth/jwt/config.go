@@ -0,0 +1,116 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Config defines JWT verification configuration.
+type Config struct {
+	// JWKSFile defines the path to a JSON Web Key Set (RFC 7517) document containing verification keys.
+	// The file is read again whenever a token references an unknown key identifier and the file has changed.
+	JWKSFile string `fig:"jwks_file"`
+
+	// Keys contains statically configured verification keys.
+	Keys []KeyConfig `fig:"keys"`
+
+	// Issuer defines the expected 'iss' claim value.
+	Issuer string `fig:"issuer"`
+
+	// Audience defines the value that must be present in token 'aud' claim.
+	Audience string `fig:"audience"`
+
+	// UsernameClaim defines the claim XMPP usernames are mapped from.
+	UsernameClaim string `fig:"username_claim" default:"sub"`
+
+	// Leeway defines the allowed clock skew when validating 'exp' and 'nbf' claims.
+	Leeway time.Duration `fig:"leeway" default:"30s"`
+
+	// AutoProvision tells whether authenticated users not yet present in repository should be created.
+	AutoProvision bool `fig:"auto_provision"`
+}
+
+// KeyConfig defines a statically configured verification key.
+type KeyConfig struct {
+	// ID defines the key identifier matched against token 'kid' header.
+	// If empty, the key will be tried for every token signed with a compatible algorithm.
+	ID string `fig:"id"`
+
+	// Algorithm defines the key signing algorithm (e.g. HS256, RS256, ES256, EdDSA).
+	Algorithm string `fig:"alg"`
+
+	// Secret defines HMAC shared secret. Only valid for HS256, HS384 and HS512 algorithms.
+	Secret string `fig:"secret"`
+
+	// PublicKeyFile defines the path to a PEM encoded public key or certificate.
+	PublicKeyFile string `fig:"public_key_file"`
+}
+
+// IsEnabled tells whether JWT verification is enabled.
+func (c Config) IsEnabled() bool {
+	return len(c.JWKSFile) > 0 || len(c.Keys) > 0
+}
+
+// Validate checks whether JWT configuration values are supported.
+func (c Config) Validate() error {
+	if !c.IsEnabled() {
+		return errors.New("jwt: no verification keys defined")
+	}
+	if len(c.Issuer) == 0 {
+		return errors.New("jwt: issuer must be specified")
+	}
+	if len(c.Audience) == 0 {
+		return errors.New("jwt: audience must be specified")
+	}
+	if len(c.UsernameClaim) == 0 {
+		return errors.New("jwt: username claim must be specified")
+	}
+	if c.Leeway < 0 {
+		return fmt.Errorf("jwt: invalid leeway: %v", c.Leeway)
+	}
+	for _, kCfg := range c.Keys {
+		if err := kCfg.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c KeyConfig) validate() error {
+	alg, ok := algorithms[c.Algorithm]
+	if !ok {
+		return fmt.Errorf("jwt: unsupported key algorithm: %s", c.Algorithm)
+	}
+	if alg.family == hmacFamily {
+		if len(c.PublicKeyFile) > 0 {
+			return fmt.Errorf("jwt: %s key cannot be loaded from a public key file", c.Algorithm)
+		}
+		// RFC 7518 section 3.2: a key of the same size as the hash output or larger must be used
+		if len(c.Secret) < alg.hash.Size() {
+			return fmt.Errorf("jwt: %s secret must be at least %d bytes", c.Algorithm, alg.hash.Size())
+		}
+		return nil
+	}
+	if len(c.Secret) > 0 {
+		return fmt.Errorf("jwt: %s key cannot be defined using a secret", c.Algorithm)
+	}
+	if len(c.PublicKeyFile) == 0 {
+		return fmt.Errorf("jwt: %s key requires a public key file", c.Algorithm)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	var tcs = map[string]struct {
+		cfg         Config
+		expectedErr string
+	}{
+		"valid": {
+			cfg: Config{
+				JWKSFile:      "jwks.json",
+				Issuer:        "https://idp.jackal.im",
+				Audience:      "jackal",
+				UsernameClaim: "sub",
+			},
+		},
+		"no keys": {
+			cfg:         Config{Issuer: "https://idp.jackal.im", Audience: "jackal", UsernameClaim: "sub"},
+			expectedErr: "jwt: no verification keys defined",
+		},
+		"missing issuer": {
+			cfg:         Config{JWKSFile: "jwks.json", Audience: "jackal", UsernameClaim: "sub"},
+			expectedErr: "jwt: issuer must be specified",
+		},
+		"missing audience": {
+			cfg:         Config{JWKSFile: "jwks.json", Issuer: "https://idp.jackal.im", UsernameClaim: "sub"},
+			expectedErr: "jwt: audience must be specified",
+		},
+		"unsupported algorithm": {
+			cfg: Config{
+				Keys:          []KeyConfig{{Algorithm: "none"}},
+				Issuer:        "https://idp.jackal.im",
+				Audience:      "jackal",
+				UsernameClaim: "sub",
+			},
+			expectedErr: "jwt: unsupported key algorithm: none",
+		},
+		"short secret": {
+			cfg: Config{
+				Keys:          []KeyConfig{{Algorithm: "HS256", Secret: "short"}},
+				Issuer:        "https://idp.jackal.im",
+				Audience:      "jackal",
+				UsernameClaim: "sub",
+			},
+			expectedErr: "jwt: HS256 secret must be at least 32 bytes",
+		},
+		"missing public key file": {
+			cfg: Config{
+				Keys:          []KeyConfig{{Algorithm: "RS256"}},
+				Issuer:        "https://idp.jackal.im",
+				Audience:      "jackal",
+				UsernameClaim: "sub",
+			},
+			expectedErr: "jwt: RS256 key requires a public key file",
+		},
+	}
+	for tn, tc := range tcs {
+		t.Run(tn, func(t *testing.T) {
+			// when
+			err := tc.cfg.Validate()
+
+			// then
+			if len(tc.expectedErr) > 0 {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // register SHA-256 hash function
+	_ "crypto/sha512" // register SHA-384 and SHA-512 hash functions
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+type keyFamily int
+
+const (
+	hmacFamily keyFamily = iota
+	rsaFamily
+	rsaPSSFamily
+	ecdsaFamily
+	ed25519Family
+)
+
+type algorithm struct {
+	family keyFamily
+	hash   crypto.Hash
+	curve  elliptic.Curve
+}
+
+var algorithms = map[string]algorithm{
+	"HS256": {family: hmacFamily, hash: crypto.SHA256},
+	"HS384": {family: hmacFamily, hash: crypto.SHA384},
+	"HS512": {family: hmacFamily, hash: crypto.SHA512},
+	"RS256": {family: rsaFamily, hash: crypto.SHA256},
+	"RS384": {family: rsaFamily, hash: crypto.SHA384},
+	"RS512": {family: rsaFamily, hash: crypto.SHA512},
+	"PS256": {family: rsaPSSFamily, hash: crypto.SHA256},
+	"PS384": {family: rsaPSSFamily, hash: crypto.SHA384},
+	"PS512": {family: rsaPSSFamily, hash: crypto.SHA512},
+	"ES256": {family: ecdsaFamily, hash: crypto.SHA256, curve: elliptic.P256()},
+	"ES384": {family: ecdsaFamily, hash: crypto.SHA384, curve: elliptic.P384()},
+	"ES512": {family: ecdsaFamily, hash: crypto.SHA512, curve: elliptic.P521()},
+	"EdDSA": {family: ed25519Family},
+}
+
+// key represents a single verification key.
+type key struct {
+	id  string
+	alg string // empty if key can be used with any compatible algorithm
+
+	secret []byte
+	pub    crypto.PublicKey
+}
+
+// supports tells whether the key can verify signatures generated using algName algorithm.
+func (k *key) supports(algName string) bool {
+	if len(k.alg) > 0 && k.alg != algName {
+		return false
+	}
+	alg := algorithms[algName]
+	switch pub := k.pub.(type) {
+	case *rsa.PublicKey:
+		return alg.family == rsaFamily || alg.family == rsaPSSFamily
+	case *ecdsa.PublicKey:
+		return alg.family == ecdsaFamily && pub.Curve == alg.curve
+	case ed25519.PublicKey:
+		return alg.family == ed25519Family
+	default:
+		return alg.family == hmacFamily && len(k.secret) > 0
+	}
+}
+
+// keySet contains the set of keys used to verify token signatures.
+type keySet struct {
+	jwksFile string
+
+	mu       sync.RWMutex
+	static   []*key
+	jwks     []*key
+	jwksTime time.Time
+}
+
+func newKeySet(cfg Config) (*keySet, error) {
+	ks := &keySet{jwksFile: cfg.JWKSFile}
+	for _, kCfg := range cfg.Keys {
+		k, err := loadStaticKey(kCfg)
+		if err != nil {
+			return nil, err
+		}
+		ks.static = append(ks.static, k)
+	}
+	if len(ks.jwksFile) > 0 {
+		if _, err := ks.reloadJWKS(); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+// candidates returns the keys able to verify a token signed using alg and kid key identifier.
+func (ks *keySet) candidates(alg, kid string) []*key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	var res []*key
+	for _, keys := range [][]*key{ks.static, ks.jwks} {
+		for _, k := range keys {
+			if len(kid) > 0 && len(k.id) > 0 && k.id != kid {
+				continue
+			}
+			if !k.supports(alg) {
+				continue
+			}
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+// reloadJWKS reads JWKS file again in case it has been modified since it was last loaded.
+// It returns whether the key set was updated.
+func (ks *keySet) reloadJWKS() (bool, error) {
+	if len(ks.jwksFile) == 0 {
+		return false, nil
+	}
+	fi, err := os.Stat(ks.jwksFile)
+	if err != nil {
+		return false, fmt.Errorf("jwt: failed to read JWKS file: %v", err)
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if fi.ModTime().Equal(ks.jwksTime) {
+		return false, nil
+	}
+	b, err := os.ReadFile(ks.jwksFile)
+	if err != nil {
+		return false, fmt.Errorf("jwt: failed to read JWKS file: %v", err)
+	}
+	keys, err := parseJWKS(b)
+	if err != nil {
+		return false, err
+	}
+	ks.jwks = keys
+	ks.jwksTime = fi.ModTime()
+	return true, nil
+}
+
+func loadStaticKey(cfg KeyConfig) (*key, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	k := &key{id: cfg.ID, alg: cfg.Algorithm}
+	if len(cfg.Secret) > 0 {
+		k.secret = []byte(cfg.Secret)
+		return k, nil
+	}
+	b, err := os.ReadFile(cfg.PublicKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: failed to read public key file: %v", err)
+	}
+	pub, err := parsePublicKeyPEM(b)
+	if err != nil {
+		return nil, err
+	}
+	k.pub = pub
+	if !k.supports(cfg.Algorithm) {
+		return nil, fmt.Errorf("jwt: public key %s cannot be used with %s algorithm", cfg.PublicKeyFile, cfg.Algorithm)
+	}
+	return k, nil
+}
+
+func parsePublicKeyPEM(b []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("jwt: no PEM data found in public key file")
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: failed to parse public key: %v", err)
+		}
+		return pub, nil
+
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: failed to parse public key: %v", err)
+		}
+		return pub, nil
+
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: failed to parse certificate: %v", err)
+		}
+		return cert.PublicKey, nil
+
+	default:
+		return nil, fmt.Errorf("jwt: unsupported PEM block type: %s", block.Type)
+	}
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+func parseJWKS(b []byte) ([]*key, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("jwt: failed to parse JWKS: %v", err)
+	}
+	var res []*key
+	for _, j := range set.Keys {
+		if len(j.Use) > 0 && j.Use != "sig" {
+			continue // not a signature verification key
+		}
+		if len(j.Alg) > 0 {
+			if _, ok := algorithms[j.Alg]; !ok {
+				continue
+			}
+		}
+		k, err := parseJWK(j)
+		if err != nil {
+			return nil, err
+		}
+		if k == nil {
+			continue // unsupported key type
+		}
+		res = append(res, k)
+	}
+	return res, nil
+}
+
+func parseJWK(j jwk) (*key, error) {
+	k := &key{id: j.Kid, alg: j.Alg}
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid RSA key %s modulus: %v", j.Kid, err)
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid RSA key %s exponent: %v", j.Kid, err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 || e.Int64() < 3 {
+			return nil, fmt.Errorf("jwt: invalid RSA key %s exponent", j.Kid)
+		}
+		k.pub = &rsa.PublicKey{N: n, E: int(e.Int64())}
+
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid EC key %s: %v", j.Kid, err)
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid EC key %s: %v", j.Kid, err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("jwt: invalid EC key %s: point is not on curve", j.Kid)
+		}
+		k.pub = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwt: invalid Ed25519 key %s", j.Kid)
+		}
+		k.pub = ed25519.PublicKey(x)
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(j.K)
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("jwt: invalid symmetric key %s", j.Kid)
+		}
+		k.secret = secret
+
+	default:
+		return nil, nil
+	}
+	return k, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
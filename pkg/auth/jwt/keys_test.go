@@ -0,0 +1,121 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySet_StaticKeys(t *testing.T) {
+	// given
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+
+	pubFile := filepath.Join(t.TempDir(), "pub.pem")
+	require.NoError(t, os.WriteFile(pubFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	// when
+	ks, err := newKeySet(Config{
+		Keys: []KeyConfig{
+			{ID: "k1", Algorithm: "ES256", PublicKeyFile: pubFile},
+			{Algorithm: "HS256", Secret: "a-very-long-shared-secret-of-32-bytes"},
+		},
+	})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, ks.candidates("ES256", "k1"), 1)
+	require.Len(t, ks.candidates("ES256", "k2"), 0)
+	require.Len(t, ks.candidates("ES384", "k1"), 0)
+	require.Len(t, ks.candidates("HS256", "k2"), 1)
+}
+
+func TestKeySet_StaticKeyAlgorithmMismatch(t *testing.T) {
+	// given
+	priv, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+
+	pubFile := filepath.Join(t.TempDir(), "pub.pem")
+	require.NoError(t, os.WriteFile(pubFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	// when
+	_, err := newKeySet(Config{
+		Keys: []KeyConfig{{Algorithm: "ES256", PublicKeyFile: pubFile}},
+	})
+
+	// then
+	require.Error(t, err)
+}
+
+func TestKeySet_ReloadJWKS(t *testing.T) {
+	// given
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, []byte(`{"keys":[
+{"kty":"oct","kid":"k1","alg":"HS256","k":"`+base64.RawURLEncoding.EncodeToString([]byte("secret"))+`"},
+{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"},
+{"kty":"unknown","kid":"k3"}
+]}`), 0600))
+
+	ks, err := newKeySet(Config{JWKSFile: jwksFile})
+	require.NoError(t, err)
+	require.Len(t, ks.candidates("HS256", "k1"), 1)
+	require.Len(t, ks.candidates("RS256", "enc"), 0)
+
+	// when
+	require.NoError(t, os.WriteFile(jwksFile, []byte(`{"keys":[
+{"kty":"EC","kid":"k2","crv":"P-256","x":"`+base64.RawURLEncoding.EncodeToString(priv.X.Bytes())+`","y":"`+base64.RawURLEncoding.EncodeToString(priv.Y.Bytes())+`"}
+]}`), 0600))
+	mt := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(jwksFile, mt, mt))
+
+	updated, err := ks.reloadJWKS()
+
+	// then
+	require.NoError(t, err)
+	require.True(t, updated)
+	require.Len(t, ks.candidates("HS256", "k1"), 0)
+	require.Len(t, ks.candidates("ES256", "k2"), 1)
+
+	updated, err = ks.reloadJWKS()
+	require.NoError(t, err)
+	require.False(t, updated)
+}
+
+func TestKeySet_InvalidJWKS(t *testing.T) {
+	// given
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, []byte(`{"keys":[
+{"kty":"EC","kid":"k1","crv":"P-256","x":"AQ","y":"AQ"}
+]}`), 0600))
+
+	// when
+	_, err := newKeySet(Config{JWKSFile: jwksFile})
+
+	// then
+	require.EqualError(t, err, "jwt: invalid EC key k1: point is not on curve")
+}
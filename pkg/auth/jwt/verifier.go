@@ -0,0 +1,235 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// maxNumericDate is the greatest accepted absolute 'exp' and 'nbf' claim value.
+const maxNumericDate = 1 << 53
+
+// ErrInvalidToken will be returned by Verify when a token is malformed, cannot be verified
+// or its claims are not acceptable.
+var ErrInvalidToken = errors.New("jwt: invalid token")
+
+// Verifier validates JSON Web Tokens (RFC 7519) issued by a trusted identity provider.
+type Verifier struct {
+	cfg   Config
+	keys  *keySet
+	nowFn func() time.Time
+}
+
+// NewVerifier returns a new Verifier instance loading the keys defined in cfg.
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	ks, err := newKeySet(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{
+		cfg:   cfg,
+		keys:  ks,
+		nowFn: time.Now,
+	}, nil
+}
+
+// AutoProvision tells whether authenticated users not yet present in repository should be created.
+func (v *Verifier) AutoProvision() bool {
+	return v.cfg.AutoProvision
+}
+
+// Verify checks token signature and claims, returning the username mapped from the configured claim.
+// An error wrapping ErrInvalidToken is returned if the token is not acceptable.
+func (v *Verifier) Verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+	var hdr struct {
+		Alg  string   `json:"alg"`
+		Kid  string   `json:"kid"`
+		Crit []string `json:"crit"`
+	}
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return "", fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	if len(hdr.Crit) > 0 {
+		return "", fmt.Errorf("%w: unsupported critical header parameters", ErrInvalidToken)
+	}
+	alg, ok := algorithms[hdr.Alg]
+	if !ok {
+		return "", fmt.Errorf("%w: unsupported algorithm: %s", ErrInvalidToken, hdr.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+
+	verified, err := v.verifySignature(alg, hdr.Alg, hdr.Kid, signingInput, sig)
+	if err != nil {
+		return "", err
+	}
+	if !verified {
+		return "", fmt.Errorf("%w: signature verification failed", ErrInvalidToken)
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return "", err
+	}
+	username, _ := claims[v.cfg.UsernameClaim].(string)
+	if len(username) == 0 {
+		return "", fmt.Errorf("%w: missing %s claim", ErrInvalidToken, v.cfg.UsernameClaim)
+	}
+	return username, nil
+}
+
+func (v *Verifier) verifySignature(alg algorithm, algName, kid string, signingInput, sig []byte) (bool, error) {
+	candidates := v.keys.candidates(algName, kid)
+	if len(candidates) == 0 {
+		// identity provider may have rotated its keys
+		updated, err := v.keys.reloadJWKS()
+		if err != nil {
+			return false, err
+		}
+		if !updated {
+			return false, fmt.Errorf("%w: no verification key found", ErrInvalidToken)
+		}
+		candidates = v.keys.candidates(algName, kid)
+	}
+	for _, k := range candidates {
+		if verifySignature(alg, k, signingInput, sig) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (v *Verifier) validateClaims(claims map[string]interface{}) error {
+	now := v.nowFn()
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if !now.Before(exp.Add(v.cfg.Leeway)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if _, ok := claims["nbf"]; ok {
+		nbf, ok := numericDate(claims["nbf"])
+		if !ok {
+			return fmt.Errorf("%w: malformed nbf claim", ErrInvalidToken)
+		}
+		if now.Add(v.cfg.Leeway).Before(nbf) {
+			return fmt.Errorf("%w: token not yet valid", ErrInvalidToken)
+		}
+	}
+	if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+		return fmt.Errorf("%w: unexpected issuer: %s", ErrInvalidToken, iss)
+	}
+	if !hasAudience(claims["aud"], v.cfg.Audience) {
+		return fmt.Errorf("%w: audience mismatch", ErrInvalidToken)
+	}
+	return nil
+}
+
+func verifySignature(alg algorithm, k *key, signingInput, sig []byte) bool {
+	if alg.family == hmacFamily {
+		m := hmac.New(alg.hash.New, k.secret)
+		m.Write(signingInput)
+		return hmac.Equal(m.Sum(nil), sig)
+	}
+	if alg.family == ed25519Family {
+		return ed25519.Verify(k.pub.(ed25519.PublicKey), signingInput, sig)
+	}
+	h := alg.hash.New()
+	h.Write(signingInput)
+	digest := h.Sum(nil)
+
+	switch alg.family {
+	case rsaFamily:
+		return rsa.VerifyPKCS1v15(k.pub.(*rsa.PublicKey), alg.hash, digest, sig) == nil
+
+	case rsaPSSFamily:
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+		return rsa.VerifyPSS(k.pub.(*rsa.PublicKey), alg.hash, digest, sig, opts) == nil
+
+	case ecdsaFamily:
+		// RFC 7518 section 3.4: signature is the concatenation of fixed size R and S values
+		size := (alg.curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(k.pub.(*ecdsa.PublicKey), digest, r, s)
+	}
+	return false
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func numericDate(v interface{}) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil || math.Abs(f) > maxNumericDate {
+		return time.Time{}, false
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))), true
+}
+
+func hasAudience(v interface{}, audience string) bool {
+	switch aud := v.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if s, _ := a.(string); s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "a-very-long-shared-secret-of-32-bytes"
+
+func TestVerifier_Verify(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":                "https://idp.jackal.im",
+			"aud":                []string{"web", "jackal"},
+			"exp":                now.Add(time.Minute).Unix(),
+			"preferred_username": "ortuman",
+		}
+	}
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	var tcs = map[string]struct {
+		token            string
+		expectedUsername string
+		expectedErr      error
+	}{
+		"HS256": {
+			token:            signToken(t, "HS256", "", []byte(testSecret), validClaims()),
+			expectedUsername: "ortuman",
+		},
+		"RS256": {
+			token:            signToken(t, "RS256", "rsa", rsaKey, validClaims()),
+			expectedUsername: "ortuman",
+		},
+		"PS256": {
+			token:            signToken(t, "PS256", "rsa", rsaKey, validClaims()),
+			expectedUsername: "ortuman",
+		},
+		"ES256": {
+			token:            signToken(t, "ES256", "ec", ecKey, validClaims()),
+			expectedUsername: "ortuman",
+		},
+		"EdDSA": {
+			token:            signToken(t, "EdDSA", "ed", edKey, validClaims()),
+			expectedUsername: "ortuman",
+		},
+		"single audience": {
+			token:            signToken(t, "HS256", "", []byte(testSecret), withClaim("aud", "jackal")),
+			expectedUsername: "ortuman",
+		},
+		"expired within leeway": {
+			token:            signToken(t, "HS256", "", []byte(testSecret), withClaim("exp", now.Add(-time.Second).Unix())),
+			expectedUsername: "ortuman",
+		},
+		"invalid signature": {
+			token:       signToken(t, "HS256", "", []byte("another-very-long-shared-secret-value"), validClaims()),
+			expectedErr: ErrInvalidToken,
+		},
+		"unknown key id": {
+			token:       signToken(t, "RS256", "unknown", rsaKey, validClaims()),
+			expectedErr: ErrInvalidToken,
+		},
+		"none algorithm": {
+			token:       encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, validClaims()) + ".",
+			expectedErr: ErrInvalidToken,
+		},
+		"malformed": {
+			token:       "not-a-token",
+			expectedErr: ErrInvalidToken,
+		},
+		"expired": {
+			token:       signToken(t, "HS256", "", []byte(testSecret), withClaim("exp", now.Add(-time.Minute).Unix())),
+			expectedErr: ErrInvalidToken,
+		},
+		"missing expiry": {
+			token:       signToken(t, "HS256", "", []byte(testSecret), withClaim("exp", nil)),
+			expectedErr: ErrInvalidToken,
+		},
+		"not yet valid": {
+			token:       signToken(t, "HS256", "", []byte(testSecret), withClaim("nbf", now.Add(time.Minute).Unix())),
+			expectedErr: ErrInvalidToken,
+		},
+		"issuer mismatch": {
+			token:       signToken(t, "HS256", "", []byte(testSecret), withClaim("iss", "https://evil.org")),
+			expectedErr: ErrInvalidToken,
+		},
+		"audience mismatch": {
+			token:       signToken(t, "HS256", "", []byte(testSecret), withClaim("aud", "web")),
+			expectedErr: ErrInvalidToken,
+		},
+		"missing username claim": {
+			token:       signToken(t, "HS256", "", []byte(testSecret), withClaim("preferred_username", nil)),
+			expectedErr: ErrInvalidToken,
+		},
+	}
+	for tn, tc := range tcs {
+		t.Run(tn, func(t *testing.T) {
+			// given
+			v := &Verifier{
+				cfg: Config{
+					Issuer:        "https://idp.jackal.im",
+					Audience:      "jackal",
+					UsernameClaim: "preferred_username",
+					Leeway:        30 * time.Second,
+				},
+				keys: &keySet{
+					static: []*key{
+						{alg: "HS256", secret: []byte(testSecret)},
+						{id: "rsa", pub: &rsaKey.PublicKey},
+						{id: "ec", alg: "ES256", pub: &ecKey.PublicKey},
+						{id: "ed", pub: edPub},
+					},
+				},
+				nowFn: func() time.Time { return now },
+			}
+
+			// when
+			username, err := v.Verify(tc.token)
+
+			// then
+			if tc.expectedErr != nil {
+				require.True(t, errors.Is(err, tc.expectedErr))
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expectedUsername, username)
+		})
+	}
+}
+
+func signToken(t *testing.T, alg, kid string, k interface{}, claims map[string]interface{}) string {
+	hdr := map[string]string{"alg": alg, "typ": "JWT"}
+	if len(kid) > 0 {
+		hdr["kid"] = kid
+	}
+	signingInput := encodeSegment(t, hdr) + "." + encodeSegment(t, claims)
+
+	a := algorithms[alg]
+
+	var sig []byte
+	var err error
+	switch a.family {
+	case hmacFamily:
+		m := hmac.New(a.hash.New, k.([]byte))
+		m.Write([]byte(signingInput))
+		sig = m.Sum(nil)
+
+	case ed25519Family:
+		sig = ed25519.Sign(k.(ed25519.PrivateKey), []byte(signingInput))
+
+	default:
+		h := a.hash.New()
+		h.Write([]byte(signingInput))
+		digest := h.Sum(nil)
+
+		switch a.family {
+		case rsaFamily:
+			sig, err = rsa.SignPKCS1v15(rand.Reader, k.(*rsa.PrivateKey), a.hash, digest)
+		case rsaPSSFamily:
+			sig, err = rsa.SignPSS(rand.Reader, k.(*rsa.PrivateKey), a.hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		case ecdsaFamily:
+			var r, s *big.Int
+			r, s, err = ecdsa.Sign(rand.Reader, k.(*ecdsa.PrivateKey), digest)
+			if err == nil {
+				size := (a.curve.Params().BitSize + 7) / 8
+				sig = make([]byte, 2*size)
+				r.FillBytes(sig[:size])
+				s.FillBytes(sig[size:])
+			}
+		}
+	}
+	require.NoError(t, err)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/ldap"
+)
+
+// LDAP represents LDAP bind authentication mechanism (PLAIN).
+type LDAP struct {
+	cl            ldapClient
+	username      string
+	authenticated bool
+}
+
+// NewLDAP returns a new LDAP authenticator.
+func NewLDAP(cl *ldap.Client) *LDAP {
+	return &LDAP{cl: cl}
+}
+
+// Mechanism returns authenticator mechanism name.
+func (l *LDAP) Mechanism() string {
+	return "PLAIN"
+}
+
+// Username returns authenticated username in case authentication process has been completed.
+func (l *LDAP) Username() string {
+	if l.authenticated {
+		return l.username
+	}
+	return ""
+}
+
+// Authenticated returns whether or not user has been authenticated.
+func (l *LDAP) Authenticated() bool {
+	return l.authenticated
+}
+
+// UsesChannelBinding returns whether or not this authenticator requires channel binding bytes.
+func (l *LDAP) UsesChannelBinding() bool {
+	return false
+}
+
+// ProcessElement process an incoming authenticator element.
+func (l *LDAP) ProcessElement(ctx context.Context, elem stravaganza.Element) (stravaganza.Element, *SASLError) {
+	if len(elem.Text()) == 0 {
+		return nil, newSASLError(MalformedRequest, nil)
+	}
+	b, err := base64.StdEncoding.DecodeString(elem.Text())
+	if err != nil {
+		return nil, newSASLError(IncorrectEncoding, nil)
+	}
+	s := bytes.Split(b, []byte{0})
+	if len(s) != 3 {
+		return nil, newSASLError(IncorrectEncoding, nil)
+	}
+	authzID := string(s[0])
+	username := string(s[1])
+	password := string(s[2])
+
+	domain := domainFromContext(ctx)
+	if len(authzID) > 0 && authzID != username && authzID != username+"@"+domain {
+		return nil, newSASLError(NotAuthorized, nil)
+	}
+	entry, err := l.cl.Authenticate(ctx, domain, username, password)
+	if err != nil {
+		return nil, newSASLError(TemporaryAuthFailure, err)
+	}
+	if entry == nil {
+		return nil, newSASLError(NotAuthorized, nil)
+	}
+	// directory username must be a valid JID local part
+	j, err := jid.New(entry.Username, domain, "", false)
+	if err != nil || len(j.Node()) == 0 {
+		return nil, newSASLError(NotAuthorized, err)
+	}
+	l.username = j.Node()
+	l.authenticated = true
+
+	return stravaganza.NewBuilder("success").
+		WithAttribute(stravaganza.Namespace, saslNamespace).
+		Build(), nil
+}
+
+// Reset resets LDAP authenticator internal state.
+func (l *LDAP) Reset() {
+	l.username = ""
+	l.authenticated = false
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/ldap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLDAP_Authenticate(t *testing.T) {
+	tcs := map[string]struct {
+		authzID          string
+		password         string
+		entryUsername    string
+		authErr          error
+		expectedReason   SASLErrorReason
+		expectedUsername string
+	}{
+		"ValidCredentials": {
+			password:         "1234",
+			expectedUsername: "ortuman",
+		},
+		"MatchingAuthzID": {
+			authzID:          "ortuman@jackal.im",
+			password:         "1234",
+			expectedUsername: "ortuman",
+		},
+		"MismatchedAuthzID": {
+			authzID:        "noelia",
+			password:       "1234",
+			expectedReason: NotAuthorized,
+		},
+		"NormalizedDirectoryUsername": {
+			password:         "1234",
+			entryUsername:    "Ortuman",
+			expectedUsername: "ortuman",
+		},
+		"InvalidDirectoryUsername": {
+			password:       "1234",
+			entryUsername:  "ortu man",
+			expectedReason: NotAuthorized,
+		},
+		"InvalidCredentials": {
+			password:       "foo-password",
+			expectedReason: NotAuthorized,
+		},
+		"DirectoryFailure": {
+			password:       "1234",
+			authErr:        errors.New("ldap: connection refused"),
+			expectedReason: TemporaryAuthFailure,
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			var domain string
+			clMock := &ldapClientMock{}
+			clMock.AuthenticateFunc = func(ctx context.Context, d, username, password string) (*ldap.Entry, error) {
+				domain = d
+				if tc.authErr != nil {
+					return nil, tc.authErr
+				}
+				if username == "ortuman" && password == "1234" {
+					entryUsername := "ortuman"
+					if len(tc.entryUsername) > 0 {
+						entryUsername = tc.entryUsername
+					}
+					return &ldap.Entry{DN: "uid=ortuman,dc=jackal,dc=im", Username: entryUsername}, nil
+				}
+				return nil, nil
+			}
+			l := &LDAP{cl: clMock}
+
+			buf := new(bytes.Buffer)
+			buf.WriteString(tc.authzID)
+			buf.WriteByte(0)
+			buf.WriteString("ortuman")
+			buf.WriteByte(0)
+			buf.WriteString(tc.password)
+
+			auth0 := stravaganza.NewBuilder("auth").
+				WithAttribute(stravaganza.Namespace, saslNamespace).
+				WithAttribute("mechanism", "PLAIN").
+				WithText(base64.StdEncoding.EncodeToString(buf.Bytes())).
+				Build()
+
+			// when
+			resp, err := l.ProcessElement(WithDomain(context.Background(), "jackal.im"), auth0)
+
+			// then
+			if len(tc.expectedUsername) > 0 {
+				require.Equal(t, "jackal.im", domain)
+				require.Nil(t, err)
+				require.Equal(t, "success", resp.Name())
+				require.True(t, l.Authenticated())
+				require.Equal(t, tc.expectedUsername, l.Username())
+				return
+			}
+			require.Nil(t, resp)
+			require.NotNil(t, err)
+			require.Equal(t, tc.expectedReason, err.Reason)
+			require.False(t, l.Authenticated())
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/auth/jwt"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+const oauthBearerKVSep = "\x01"
+
+// invalidTokenStatus is the RFC 7628 error challenge sent whenever a token is not accepted.
+var invalidTokenStatus = []byte(`{"status":"invalid_token"}`)
+
+// OAuthBearer represents OAUTHBEARER authentication mechanism (RFC 7628) validating JWT bearer tokens.
+type OAuthBearer struct {
+	verifier      jwtVerifier
+	rep           repository.User
+	username      string
+	authenticated bool
+	failed        bool
+}
+
+// NewOAuthBearer returns a new OAUTHBEARER authenticator.
+func NewOAuthBearer(verifier *jwt.Verifier, rep repository.User) *OAuthBearer {
+	return &OAuthBearer{
+		verifier: verifier,
+		rep:      rep,
+	}
+}
+
+// Mechanism returns authenticator mechanism name.
+func (o *OAuthBearer) Mechanism() string {
+	return "OAUTHBEARER"
+}
+
+// Username returns authenticated username in case authentication process has been completed.
+func (o *OAuthBearer) Username() string {
+	if o.authenticated {
+		return o.username
+	}
+	return ""
+}
+
+// Authenticated returns whether or not user has been authenticated.
+func (o *OAuthBearer) Authenticated() bool {
+	return o.authenticated
+}
+
+// UsesChannelBinding returns whether or not this authenticator requires channel binding bytes.
+func (o *OAuthBearer) UsesChannelBinding() bool {
+	return false
+}
+
+// ProcessElement process an incoming authenticator element.
+func (o *OAuthBearer) ProcessElement(ctx context.Context, elem stravaganza.Element) (stravaganza.Element, *SASLError) {
+	switch elem.Name() {
+	case "auth":
+		o.Reset()
+		return o.handleStart(ctx, elem)
+	case "response":
+		if o.failed {
+			// client acknowledged error challenge (RFC 7628 section 3.2.3)
+			return nil, newSASLError(NotAuthorized, nil)
+		}
+	}
+	return nil, newSASLError(NotAuthorized, nil)
+}
+
+// Reset resets OAUTHBEARER authenticator internal state.
+func (o *OAuthBearer) Reset() {
+	o.username = ""
+	o.authenticated = false
+	o.failed = false
+}
+
+func (o *OAuthBearer) handleStart(ctx context.Context, elem stravaganza.Element) (stravaganza.Element, *SASLError) {
+	if len(elem.Text()) == 0 {
+		return nil, newSASLError(MalformedRequest, nil)
+	}
+	b, err := base64.StdEncoding.DecodeString(elem.Text())
+	if err != nil {
+		return nil, newSASLError(IncorrectEncoding, nil)
+	}
+	authzID, token, ok := parseOAuthBearerMessage(string(b))
+	if !ok {
+		return nil, newSASLError(MalformedRequest, nil)
+	}
+	claimedUsername, err := o.verifier.Verify(token)
+	switch {
+	case errors.Is(err, jwt.ErrInvalidToken):
+		return o.fail(), nil
+	case err != nil:
+		return nil, newSASLError(TemporaryAuthFailure, err)
+	}
+	domain := domainFromContext(ctx)
+
+	// username claim can either be a local part or a bare JID belonging to the authenticating domain
+	node := claimedUsername
+	if i := strings.LastIndex(claimedUsername, "@"); i >= 0 {
+		if len(domain) > 0 && claimedUsername[i+1:] != domain {
+			return o.fail(), nil
+		}
+		node = claimedUsername[:i]
+	}
+	j, err := jid.New(node, domain, "", false)
+	if err != nil || len(j.Node()) == 0 {
+		return o.fail(), nil
+	}
+	username := j.Node()
+
+	if len(authzID) > 0 && authzID != username && authzID != username+"@"+domain {
+		return o.fail(), nil
+	}
+	exists, err := o.rep.UserExists(ctx, username)
+	if err != nil {
+		return nil, newSASLError(TemporaryAuthFailure, err)
+	}
+	if !exists {
+		if !o.verifier.AutoProvision() {
+			return o.fail(), nil
+		}
+		// provisioned users have no stored credentials, so they will only be able to log in using a token
+		err := o.rep.UpsertUser(ctx, &usermodel.User{
+			Username: username,
+			Scram:    &usermodel.Scram{},
+		})
+		if err != nil {
+			return nil, newSASLError(TemporaryAuthFailure, err)
+		}
+	}
+	o.username = username
+	o.authenticated = true
+
+	return stravaganza.NewBuilder("success").
+		WithAttribute(stravaganza.Namespace, saslNamespace).
+		Build(), nil
+}
+
+func (o *OAuthBearer) fail() stravaganza.Element {
+	o.failed = true
+	return stravaganza.NewBuilder("challenge").
+		WithAttribute(stravaganza.Namespace, saslNamespace).
+		WithText(base64.StdEncoding.EncodeToString(invalidTokenStatus)).
+		Build()
+}
+
+// parseOAuthBearerMessage extracts authorization identity and bearer token from an RFC 7628 client initial response.
+func parseOAuthBearerMessage(msg string) (authzID, token string, ok bool) {
+	// gs2-header: cbind-flag "," [authzid] ","
+	hdr := strings.SplitN(msg, ",", 3)
+	if len(hdr) != 3 {
+		return "", "", false
+	}
+	switch hdr[0] {
+	case "n", "y":
+	default:
+		return "", "", false // channel binding is not supported
+	}
+	if len(hdr[1]) > 0 {
+		if !strings.HasPrefix(hdr[1], "a=") {
+			return "", "", false
+		}
+		authzID = decodeSASLName(hdr[1][2:])
+	}
+	kvs := hdr[2]
+	if !strings.HasPrefix(kvs, oauthBearerKVSep) || !strings.HasSuffix(kvs, oauthBearerKVSep+oauthBearerKVSep) {
+		return "", "", false
+	}
+	for _, kv := range strings.Split(kvs[1:len(kvs)-2], oauthBearerKVSep) {
+		k, v, found := strings.Cut(kv, "=")
+		if !found || k != "auth" {
+			continue
+		}
+		scheme, credentials, found := strings.Cut(v, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return "", "", false
+		}
+		token = strings.TrimSpace(credentials)
+	}
+	return authzID, token, len(token) > 0
+}
+
+func decodeSASLName(s string) string {
+	return strings.NewReplacer("=2C", ",", "=3D", "=").Replace(s)
+}
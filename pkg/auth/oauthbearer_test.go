@@ -0,0 +1,165 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/auth/jwt"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthBearer_Authenticate(t *testing.T) {
+	tcs := map[string]struct {
+		message          string
+		claimedUsername  string
+		verifyErr        error
+		autoProvision    bool
+		expectedReason   SASLErrorReason
+		expectedUsername string
+		expectsChallenge bool
+		expectsUpsert    bool
+	}{
+		"ValidToken": {
+			message:          "n,,\x01auth=Bearer valid-token\x01\x01",
+			claimedUsername:  "ortuman",
+			expectedUsername: "ortuman",
+		},
+		"BareJIDClaim": {
+			message:          "n,a=ortuman@jackal.im,\x01host=jackal.im\x01port=5222\x01auth=Bearer valid-token\x01\x01",
+			claimedUsername:  "ortuman@jackal.im",
+			expectedUsername: "ortuman",
+		},
+		"AutoProvision": {
+			message:          "n,,\x01auth=Bearer valid-token\x01\x01",
+			claimedUsername:  "noelia",
+			autoProvision:    true,
+			expectedUsername: "noelia",
+			expectsUpsert:    true,
+		},
+		"UnknownUser": {
+			message:          "n,,\x01auth=Bearer valid-token\x01\x01",
+			claimedUsername:  "noelia",
+			expectsChallenge: true,
+		},
+		"ForeignDomainClaim": {
+			message:          "n,,\x01auth=Bearer valid-token\x01\x01",
+			claimedUsername:  "ortuman@jabber.org",
+			expectsChallenge: true,
+		},
+		"AuthzIDMismatch": {
+			message:          "n,a=noelia@jackal.im,\x01auth=Bearer valid-token\x01\x01",
+			claimedUsername:  "ortuman",
+			expectsChallenge: true,
+		},
+		"InvalidToken": {
+			message:          "n,,\x01auth=Bearer invalid-token\x01\x01",
+			verifyErr:        fmt.Errorf("%w: token expired", jwt.ErrInvalidToken),
+			expectsChallenge: true,
+		},
+		"VerifierFailure": {
+			message:        "n,,\x01auth=Bearer valid-token\x01\x01",
+			verifyErr:      errors.New("jwt: failed to read JWKS file"),
+			expectedReason: TemporaryAuthFailure,
+		},
+		"ChannelBinding": {
+			message:        "p=tls-unique,,\x01auth=Bearer valid-token\x01\x01",
+			expectedReason: MalformedRequest,
+		},
+		"MissingToken": {
+			message:        "n,,\x01host=jackal.im\x01\x01",
+			expectedReason: MalformedRequest,
+		},
+		"UnsupportedScheme": {
+			message:        "n,,\x01auth=Basic b3J0dW1hbjoxMjM0\x01\x01",
+			expectedReason: MalformedRequest,
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			verifierMock := &jwtVerifierMock{}
+			verifierMock.VerifyFunc = func(token string) (string, error) {
+				if tc.verifyErr != nil {
+					return "", tc.verifyErr
+				}
+				return tc.claimedUsername, nil
+			}
+			verifierMock.AutoProvisionFunc = func() bool { return tc.autoProvision }
+
+			var upserted *usermodel.User
+			repMock := &usersRepository{}
+			repMock.UserExistsFunc = func(_ context.Context, username string) (bool, error) {
+				return username == "ortuman", nil
+			}
+			repMock.UpsertUserFunc = func(_ context.Context, user *usermodel.User) error {
+				upserted = user
+				return nil
+			}
+			o := &OAuthBearer{verifier: verifierMock, rep: repMock}
+
+			auth0 := stravaganza.NewBuilder("auth").
+				WithAttribute(stravaganza.Namespace, saslNamespace).
+				WithAttribute("mechanism", "OAUTHBEARER").
+				WithText(base64.StdEncoding.EncodeToString([]byte(tc.message))).
+				Build()
+
+			// when
+			resp, err := o.ProcessElement(WithDomain(context.Background(), "jackal.im"), auth0)
+
+			// then
+			switch {
+			case len(tc.expectedUsername) > 0:
+				require.Nil(t, err)
+				require.Equal(t, "success", resp.Name())
+				require.True(t, o.Authenticated())
+				require.Equal(t, tc.expectedUsername, o.Username())
+
+			case tc.expectsChallenge:
+				require.Nil(t, err)
+				require.Equal(t, "challenge", resp.Name())
+				require.Equal(t, base64.StdEncoding.EncodeToString(invalidTokenStatus), resp.Text())
+				require.False(t, o.Authenticated())
+
+				// client acknowledges error
+				resp1 := stravaganza.NewBuilder("response").
+					WithAttribute(stravaganza.Namespace, saslNamespace).
+					WithText(base64.StdEncoding.EncodeToString([]byte("\x01"))).
+					Build()
+				_, err = o.ProcessElement(context.Background(), resp1)
+				require.NotNil(t, err)
+				require.Equal(t, NotAuthorized, err.Reason)
+
+			default:
+				require.Nil(t, resp)
+				require.NotNil(t, err)
+				require.Equal(t, tc.expectedReason, err.Reason)
+				require.False(t, o.Authenticated())
+			}
+			if tc.expectsUpsert {
+				require.NotNil(t, upserted)
+				require.Equal(t, tc.expectedUsername, upserted.Username)
+			} else {
+				require.Nil(t, upserted)
+			}
+		})
+	}
+}
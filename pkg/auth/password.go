@@ -0,0 +1,96 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"hash"
+
+	"github.com/ortuman/jackal/pkg/auth/pepper"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/sha3"
+)
+
+const saltLength = 32
+
+// NewScramCredentials derives the salted SCRAM credentials for password using
+// active pepper key and configured iteration count.
+func NewScramCredentials(password string, peppers *pepper.Keys) (*usermodel.Scram, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	pepperedSalt := pepperSalt(salt, peppers.GetActiveKey())
+	iterationCount := peppers.IterationCount()
+
+	// generate password hashes
+	hSHA1 := hashPassword([]byte(password), pepperedSalt, iterationCount, sha1.Size, sha1.New)
+	hSHA256 := hashPassword([]byte(password), pepperedSalt, iterationCount, sha256.Size, sha256.New)
+	hSHA512 := hashPassword([]byte(password), pepperedSalt, iterationCount, sha512.Size, sha512.New)
+	hSHA3512 := hashPassword([]byte(password), pepperedSalt, iterationCount, sha512.Size, sha3.New512)
+
+	return &usermodel.Scram{
+		Sha1:           base64.RawURLEncoding.EncodeToString(hSHA1),
+		Sha256:         base64.RawURLEncoding.EncodeToString(hSHA256),
+		Sha512:         base64.RawURLEncoding.EncodeToString(hSHA512),
+		Sha3512:        base64.RawURLEncoding.EncodeToString(hSHA3512),
+		Salt:           base64.RawURLEncoding.EncodeToString(salt),
+		IterationCount: int64(iterationCount),
+		PepperId:       peppers.GetActiveID(),
+	}, nil
+}
+
+// ScramCredentialsOutdated tells whether stored credentials were derived using a pepper key
+// other than the active one, or using less iterations than currently configured.
+func ScramCredentialsOutdated(scram *usermodel.Scram, peppers *pepper.Keys) bool {
+	return scram.GetPepperId() != peppers.GetActiveID() || scram.GetIterationCount() < int64(peppers.IterationCount())
+}
+
+// verifyPassword checks password against the stored SCRAM credentials.
+func verifyPassword(password string, scram *usermodel.Scram, peppers *pepper.Keys) bool {
+	if len(scram.GetSha256()) == 0 || scram.GetIterationCount() <= 0 {
+		return false
+	}
+	storedHash, err := base64.RawURLEncoding.DecodeString(scram.GetSha256())
+	if err != nil {
+		return false
+	}
+	salt, err := base64.RawURLEncoding.DecodeString(scram.GetSalt())
+	if err != nil {
+		return false
+	}
+	pepperedSalt := pepperSalt(salt, peppers.GetKey(scram.GetPepperId()))
+	h := hashPassword([]byte(password), pepperedSalt, int(scram.GetIterationCount()), sha256.Size, sha256.New)
+
+	return subtle.ConstantTimeCompare(h, storedHash) == 1
+}
+
+func pepperSalt(salt []byte, pepper string) []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.Write(salt)
+	buf.WriteString(pepper)
+	return buf.Bytes()
+}
+
+func hashPassword(password, salt []byte, iterations int, hKeyLen int, h func() hash.Hash) []byte {
+	return pbkdf2.Key(password, salt, iterations, hKeyLen, h)
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/ortuman/jackal/pkg/auth/pepper"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassword_NewScramCredentials(t *testing.T) {
+	// given
+	peppers := testPeppers()
+
+	// when
+	scram, err := NewScramCredentials("1234", peppers)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, "v1", scram.PepperId)
+	require.Equal(t, int64(peppers.IterationCount()), scram.IterationCount)
+	require.NotEmpty(t, scram.Sha1)
+	require.NotEmpty(t, scram.Sha512)
+	require.NotEmpty(t, scram.Sha3512)
+
+	require.True(t, verifyPassword("1234", scram, peppers))
+	require.False(t, verifyPassword("4321", scram, peppers))
+	require.False(t, ScramCredentialsOutdated(scram, peppers))
+}
+
+func TestPassword_ScramCredentialsOutdated(t *testing.T) {
+	peppers, _ := pepper.NewKeys(pepper.Config{
+		Keys: map[string]string{
+			"v1": pepperKey,
+			"v2": "Y3kLqUvGz8mWn2bTr5HsJd7x",
+		},
+		UseID:          "v2",
+		IterationCount: 20_000,
+	})
+	tcs := map[string]struct {
+		scram    *usermodel.Scram
+		outdated bool
+	}{
+		"UpToDate": {
+			scram:    &usermodel.Scram{PepperId: "v2", IterationCount: 20_000},
+			outdated: false,
+		},
+		"MoreIterations": {
+			scram:    &usermodel.Scram{PepperId: "v2", IterationCount: 100_000},
+			outdated: false,
+		},
+		"StalePepper": {
+			scram:    &usermodel.Scram{PepperId: "v1", IterationCount: 20_000},
+			outdated: true,
+		},
+		"StaleIterationCount": {
+			scram:    &usermodel.Scram{PepperId: "v2", IterationCount: 15_000},
+			outdated: true,
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			require.Equal(t, tc.outdated, ScramCredentialsOutdated(tc.scram, peppers))
+		})
+	}
+}
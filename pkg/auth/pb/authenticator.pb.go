@@ -14,23 +14,17 @@
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.25.0
-// 	protoc        v3.6.1
-// source: jackal/proto/authenticator/v1/authenticator.proto
+// 	protoc-gen-go v1.26.0
+// 	protoc        v3.21.5
+// source: proto/authenticator/v1/authenticator.proto
 
-package v1
+package pb
 
 import (
-	context "context"
-	reflect "reflect"
-	sync "sync"
-
-	proto "github.com/golang/protobuf/proto"
-	grpc "google.golang.org/grpc"
-	codes "google.golang.org/grpc/codes"
-	status "google.golang.org/grpc/status"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
 )
 
 const (
@@ -40,9 +34,61 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// This is a compile-time assertion that a sufficiently up-to-date version
-// of the legacy proto package is being used.
-const _ = proto.ProtoPackageIsVersion4
+// ScramMechanism represents a SCRAM hash function.
+type ScramMechanism int32
+
+const (
+	ScramMechanism_SCRAM_MECHANISM_UNSPECIFIED ScramMechanism = 0
+	ScramMechanism_SCRAM_MECHANISM_SHA_1       ScramMechanism = 1
+	ScramMechanism_SCRAM_MECHANISM_SHA_256     ScramMechanism = 2
+	ScramMechanism_SCRAM_MECHANISM_SHA_512     ScramMechanism = 3
+	ScramMechanism_SCRAM_MECHANISM_SHA3_512    ScramMechanism = 4
+)
+
+// Enum value maps for ScramMechanism.
+var (
+	ScramMechanism_name = map[int32]string{
+		0: "SCRAM_MECHANISM_UNSPECIFIED",
+		1: "SCRAM_MECHANISM_SHA_1",
+		2: "SCRAM_MECHANISM_SHA_256",
+		3: "SCRAM_MECHANISM_SHA_512",
+		4: "SCRAM_MECHANISM_SHA3_512",
+	}
+	ScramMechanism_value = map[string]int32{
+		"SCRAM_MECHANISM_UNSPECIFIED": 0,
+		"SCRAM_MECHANISM_SHA_1":       1,
+		"SCRAM_MECHANISM_SHA_256":     2,
+		"SCRAM_MECHANISM_SHA_512":     3,
+		"SCRAM_MECHANISM_SHA3_512":    4,
+	}
+)
+
+func (x ScramMechanism) Enum() *ScramMechanism {
+	p := new(ScramMechanism)
+	*p = x
+	return p
+}
+
+func (x ScramMechanism) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ScramMechanism) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_authenticator_v1_authenticator_proto_enumTypes[0].Descriptor()
+}
+
+func (ScramMechanism) Type() protoreflect.EnumType {
+	return &file_proto_authenticator_v1_authenticator_proto_enumTypes[0]
+}
+
+func (x ScramMechanism) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ScramMechanism.Descriptor instead.
+func (ScramMechanism) EnumDescriptor() ([]byte, []int) {
+	return file_proto_authenticator_v1_authenticator_proto_rawDescGZIP(), []int{0}
+}
 
 // AuthenticateRequest is the parameter message for Authenticate rpc.
 type AuthenticateRequest struct {
@@ -59,7 +105,7 @@ type AuthenticateRequest struct {
 func (x *AuthenticateRequest) Reset() {
 	*x = AuthenticateRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jackal_proto_authenticator_v1_authenticator_proto_msgTypes[0]
+		mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[0]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -72,7 +118,7 @@ func (x *AuthenticateRequest) String() string {
 func (*AuthenticateRequest) ProtoMessage() {}
 
 func (x *AuthenticateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_jackal_proto_authenticator_v1_authenticator_proto_msgTypes[0]
+	mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[0]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -85,7 +131,7 @@ func (x *AuthenticateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AuthenticateRequest.ProtoReflect.Descriptor instead.
 func (*AuthenticateRequest) Descriptor() ([]byte, []int) {
-	return file_jackal_proto_authenticator_v1_authenticator_proto_rawDescGZIP(), []int{0}
+	return file_proto_authenticator_v1_authenticator_proto_rawDescGZIP(), []int{0}
 }
 
 func (x *AuthenticateRequest) GetUsername() string {
@@ -115,7 +161,7 @@ type AuthenticateResponse struct {
 func (x *AuthenticateResponse) Reset() {
 	*x = AuthenticateResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_jackal_proto_authenticator_v1_authenticator_proto_msgTypes[1]
+		mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[1]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -128,7 +174,7 @@ func (x *AuthenticateResponse) String() string {
 func (*AuthenticateResponse) ProtoMessage() {}
 
 func (x *AuthenticateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_jackal_proto_authenticator_v1_authenticator_proto_msgTypes[1]
+	mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[1]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -141,7 +187,7 @@ func (x *AuthenticateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AuthenticateResponse.ProtoReflect.Descriptor instead.
 func (*AuthenticateResponse) Descriptor() ([]byte, []int) {
-	return file_jackal_proto_authenticator_v1_authenticator_proto_rawDescGZIP(), []int{1}
+	return file_proto_authenticator_v1_authenticator_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *AuthenticateResponse) GetAuthenticated() bool {
@@ -151,75 +197,372 @@ func (x *AuthenticateResponse) GetAuthenticated() bool {
 	return false
 }
 
-var File_jackal_proto_authenticator_v1_authenticator_proto protoreflect.FileDescriptor
+// GetScramCredentialsRequest is the parameter message for GetScramCredentials rpc.
+type GetScramCredentialsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// username is the name of the user who's trying to authenticate.
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	// mechanism is the SCRAM hash function credentials are requested for.
+	Mechanism ScramMechanism `protobuf:"varint,2,opt,name=mechanism,proto3,enum=jackal.api.authenticator.v1.ScramMechanism" json:"mechanism,omitempty"`
+}
+
+func (x *GetScramCredentialsRequest) Reset() {
+	*x = GetScramCredentialsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetScramCredentialsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetScramCredentialsRequest) ProtoMessage() {}
+
+func (x *GetScramCredentialsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetScramCredentialsRequest.ProtoReflect.Descriptor instead.
+func (*GetScramCredentialsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_authenticator_v1_authenticator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetScramCredentialsRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *GetScramCredentialsRequest) GetMechanism() ScramMechanism {
+	if x != nil {
+		return x.Mechanism
+	}
+	return ScramMechanism_SCRAM_MECHANISM_UNSPECIFIED
+}
+
+// GetScramCredentialsResponse is the response returned by GetScramCredentials rpc.
+type GetScramCredentialsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// found tells whether or not user credentials for the requested mechanism exist.
+	Found bool `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	// salt is the salt used to derive the salted password.
+	Salt []byte `protobuf:"bytes,2,opt,name=salt,proto3" json:"salt,omitempty"`
+	// iteration_count is the number of iterations used to derive the salted password.
+	IterationCount uint32 `protobuf:"varint,3,opt,name=iteration_count,json=iterationCount,proto3" json:"iteration_count,omitempty"`
+	// stored_key is H(HMAC(SaltedPassword, "Client Key")) as defined in RFC 5802.
+	StoredKey []byte `protobuf:"bytes,4,opt,name=stored_key,json=storedKey,proto3" json:"stored_key,omitempty"`
+	// server_key is HMAC(SaltedPassword, "Server Key") as defined in RFC 5802.
+	ServerKey []byte `protobuf:"bytes,5,opt,name=server_key,json=serverKey,proto3" json:"server_key,omitempty"`
+}
+
+func (x *GetScramCredentialsResponse) Reset() {
+	*x = GetScramCredentialsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetScramCredentialsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetScramCredentialsResponse) ProtoMessage() {}
+
+func (x *GetScramCredentialsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetScramCredentialsResponse.ProtoReflect.Descriptor instead.
+func (*GetScramCredentialsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_authenticator_v1_authenticator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetScramCredentialsResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *GetScramCredentialsResponse) GetSalt() []byte {
+	if x != nil {
+		return x.Salt
+	}
+	return nil
+}
+
+func (x *GetScramCredentialsResponse) GetIterationCount() uint32 {
+	if x != nil {
+		return x.IterationCount
+	}
+	return 0
+}
+
+func (x *GetScramCredentialsResponse) GetStoredKey() []byte {
+	if x != nil {
+		return x.StoredKey
+	}
+	return nil
+}
+
+func (x *GetScramCredentialsResponse) GetServerKey() []byte {
+	if x != nil {
+		return x.ServerKey
+	}
+	return nil
+}
+
+// UserExistsRequest is the parameter message for UserExists rpc.
+type UserExistsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// username is the name of the user whose existence is checked.
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+}
+
+func (x *UserExistsRequest) Reset() {
+	*x = UserExistsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
 
-var file_jackal_proto_authenticator_v1_authenticator_proto_rawDesc = []byte{
-	0x0a, 0x31, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61,
-	0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2f, 0x76, 0x31, 0x2f,
-	0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x12, 0x1b, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+func (x *UserExistsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserExistsRequest) ProtoMessage() {}
+
+func (x *UserExistsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserExistsRequest.ProtoReflect.Descriptor instead.
+func (*UserExistsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_authenticator_v1_authenticator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UserExistsRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+// UserExistsResponse is the response returned by UserExists rpc.
+type UserExistsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// exists tells whether or not user exists.
+	Exists bool `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
+}
+
+func (x *UserExistsResponse) Reset() {
+	*x = UserExistsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UserExistsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserExistsResponse) ProtoMessage() {}
+
+func (x *UserExistsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_authenticator_v1_authenticator_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserExistsResponse.ProtoReflect.Descriptor instead.
+func (*UserExistsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_authenticator_v1_authenticator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UserExistsResponse) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
+var File_proto_authenticator_v1_authenticator_proto protoreflect.FileDescriptor
+
+var file_proto_authenticator_v1_authenticator_proto_rawDesc = []byte{
+	0x0a, 0x2a, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69,
+	0x63, 0x61, 0x74, 0x6f, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74,
+	0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1b, 0x6a, 0x61,
+	0x63, 0x6b, 0x61, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74,
+	0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x22, 0x4d, 0x0a, 0x13, 0x41, 0x75, 0x74,
+	0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x22, 0x3c, 0x0a, 0x14, 0x41, 0x75, 0x74, 0x68,
+	0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x24, 0x0a, 0x0d, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x64, 0x22, 0x83, 0x01, 0x0a, 0x1a, 0x47, 0x65, 0x74, 0x53, 0x63,
+	0x72, 0x61, 0x6d, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x49, 0x0a, 0x09, 0x6d, 0x65, 0x63, 0x68, 0x61, 0x6e, 0x69, 0x73, 0x6d, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x2b, 0x2e, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x63, 0x72, 0x61, 0x6d, 0x4d, 0x65, 0x63, 0x68, 0x61, 0x6e, 0x69, 0x73,
+	0x6d, 0x52, 0x09, 0x6d, 0x65, 0x63, 0x68, 0x61, 0x6e, 0x69, 0x73, 0x6d, 0x22, 0xae, 0x01, 0x0a,
+	0x1b, 0x47, 0x65, 0x74, 0x53, 0x63, 0x72, 0x61, 0x6d, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x66, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x66, 0x6f, 0x75,
+	0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x61, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x04, 0x73, 0x61, 0x6c, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0e, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12,
+	0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x64, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x12, 0x1d,
+	0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x09, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x22, 0x2f, 0x0a,
+	0x11, 0x55, 0x73, 0x65, 0x72, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x2c,
+	0x0a, 0x12, 0x55, 0x73, 0x65, 0x72, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x2a, 0xa4, 0x01, 0x0a,
+	0x0e, 0x53, 0x63, 0x72, 0x61, 0x6d, 0x4d, 0x65, 0x63, 0x68, 0x61, 0x6e, 0x69, 0x73, 0x6d, 0x12,
+	0x1f, 0x0a, 0x1b, 0x53, 0x43, 0x52, 0x41, 0x4d, 0x5f, 0x4d, 0x45, 0x43, 0x48, 0x41, 0x4e, 0x49,
+	0x53, 0x4d, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00,
+	0x12, 0x19, 0x0a, 0x15, 0x53, 0x43, 0x52, 0x41, 0x4d, 0x5f, 0x4d, 0x45, 0x43, 0x48, 0x41, 0x4e,
+	0x49, 0x53, 0x4d, 0x5f, 0x53, 0x48, 0x41, 0x5f, 0x31, 0x10, 0x01, 0x12, 0x1b, 0x0a, 0x17, 0x53,
+	0x43, 0x52, 0x41, 0x4d, 0x5f, 0x4d, 0x45, 0x43, 0x48, 0x41, 0x4e, 0x49, 0x53, 0x4d, 0x5f, 0x53,
+	0x48, 0x41, 0x5f, 0x32, 0x35, 0x36, 0x10, 0x02, 0x12, 0x1b, 0x0a, 0x17, 0x53, 0x43, 0x52, 0x41,
+	0x4d, 0x5f, 0x4d, 0x45, 0x43, 0x48, 0x41, 0x4e, 0x49, 0x53, 0x4d, 0x5f, 0x53, 0x48, 0x41, 0x5f,
+	0x35, 0x31, 0x32, 0x10, 0x03, 0x12, 0x1c, 0x0a, 0x18, 0x53, 0x43, 0x52, 0x41, 0x4d, 0x5f, 0x4d,
+	0x45, 0x43, 0x48, 0x41, 0x4e, 0x49, 0x53, 0x4d, 0x5f, 0x53, 0x48, 0x41, 0x33, 0x5f, 0x35, 0x31,
+	0x32, 0x10, 0x04, 0x32, 0xfe, 0x02, 0x0a, 0x0d, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69,
+	0x63, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x73, 0x0a, 0x0c, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x30, 0x2e, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x31, 0x2e, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74,
+	0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x88, 0x01, 0x0a, 0x13, 0x47,
+	0x65, 0x74, 0x53, 0x63, 0x72, 0x61, 0x6d, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61,
+	0x6c, 0x73, 0x12, 0x37, 0x2e, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e,
 	0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31,
-	0x22, 0x4d, 0x0a, 0x13, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e,
-	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e,
-	0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x22,
-	0x3c, 0x0a, 0x14, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x61, 0x75, 0x74, 0x68, 0x65,
-	0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d,
-	0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x64, 0x32, 0x84, 0x01,
-	0x0a, 0x0d, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x12,
-	0x73, 0x0a, 0x0c, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12,
-	0x30, 0x2e, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x61, 0x75, 0x74,
-	0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x75,
-	0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x31, 0x2e, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x61,
-	0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e,
-	0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x42, 0x72, 0x0a, 0x21, 0x6f, 0x72, 0x67, 0x2e, 0x6a, 0x61, 0x63, 0x6b,
-	0x61, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74,
-	0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x42, 0x14, 0x41, 0x75, 0x74, 0x68, 0x65,
-	0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x47, 0x72, 0x70, 0x63, 0x41, 0x50, 0x49, 0x50,
-	0x01, 0x5a, 0x35, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x61,
-	0x63, 0x6b, 0x61, 0x6c, 0x2d, 0x78, 0x6d, 0x70, 0x70, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x67, 0x6f, 0x2f, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69,
-	0x63, 0x61, 0x74, 0x6f, 0x72, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x2e, 0x47, 0x65, 0x74, 0x53, 0x63, 0x72, 0x61, 0x6d, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x38, 0x2e, 0x6a, 0x61,
+	0x63, 0x6b, 0x61, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74,
+	0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x63, 0x72,
+	0x61, 0x6d, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x6d, 0x0a, 0x0a, 0x55, 0x73, 0x65, 0x72, 0x45, 0x78, 0x69,
+	0x73, 0x74, 0x73, 0x12, 0x2e, 0x2e, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x2f, 0x2e, 0x6a, 0x61, 0x63, 0x6b, 0x61, 0x6c, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0d, 0x5a, 0x0b, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x75, 0x74, 0x68,
+	0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
-	file_jackal_proto_authenticator_v1_authenticator_proto_rawDescOnce sync.Once
-	file_jackal_proto_authenticator_v1_authenticator_proto_rawDescData = file_jackal_proto_authenticator_v1_authenticator_proto_rawDesc
+	file_proto_authenticator_v1_authenticator_proto_rawDescOnce sync.Once
+	file_proto_authenticator_v1_authenticator_proto_rawDescData = file_proto_authenticator_v1_authenticator_proto_rawDesc
 )
 
-func file_jackal_proto_authenticator_v1_authenticator_proto_rawDescGZIP() []byte {
-	file_jackal_proto_authenticator_v1_authenticator_proto_rawDescOnce.Do(func() {
-		file_jackal_proto_authenticator_v1_authenticator_proto_rawDescData = protoimpl.X.CompressGZIP(file_jackal_proto_authenticator_v1_authenticator_proto_rawDescData)
+func file_proto_authenticator_v1_authenticator_proto_rawDescGZIP() []byte {
+	file_proto_authenticator_v1_authenticator_proto_rawDescOnce.Do(func() {
+		file_proto_authenticator_v1_authenticator_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_authenticator_v1_authenticator_proto_rawDescData)
 	})
-	return file_jackal_proto_authenticator_v1_authenticator_proto_rawDescData
+	return file_proto_authenticator_v1_authenticator_proto_rawDescData
 }
 
-var file_jackal_proto_authenticator_v1_authenticator_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
-var file_jackal_proto_authenticator_v1_authenticator_proto_goTypes = []interface{}{
-	(*AuthenticateRequest)(nil),  // 0: jackal.api.authenticator.v1.AuthenticateRequest
-	(*AuthenticateResponse)(nil), // 1: jackal.api.authenticator.v1.AuthenticateResponse
+var file_proto_authenticator_v1_authenticator_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_authenticator_v1_authenticator_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_authenticator_v1_authenticator_proto_goTypes = []interface{}{
+	(ScramMechanism)(0),                 // 0: jackal.api.authenticator.v1.ScramMechanism
+	(*AuthenticateRequest)(nil),         // 1: jackal.api.authenticator.v1.AuthenticateRequest
+	(*AuthenticateResponse)(nil),        // 2: jackal.api.authenticator.v1.AuthenticateResponse
+	(*GetScramCredentialsRequest)(nil),  // 3: jackal.api.authenticator.v1.GetScramCredentialsRequest
+	(*GetScramCredentialsResponse)(nil), // 4: jackal.api.authenticator.v1.GetScramCredentialsResponse
+	(*UserExistsRequest)(nil),           // 5: jackal.api.authenticator.v1.UserExistsRequest
+	(*UserExistsResponse)(nil),          // 6: jackal.api.authenticator.v1.UserExistsResponse
 }
-var file_jackal_proto_authenticator_v1_authenticator_proto_depIdxs = []int32{
-	0, // 0: jackal.api.authenticator.v1.Authenticator.Authenticate:input_type -> jackal.api.authenticator.v1.AuthenticateRequest
-	1, // 1: jackal.api.authenticator.v1.Authenticator.Authenticate:output_type -> jackal.api.authenticator.v1.AuthenticateResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+var file_proto_authenticator_v1_authenticator_proto_depIdxs = []int32{
+	0, // 0: jackal.api.authenticator.v1.GetScramCredentialsRequest.mechanism:type_name -> jackal.api.authenticator.v1.ScramMechanism
+	1, // 1: jackal.api.authenticator.v1.Authenticator.Authenticate:input_type -> jackal.api.authenticator.v1.AuthenticateRequest
+	3, // 2: jackal.api.authenticator.v1.Authenticator.GetScramCredentials:input_type -> jackal.api.authenticator.v1.GetScramCredentialsRequest
+	5, // 3: jackal.api.authenticator.v1.Authenticator.UserExists:input_type -> jackal.api.authenticator.v1.UserExistsRequest
+	2, // 4: jackal.api.authenticator.v1.Authenticator.Authenticate:output_type -> jackal.api.authenticator.v1.AuthenticateResponse
+	4, // 5: jackal.api.authenticator.v1.Authenticator.GetScramCredentials:output_type -> jackal.api.authenticator.v1.GetScramCredentialsResponse
+	6, // 6: jackal.api.authenticator.v1.Authenticator.UserExists:output_type -> jackal.api.authenticator.v1.UserExistsResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
-func init() { file_jackal_proto_authenticator_v1_authenticator_proto_init() }
-func file_jackal_proto_authenticator_v1_authenticator_proto_init() {
-	if File_jackal_proto_authenticator_v1_authenticator_proto != nil {
+func init() { file_proto_authenticator_v1_authenticator_proto_init() }
+func file_proto_authenticator_v1_authenticator_proto_init() {
+	if File_proto_authenticator_v1_authenticator_proto != nil {
 		return
 	}
 	if !protoimpl.UnsafeEnabled {
-		file_jackal_proto_authenticator_v1_authenticator_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+		file_proto_authenticator_v1_authenticator_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*AuthenticateRequest); i {
 			case 0:
 				return &v.state
@@ -231,7 +574,7 @@ func file_jackal_proto_authenticator_v1_authenticator_proto_init() {
 				return nil
 			}
 		}
-		file_jackal_proto_authenticator_v1_authenticator_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+		file_proto_authenticator_v1_authenticator_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*AuthenticateResponse); i {
 			case 0:
 				return &v.state
@@ -243,105 +586,72 @@ func file_jackal_proto_authenticator_v1_authenticator_proto_init() {
 				return nil
 			}
 		}
+		file_proto_authenticator_v1_authenticator_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetScramCredentialsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_authenticator_v1_authenticator_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetScramCredentialsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_authenticator_v1_authenticator_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UserExistsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_authenticator_v1_authenticator_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UserExistsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_jackal_proto_authenticator_v1_authenticator_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   2,
+			RawDescriptor: file_proto_authenticator_v1_authenticator_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
-		GoTypes:           file_jackal_proto_authenticator_v1_authenticator_proto_goTypes,
-		DependencyIndexes: file_jackal_proto_authenticator_v1_authenticator_proto_depIdxs,
-		MessageInfos:      file_jackal_proto_authenticator_v1_authenticator_proto_msgTypes,
+		GoTypes:           file_proto_authenticator_v1_authenticator_proto_goTypes,
+		DependencyIndexes: file_proto_authenticator_v1_authenticator_proto_depIdxs,
+		EnumInfos:         file_proto_authenticator_v1_authenticator_proto_enumTypes,
+		MessageInfos:      file_proto_authenticator_v1_authenticator_proto_msgTypes,
 	}.Build()
-	File_jackal_proto_authenticator_v1_authenticator_proto = out.File
-	file_jackal_proto_authenticator_v1_authenticator_proto_rawDesc = nil
-	file_jackal_proto_authenticator_v1_authenticator_proto_goTypes = nil
-	file_jackal_proto_authenticator_v1_authenticator_proto_depIdxs = nil
-}
-
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// AuthenticatorClient is the client API for Authenticator service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type AuthenticatorClient interface {
-	// Authenticate method performs authentication given a username and password.
-	Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error)
-}
-
-type authenticatorClient struct {
-	cc *grpc.ClientConn
-}
-
-func NewAuthenticatorClient(cc *grpc.ClientConn) AuthenticatorClient {
-	return &authenticatorClient{cc}
-}
-
-func (c *authenticatorClient) Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error) {
-	out := new(AuthenticateResponse)
-	err := c.cc.Invoke(ctx, "/jackal.api.authenticator.v1.Authenticator/Authenticate", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
-// AuthenticatorServer is the server API for Authenticator service.
-type AuthenticatorServer interface {
-	// Authenticate method performs authentication given a username and password.
-	Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error)
-}
-
-// UnimplementedAuthenticatorServer can be embedded to have forward compatible implementations.
-type UnimplementedAuthenticatorServer struct {
-}
-
-func (*UnimplementedAuthenticatorServer) Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Authenticate not implemented")
-}
-
-func RegisterAuthenticatorServer(s *grpc.Server, srv AuthenticatorServer) {
-	s.RegisterService(&_Authenticator_serviceDesc, srv)
-}
-
-func _Authenticator_Authenticate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AuthenticateRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(AuthenticatorServer).Authenticate(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/jackal.api.authenticator.v1.Authenticator/Authenticate",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthenticatorServer).Authenticate(ctx, req.(*AuthenticateRequest))
-	}
-	return interceptor(ctx, in, info, handler)
-}
-
-var _Authenticator_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "jackal.api.authenticator.v1.Authenticator",
-	HandlerType: (*AuthenticatorServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Authenticate",
-			Handler:    _Authenticator_Authenticate_Handler,
-		},
-	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "jackal/proto/authenticator/v1/authenticator.proto",
+	File_proto_authenticator_v1_authenticator_proto = out.File
+	file_proto_authenticator_v1_authenticator_proto_rawDesc = nil
+	file_proto_authenticator_v1_authenticator_proto_goTypes = nil
+	file_proto_authenticator_v1_authenticator_proto_depIdxs = nil
 }
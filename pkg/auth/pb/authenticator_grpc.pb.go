@@ -0,0 +1,179 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AuthenticatorClient is the client API for Authenticator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AuthenticatorClient interface {
+	// Authenticate method performs authentication given a username and password.
+	Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error)
+	// GetScramCredentials method returns the salted credentials used to run a SCRAM exchange locally.
+	GetScramCredentials(ctx context.Context, in *GetScramCredentialsRequest, opts ...grpc.CallOption) (*GetScramCredentialsResponse, error)
+	// UserExists method tells whether or not a user exists.
+	UserExists(ctx context.Context, in *UserExistsRequest, opts ...grpc.CallOption) (*UserExistsResponse, error)
+}
+
+type authenticatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuthenticatorClient(cc grpc.ClientConnInterface) AuthenticatorClient {
+	return &authenticatorClient{cc}
+}
+
+func (c *authenticatorClient) Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error) {
+	out := new(AuthenticateResponse)
+	err := c.cc.Invoke(ctx, "/jackal.api.authenticator.v1.Authenticator/Authenticate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authenticatorClient) GetScramCredentials(ctx context.Context, in *GetScramCredentialsRequest, opts ...grpc.CallOption) (*GetScramCredentialsResponse, error) {
+	out := new(GetScramCredentialsResponse)
+	err := c.cc.Invoke(ctx, "/jackal.api.authenticator.v1.Authenticator/GetScramCredentials", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authenticatorClient) UserExists(ctx context.Context, in *UserExistsRequest, opts ...grpc.CallOption) (*UserExistsResponse, error) {
+	out := new(UserExistsResponse)
+	err := c.cc.Invoke(ctx, "/jackal.api.authenticator.v1.Authenticator/UserExists", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuthenticatorServer is the server API for Authenticator service.
+// All implementations must embed UnimplementedAuthenticatorServer
+// for forward compatibility
+type AuthenticatorServer interface {
+	// Authenticate method performs authentication given a username and password.
+	Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error)
+	// GetScramCredentials method returns the salted credentials used to run a SCRAM exchange locally.
+	GetScramCredentials(context.Context, *GetScramCredentialsRequest) (*GetScramCredentialsResponse, error)
+	// UserExists method tells whether or not a user exists.
+	UserExists(context.Context, *UserExistsRequest) (*UserExistsResponse, error)
+	mustEmbedUnimplementedAuthenticatorServer()
+}
+
+// UnimplementedAuthenticatorServer must be embedded to have forward compatible implementations.
+type UnimplementedAuthenticatorServer struct {
+}
+
+func (UnimplementedAuthenticatorServer) Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Authenticate not implemented")
+}
+func (UnimplementedAuthenticatorServer) GetScramCredentials(context.Context, *GetScramCredentialsRequest) (*GetScramCredentialsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetScramCredentials not implemented")
+}
+func (UnimplementedAuthenticatorServer) UserExists(context.Context, *UserExistsRequest) (*UserExistsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UserExists not implemented")
+}
+func (UnimplementedAuthenticatorServer) mustEmbedUnimplementedAuthenticatorServer() {}
+
+// UnsafeAuthenticatorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuthenticatorServer will
+// result in compilation errors.
+type UnsafeAuthenticatorServer interface {
+	mustEmbedUnimplementedAuthenticatorServer()
+}
+
+func RegisterAuthenticatorServer(s grpc.ServiceRegistrar, srv AuthenticatorServer) {
+	s.RegisterService(&Authenticator_ServiceDesc, srv)
+}
+
+func _Authenticator_Authenticate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthenticateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthenticatorServer).Authenticate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jackal.api.authenticator.v1.Authenticator/Authenticate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthenticatorServer).Authenticate(ctx, req.(*AuthenticateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Authenticator_GetScramCredentials_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetScramCredentialsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthenticatorServer).GetScramCredentials(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jackal.api.authenticator.v1.Authenticator/GetScramCredentials",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthenticatorServer).GetScramCredentials(ctx, req.(*GetScramCredentialsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Authenticator_UserExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserExistsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthenticatorServer).UserExists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jackal.api.authenticator.v1.Authenticator/UserExists",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthenticatorServer).UserExists(ctx, req.(*UserExistsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Authenticator_ServiceDesc is the grpc.ServiceDesc for Authenticator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Authenticator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "jackal.api.authenticator.v1.Authenticator",
+	HandlerType: (*AuthenticatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Authenticate",
+			Handler:    _Authenticator_Authenticate_Handler,
+		},
+		{
+			MethodName: "GetScramCredentials",
+			Handler:    _Authenticator_GetScramCredentials_Handler,
+		},
+		{
+			MethodName: "UserExists",
+			Handler:    _Authenticator_UserExists_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/authenticator/v1/authenticator.proto",
+}
@@ -17,28 +17,45 @@ package pepper
 import (
 	"errors"
 	"fmt"
+	"sort"
 )
 
-const noneID = "none"
+const (
+	noneID = "none"
+
+	defaultIterationCount = 15_000
+	minIterationCount     = 4096
+)
 
 var minKeyLength = 24
 
 // Keys contains all configured pepper keys.
 type Keys struct {
-	ks    map[string]string
-	useID string
+	ks             map[string]string
+	useID          string
+	iterationCount int
 }
 
 // Config contains Keys configuration parameters.
 type Config struct {
 	Keys  map[string]string `fig:"keys"`
 	UseID string            `fig:"use"`
+
+	// IterationCount defines the PBKDF2 iteration count used to derive newly stored credentials.
+	IterationCount int `fig:"iteration_count" default:"15000"`
 }
 
 // NewKeys returns an initialized set of pepper keys.
 func NewKeys(cfg Config) (*Keys, error) {
+	iterationCount := cfg.IterationCount
+	switch {
+	case iterationCount == 0:
+		iterationCount = defaultIterationCount
+	case iterationCount < minIterationCount:
+		return nil, fmt.Errorf("pepper: iteration count must be at least %d", minIterationCount)
+	}
 	if len(cfg.Keys) == 0 {
-		return &Keys{useID: noneID}, nil
+		return &Keys{useID: noneID, iterationCount: iterationCount}, nil
 	}
 	if len(cfg.UseID) == 0 {
 		return nil, errors.New(`pepper: no active key defined (forgot to set "use" key?)`)
@@ -52,7 +69,7 @@ func NewKeys(cfg Config) (*Keys, error) {
 	if !ok {
 		return nil, fmt.Errorf("pepper: active key not found: %s", cfg.UseID)
 	}
-	return &Keys{ks: cfg.Keys, useID: cfg.UseID}, nil
+	return &Keys{ks: cfg.Keys, useID: cfg.UseID, iterationCount: iterationCount}, nil
 }
 
 // GetKey returns pepper associated to an identifier.
@@ -72,3 +89,21 @@ func (k *Keys) GetActiveKey() string {
 func (k *Keys) GetActiveID() string {
 	return k.useID
 }
+
+// GetIDs returns all configured pepper identifiers.
+func (k *Keys) GetIDs() []string {
+	if k.useID == noneID {
+		return nil
+	}
+	ids := make([]string, 0, len(k.ks))
+	for id := range k.ks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// IterationCount returns the iteration count used to derive newly stored credentials.
+func (k *Keys) IterationCount() int {
+	return k.iterationCount
+}
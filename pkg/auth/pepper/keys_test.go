@@ -41,6 +41,8 @@ func TestKeys_Get(t *testing.T) {
 	require.Equal(t, "k1", ks.GetKey("v1"))
 
 	require.Equal(t, "v2", ks.GetActiveID())
+	require.Equal(t, []string{"v1", "v2", "v3"}, ks.GetIDs())
+	require.Equal(t, defaultIterationCount, ks.IterationCount())
 }
 
 func TestKeys_None(t *testing.T) {
@@ -51,6 +53,18 @@ func TestKeys_None(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, noneID, k.GetActiveID())
 	require.Equal(t, "", k.GetActiveKey())
+	require.Nil(t, k.GetIDs())
+}
+
+func TestKeys_IterationCount(t *testing.T) {
+	// given
+	ks, err1 := NewKeys(Config{IterationCount: 20_000})
+	_, err2 := NewKeys(Config{IterationCount: 1000})
+
+	// then
+	require.NoError(t, err1)
+	require.Equal(t, 20_000, ks.IterationCount())
+	require.NotNil(t, err2)
 }
 
 func TestKeys_Error(t *testing.T) {
@@ -0,0 +1,131 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/auth/pepper"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+// Plain represents PLAIN authentication mechanism verifying passwords against the credentials stored in repository.
+// Since the password is available on every successful login, outdated credentials are transparently
+// re-derived using the active pepper key and configured iteration count.
+type Plain struct {
+	rep           repository.User
+	peppers       *pepper.Keys
+	logger        kitlog.Logger
+	username      string
+	authenticated bool
+}
+
+// NewPlain returns a new PLAIN authenticator.
+func NewPlain(rep repository.User, peppers *pepper.Keys, logger kitlog.Logger) *Plain {
+	return &Plain{
+		rep:     rep,
+		peppers: peppers,
+		logger:  logger,
+	}
+}
+
+// Mechanism returns authenticator mechanism name.
+func (p *Plain) Mechanism() string {
+	return "PLAIN"
+}
+
+// Username returns authenticated username in case authentication process has been completed.
+func (p *Plain) Username() string {
+	if p.authenticated {
+		return p.username
+	}
+	return ""
+}
+
+// Authenticated returns whether or not user has been authenticated.
+func (p *Plain) Authenticated() bool {
+	return p.authenticated
+}
+
+// UsesChannelBinding returns whether or not this authenticator requires channel binding bytes.
+func (p *Plain) UsesChannelBinding() bool {
+	return false
+}
+
+// ProcessElement process an incoming authenticator element.
+func (p *Plain) ProcessElement(ctx context.Context, elem stravaganza.Element) (stravaganza.Element, *SASLError) {
+	if len(elem.Text()) == 0 {
+		return nil, newSASLError(MalformedRequest, nil)
+	}
+	b, err := base64.StdEncoding.DecodeString(elem.Text())
+	if err != nil {
+		return nil, newSASLError(IncorrectEncoding, nil)
+	}
+	s := bytes.Split(b, []byte{0})
+	if len(s) != 3 {
+		return nil, newSASLError(IncorrectEncoding, nil)
+	}
+	authzID := string(s[0])
+	username := string(s[1])
+	password := string(s[2])
+
+	if len(authzID) > 0 && authzID != username && authzID != username+"@"+domainFromContext(ctx) {
+		return nil, newSASLError(NotAuthorized, nil)
+	}
+	usr, err := p.rep.FetchUser(ctx, username)
+	if err != nil {
+		return nil, newSASLError(TemporaryAuthFailure, err)
+	}
+	if usr == nil || !verifyPassword(password, usr.Scram, p.peppers) {
+		return nil, newSASLError(NotAuthorized, nil)
+	}
+	if ScramCredentialsOutdated(usr.Scram, p.peppers) {
+		p.rehash(ctx, username, password)
+	}
+	p.username = username
+	p.authenticated = true
+
+	return stravaganza.NewBuilder("success").
+		WithAttribute(stravaganza.Namespace, saslNamespace).
+		Build(), nil
+}
+
+// Reset resets PLAIN authenticator internal state.
+func (p *Plain) Reset() {
+	p.username = ""
+	p.authenticated = false
+}
+
+func (p *Plain) rehash(ctx context.Context, username, password string) {
+	scram, err := NewScramCredentials(password, p.peppers)
+	if err == nil {
+		err = p.rep.UpsertUser(ctx, &usermodel.User{Username: username, Scram: scram})
+	}
+	if err != nil {
+		level.Warn(p.logger).Log("msg", "failed to rehash user credentials", "username", username, "err", err)
+		return
+	}
+	level.Info(p.logger).Log("msg", "rehashed user credentials",
+		"username", username,
+		"pepper_id", scram.PepperId,
+		"iteration_count", scram.IterationCount,
+	)
+}
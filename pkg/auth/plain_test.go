@@ -0,0 +1,177 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/ortuman/jackal/pkg/auth/pepper"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlain_Authenticate(t *testing.T) {
+	rotatedPeppers, _ := pepper.NewKeys(pepper.Config{
+		Keys: map[string]string{
+			"v1": pepperKey,
+			"v2": "Y3kLqUvGz8mWn2bTr5HsJd7x",
+		},
+		UseID: "v2",
+	})
+	tcs := map[string]struct {
+		authzID          string
+		password         string
+		peppers          *pepper.Keys
+		fetchErr         error
+		upsertErr        error
+		expectedReason   SASLErrorReason
+		expectedUsername string
+		expectedRehash   bool
+	}{
+		"ValidCredentials": {
+			password:         "1234",
+			peppers:          testPeppers(),
+			expectedUsername: "ortuman",
+		},
+		"ValidAuthzID": {
+			authzID:          "ortuman@jackal.im",
+			password:         "1234",
+			peppers:          testPeppers(),
+			expectedUsername: "ortuman",
+		},
+		"OutdatedPepper": {
+			password:         "1234",
+			peppers:          rotatedPeppers,
+			expectedUsername: "ortuman",
+			expectedRehash:   true,
+		},
+		"RehashFailure": {
+			password:         "1234",
+			peppers:          rotatedPeppers,
+			upsertErr:        errors.New("storage: connection refused"),
+			expectedUsername: "ortuman",
+			expectedRehash:   true,
+		},
+		"InvalidCredentials": {
+			password:       "foo-password",
+			peppers:        rotatedPeppers,
+			expectedReason: NotAuthorized,
+		},
+		"InvalidAuthzID": {
+			authzID:        "noelia@jackal.im",
+			password:       "1234",
+			peppers:        testPeppers(),
+			expectedReason: NotAuthorized,
+		},
+		"RepositoryFailure": {
+			password:       "1234",
+			peppers:        testPeppers(),
+			fetchErr:       errors.New("storage: connection refused"),
+			expectedReason: TemporaryAuthFailure,
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			var upsertedUser *usermodel.User
+			repMock := &usersRepository{}
+			repMock.FetchUserFunc = func(ctx context.Context, username string) (*usermodel.User, error) {
+				if tc.fetchErr != nil {
+					return nil, tc.fetchErr
+				}
+				if username != "ortuman" {
+					return nil, nil
+				}
+				return testUser(), nil
+			}
+			repMock.UpsertUserFunc = func(ctx context.Context, user *usermodel.User) error {
+				upsertedUser = user
+				return tc.upsertErr
+			}
+			p := &Plain{
+				rep:     repMock,
+				peppers: tc.peppers,
+				logger:  kitlog.NewNopLogger(),
+			}
+
+			buf := new(bytes.Buffer)
+			buf.WriteString(tc.authzID)
+			buf.WriteByte(0)
+			buf.WriteString("ortuman")
+			buf.WriteByte(0)
+			buf.WriteString(tc.password)
+
+			auth0 := stravaganza.NewBuilder("auth").
+				WithAttribute(stravaganza.Namespace, saslNamespace).
+				WithAttribute("mechanism", "PLAIN").
+				WithText(base64.StdEncoding.EncodeToString(buf.Bytes())).
+				Build()
+
+			// when
+			resp, err := p.ProcessElement(WithDomain(context.Background(), "jackal.im"), auth0)
+
+			// then
+			if !tc.expectedRehash {
+				require.Len(t, repMock.UpsertUserCalls(), 0)
+			} else {
+				require.Len(t, repMock.UpsertUserCalls(), 1)
+				require.Equal(t, "ortuman", upsertedUser.Username)
+				require.Equal(t, "v2", upsertedUser.Scram.PepperId)
+				require.Equal(t, int64(tc.peppers.IterationCount()), upsertedUser.Scram.IterationCount)
+				require.True(t, verifyPassword(tc.password, upsertedUser.Scram, tc.peppers))
+			}
+			if len(tc.expectedUsername) > 0 {
+				require.Nil(t, err)
+				require.Equal(t, "success", resp.Name())
+				require.True(t, p.Authenticated())
+				require.Equal(t, tc.expectedUsername, p.Username())
+				return
+			}
+			require.Nil(t, resp)
+			require.NotNil(t, err)
+			require.Equal(t, tc.expectedReason, err.Reason)
+			require.False(t, p.Authenticated())
+		})
+	}
+}
+
+func TestPlain_UserNotFound(t *testing.T) {
+	// given
+	repMock := &usersRepository{}
+	repMock.FetchUserFunc = func(ctx context.Context, username string) (*usermodel.User, error) {
+		return nil, nil
+	}
+	p := &Plain{rep: repMock, peppers: testPeppers(), logger: kitlog.NewNopLogger()}
+
+	auth0 := stravaganza.NewBuilder("auth").
+		WithAttribute(stravaganza.Namespace, saslNamespace).
+		WithAttribute("mechanism", "PLAIN").
+		WithText(base64.StdEncoding.EncodeToString([]byte("\x00noelia\x001234"))).
+		Build()
+
+	// when
+	resp, err := p.ProcessElement(context.Background(), auth0)
+
+	// then
+	require.Nil(t, resp)
+	require.NotNil(t, err)
+	require.Equal(t, NotAuthorized, err.Reason)
+}
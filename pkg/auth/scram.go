@@ -26,6 +26,8 @@ import (
 	"hash"
 	"strings"
 
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/google/uuid"
 	"github.com/jackal-xmpp/stravaganza"
 	"github.com/ortuman/jackal/pkg/auth/pepper"
@@ -92,6 +94,14 @@ func (s *scramParameters) String() string {
 	return ret
 }
 
+// scramCredentials contains the salted credentials used to verify a SCRAM exchange (RFC 5802).
+type scramCredentials struct {
+	salt           []byte
+	iterationCount int
+	storedKey      []byte
+	serverKey      []byte
+}
+
 // Scram represents a SCRAM authenticator.
 type Scram struct {
 	tr            transport.Transport
@@ -99,10 +109,14 @@ type Scram struct {
 	usesCb        bool
 	rep           repository.User
 	peppers       *pepper.Keys
+	extCl         *ExternalClient
 	h             func() hash.Hash
+	logger        kitlog.Logger
 	state         scramState
 	params        *scramParameters
-	user          *usermodel.User
+	username      string
+	creds         *scramCredentials
+	outdated      bool
 	srvNonce      string
 	firstMessage  string
 	authenticated bool
@@ -115,6 +129,7 @@ func NewScram(
 	usesChannelBinding bool,
 	rep repository.User,
 	peppers *pepper.Keys,
+	logger kitlog.Logger,
 ) *Scram {
 	s := &Scram{
 		tr:      tr,
@@ -122,19 +137,48 @@ func NewScram(
 		usesCb:  usesChannelBinding,
 		rep:     rep,
 		peppers: peppers,
+		logger:  logger,
 		state:   startScramState,
 	}
-	switch s.tp {
+	s.setHash()
+	return s
+}
+
+// NewExternalScram returns a new scram authenticator instance verifying the salted credentials
+// provided by an external authenticator.
+func NewExternalScram(
+	tr transport.Transport,
+	scramType ScramType,
+	usesChannelBinding bool,
+	extCl *ExternalClient,
+) *Scram {
+	s := &Scram{
+		tr:     tr,
+		tp:     scramType,
+		usesCb: usesChannelBinding,
+		extCl:  extCl,
+		state:  startScramState,
+	}
+	s.setHash()
+	return s
+}
+
+func (s *Scram) setHash() {
+	s.h = scramHash(s.tp)
+}
+
+func scramHash(tp ScramType) func() hash.Hash {
+	switch tp {
 	case ScramSHA1:
-		s.h = sha1.New
+		return sha1.New
 	case ScramSHA256:
-		s.h = sha256.New
+		return sha256.New
 	case ScramSHA512:
-		s.h = sha512.New
+		return sha512.New
 	case ScramSHA3512:
-		s.h = sha3.New512
+		return sha3.New512
 	}
-	return s
+	return nil
 }
 
 // Mechanism returns authenticator mechanism name.
@@ -170,7 +214,7 @@ func (s *Scram) Mechanism() string {
 // Username returns authenticated username in case authentication process has been completed.
 func (s *Scram) Username() string {
 	if s.authenticated {
-		return s.user.Username
+		return s.username
 	}
 	return ""
 }
@@ -206,7 +250,9 @@ func (s *Scram) Reset() {
 
 	s.state = startScramState
 	s.params = nil
-	s.user = nil
+	s.username = ""
+	s.creds = nil
+	s.outdated = false
 	s.srvNonce = ""
 	s.firstMessage = ""
 }
@@ -225,26 +271,18 @@ func (s *Scram) handleStart(ctx context.Context, elem stravaganza.Element) (stra
 	if len(username) == 0 || len(cNonce) == 0 {
 		return nil, newSASLError(MalformedRequest, nil)
 	}
-	user, err := s.rep.FetchUser(ctx, username)
+	creds, err := s.fetchCredentials(ctx, username)
 	if err != nil {
 		return nil, newSASLError(TemporaryAuthFailure, err)
 	}
-	if user == nil {
+	if creds == nil {
 		return nil, newSASLError(NotAuthorized, nil)
 	}
-	s.user = user
-
-	saltBytes, err := base64.RawURLEncoding.DecodeString(user.Scram.Salt)
-	if err != nil {
-		return nil, newSASLError(TemporaryAuthFailure, err)
-	}
-	buf := bytes.NewBuffer(saltBytes)
-	buf.WriteString(s.peppers.GetKey(user.Scram.PepperId))
-
-	pepperedSaltB64 := base64.StdEncoding.EncodeToString(buf.Bytes())
+	s.username = username
+	s.creds = creds
 
 	s.srvNonce = cNonce + "-" + uuid.New().String()
-	s.firstMessage = fmt.Sprintf("r=%s,s=%s,i=%d", s.srvNonce, pepperedSaltB64, user.Scram.IterationCount)
+	s.firstMessage = fmt.Sprintf("r=%s,s=%s,i=%d", s.srvNonce, base64.StdEncoding.EncodeToString(creds.salt), creds.iterationCount)
 
 	s.state = challengedScramState
 
@@ -263,30 +301,40 @@ func (s *Scram) handleChallenged(elem stravaganza.Element) (stravaganza.Element,
 	initialMessage := s.params.String()
 	clientFinalMessageBare := fmt.Sprintf("c=%s,r=%s", c, s.srvNonce)
 
-	scramPassword, err := s.getScramPassword()
-	if err != nil {
-		return nil, newSASLError(TemporaryAuthFailure, err)
-	}
-	clientKey := s.hmac([]byte("Client Key"), scramPassword)
-	storedKey := s.hash(clientKey)
 	authMessage := initialMessage + "," + s.firstMessage + "," + clientFinalMessageBare
-	clientSignature := s.hmac([]byte(authMessage), storedKey)
 
-	clientProof := make([]byte, len(clientKey))
-	for i := 0; i < len(clientKey); i++ {
-		clientProof[i] = clientKey[i] ^ clientSignature[i]
+	proofPrefix := clientFinalMessageBare + ",p="
+	if !strings.HasPrefix(p, proofPrefix) {
+		return nil, newSASLError(NotAuthorized, nil)
 	}
-	serverKey := s.hmac([]byte("Server Key"), scramPassword)
-	serverSignature := s.hmac([]byte(authMessage), serverKey)
+	clientProof, err := base64.StdEncoding.DecodeString(p[len(proofPrefix):])
+	if err != nil || len(clientProof) != len(s.creds.storedKey) {
+		return nil, newSASLError(NotAuthorized, nil)
+	}
+	// recover client key from proof and check it matches stored one
+	clientSignature := s.hmac([]byte(authMessage), s.creds.storedKey)
 
-	clientFinalMessage := clientFinalMessageBare + ",p=" + base64.StdEncoding.EncodeToString(clientProof)
-	if clientFinalMessage != p {
-		return nil, newSASLError(NotAuthorized, err)
+	clientKey := make([]byte, len(clientProof))
+	for i := 0; i < len(clientProof); i++ {
+		clientKey[i] = clientProof[i] ^ clientSignature[i]
+	}
+	if !hmac.Equal(s.hash(clientKey), s.creds.storedKey) {
+		return nil, newSASLError(NotAuthorized, nil)
 	}
+	serverSignature := s.hmac([]byte(authMessage), s.creds.serverKey)
+
 	v := "v=" + base64.StdEncoding.EncodeToString(serverSignature)
 
 	s.authenticated = true
 
+	if s.outdated {
+		// password is not available to SCRAM, so credentials can only be re-derived on a PLAIN login
+		level.Info(s.logger).Log("msg", "user logged in with outdated credentials",
+			"username", s.username,
+			"mechanism", s.Mechanism(),
+		)
+	}
+
 	return stravaganza.NewBuilder("success").
 		WithAttribute(stravaganza.Namespace, saslNamespace).
 		WithText(base64.StdEncoding.EncodeToString([]byte(v))).
@@ -364,19 +412,52 @@ func (s *Scram) getCBindInputString() string {
 	return base64.StdEncoding.EncodeToString(buf.Bytes())
 }
 
-func (s *Scram) getScramPassword() ([]byte, error) {
-	var scramPass string
+func (s *Scram) fetchCredentials(ctx context.Context, username string) (*scramCredentials, error) {
+	if s.extCl != nil {
+		return s.extCl.scramCredentials(ctx, username, s.tp)
+	}
+	user, err := s.rep.FetchUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	// users with no stored credentials (e.g. provisioned through token authentication) cannot use SCRAM
+	encodedPassword := s.encodedScramPassword(user)
+	if len(encodedPassword) == 0 {
+		return nil, nil
+	}
+	saltedPassword, err := base64.RawURLEncoding.DecodeString(encodedPassword)
+	if err != nil {
+		return nil, err
+	}
+	saltBytes, err := base64.RawURLEncoding.DecodeString(user.Scram.Salt)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(saltBytes)
+	buf.WriteString(s.peppers.GetKey(user.Scram.PepperId))
+
+	s.outdated = ScramCredentialsOutdated(user.Scram, s.peppers)
+
+	return &scramCredentials{
+		salt:           buf.Bytes(),
+		iterationCount: int(user.Scram.IterationCount),
+		storedKey:      s.hash(s.hmac([]byte("Client Key"), saltedPassword)),
+		serverKey:      s.hmac([]byte("Server Key"), saltedPassword),
+	}, nil
+}
+
+func (s *Scram) encodedScramPassword(user *usermodel.User) string {
 	switch s.tp {
 	case ScramSHA1:
-		scramPass = s.user.Scram.Sha1
+		return user.GetScram().GetSha1()
 	case ScramSHA256:
-		scramPass = s.user.Scram.Sha256
+		return user.GetScram().GetSha256()
 	case ScramSHA512:
-		scramPass = s.user.Scram.Sha512
+		return user.GetScram().GetSha512()
 	case ScramSHA3512:
-		scramPass = s.user.Scram.Sha3512
+		return user.GetScram().GetSha3512()
 	}
-	return base64.RawURLEncoding.DecodeString(scramPass)
+	return ""
 }
 
 func (s *Scram) hmac(b []byte, key []byte) []byte {
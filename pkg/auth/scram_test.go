@@ -29,7 +29,9 @@ import (
 	"strings"
 	"testing"
 
+	kitlog "github.com/go-kit/log"
 	"github.com/jackal-xmpp/stravaganza"
+	authpb "github.com/ortuman/jackal/pkg/auth/pb"
 	"github.com/ortuman/jackal/pkg/auth/pepper"
 	usermodel "github.com/ortuman/jackal/pkg/model/user"
 	"github.com/ortuman/jackal/pkg/transport"
@@ -37,6 +39,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/pbkdf2"
 	"golang.org/x/crypto/sha3"
+	"google.golang.org/grpc"
 )
 
 const (
@@ -159,6 +162,18 @@ func testScramTypeCases(t *testing.T, tp ScramType) {
 			expectsError:      true,
 			expectedErrReason: NotAuthorized,
 		},
+		{
+			// User with no stored credentials
+			name:              "NoCredentials",
+			scramType:         tp,
+			usesCb:            false,
+			gs2BindFlag:       "n",
+			n:                 "noelia",
+			r:                 "bb769406-eaa4-4f38-a279-2b90e596f6dd",
+			password:          "",
+			expectsError:      true,
+			expectedErrReason: NotAuthorized,
+		},
 		{
 			// Invalid password
 			name:              "InvalidPassword",
@@ -238,6 +253,96 @@ func testScramTypeCases(t *testing.T, tp ScramType) {
 	}
 }
 
+func TestScram_ExternalCredentials(t *testing.T) {
+	var tcs = map[string]struct {
+		username       string
+		password       string
+		expectedReason SASLErrorReason
+		expectsError   bool
+	}{
+		"Success": {
+			username: "ortuman",
+			password: "1234",
+		},
+		"InvalidPassword": {
+			username:       "ortuman",
+			password:       "12345678",
+			expectsError:   true,
+			expectedReason: NotAuthorized,
+		},
+		"UnknownUser": {
+			username:       "noelia",
+			password:       "1234",
+			expectsError:   true,
+			expectedReason: NotAuthorized,
+		},
+	}
+	for _, tp := range []ScramType{ScramSHA1, ScramSHA256, ScramSHA512, ScramSHA3512} {
+		for tn, tc := range tcs {
+			t.Run(fmt.Sprintf("%s/%s", scramTypeStr[tp], tn), func(t *testing.T) {
+				// given
+				salt := make([]byte, 16)
+				_, _ = rand.Read(salt)
+				saltedPassword := testScramAuthPbkdf2([]byte("1234"), salt, tp, 4096)
+
+				var reqMechanism authpb.ScramMechanism
+				clMock := &extGrpcClientMock{}
+				clMock.GetScramCredentialsFunc = func(_ context.Context, in *authpb.GetScramCredentialsRequest, _ ...grpc.CallOption) (*authpb.GetScramCredentialsResponse, error) {
+					reqMechanism = in.Mechanism
+					if in.Username != "ortuman" {
+						return &authpb.GetScramCredentialsResponse{}, nil
+					}
+					return &authpb.GetScramCredentialsResponse{
+						Found:          true,
+						Salt:           salt,
+						IterationCount: 4096,
+						StoredKey:      testScramAuthHash(testScramAuthHmac([]byte("Client Key"), saltedPassword, tp), tp),
+						ServerKey:      testScramAuthHmac([]byte("Server Key"), saltedPassword, tp),
+					}, nil
+				}
+				auth := NewExternalScram(&transportMock{}, tp, false, &ExternalClient{cl: clMock})
+
+				clientInitialMessage := fmt.Sprintf("n=%s,r=%s", tc.username, "bb769406-eaa4-4f38-a279-2b90e596f6dd")
+				authElem := stravaganza.NewBuilder("auth").
+					WithAttribute(stravaganza.Namespace, saslNamespace).
+					WithAttribute("mechanism", auth.Mechanism()).
+					WithText(base64.StdEncoding.EncodeToString([]byte("n,," + clientInitialMessage))).
+					Build()
+
+				// when
+				challengeElem, saslErr := auth.ProcessElement(context.Background(), authElem)
+				if saslErr == nil {
+					srvInitialMessage, _ := base64.StdEncoding.DecodeString(challengeElem.Text())
+					resp, _ := parseScramResponse(challengeElem.Text())
+					respSalt, _ := base64.StdEncoding.DecodeString(resp["s"])
+					iterations, _ := strconv.Atoi(resp["i"])
+
+					cBytes := base64.StdEncoding.EncodeToString([]byte("n,,"))
+					res := computeScramAuthResult(tp, clientInitialMessage, string(srvInitialMessage), resp["r"], cBytes, tc.password, respSalt, iterations)
+
+					responseElem := stravaganza.NewBuilder("response").
+						WithAttribute(stravaganza.Namespace, saslNamespace).
+						WithText(base64.StdEncoding.EncodeToString([]byte(res.clientFinalMessage))).
+						Build()
+					_, saslErr = auth.ProcessElement(context.Background(), responseElem)
+				}
+
+				// then
+				require.Equal(t, scramMechanisms[tp], reqMechanism)
+				if tc.expectsError {
+					require.NotNil(t, saslErr)
+					require.Equal(t, tc.expectedReason, saslErr.Reason)
+					require.False(t, auth.Authenticated())
+					return
+				}
+				require.Nil(t, saslErr)
+				require.True(t, auth.Authenticated())
+				require.Equal(t, tc.username, auth.Username())
+			})
+		}
+	}
+}
+
 func processScramTestCase(t *testing.T, tc *scramAuthTestCase) *SASLError {
 	trMock := &transportMock{}
 	repMock := &usersRepository{}
@@ -247,12 +352,15 @@ func processScramTestCase(t *testing.T, tc *scramAuthTestCase) *SASLError {
 	}
 	testUsr := testUser()
 	repMock.FetchUserFunc = func(_ context.Context, username string) (*usermodel.User, error) {
-		if username != "ortuman" {
-			return nil, nil
+		switch username {
+		case "ortuman":
+			return testUsr, nil
+		case "noelia":
+			return &usermodel.User{Username: username}, nil
 		}
-		return testUsr, nil
+		return nil, nil
 	}
-	auth := NewScram(trMock, tc.scramType, tc.usesCb, repMock, testPeppers())
+	auth := NewScram(trMock, tc.scramType, tc.usesCb, repMock, testPeppers(), kitlog.NewNopLogger())
 
 	clientInitialMessage := fmt.Sprintf(`n=%s,r=%s`, tc.n, tc.r)
 	gs2Header := fmt.Sprintf(`%s,%s,`, tc.gs2BindFlag, tc.authID)
@@ -14,7 +14,14 @@
 
 package c2s
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ortuman/jackal/pkg/transport"
+	"github.com/ortuman/jackal/pkg/transport/proxyproto"
+)
 
 // ListenersConfig defines a set of C2S listener configurations.
 type ListenersConfig []ListenerConfig
@@ -33,16 +40,38 @@ type ListenerConfig struct {
 	// DirectTLS, if true, tls.Listen will be used as network listener.
 	DirectTLS bool `fig:"direct_tls"`
 
+	// ProxyProtocol defines PROXY protocol (v1/v2) configuration.
+	ProxyProtocol proxyproto.Config `fig:"proxy_protocol"`
+
 	// SASL contains authentication related configuration.
 	SASL struct {
 		// Mechanisms contains enabled SASL mechanisms.
+		// Enabling 'plain' allows outdated stored credentials to be re-derived on login,
+		// as the password is never available to the server during a SCRAM exchange.
 		Mechanisms []string `fig:"mechanisms" default:"[scram_sha_1, scram_sha_256, scram_sha_512, scram_sha3_512]"`
 
 		// External contains external authenticator configuration.
 		External struct {
 			Address  string `fig:"address"`
 			IsSecure bool   `fig:"is_secure"`
+
+			// Scram, if true, enabled SCRAM mechanisms verify the salted credentials provided
+			// by the external authenticator instead of the ones stored in repository.
+			Scram bool `fig:"scram"`
+
+			// DisablePlain, if true, PLAIN mechanism won't be offered, so that passwords are never sent
+			// to the external authenticator.
+			DisablePlain bool `fig:"disable_plain"`
+
+			// UserExistence, if true, user existence checks will also be answered by the external authenticator.
+			UserExistence bool `fig:"user_existence"`
 		} `fig:"external"`
+
+		// LDAP, if true, offers PLAIN mechanism verifying credentials against the configured LDAP directory.
+		LDAP bool `fig:"ldap"`
+
+		// OAuthBearer, if true, offers OAUTHBEARER mechanism validating bearer tokens against the configured JWT keys.
+		OAuthBearer bool `fig:"oauthbearer"`
 	} `fig:"sasl"`
 
 	// CompressionLevel is the compression level that may be applied to the stream.
@@ -69,3 +98,32 @@ type ListenerConfig struct {
 	// RequestTimeout defines C2S stream request timeout.
 	RequestTimeout time.Duration `fig:"req_timeout" default:"15s"`
 }
+
+// Validate checks whether listener configuration values are supported.
+func (c ListenerConfig) Validate() error {
+	if c.Transport != transport.Socket.String() {
+		return fmt.Errorf("c2s: unrecognized transport: %s", c.Transport)
+	}
+	if _, ok := cmpLevelMap[c.CompressionLevel]; !ok {
+		return fmt.Errorf("c2s: unrecognized compression level: %s", c.CompressionLevel)
+	}
+	if _, ok := resConflictMap[c.ResourceConflict]; !ok {
+		return fmt.Errorf("c2s: unrecognized resource conflict rule: %s", c.ResourceConflict)
+	}
+	ext := c.SASL.External
+	if len(ext.Address) == 0 && (ext.Scram || ext.DisablePlain || ext.UserExistence) {
+		return errors.New("c2s: external authenticator options require an address")
+	}
+	for _, mechanism := range c.SASL.Mechanisms {
+		switch mechanism {
+		case scramSHA1Mechanism, scramSHA256Mechanism, scramSHA512Mechanism, scramSHA3512Mechanism:
+		case plainMechanism:
+			if c.SASL.LDAP || (len(ext.Address) > 0 && !ext.DisablePlain) {
+				return errors.New("c2s: plain mechanism can't be combined with another PLAIN authenticator")
+			}
+		default:
+			return fmt.Errorf("c2s: unsupported authentication mechanism: %s", mechanism)
+		}
+	}
+	return c.ProxyProtocol.Validate()
+}
@@ -111,7 +111,7 @@ type inC2S struct {
 	mods         modules
 	resMng       resourcemanager.Manager
 	session      session
-	shapers      shaper.Shapers
+	shapers      *shaper.Registry
 	hk           *hook.Hooks
 	logger       kitlog.Logger
 	rq           *runqueue.RunQueue
@@ -136,7 +136,7 @@ func newInC2S(
 	comps *component.Components,
 	mods *module.Modules,
 	resMng resourcemanager.Manager,
-	shapers shaper.Shapers,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) (*inC2S, error) {
@@ -148,7 +148,7 @@ func newInC2S(
 	// create session
 	id := nextStreamID()
 
-	sLogger := kitlog.With(logger, "id", id)
+	sLogger := kitlog.With(logger, "id", id, "remote_address", tr.RemoteAddr())
 	session := xmppsession.New(
 		xmppsession.C2SSession,
 		id.String(),
@@ -164,7 +164,7 @@ func newInC2S(
 		id:      id,
 		cfg:     cfg,
 		tr:      tr,
-		inf:     c2smodel.NewInfoMap(),
+		inf:     newInfoMap(tr),
 		session: session,
 		authSt:  authState{authenticators: authenticators},
 		hosts:   hosts,
@@ -291,6 +291,9 @@ func (s *inC2S) Resume(ctx context.Context, jd *jid.JID, pr *stravaganza.Presenc
 	s.jd = jd
 	s.pr = pr
 	s.inf = c2smodel.NewInfoMapFromInfo(inf)
+	if addr := s.tr.RemoteAddr(); addr != nil {
+		s.inf.SetString(c2smodel.RemoteAddressInfoKey, addr.String()) // resumed from a new connection
+	}
 	s.mu.Unlock()
 
 	s.session.SetFromJID(jd)
@@ -842,7 +845,7 @@ func (s *inC2S) proceedStartTLS(ctx context.Context, elem stravaganza.Element) e
 		return err
 	}
 	s.tr.StartTLS(&tls.Config{
-		Certificates: s.hosts.Certificates(),
+		GetCertificate: s.hosts.GetCertificate,
 	}, false)
 
 	level.Info(s.logger).Log("msg", "secured C2S stream")
@@ -882,7 +885,7 @@ func (s *inC2S) startAuthentication(ctx context.Context, elem stravaganza.Elemen
 }
 
 func (s *inC2S) continueAuthentication(ctx context.Context, elem stravaganza.Element) error {
-	elem, saslErr := s.authSt.active.ProcessElement(ctx, elem)
+	elem, saslErr := s.authSt.active.ProcessElement(auth.WithDomain(ctx, s.Domain()), elem)
 	if saslErr != nil {
 		return saslErr
 	}
@@ -1232,3 +1235,11 @@ var currentID uint64
 func nextStreamID() stream.C2SID {
 	return stream.C2SID(atomic.AddUint64(&currentID, 1))
 }
+
+func newInfoMap(tr transport.Transport) *c2smodel.InfoMap {
+	inf := c2smodel.NewInfoMap()
+	if addr := tr.RemoteAddr(); addr != nil {
+		inf.SetString(c2smodel.RemoteAddressInfoKey, addr.String())
+	}
+	return inf
+}
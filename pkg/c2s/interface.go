@@ -74,6 +74,7 @@ type globalC2SRouter interface {
 //go:generate moq -out hosts.mock_test.go . hosts
 type hosts interface {
 	Certificates() []tls.Certificate
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
 	IsLocalHost(host string) bool
 }
 
@@ -25,17 +25,20 @@ import (
 	kitlog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/ortuman/jackal/pkg/auth"
+	"github.com/ortuman/jackal/pkg/auth/jwt"
 	"github.com/ortuman/jackal/pkg/auth/pepper"
 	"github.com/ortuman/jackal/pkg/cluster/resourcemanager"
 	"github.com/ortuman/jackal/pkg/component"
 	"github.com/ortuman/jackal/pkg/hook"
 	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/ldap"
 	"github.com/ortuman/jackal/pkg/module"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/shaper"
 	"github.com/ortuman/jackal/pkg/storage/repository"
 	"github.com/ortuman/jackal/pkg/transport"
 	"github.com/ortuman/jackal/pkg/transport/compress"
+	"github.com/ortuman/jackal/pkg/transport/proxyproto"
 )
 
 const (
@@ -45,12 +48,14 @@ const (
 	scramSHA256Mechanism  = "scram_sha_256"
 	scramSHA512Mechanism  = "scram_sha_512"
 	scramSHA3512Mechanism = "scram_sha3_512"
+	plainMechanism        = "plain"
 )
 
 var cmpLevelMap = map[string]compress.Level{
-	"default": compress.DefaultCompression,
-	"best":    compress.BestCompression,
-	"speed":   compress.SpeedCompression,
+	"default":        compress.DefaultCompression,
+	"best":           compress.BestCompression,
+	"speed":          compress.SpeedCompression,
+	"no_compression": compress.NoCompression,
 }
 
 var resConflictMap = map[string]resourceConflict{
@@ -62,7 +67,7 @@ var resConflictMap = map[string]resourceConflict{
 // SocketListener represents a C2S socket listener type.
 type SocketListener struct {
 	cfg     ListenerConfig
-	extAuth *auth.External
+	extCl   *auth.ExternalClient
 	hosts   *host.Hosts
 	router  router.Router
 	comps   *component.Components
@@ -70,7 +75,9 @@ type SocketListener struct {
 	resMng  resourcemanager.Manager
 	rep     repository.Repository
 	peppers *pepper.Keys
-	shapers shaper.Shapers
+	ldapCl  *ldap.Client
+	jwtVer  *jwt.Verifier
+	shapers *shaper.Registry
 	hk      *hook.Hooks
 	logger  kitlog.Logger
 
@@ -91,7 +98,10 @@ func NewListeners(
 	resMng resourcemanager.Manager,
 	rep repository.Repository,
 	peppers *pepper.Keys,
-	shapers shaper.Shapers,
+	extCls map[string]*auth.ExternalClient,
+	ldapCl *ldap.Client,
+	jwtVer *jwt.Verifier,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) []*SocketListener {
@@ -99,6 +109,7 @@ func NewListeners(
 	for _, lnCfg := range cfg {
 		ln := newSocketListener(
 			lnCfg,
+			extCls[lnCfg.SASL.External.Address],
 			hosts,
 			router,
 			comps,
@@ -106,6 +117,8 @@ func NewListeners(
 			resMng,
 			rep,
 			peppers,
+			ldapCl,
+			jwtVer,
 			shapers,
 			hk,
 			logger,
@@ -117,6 +130,7 @@ func NewListeners(
 
 func newSocketListener(
 	cfg ListenerConfig,
+	extCl *auth.ExternalClient,
 	hosts *host.Hosts,
 	router router.Router,
 	comps *component.Components,
@@ -124,20 +138,15 @@ func newSocketListener(
 	resMng resourcemanager.Manager,
 	rep repository.Repository,
 	peppers *pepper.Keys,
-	shapers shaper.Shapers,
+	ldapCl *ldap.Client,
+	jwtVer *jwt.Verifier,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) *SocketListener {
-	var extAuth *auth.External
-	if len(cfg.SASL.External.Address) > 0 {
-		extAuth = auth.NewExternal(
-			cfg.SASL.External.Address,
-			cfg.SASL.External.IsSecure,
-		)
-	}
 	ln := &SocketListener{
 		cfg:     cfg,
-		extAuth: extAuth,
+		extCl:   extCl,
 		hosts:   hosts,
 		router:  router,
 		comps:   comps,
@@ -145,6 +154,8 @@ func newSocketListener(
 		resMng:  resMng,
 		rep:     rep,
 		peppers: peppers,
+		ldapCl:  ldapCl,
+		jwtVer:  jwtVer,
 		shapers: shapers,
 		hk:      hk,
 		logger:  logger,
@@ -155,12 +166,6 @@ func newSocketListener(
 
 // Start starts listening on a TCP network address to handle incoming C2S connections.
 func (l *SocketListener) Start(ctx context.Context) error {
-	if l.extAuth != nil {
-		// dial external authenticator
-		if err := l.extAuth.Start(ctx); err != nil {
-			return err
-		}
-	}
 	var err error
 	var ln net.Listener
 
@@ -171,10 +176,18 @@ func (l *SocketListener) Start(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if l.cfg.ProxyProtocol.Enabled {
+		pln, err := proxyproto.NewListener(ln, l.cfg.ProxyProtocol)
+		if err != nil {
+			_ = ln.Close()
+			return err
+		}
+		ln = pln
+	}
 	if l.cfg.DirectTLS {
 		l.tlsCfg = &tls.Config{
-			Certificates: l.hosts.Certificates(),
-			MinVersion:   tls.VersionTLS12,
+			GetCertificate: l.hosts.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
 		}
 		ln = tls.NewListener(ln, l.tlsCfg)
 	}
@@ -194,6 +207,7 @@ func (l *SocketListener) Start(ctx context.Context) error {
 	level.Info(l.logger).Log("msg", "accepting C2S socket connections",
 		"bind_addr", l.getAddress(),
 		"direct_tls", l.cfg.DirectTLS,
+		"proxy_protocol", l.cfg.ProxyProtocol.Enabled,
 	)
 	return nil
 }
@@ -204,20 +218,23 @@ func (l *SocketListener) Stop(ctx context.Context) error {
 	if err := l.ln.Close(); err != nil {
 		return err
 	}
-	if l.extAuth != nil {
-		// close external authenticator conn
-		if err := l.extAuth.Stop(ctx); err != nil {
-			return err
-		}
-	}
 	level.Info(l.logger).Log("msg", "stopped C2S listener", "bind_addr", l.getAddress())
 	return nil
 }
 
+// HandleConn handles an incoming C2S connection whose TLS handshake has already been completed.
+func (l *SocketListener) HandleConn(conn net.Conn) {
+	l.serveConn(conn, true)
+}
+
 func (l *SocketListener) handleConn(conn net.Conn) {
+	l.serveConn(conn, l.cfg.DirectTLS)
+}
+
+func (l *SocketListener) serveConn(conn net.Conn, directTLS bool) {
 	tr := transport.NewSocketTransport(conn, l.cfg.ConnectTimeout, l.cfg.KeepAliveTimeout)
 	stm, err := newInC2S(
-		l.getInConfig(),
+		l.getInConfig(directTLS),
 		tr,
 		l.getAuthenticators(tr),
 		l.hosts,
@@ -242,41 +259,54 @@ func (l *SocketListener) handleConn(conn net.Conn) {
 
 func (l *SocketListener) getAuthenticators(tr transport.Transport) []auth.Authenticator {
 	var res []auth.Authenticator
-	if l.extAuth != nil {
-		res = append(res, l.extAuth)
+	if l.extCl != nil && !l.cfg.SASL.External.DisablePlain {
+		res = append(res, auth.NewExternal(l.extCl))
+	}
+	if l.cfg.SASL.LDAP && l.ldapCl != nil {
+		res = append(res, auth.NewLDAP(l.ldapCl))
+	}
+	if l.cfg.SASL.OAuthBearer && l.jwtVer != nil {
+		res = append(res, auth.NewOAuthBearer(l.jwtVer, l.rep))
 	}
 	for _, mechanism := range l.cfg.SASL.Mechanisms {
+		var tp auth.ScramType
 		switch mechanism {
+		case plainMechanism:
+			res = append(res, auth.NewPlain(l.rep, l.peppers, l.logger))
+			continue
 		case scramSHA1Mechanism:
-			res = append(res, auth.NewScram(tr, auth.ScramSHA1, false, l.rep, l.peppers))
-			res = append(res, auth.NewScram(tr, auth.ScramSHA1, true, l.rep, l.peppers))
-
+			tp = auth.ScramSHA1
 		case scramSHA256Mechanism:
-			res = append(res, auth.NewScram(tr, auth.ScramSHA256, false, l.rep, l.peppers))
-			res = append(res, auth.NewScram(tr, auth.ScramSHA256, true, l.rep, l.peppers))
-
+			tp = auth.ScramSHA256
 		case scramSHA512Mechanism:
-			res = append(res, auth.NewScram(tr, auth.ScramSHA512, false, l.rep, l.peppers))
-			res = append(res, auth.NewScram(tr, auth.ScramSHA512, true, l.rep, l.peppers))
-
+			tp = auth.ScramSHA512
 		case scramSHA3512Mechanism:
-			res = append(res, auth.NewScram(tr, auth.ScramSHA3512, false, l.rep, l.peppers))
-			res = append(res, auth.NewScram(tr, auth.ScramSHA3512, true, l.rep, l.peppers))
+			tp = auth.ScramSHA3512
 		default:
 			level.Warn(l.logger).Log("msg", "unsupported authentication mechanism", "mechanism", mechanism)
+			continue
 		}
+		res = append(res, l.newScram(tr, tp, false))
+		res = append(res, l.newScram(tr, tp, true))
 	}
 	return res
 }
 
-func (l *SocketListener) getInConfig() inCfg {
+func (l *SocketListener) newScram(tr transport.Transport, tp auth.ScramType, usesCb bool) *auth.Scram {
+	if l.extCl != nil && l.cfg.SASL.External.Scram {
+		return auth.NewExternalScram(tr, tp, usesCb, l.extCl)
+	}
+	return auth.NewScram(tr, tp, usesCb, l.rep, l.peppers, l.logger)
+}
+
+func (l *SocketListener) getInConfig(directTLS bool) inCfg {
 	return inCfg{
 		authenticateTimeout: l.cfg.AuthenticateTimeout,
 		reqTimeout:          l.cfg.RequestTimeout,
 		maxStanzaSize:       l.cfg.MaxStanzaSize,
 		compressionLevel:    cmpLevelMap[l.cfg.CompressionLevel],
 		resConflict:         resConflictMap[l.cfg.ResourceConflict],
-		useTLS:              l.cfg.DirectTLS,
+		useTLS:              directTLS,
 		tlsConfig:           l.tlsCfg,
 	}
 }
@@ -80,6 +80,18 @@ func (k *KV) Put(ctx context.Context, key string, value string) error {
 	return err
 }
 
+// PutIfAbsent stores a new value associated to a given key, only if key is not already present.
+func (k *KV) PutIfAbsent(ctx context.Context, key string, value string) (bool, error) {
+	txnResp, err := k.cli.Txn(ctx).
+		If(etcdv3.Compare(etcdv3.CreateRevision(key), "=", 0)).
+		Then(etcdv3.OpPut(key, value, etcdv3.WithLease(k.leaseID))).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	return txnResp.Succeeded, nil
+}
+
 // Get retrieves a value associated to a given key.
 func (k *KV) Get(ctx context.Context, key string) ([]byte, error) {
 	getResp, err := k.cli.Get(ctx, key)
@@ -30,6 +30,10 @@ type KV interface {
 	// Put stores a new value associated to a given key.
 	Put(ctx context.Context, key string, value string) error
 
+	// PutIfAbsent stores a new value associated to a given key, only if key is not already present.
+	// It reports whether value was stored.
+	PutIfAbsent(ctx context.Context, key string, value string) (bool, error)
+
 	// Get retrieves a value associated to a given key.
 	Get(ctx context.Context, key string) ([]byte, error)
 
@@ -65,3 +69,11 @@ func New(cfg Config, logger kitlog.Logger) (KV, error) {
 		return nil, fmt.Errorf("unrecognized cluster kv type: %s", cfg.Type)
 	}
 }
+
+// Validate checks whether KV configuration values are supported.
+func (c Config) Validate() error {
+	if c.Type != etcdKVType {
+		return fmt.Errorf("unrecognized cluster kv type: %s", c.Type)
+	}
+	return nil
+}
@@ -49,6 +49,14 @@ func (m *Measured) Put(ctx context.Context, key string, value string) error {
 	return err
 }
 
+// PutIfAbsent stores a new value associated to a given key, only if key is not already present.
+func (m *Measured) PutIfAbsent(ctx context.Context, key string, value string) (bool, error) {
+	t0 := time.Now()
+	ok, err := m.kv.PutIfAbsent(ctx, key, value)
+	reportMetric(putOpType, time.Since(t0).Seconds(), err == nil)
+	return ok, err
+}
+
 // Get retrieves a value associated to a given key.
 func (m *Measured) Get(ctx context.Context, key string) ([]byte, error) {
 	t0 := time.Now()
@@ -37,6 +37,22 @@ func TestMeasuredKV_Put(t *testing.T) {
 	require.Len(t, kvMock.PutCalls(), 1)
 }
 
+func TestMeasuredKV_PutIfAbsent(t *testing.T) {
+	// given
+	kvMock := &kvMock{}
+	kvMock.PutIfAbsentFunc = func(ctx context.Context, key string, value string) (bool, error) {
+		return true, nil
+	}
+	mkv := NewMeasured(kvMock)
+
+	// when
+	ok, _ := mkv.PutIfAbsent(context.Background(), "k0", "v0")
+
+	// then
+	require.True(t, ok)
+	require.Len(t, kvMock.PutIfAbsentCalls(), 1)
+}
+
 func TestMeasuredKV_Get(t *testing.T) {
 	// given
 	kvMock := &kvMock{}
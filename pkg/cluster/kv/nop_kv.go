@@ -32,6 +32,11 @@ func IsNop(kv KV) bool {
 type nopKV struct{}
 
 func (k *nopKV) Put(_ context.Context, _ string, _ string) error { return nil }
+
+func (k *nopKV) PutIfAbsent(_ context.Context, _ string, _ string) (bool, error) {
+	return true, nil
+}
+
 func (k *nopKV) Get(_ context.Context, _ string) ([]byte, error) { return nil, nil }
 
 func (k *nopKV) GetPrefix(_ context.Context, _ string) (map[string][]byte, error) {
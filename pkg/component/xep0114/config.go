@@ -14,7 +14,11 @@
 
 package xep0114
 
-import "time"
+import (
+	"time"
+
+	"github.com/ortuman/jackal/pkg/transport/proxyproto"
+)
 
 // ListenersConfig defines a set of component listener configurations.
 type ListenersConfig []ListenerConfig
@@ -39,4 +43,7 @@ type ListenerConfig struct {
 
 	// MaxStanzaSize is the maximum size a listener incoming stanza may have.
 	MaxStanzaSize int
+
+	// ProxyProtocol defines PROXY protocol (v1/v2) configuration.
+	ProxyProtocol proxyproto.Config `fig:"proxy_protocol"`
 }
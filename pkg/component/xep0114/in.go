@@ -69,7 +69,7 @@ type inComponent struct {
 	id           inComponentID
 	cfg          inConfig
 	tr           transport.Transport
-	shapers      shaper.Shapers
+	shapers      *shaper.Registry
 	session      session
 	comps        components
 	router       router.Router
@@ -96,7 +96,7 @@ func newInComponent(
 	extCompMng *extcomponentmanager.Manager,
 	stmHub *inHub,
 	router router.Router,
-	shapers shaper.Shapers,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 	cfg inConfig,
@@ -109,7 +109,7 @@ func newInComponent(
 	// create session
 	id := nextStreamID()
 
-	sLogger := kitlog.With(logger, "id", id)
+	sLogger := kitlog.With(logger, "id", id, "remote_address", tr.RemoteAddr())
 	session := xmppsession.New(
 		xmppsession.ComponentSession,
 		id.String(),
@@ -32,6 +32,7 @@ import (
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/shaper"
 	"github.com/ortuman/jackal/pkg/transport"
+	"github.com/ortuman/jackal/pkg/transport/proxyproto"
 )
 
 const (
@@ -45,7 +46,7 @@ type SocketListener struct {
 	hosts         *host.Hosts
 	comps         *component.Components
 	router        router.Router
-	shapers       shaper.Shapers
+	shapers       *shaper.Registry
 	hk            *hook.Hooks
 	logger        kitlog.Logger
 	extCompMng    *extcomponentmanager.Manager
@@ -64,7 +65,7 @@ func NewListeners(
 	comps *component.Components,
 	extCompMng *extcomponentmanager.Manager,
 	router router.Router,
-	shapers shaper.Shapers,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) []*SocketListener {
@@ -93,7 +94,7 @@ func newSocketListener(
 	comps *component.Components,
 	extCompMng *extcomponentmanager.Manager,
 	router router.Router,
-	shapers shaper.Shapers,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) *SocketListener {
@@ -123,6 +124,14 @@ func (l *SocketListener) Start(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if l.cfg.ProxyProtocol.Enabled {
+		pln, err := proxyproto.NewListener(ln, l.cfg.ProxyProtocol)
+		if err != nil {
+			_ = ln.Close()
+			return err
+		}
+		ln = pln
+	}
 	l.ln = ln
 	l.active = 1
 
@@ -132,14 +141,13 @@ func (l *SocketListener) Start(ctx context.Context) error {
 			if err != nil {
 				continue
 			}
-			level.Info(l.logger).Log("msg", "received component incoming connection",
-				"bind_addr", l.getAddress(),
-				"remote_address", conn.RemoteAddr().String(),
-			)
 			go l.connHandlerFn(conn)
 		}
 	}()
-	level.Info(l.logger).Log("msg", "accepting external component connections", "bind_addr", l.getAddress())
+	level.Info(l.logger).Log("msg", "accepting external component connections",
+		"bind_addr", l.getAddress(),
+		"proxy_protocol", l.cfg.ProxyProtocol.Enabled,
+	)
 	return nil
 }
 
@@ -155,8 +163,18 @@ func (l *SocketListener) Stop(ctx context.Context) error {
 	return nil
 }
 
+// HandleConn handles an incoming component connection whose TLS handshake has already been completed.
+func (l *SocketListener) HandleConn(conn net.Conn) {
+	l.handleConn(conn)
+}
+
 func (l *SocketListener) handleConn(conn net.Conn) {
 	tr := transport.NewSocketTransport(conn, l.cfg.ConnectTimeout, l.cfg.KeepAliveTimeout)
+
+	level.Info(l.logger).Log("msg", "received component incoming connection",
+		"bind_addr", l.getAddress(),
+		"remote_address", tr.RemoteAddr().String(),
+	)
 	stm, err := newInComponent(
 		tr,
 		l.hosts,
@@ -0,0 +1,32 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+const (
+	// ConfigReloaded hook runs after every configuration reload attempt.
+	ConfigReloaded = "config.reloaded"
+)
+
+// ConfigInfo contains all information associated to a configuration event.
+type ConfigInfo struct {
+	// Applied contains the names of the changed configuration sections that have been applied.
+	Applied []string
+
+	// NonReloadable contains the names of the changed configuration sections requiring a restart.
+	NonReloadable []string
+
+	// Err is the error that caused the reload to fail, if any.
+	Err error
+}
@@ -24,6 +24,15 @@ const (
 
 	// ArchiveMessageArchived hook runs whenever a message is archived.
 	ArchiveMessageArchived = "mam.message.archieved"
+
+	// ArchiveMessageCorrected hook runs whenever an archived message is replaced by its sender correction.
+	ArchiveMessageCorrected = "mam.message.corrected"
+
+	// ArchiveMessageRetracted hook runs whenever an archived message is retracted by its sender.
+	ArchiveMessageRetracted = "mam.message.retracted"
+
+	// ArchivePreferencesUpdated hook runs whenever archive preferences are updated.
+	ArchivePreferencesUpdated = "mam.preferences.updated"
 )
 
 // MamInfo contains all information associated to a mam (XEP-0313) event.
@@ -36,4 +45,7 @@ type MamInfo struct {
 
 	// Filters contains filters applied to the archive queried event.
 	Filters *archivemodel.Filters
+
+	// Preferences contains the archive preferences associated to this event.
+	Preferences *archivemodel.Preferences
 }
@@ -0,0 +1,238 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// CertificatesConfig defines host certificates reloading configuration.
+type CertificatesConfig struct {
+	// ReloadInterval defines how often certificate and private key files are checked for changes.
+	ReloadInterval time.Duration `fig:"reload_interval" default:"1m"`
+}
+
+type certFiles struct {
+	certFile    string
+	keyFile     string
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+// CertificateWatcher reloads host certificates whenever their files change on disk.
+// New certificates are validated before replacing the ones in use, so that
+// only subsequent TLS handshakes are affected.
+type CertificateWatcher struct {
+	hosts    *Hosts
+	interval time.Duration
+	logger   kitlog.Logger
+
+	mu    sync.Mutex
+	files map[string]*certFiles
+
+	// hosts whose certificate is held in memory, flagged once found no longer valid
+	tracked map[string]bool
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewCertificateWatcher returns a new CertificateWatcher watching all file based host certificates.
+func NewCertificateWatcher(configs Configs, hosts *Hosts, cfg CertificatesConfig, logger kitlog.Logger) *CertificateWatcher {
+	w := &CertificateWatcher{
+		hosts:    hosts,
+		interval: cfg.ReloadInterval,
+		logger:   logger,
+		files:    make(map[string]*certFiles),
+		tracked:  make(map[string]bool),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	for _, config := range configs {
+		if len(config.TLS.CertFile) == 0 || len(config.TLS.PrivateKeyFile) == 0 {
+			continue // self-signed certificate
+		}
+		w.Watch(config.Domain, config.TLS.CertFile, config.TLS.PrivateKeyFile)
+	}
+	return w
+}
+
+// Watch starts watching certificate and private key files associated to a host.
+func (w *CertificateWatcher) Watch(h, certFile, keyFile string) {
+	w.mu.Lock()
+	w.files[h] = &certFiles{
+		certFile:    certFile,
+		keyFile:     keyFile,
+		certModTime: modTime(certFile),
+		keyModTime:  modTime(keyFile),
+	}
+	w.mu.Unlock()
+
+	w.reportExpiry(h)
+}
+
+// Track starts tracking the in-memory certificate of a host defined at runtime.
+// Certificate expiry gets reported, and its validity checked on every reload.
+func (w *CertificateWatcher) Track(h string) {
+	w.mu.Lock()
+	w.tracked[h] = false
+	w.mu.Unlock()
+
+	w.reportExpiry(h)
+}
+
+// Unwatch stops watching host certificate.
+func (w *CertificateWatcher) Unwatch(h string) {
+	w.mu.Lock()
+	delete(w.files, h)
+	delete(w.tracked, h)
+	w.mu.Unlock()
+
+	clearCertificateExpiry(h)
+}
+
+// Reload reloads all watched host certificates, regardless of whether their files changed.
+func (w *CertificateWatcher) Reload() {
+	w.reload(true)
+}
+
+// Start starts watching host certificate files.
+func (w *CertificateWatcher) Start(_ context.Context) error {
+	go w.loop()
+	level.Info(w.logger).Log("msg", "started certificate watcher", "reload_interval", w.interval)
+	return nil
+}
+
+// Stop stops watching host certificate files.
+func (w *CertificateWatcher) Stop(_ context.Context) error {
+	close(w.stopCh)
+	<-w.doneCh
+	level.Info(w.logger).Log("msg", "stopped certificate watcher")
+	return nil
+}
+
+func (w *CertificateWatcher) loop() {
+	defer close(w.doneCh)
+
+	tc := time.NewTicker(w.interval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+			w.reload(false)
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *CertificateWatcher) reload(force bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for h, cf := range w.files {
+		certModTime, keyModTime := modTime(cf.certFile), modTime(cf.keyFile)
+		if !force && certModTime.Equal(cf.certModTime) && keyModTime.Equal(cf.keyModTime) {
+			continue
+		}
+		cf.certModTime, cf.keyModTime = certModTime, keyModTime
+
+		if err := w.reloadCertificate(h, cf); err != nil {
+			reportCertificateReload(h, reloadResultFailure)
+			level.Warn(w.logger).Log("msg", "failed to reload host certificate", "host", h, "err", err)
+			continue
+		}
+		reportCertificateReload(h, reloadResultSuccess)
+		level.Info(w.logger).Log("msg", "reloaded host certificate", "host", h, "cert_file", cf.certFile)
+	}
+	now := time.Now()
+	for h, invalid := range w.tracked {
+		cer, ok := w.hosts.Certificate(h)
+		if !ok {
+			continue
+		}
+		_, err := validateCertificate(cer, h, now)
+		if err != nil && !invalid {
+			level.Warn(w.logger).Log("msg", "host certificate is no longer valid", "host", h, "err", err)
+		}
+		w.tracked[h] = err != nil
+	}
+}
+
+func (w *CertificateWatcher) reportExpiry(h string) {
+	if cer, ok := w.hosts.Certificate(h); ok && len(cer.Certificate) > 0 {
+		if leaf, err := x509.ParseCertificate(cer.Certificate[0]); err == nil {
+			reportCertificateExpiry(h, leaf.NotAfter)
+		}
+	}
+}
+
+func (w *CertificateWatcher) reloadCertificate(h string, cf *certFiles) error {
+	cer, err := tls.LoadX509KeyPair(cf.certFile, cf.keyFile)
+	if err != nil {
+		return err
+	}
+	leaf, err := validateCertificate(cer, h, time.Now())
+	if err != nil {
+		return err
+	}
+	cer.Leaf = leaf
+
+	if !w.hosts.replaceCertificate(h, cer) {
+		return fmt.Errorf("host %s is not registered", h)
+	}
+	reportCertificateExpiry(h, leaf.NotAfter)
+	return nil
+}
+
+// validateCertificate verifies that a certificate can be used by a host at a given time.
+func validateCertificate(cer tls.Certificate, h string, now time.Time) (*x509.Certificate, error) {
+	if len(cer.Certificate) == 0 {
+		return nil, errors.New("empty certificate chain")
+	}
+	leaf, err := x509.ParseCertificate(cer.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	if now.Before(leaf.NotBefore) {
+		return nil, fmt.Errorf("certificate not valid before %s", leaf.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(leaf.NotAfter) {
+		return nil, fmt.Errorf("certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+	if err := leaf.VerifyHostname(h); err != nil {
+		return nil, err
+	}
+	return leaf, nil
+}
+
+func modTime(file string) time.Time {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,199 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertificateWatcher_Reload(t *testing.T) {
+	var tcs = map[string]struct {
+		domain      string
+		notAfter    time.Duration
+		expectedSN  int64
+		expectedErr bool
+	}{
+		"Renewed": {
+			domain:     "jackal.im",
+			notAfter:   time.Hour,
+			expectedSN: 2,
+		},
+		"Expired": {
+			domain:     "jackal.im",
+			notAfter:   -time.Minute,
+			expectedSN: 1,
+		},
+		"Domain mismatch": {
+			domain:     "jackal.org",
+			notAfter:   time.Hour,
+			expectedSN: 1,
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			dir := t.TempDir()
+			cfg := testCertificateFiles(t, dir, "jackal.im", 1, time.Hour)
+
+			hs, err := NewHosts(Configs{cfg})
+			require.Nil(t, err)
+
+			w := NewCertificateWatcher(Configs{cfg}, hs, CertificatesConfig{ReloadInterval: time.Minute}, kitlog.NewNopLogger())
+
+			// when
+			_ = testCertificateFiles(t, dir, tc.domain, 2, tc.notAfter)
+
+			future := time.Now().Add(time.Minute)
+			require.Nil(t, os.Chtimes(cfg.TLS.CertFile, future, future))
+			require.Nil(t, os.Chtimes(cfg.TLS.PrivateKeyFile, future, future))
+
+			w.reload(false)
+
+			// then
+			cer, ok := hs.Certificate("jackal.im")
+			require.True(t, ok)
+
+			leaf, err := x509.ParseCertificate(cer.Certificate[0])
+			require.Nil(t, err)
+			require.Equal(t, tc.expectedSN, leaf.SerialNumber.Int64())
+		})
+	}
+}
+
+func TestCertificateWatcher_Unchanged(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	cfg := testCertificateFiles(t, dir, "jackal.im", 1, time.Hour)
+
+	hs, err := NewHosts(Configs{cfg})
+	require.Nil(t, err)
+
+	w := NewCertificateWatcher(Configs{cfg}, hs, CertificatesConfig{ReloadInterval: time.Minute}, kitlog.NewNopLogger())
+
+	// when
+	hs.RegisterHost("jackal.im", testCertificate(t, "jackal.im", 3))
+	w.reload(false)
+
+	// then
+	cer, _ := hs.Certificate("jackal.im")
+	leaf, err := x509.ParseCertificate(cer.Certificate[0])
+	require.Nil(t, err)
+	require.Equal(t, int64(3), leaf.SerialNumber.Int64()) // files didn't change
+
+	// when
+	w.Reload()
+
+	// then
+	cer, _ = hs.Certificate("jackal.im")
+	leaf, err = x509.ParseCertificate(cer.Certificate[0])
+	require.Nil(t, err)
+	require.Equal(t, int64(1), leaf.SerialNumber.Int64())
+}
+
+func TestCertificateWatcher_TrackInMemoryCertificate(t *testing.T) {
+	// given
+	cfg := testCertificateFiles(t, t.TempDir(), "jackal.im", 1, time.Hour)
+
+	hs, err := NewHosts(Configs{cfg})
+	require.Nil(t, err)
+
+	w := NewCertificateWatcher(Configs{cfg}, hs, CertificatesConfig{ReloadInterval: time.Minute}, kitlog.NewNopLogger())
+
+	certPEM, keyPEM := testCertificatePEM(t, "jackal.org", 2, -time.Minute)
+	cer, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.Nil(t, err)
+
+	hs.RegisterHost("jackal.org", cer)
+
+	// when
+	w.Track("jackal.org")
+	w.reload(false)
+
+	invalid := w.tracked["jackal.org"]
+
+	w.Unwatch("jackal.org")
+
+	// then
+	require.True(t, invalid)
+	require.NotContains(t, w.tracked, "jackal.org")
+
+	c, _ := hs.Certificate("jackal.org")
+	leaf, err := x509.ParseCertificate(c.Certificate[0])
+	require.Nil(t, err)
+	require.Equal(t, int64(2), leaf.SerialNumber.Int64()) // in-memory certificates are never replaced
+}
+
+func testCertificateFiles(t *testing.T, dir, domain string, sn int64, notAfter time.Duration) Config {
+	t.Helper()
+
+	certB, keyB := testCertificatePEM(t, domain, sn, notAfter)
+
+	var cfg Config
+	cfg.Domain = "jackal.im"
+	cfg.TLS.CertFile = filepath.Join(dir, "cert.pem")
+	cfg.TLS.PrivateKeyFile = filepath.Join(dir, "key.pem")
+
+	require.Nil(t, os.WriteFile(cfg.TLS.CertFile, certB, 0600))
+	require.Nil(t, os.WriteFile(cfg.TLS.PrivateKeyFile, keyB, 0600))
+	return cfg
+}
+
+func testCertificate(t *testing.T, domain string, sn int64) tls.Certificate {
+	t.Helper()
+
+	certB, keyB := testCertificatePEM(t, domain, sn, time.Hour)
+	cer, err := tls.X509KeyPair(certB, keyB)
+	require.Nil(t, err)
+	return cer
+}
+
+func testCertificatePEM(t *testing.T, domain string, sn int64, notAfter time.Duration) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(sn),
+		Subject:      pkix.Name{CommonName: domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(notAfter),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{domain},
+	}
+	b, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	keyB, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: b}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyB})
+}
@@ -16,8 +16,11 @@ package host
 
 import (
 	"crypto/tls"
+	"fmt"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	tlsutil "github.com/ortuman/jackal/pkg/util/tls"
 )
@@ -41,6 +44,16 @@ type Config struct {
 		CertFile       string `fig:"cert_file"`
 		PrivateKeyFile string `fig:"privkey_file"`
 	} `fig:"tls"`
+	POSH POSHConfig `fig:"posh"`
+}
+
+// POSHConfig contains host POSH (RFC 7711) publishing parameters.
+type POSHConfig struct {
+	// URL, if set, delegates domain to the certificate fingerprints published at this location.
+	URL string `fig:"url"`
+
+	// Expires defines how long published document can be cached.
+	Expires time.Duration `fig:"expires" default:"24h"`
 }
 
 // NewHosts creates and initializes a Hosts instance.
@@ -85,6 +98,27 @@ func (hs *Hosts) RegisterHost(h string, cer tls.Certificate) {
 	hs.hosts[h] = cer
 }
 
+// UnregisterHost unregisters a non default host.
+func (hs *Hosts) UnregisterHost(h string) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if h == hs.defaultHost {
+		return
+	}
+	delete(hs.hosts, h)
+}
+
+// replaceCertificate replaces the certificate of an already registered host.
+func (hs *Hosts) replaceCertificate(h string, cer tls.Certificate) bool {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if _, ok := hs.hosts[h]; !ok {
+		return false
+	}
+	hs.hosts[h] = cer
+	return true
+}
+
 // DefaultHostName returns default host name value.
 func (hs *Hosts) DefaultHostName() string {
 	hs.mu.RLock()
@@ -112,6 +146,14 @@ func (hs *Hosts) HostNames() []string {
 	return ret
 }
 
+// Certificate returns the certificate registered for a local host.
+func (hs *Hosts) Certificate(h string) (tls.Certificate, bool) {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	cer, ok := hs.hosts[h]
+	return cer, ok
+}
+
 // Certificates returns all registered domain certificates.
 func (hs *Hosts) Certificates() []tls.Certificate {
 	hs.mu.RLock()
@@ -122,3 +164,19 @@ func (hs *Hosts) Certificates() []tls.Certificate {
 	}
 	return certs
 }
+
+// GetCertificate returns the certificate matching the server name requested by a TLS client,
+// falling back to default host certificate when no local host matches.
+// It can be used as tls.Config GetCertificate callback.
+func (hs *Hosts) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	if cer, ok := hs.hosts[strings.ToLower(hello.ServerName)]; ok {
+		return &cer, nil
+	}
+	cer, ok := hs.hosts[hs.defaultHost]
+	if !ok {
+		return nil, fmt.Errorf("host: no certificate available for server name '%s'", hello.ServerName)
+	}
+	return &cer, nil
+}
@@ -55,3 +55,25 @@ func TestHosts_Domains(t *testing.T) {
 	require.True(t, h.IsLocalHost("jackal.org"))
 	require.True(t, h.IsLocalHost("jackal.net"))
 }
+
+func TestHosts_GetCertificate(t *testing.T) {
+	// given
+	h := &Hosts{
+		hosts: make(map[string]tls.Certificate),
+	}
+	c1 := tls.Certificate{OCSPStaple: []byte("jackal.im")}
+	c2 := tls.Certificate{OCSPStaple: []byte("jackal.org")}
+	h.RegisterDefaultHost("jackal.im", c1)
+	h.RegisterHost("jackal.org", c2)
+
+	// when
+	cer1, err1 := h.GetCertificate(&tls.ClientHelloInfo{ServerName: "Jackal.org"})
+	cer2, err2 := h.GetCertificate(&tls.ClientHelloInfo{ServerName: "jackal.net"})
+
+	// then
+	require.Nil(t, err1)
+	require.Equal(t, []byte("jackal.org"), cer1.OCSPStaple)
+
+	require.Nil(t, err2)
+	require.Equal(t, []byte("jackal.im"), cer2.OCSPStaple)
+}
@@ -0,0 +1,341 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/cluster/kv"
+	kvtypes "github.com/ortuman/jackal/pkg/cluster/kv/types"
+	hostpb "github.com/ortuman/jackal/pkg/host/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+const hostKeyPrefix = "h://"
+
+var (
+	// ErrHostExists is returned when trying to add an already registered host.
+	ErrHostExists = errors.New("host: host already registered")
+
+	// ErrHostNotFound is returned when trying to update or remove a non registered host.
+	ErrHostNotFound = errors.New("host: host not found")
+
+	// ErrStaticHost is returned when trying to update or remove a host defined in configuration.
+	ErrStaticHost = errors.New("host: host defined in configuration")
+)
+
+// InvalidHostError is returned when a virtual host definition is not valid.
+type InvalidHostError struct {
+	err error
+}
+
+func (e *InvalidHostError) Error() string {
+	return "host: invalid host definition: " + e.err.Error()
+}
+
+// Desc represents a local host description.
+type Desc struct {
+	Domain    string
+	IsDefault bool
+	IsDynamic bool
+}
+
+// KVManager manages virtual hosts defined at runtime.
+// Host definitions are persisted into the cluster KV storage, so that all cluster members
+// serve the same set of hosts.
+type KVManager struct {
+	hosts       *Hosts
+	certWatcher *CertificateWatcher
+	kv          kv.KV
+	static      map[string]POSHConfig
+	ctx         context.Context
+	ctxCancel   context.CancelFunc
+	logger      kitlog.Logger
+
+	addMu sync.Mutex
+
+	mu      sync.RWMutex
+	dynamic map[string]struct{}
+
+	stopCh chan struct{}
+}
+
+// NewKVManager returns a new initialized KVManager instance.
+// Certificates of hosts defined at runtime are tracked by certWatcher.
+func NewKVManager(configs Configs, hosts *Hosts, certWatcher *CertificateWatcher, kv kv.KV, logger kitlog.Logger) *KVManager {
+	ctx, cancelFn := context.WithCancel(context.Background())
+	m := &KVManager{
+		hosts:       hosts,
+		certWatcher: certWatcher,
+		kv:          kv,
+		static:      make(map[string]POSHConfig),
+		dynamic:     make(map[string]struct{}),
+		ctx:         ctx,
+		ctxCancel:   cancelFn,
+		logger:      logger,
+		stopCh:      make(chan struct{}),
+	}
+	for _, config := range configs {
+		m.static[config.Domain] = config.POSH
+	}
+	if len(configs) == 0 {
+		m.static[hosts.DefaultHostName()] = POSHConfig{}
+	}
+	return m
+}
+
+// Start loads all persisted virtual hosts and starts watching for changes.
+func (m *KVManager) Start(ctx context.Context) error {
+	wCh := m.kv.Watch(m.ctx, hostKeyPrefix, false)
+
+	vs, err := m.kv.GetPrefix(ctx, hostKeyPrefix)
+	if err != nil {
+		return err
+	}
+	for k, val := range vs {
+		if err := m.applyPut(k, val); err != nil {
+			level.Warn(m.logger).Log("msg", "failed to load virtual host", "key", k, "err", err)
+		}
+	}
+	go func() {
+		for wResp := range wCh {
+			if err := wResp.Err; err != nil {
+				level.Warn(m.logger).Log("msg", "error occurred watching virtual hosts", "err", err)
+				continue
+			}
+			m.processKVEvents(wResp.Events)
+		}
+		close(m.stopCh)
+	}()
+	level.Info(m.logger).Log("msg", "started virtual host manager", "dynamic_hosts", len(vs))
+	return nil
+}
+
+// Stop stops watching virtual host changes.
+func (m *KVManager) Stop(_ context.Context) error {
+	m.ctxCancel()
+	<-m.stopCh
+
+	level.Info(m.logger).Log("msg", "stopped virtual host manager")
+	return nil
+}
+
+// Hosts returns the description of all local hosts.
+func (m *KVManager) Hosts() []Desc {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	defaultHost := m.hosts.DefaultHostName()
+
+	var ret []Desc
+	for _, h := range m.hosts.HostNames() {
+		_, isDynamic := m.dynamic[h]
+		ret = append(ret, Desc{
+			Domain:    h,
+			IsDefault: h == defaultHost,
+			IsDynamic: isDynamic,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Domain < ret[j].Domain })
+	return ret
+}
+
+// POSHConfig returns the POSH publishing configuration of a local host.
+// Hosts defined at runtime publish their own certificate fingerprints.
+func (m *KVManager) POSHConfig(domain string) (POSHConfig, bool) {
+	if cfg, ok := m.static[domain]; ok {
+		return cfg, true
+	}
+	m.mu.RLock()
+	_, ok := m.dynamic[domain]
+	m.mu.RUnlock()
+	return POSHConfig{}, ok
+}
+
+// AddHost registers a new virtual host along with its PEM encoded certificate and private key.
+// Host is only added if no other cluster member registered it in the meantime.
+func (m *KVManager) AddHost(ctx context.Context, domain string, certPEM, keyPEM []byte) error {
+	m.addMu.Lock()
+	defer m.addMu.Unlock()
+
+	if m.hosts.IsLocalHost(domain) {
+		return ErrHostExists
+	}
+	val, cer, err := encodeVirtualHost(domain, certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+	ok, err := m.kv.PutIfAbsent(ctx, hostKey(domain), val)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrHostExists
+	}
+	// apply locally right away, without waiting for the watch event
+	m.register(domain, cer)
+	return nil
+}
+
+// UpdateHost replaces the certificate of a virtual host defined at runtime.
+func (m *KVManager) UpdateHost(ctx context.Context, domain string, certPEM, keyPEM []byte) error {
+	if err := m.checkDynamic(domain); err != nil {
+		return err
+	}
+	val, cer, err := encodeVirtualHost(domain, certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+	if err := m.kv.Put(ctx, hostKey(domain), val); err != nil {
+		return err
+	}
+	m.register(domain, cer)
+	return nil
+}
+
+// RemoveHost unregisters a virtual host defined at runtime.
+func (m *KVManager) RemoveHost(ctx context.Context, domain string) error {
+	if err := m.checkDynamic(domain); err != nil {
+		return err
+	}
+	if err := m.kv.Del(ctx, hostKey(domain)); err != nil {
+		return err
+	}
+	m.applyDel(domain)
+	return nil
+}
+
+func encodeVirtualHost(domain string, certPEM, keyPEM []byte) (string, tls.Certificate, error) {
+	vh := &hostpb.VirtualHost{
+		Domain:        domain,
+		CertPem:       certPEM,
+		PrivateKeyPem: keyPEM,
+	}
+	cer, err := loadVirtualHostCertificate(vh)
+	if err != nil {
+		return "", tls.Certificate{}, &InvalidHostError{err: err}
+	}
+	b, err := proto.Marshal(vh)
+	if err != nil {
+		return "", tls.Certificate{}, err
+	}
+	return string(b), cer, nil
+}
+
+func (m *KVManager) checkDynamic(domain string) error {
+	if _, ok := m.static[domain]; ok {
+		return ErrStaticHost
+	}
+	m.mu.RLock()
+	_, ok := m.dynamic[domain]
+	m.mu.RUnlock()
+	if !ok {
+		return ErrHostNotFound
+	}
+	return nil
+}
+
+func (m *KVManager) processKVEvents(kvEvents []kvtypes.WatchEvent) {
+	for _, ev := range kvEvents {
+		switch ev.Type {
+		case kvtypes.Put:
+			if err := m.applyPut(ev.Key, ev.Val); err != nil {
+				level.Warn(m.logger).Log("msg", "failed to apply virtual host change", "key", ev.Key, "err", err)
+			}
+		case kvtypes.Del:
+			m.applyDel(strings.TrimPrefix(ev.Key, hostKeyPrefix))
+		}
+	}
+}
+
+func (m *KVManager) applyPut(key string, val []byte) error {
+	var vh hostpb.VirtualHost
+	if err := proto.Unmarshal(val, &vh); err != nil {
+		return err
+	}
+	if vh.Domain != strings.TrimPrefix(key, hostKeyPrefix) {
+		return errors.New("host: virtual host key mismatch")
+	}
+	if _, ok := m.static[vh.Domain]; ok {
+		return ErrStaticHost
+	}
+	cer, err := loadVirtualHostCertificate(&vh)
+	if err != nil {
+		return err
+	}
+	m.register(vh.Domain, cer)
+	return nil
+}
+
+func (m *KVManager) applyDel(domain string) {
+	if _, ok := m.static[domain]; ok {
+		return
+	}
+	m.mu.Lock()
+	_, ok := m.dynamic[domain]
+	delete(m.dynamic, domain)
+	m.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	m.hosts.UnregisterHost(domain)
+	m.certWatcher.Unwatch(domain)
+
+	level.Info(m.logger).Log("msg", "unregistered virtual host", "domain", domain)
+}
+
+func (m *KVManager) register(domain string, cer tls.Certificate) {
+	m.mu.Lock()
+	m.dynamic[domain] = struct{}{}
+	m.mu.Unlock()
+
+	m.hosts.RegisterHost(domain, cer)
+	m.certWatcher.Track(domain)
+
+	level.Info(m.logger).Log("msg", "registered virtual host", "domain", domain)
+}
+
+func loadVirtualHostCertificate(vh *hostpb.VirtualHost) (tls.Certificate, error) {
+	if len(vh.Domain) == 0 || strings.ContainsAny(vh.Domain, "@/") {
+		return tls.Certificate{}, errors.New("malformed domain")
+	}
+	if _, err := jid.NewWithString(vh.Domain, false); err != nil {
+		return tls.Certificate{}, err
+	}
+	cer, err := tls.X509KeyPair(vh.CertPem, vh.PrivateKeyPem)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := validateCertificate(cer, vh.Domain, time.Now())
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	cer.Leaf = leaf
+	return cer, nil
+}
+
+func hostKey(domain string) string {
+	return hostKeyPrefix + domain
+}
@@ -0,0 +1,199 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	kvtypes "github.com/ortuman/jackal/pkg/cluster/kv/types"
+	hostpb "github.com/ortuman/jackal/pkg/host/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestKVManager_AddHost(t *testing.T) {
+	// given
+	kvMock := &kvMock{}
+	kvMock.PutIfAbsentFunc = func(ctx context.Context, key string, value string) (bool, error) { return true, nil }
+
+	m, hs := testKVManager(t, kvMock)
+
+	certPEM, keyPEM := testCertificatePEM(t, "jackal.org", 1, time.Hour)
+
+	// when
+	err := m.AddHost(context.Background(), "jackal.org", certPEM, keyPEM)
+
+	// then
+	require.Nil(t, err)
+	require.True(t, hs.IsLocalHost("jackal.org"))
+
+	require.Len(t, kvMock.PutIfAbsentCalls(), 1)
+	require.Equal(t, "h://jackal.org", kvMock.PutIfAbsentCalls()[0].Key)
+
+	_, ok := m.POSHConfig("jackal.org")
+	require.True(t, ok)
+
+	require.Equal(t, []Desc{
+		{Domain: "jackal.im", IsDefault: true},
+		{Domain: "jackal.org", IsDynamic: true},
+	}, m.Hosts())
+}
+
+func TestKVManager_AddHostErrors(t *testing.T) {
+	var tcs = map[string]struct {
+		domain      string
+		certDomain  string
+		notAfter    time.Duration
+		expectedErr error
+	}{
+		"Exists": {
+			domain:      "jackal.im",
+			certDomain:  "jackal.im",
+			notAfter:    time.Hour,
+			expectedErr: ErrHostExists,
+		},
+		"Domain mismatch": {
+			domain:     "jackal.org",
+			certDomain: "jackal.net",
+			notAfter:   time.Hour,
+		},
+		"Expired": {
+			domain:     "jackal.org",
+			certDomain: "jackal.org",
+			notAfter:   -time.Minute,
+		},
+		"Malformed domain": {
+			domain:     "ortuman@jackal.org",
+			certDomain: "jackal.org",
+			notAfter:   time.Hour,
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			kvMock := &kvMock{}
+			m, hs := testKVManager(t, kvMock)
+
+			certPEM, keyPEM := testCertificatePEM(t, tc.certDomain, 1, tc.notAfter)
+
+			// when
+			err := m.AddHost(context.Background(), tc.domain, certPEM, keyPEM)
+
+			// then
+			if tc.expectedErr != nil {
+				require.Equal(t, tc.expectedErr, err)
+			} else {
+				require.IsType(t, &InvalidHostError{}, err)
+				require.False(t, hs.IsLocalHost(tc.domain))
+			}
+			require.Len(t, kvMock.PutIfAbsentCalls(), 0)
+		})
+	}
+}
+
+func TestKVManager_AddHostRegisteredByOtherMember(t *testing.T) {
+	// given
+	kvMock := &kvMock{}
+	kvMock.PutIfAbsentFunc = func(ctx context.Context, key string, value string) (bool, error) { return false, nil }
+
+	m, hs := testKVManager(t, kvMock)
+
+	certPEM, keyPEM := testCertificatePEM(t, "jackal.org", 1, time.Hour)
+
+	// when
+	err := m.AddHost(context.Background(), "jackal.org", certPEM, keyPEM)
+
+	// then
+	require.Equal(t, ErrHostExists, err)
+	require.False(t, hs.IsLocalHost("jackal.org"))
+
+	_, ok := m.POSHConfig("jackal.org")
+	require.False(t, ok)
+}
+
+func TestKVManager_RemoveHost(t *testing.T) {
+	// given
+	kvMock := &kvMock{}
+	kvMock.PutIfAbsentFunc = func(ctx context.Context, key string, value string) (bool, error) { return true, nil }
+	kvMock.DelFunc = func(ctx context.Context, key string) error { return nil }
+
+	m, hs := testKVManager(t, kvMock)
+
+	certPEM, keyPEM := testCertificatePEM(t, "jackal.org", 1, time.Hour)
+	require.Nil(t, m.AddHost(context.Background(), "jackal.org", certPEM, keyPEM))
+
+	// when
+	err1 := m.RemoveHost(context.Background(), "jackal.im")
+	err2 := m.RemoveHost(context.Background(), "jackal.net")
+	err3 := m.RemoveHost(context.Background(), "jackal.org")
+
+	// then
+	require.Equal(t, ErrStaticHost, err1)
+	require.Equal(t, ErrHostNotFound, err2)
+	require.Nil(t, err3)
+
+	require.False(t, hs.IsLocalHost("jackal.org"))
+	require.Len(t, kvMock.DelCalls(), 1)
+}
+
+func TestKVManager_Watch(t *testing.T) {
+	// given
+	certPEM, keyPEM := testCertificatePEM(t, "jackal.org", 1, time.Hour)
+	b, _ := proto.Marshal(&hostpb.VirtualHost{
+		Domain:        "jackal.org",
+		CertPem:       certPEM,
+		PrivateKeyPem: keyPEM,
+	})
+
+	kvMock := &kvMock{}
+	wCh := make(chan kvtypes.WatchResp)
+	kvMock.WatchFunc = func(ctx context.Context, prefix string, withPrevVal bool) <-chan kvtypes.WatchResp {
+		return wCh
+	}
+	kvMock.GetPrefixFunc = func(ctx context.Context, prefix string) (map[string][]byte, error) {
+		return map[string][]byte{"h://jackal.org": b}, nil
+	}
+	m, hs := testKVManager(t, kvMock)
+
+	// when
+	err := m.Start(context.Background())
+	require.Nil(t, err)
+
+	isLocalOnStart := hs.IsLocalHost("jackal.org")
+
+	wCh <- kvtypes.WatchResp{
+		Events: []kvtypes.WatchEvent{{Type: kvtypes.Del, Key: "h://jackal.org"}},
+	}
+	close(wCh)
+	_ = m.Stop(context.Background())
+
+	// then
+	require.True(t, isLocalOnStart)
+	require.False(t, hs.IsLocalHost("jackal.org"))
+}
+
+func testKVManager(t *testing.T, kvMock *kvMock) (*KVManager, *Hosts) {
+	t.Helper()
+
+	cfg := testCertificateFiles(t, t.TempDir(), "jackal.im", 1, time.Hour)
+	hs, err := NewHosts(Configs{cfg})
+	require.Nil(t, err)
+
+	w := NewCertificateWatcher(Configs{cfg}, hs, CertificatesConfig{ReloadInterval: time.Minute}, kitlog.NewNopLogger())
+	return NewKVManager(Configs{cfg}, hs, w, kvMock, kitlog.NewNopLogger()), hs
+}
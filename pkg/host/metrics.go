@@ -0,0 +1,77 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+import (
+	"time"
+
+	"github.com/ortuman/jackal/pkg/cluster/instance"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	reloadResultSuccess = "success"
+	reloadResultFailure = "failure"
+)
+
+var (
+	hostCertificateReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "jackal",
+			Subsystem: "host",
+			Name:      "certificate_reloads_total",
+			Help:      "The total number of host certificate reload attempts.",
+		},
+		[]string{"instance", "host", "result"},
+	)
+	hostCertificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "jackal",
+			Subsystem: "host",
+			Name:      "certificate_expiry_timestamp_seconds",
+			Help:      "The expiration time of the certificate currently in use by a host.",
+		},
+		[]string{"instance", "host"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(hostCertificateReloads)
+	prometheus.MustRegister(hostCertificateExpiry)
+}
+
+func reportCertificateReload(host, result string) {
+	metricLabel := prometheus.Labels{
+		"instance": instance.ID(),
+		"host":     host,
+		"result":   result,
+	}
+	hostCertificateReloads.With(metricLabel).Inc()
+}
+
+func reportCertificateExpiry(host string, notAfter time.Time) {
+	metricLabel := prometheus.Labels{
+		"instance": instance.ID(),
+		"host":     host,
+	}
+	hostCertificateExpiry.With(metricLabel).Set(float64(notAfter.Unix()))
+}
+
+func clearCertificateExpiry(host string) {
+	hostCertificateExpiry.Delete(prometheus.Labels{
+		"instance": instance.ID(),
+		"host":     host,
+	})
+}
@@ -0,0 +1,285 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jackal
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ortuman/jackal/pkg/auth/jwt"
+	"github.com/ortuman/jackal/pkg/auth/pepper"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/log"
+	"github.com/ortuman/jackal/pkg/s2s"
+	"github.com/ortuman/jackal/pkg/shaper"
+	"gopkg.in/yaml.v3"
+)
+
+const redactedValue = "<redacted>"
+
+// secretKeys contains the set of configuration keys whose values should never be printed.
+var secretKeys = map[string]struct{}{
+	"password":        {},
+	"secret":          {},
+	"dialback_secret": {},
+	"bind_password":   {},
+	"keys":            {},
+}
+
+type configError struct {
+	section string
+	err     error
+}
+
+func (e *configError) Error() string {
+	return fmt.Sprintf("%s: %v", e.section, e.err)
+}
+
+func (j *Jackal) checkConfig(cfg *Config) error {
+	errs := validateConfig(cfg)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			_, _ = fmt.Fprintf(j.output, "error: %v\n", err)
+		}
+		return fmt.Errorf("main: configuration check failed with %d error(s)", len(errs))
+	}
+	return printConfig(j.output, cfg)
+}
+
+// validateConfig checks every configuration section, returning all found errors.
+func validateConfig(cfg *Config) []error {
+	var errs []error
+	check := func(section string, err error) {
+		if err != nil {
+			errs = append(errs, &configError{section: section, err: err})
+		}
+	}
+	check("logger", log.Validate(cfg.Logger.Level, cfg.Logger.Format))
+
+	switch cfg.Cluster.Type {
+	case kvClusterType:
+		check("cluster.kv", cfg.Cluster.KV.Validate())
+	case noneClusterType:
+	default:
+		check("cluster", fmt.Errorf("unrecognized cluster type: %s", cfg.Cluster.Type))
+	}
+	_, err := pepper.NewKeys(cfg.Peppers)
+	check("peppers", err)
+
+	if cfg.LDAP.IsEnabled() {
+		check("ldap", cfg.LDAP.Validate())
+	} else if cfg.LDAP.UserRepository {
+		check("ldap", errors.New("user repository requires a directory url"))
+	}
+	if cfg.JWT.IsEnabled() {
+		_, err := jwt.NewVerifier(cfg.JWT)
+		check("jwt", err)
+	}
+	check("storage", cfg.Storage.Validate())
+	if cfg.Storage.Type == "boltdb" {
+		check("storage.boltdb", checkDir(filepath.Dir(cfg.Storage.BoltDB.Path)))
+	}
+	check("hosts", validateHosts(cfg.Hosts))
+
+	ss := make(shaper.Shapers, 0, len(cfg.Shapers))
+	for i, shpCfg := range cfg.Shapers {
+		shp, err := shaper.New(shpCfg)
+		if err != nil {
+			check(fmt.Sprintf("shapers[%d]", i), err)
+			continue
+		}
+		ss = append(ss, shp)
+	}
+	for i, lnCfg := range cfg.C2S.Listeners {
+		section := fmt.Sprintf("c2s.listeners[%d]", i)
+		check(section, lnCfg.Validate())
+		if lnCfg.SASL.LDAP && !cfg.LDAP.IsEnabled() {
+			check(section, errors.New("LDAP authentication requires LDAP configuration"))
+		}
+		if lnCfg.SASL.OAuthBearer && !cfg.JWT.IsEnabled() {
+			check(section, errors.New("OAUTHBEARER authentication requires JWT configuration"))
+		}
+	}
+	for i, lnCfg := range cfg.S2S.Listeners {
+		check(fmt.Sprintf("s2s.listeners[%d]", i), lnCfg.ProxyProtocol.Validate())
+	}
+	check("s2s.out", cfg.S2S.Out.Validate())
+	for _, caFile := range cfg.S2S.Auth.CACertFiles {
+		check("s2s.auth", checkFile(caFile))
+	}
+	_, err = s2s.NewFederationPolicy(cfg.S2S.Federation, shaper.NewRegistry(ss))
+	check("s2s.federation", err)
+
+	for i, lnCfg := range cfg.Components.Listeners {
+		check(fmt.Sprintf("components.listeners[%d]", i), lnCfg.ProxyProtocol.Validate())
+	}
+	check("modules", cfg.Modules.validate())
+	check("modules.ping", cfg.Modules.Ping.Validate())
+	for _, hCfg := range cfg.Modules.Hosts {
+		if hCfg.Ping != nil {
+			check(fmt.Sprintf("modules.hosts[%s].ping", hCfg.Domain), hCfg.Ping.Validate())
+		}
+	}
+	if cfg.Mux.Enabled {
+		check("mux", cfg.Mux.Validate())
+	}
+	return errs
+}
+
+func validateHosts(configs host.Configs) error {
+	domains := make(map[string]struct{})
+	for _, hCfg := range configs {
+		if len(hCfg.Domain) == 0 {
+			return errors.New("missing host domain")
+		}
+		if _, ok := domains[hCfg.Domain]; ok {
+			return fmt.Errorf("duplicated host: %s", hCfg.Domain)
+		}
+		domains[hCfg.Domain] = struct{}{}
+
+		certFile, keyFile := hCfg.TLS.CertFile, hCfg.TLS.PrivateKeyFile
+		if len(certFile) == 0 && len(keyFile) == 0 {
+			continue // self-signed certificate
+		}
+		if len(certFile) == 0 || len(keyFile) == 0 {
+			return fmt.Errorf("%s: both cert_file and privkey_file must be specified", hCfg.Domain)
+		}
+		if err := checkFile(certFile); err != nil {
+			return fmt.Errorf("%s: %v", hCfg.Domain, err)
+		}
+		if err := checkFile(keyFile); err != nil {
+			return fmt.Errorf("%s: %v", hCfg.Domain, err)
+		}
+		// make sure certificate can be loaded, without generating self-signed ones on disk
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return fmt.Errorf("%s: %v", hCfg.Domain, err)
+		}
+	}
+	return nil
+}
+
+func checkFile(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
+
+func checkDir(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
+	}
+	return nil
+}
+
+// printConfig writes cfg in YAML format to w, redacting any secret value.
+func printConfig(w io.Writer, cfg *Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(configNode(reflect.ValueOf(*cfg), false)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func configNode(v reflect.Value, redact bool) *yaml.Node {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return scalarNode("null", "!!null")
+		}
+		return configNode(v.Elem(), redact)
+
+	case reflect.Struct:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Ptr && fv.IsNil() {
+				continue
+			}
+			key := configKey(f)
+			_, isSecret := secretKeys[key]
+			n.Content = append(n.Content, scalarNode(key, "!!str"), configNode(fv, isSecret))
+		}
+		return n
+
+	case reflect.Map:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			n.Content = append(n.Content,
+				scalarNode(fmt.Sprint(k.Interface()), "!!str"),
+				configNode(v.MapIndex(k), redact),
+			)
+		}
+		return n
+
+	case reflect.Slice, reflect.Array:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for i := 0; i < v.Len(); i++ {
+			n.Content = append(n.Content, configNode(v.Index(i), redact))
+		}
+		return n
+
+	case reflect.String:
+		if redact && v.Len() > 0 {
+			return scalarNode(redactedValue, "!!str")
+		}
+		return scalarNode(v.String(), "!!str")
+
+	case reflect.Bool:
+		return scalarNode(fmt.Sprint(v.Bool()), "!!bool")
+
+	case reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			return scalarNode(time.Duration(v.Int()).String(), "!!str")
+		}
+	}
+	return scalarNode(fmt.Sprint(v.Interface()), "")
+}
+
+func configKey(f reflect.StructField) string {
+	if tag := f.Tag.Get("fig"); len(tag) > 0 {
+		return strings.Split(tag, ",")[0]
+	}
+	return strings.ToLower(f.Name)
+}
+
+func scalarNode(value, tag string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value, Tag: tag}
+}
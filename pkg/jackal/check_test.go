@@ -0,0 +1,184 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jackal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tcs := map[string]struct {
+		config string
+		errs   []string
+	}{
+		"Valid": {
+			config: `
+c2s:
+  listeners:
+    - port: 5222
+      sasl:
+        mechanisms: [scram_sha_1]
+`,
+		},
+		"InvalidValues": {
+			config: `
+logger:
+  level: verbose
+cluster:
+  type: consul
+peppers:
+  iteration_count: 1000
+jwt:
+  jwks_file: /nonexistent/jwks.json
+c2s:
+  listeners:
+    - resource_conflict: replace
+      sasl:
+        ldap: true
+        oauthbearer: true
+    - port: 5223
+      sasl:
+        external:
+          scram: true
+    - port: 5224
+      sasl:
+        mechanisms: [plain, scram_sha_256]
+        external:
+          address: localhost:4567
+modules:
+  enabled: [roster, foo]
+`,
+			errs: []string{
+				"logger: log: unrecognized level: verbose",
+				"cluster: unrecognized cluster type: consul",
+				"peppers: pepper: iteration count must be at least 4096",
+				"jwt: jwt: issuer must be specified",
+				"c2s.listeners[0]: c2s: unrecognized resource conflict rule: replace",
+				"c2s.listeners[0]: LDAP authentication requires LDAP configuration",
+				"c2s.listeners[1]: c2s: external authenticator options require an address",
+				"c2s.listeners[2]: c2s: plain mechanism can't be combined with another PLAIN authenticator",
+				"modules: main: unrecognized module name: foo",
+			},
+		},
+		"MissingFiles": {
+			config: `
+hosts:
+  - domain: jackal.im
+    tls:
+      cert_file: /nonexistent/cert.pem
+      privkey_file: /nonexistent/key.pem
+s2s:
+  auth:
+    ca_cert_files: [/nonexistent/ca.pem]
+`,
+			errs: []string{
+				"hosts: jackal.im: stat /nonexistent/cert.pem: no such file or directory",
+				"s2s.auth: stat /nonexistent/ca.pem: no such file or directory",
+			},
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			cfg := testLoadConfig(t, tc.config)
+
+			// when
+			errs := validateConfig(cfg)
+
+			// then
+			var errStrs []string
+			for _, err := range errs {
+				errStrs = append(errStrs, err.Error())
+			}
+			require.Equal(t, tc.errs, errStrs)
+		})
+	}
+}
+
+func TestJackal_CheckConfigWritesNoFiles(t *testing.T) {
+	// given
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte(`
+hosts:
+  - domain: jackal.im
+c2s:
+  listeners:
+    - port: 5222
+      sasl:
+        mechanisms: [scram_sha_1]
+`), 0600))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	workDir := t.TempDir()
+	require.NoError(t, os.Chdir(workDir))
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+	buf := bytes.NewBuffer(nil)
+
+	// when
+	err = New(buf, []string{"jackal", "--config", cfgFile, "--check-config"}).Run()
+
+	// then
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "domain: jackal.im")
+
+	entries, err := os.ReadDir(workDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 0)
+}
+
+func TestPrintConfig(t *testing.T) {
+	// given
+	cfg := testLoadConfig(t, `
+peppers:
+  keys:
+    v1: s3cr3t_p3pp3r_k3y
+  use: v1
+storage:
+  pgsql:
+    password: hunter2
+`)
+	buf := bytes.NewBuffer(nil)
+
+	// when
+	err := printConfig(buf, cfg)
+
+	// then
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, "    v1: <redacted>\n  use: v1\n")
+	require.Contains(t, out, "    password: <redacted>\n")
+	require.Contains(t, out, "    hibernate_time: 3m0s\n")
+	require.NotContains(t, out, "s3cr3t_p3pp3r_k3y")
+	require.NotContains(t, out, "hunter2")
+}
+
+func testLoadConfig(t *testing.T, content string) *Config {
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte(content), 0600))
+
+	cfg, err := loadConfig(cfgFile)
+	require.NoError(t, err)
+	return cfg
+}
@@ -15,22 +15,27 @@
 package jackal
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
 
 	"github.com/ortuman/jackal/pkg/module/xep0313"
 
 	"github.com/kkyr/fig"
 	adminserver "github.com/ortuman/jackal/pkg/admin/server"
+	"github.com/ortuman/jackal/pkg/auth/jwt"
 	"github.com/ortuman/jackal/pkg/auth/pepper"
 	"github.com/ortuman/jackal/pkg/c2s"
 	"github.com/ortuman/jackal/pkg/cluster/kv"
 	clusterserver "github.com/ortuman/jackal/pkg/cluster/server"
 	"github.com/ortuman/jackal/pkg/component/xep0114"
 	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/ldap"
 	"github.com/ortuman/jackal/pkg/module/offline"
 	"github.com/ortuman/jackal/pkg/module/xep0092"
 	"github.com/ortuman/jackal/pkg/module/xep0198"
 	"github.com/ortuman/jackal/pkg/module/xep0199"
+	"github.com/ortuman/jackal/pkg/mux"
 	"github.com/ortuman/jackal/pkg/s2s"
 	"github.com/ortuman/jackal/pkg/shaper"
 	"github.com/ortuman/jackal/pkg/storage"
@@ -71,8 +76,10 @@ type C2SConfig struct {
 
 // S2SConfig defines S2S subsystem configuration.
 type S2SConfig struct {
-	Listeners s2s.ListenersConfig `fig:"listeners"`
-	Out       s2s.OutConfig       `fig:"out"`
+	Listeners  s2s.ListenersConfig  `fig:"listeners"`
+	Out        s2s.OutConfig        `fig:"out"`
+	Auth       s2s.AuthConfig       `fig:"auth"`
+	Federation s2s.FederationConfig `fig:"federation"`
 }
 
 // ComponentsConfig defines application components configuration.
@@ -100,6 +107,102 @@ type ModulesConfig struct {
 
 	// XEP-0313: Message Archive Management
 	Mam xep0313.Config `fig:"mam"`
+
+	// Hosts specifies per-host module overrides.
+	Hosts []HostModulesConfig `fig:"hosts"`
+}
+
+func (c ModulesConfig) validate() error {
+	modNames := append([]string{}, c.Enabled...)
+	domains := make(map[string]struct{})
+	for _, hCfg := range c.Hosts {
+		if len(hCfg.Domain) == 0 {
+			return errors.New("main: missing module host overrides domain")
+		}
+		if _, ok := domains[hCfg.Domain]; ok {
+			return fmt.Errorf("main: duplicated module host overrides: %s", hCfg.Domain)
+		}
+		domains[hCfg.Domain] = struct{}{}
+		modNames = append(modNames, hCfg.Enabled...)
+	}
+	for _, mName := range modNames {
+		if _, ok := modFns[mName]; !ok {
+			return fmt.Errorf("main: unrecognized module name: %s", mName)
+		}
+	}
+	return nil
+}
+
+func (c ModulesConfig) offlineHostConfigs() map[string]offline.Config {
+	ret := make(map[string]offline.Config)
+	for _, hCfg := range c.Hosts {
+		if hCfg.Offline != nil {
+			ret[hCfg.Domain] = *hCfg.Offline
+		}
+	}
+	return ret
+}
+
+func (c ModulesConfig) pingHostConfigs() map[string]xep0199.Config {
+	ret := make(map[string]xep0199.Config)
+	for _, hCfg := range c.Hosts {
+		if hCfg.Ping != nil {
+			ret[hCfg.Domain] = *hCfg.Ping
+		}
+	}
+	return ret
+}
+
+func (c ModulesConfig) mamHostConfigs() map[string]xep0313.Config {
+	ret := make(map[string]xep0313.Config)
+	for _, hCfg := range c.Hosts {
+		if hCfg.Mam != nil {
+			ret[hCfg.Domain] = *hCfg.Mam
+		}
+	}
+	return ret
+}
+
+func (c ModulesConfig) hostEnabled() map[string][]string {
+	ret := make(map[string][]string)
+	for _, hCfg := range c.Hosts {
+		if len(hCfg.Enabled) > 0 {
+			ret[hCfg.Domain] = hCfg.Enabled
+		}
+	}
+	return ret
+}
+
+func (c ModulesConfig) hostOverrides() map[string]HostModulesConfig {
+	ret := make(map[string]HostModulesConfig)
+	for _, hCfg := range c.Hosts {
+		if hCfg.Offline == nil && hCfg.Ping == nil && hCfg.Mam == nil {
+			continue
+		}
+		hCfg.Enabled = nil
+		ret[hCfg.Domain] = hCfg
+	}
+	return ret
+}
+
+// HostModulesConfig defines modules configuration overrides for a single host.
+// Any specified module configuration entirely replaces the global one for that host.
+type HostModulesConfig struct {
+	// Domain specifies the host domain these overrides apply to.
+	Domain string `fig:"domain"`
+
+	// Enabled specifies the set of modules enabled for this host.
+	// If empty, globally enabled modules will be used.
+	Enabled []string `fig:"enabled"`
+
+	// Offline: offline storage
+	Offline *offline.Config `fig:"offline"`
+
+	// XEP-0199: XMPP Ping
+	Ping *xep0199.Config `fig:"ping"`
+
+	// XEP-0313: Message Archive Management
+	Mam *xep0313.Config `fig:"mam"`
 }
 
 // Config defines jackal application configuration.
@@ -111,16 +214,21 @@ type Config struct {
 
 	HTTP HTTPConfig `fig:"http"`
 
-	Peppers pepper.Config      `fig:"peppers"`
-	Admin   adminserver.Config `fig:"admin"`
-	Storage storage.Config     `fig:"storage"`
-	Hosts   host.Configs       `fig:"hosts"`
-	Shapers []shaper.Config    `fig:"shapers"`
+	Peppers pepper.Config           `fig:"peppers"`
+	LDAP    ldap.Config             `fig:"ldap"`
+	JWT     jwt.Config              `fig:"jwt"`
+	Admin   adminserver.Config      `fig:"admin"`
+	Storage storage.Config          `fig:"storage"`
+	Hosts   host.Configs            `fig:"hosts"`
+	Certs   host.CertificatesConfig `fig:"certificates"`
+	Shapers []shaper.Config         `fig:"shapers"`
 
 	C2S        C2SConfig        `fig:"c2s"`
 	S2S        S2SConfig        `fig:"s2s"`
 	Components ComponentsConfig `fig:"components"`
 	Modules    ModulesConfig    `fig:"modules"`
+
+	Mux mux.Config `fig:"mux"`
 }
 
 func loadConfig(configFile string) (*Config, error) {
@@ -23,18 +23,26 @@ import (
 
 	kitlog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/ortuman/jackal/pkg/s2s"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type httpServer struct {
-	port   int
-	srv    *http.Server
-	logger kitlog.Logger
+	port        int
+	poshHandler http.Handler
+	srv         *http.Server
+	extraLns    []net.Listener
+	logger      kitlog.Logger
 }
 
-func newHTTPServer(port int, logger kitlog.Logger) *httpServer {
-	return &httpServer{port: port, logger: logger}
+func newHTTPServer(port int, poshHandler http.Handler, logger kitlog.Logger) *httpServer {
+	return &httpServer{port: port, poshHandler: poshHandler, logger: logger}
+}
+
+// addListener registers an additional listener to be served along with the HTTP port one.
+func (h *httpServer) addListener(ln net.Listener) {
+	h.extraLns = append(h.extraLns, ln)
 }
 
 func (h *httpServer) Start(_ context.Context) error {
@@ -51,16 +59,16 @@ func (h *httpServer) Start(_ context.Context) error {
 
 	mux.Handle("/healthz", http.HandlerFunc(h.healthCheck))
 
+	mux.Handle(s2s.POSHPath, h.poshHandler)
+
 	h.srv = &http.Server{Handler: mux}
 	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", h.port))
 	if err != nil {
 		return err
 	}
-	go func() {
-		if err := h.srv.Serve(ln); err != nil && err != http.ErrServerClosed {
-			level.Error(h.logger).Log("msg", "failed to serve HTTP", "err", err)
-		}
-	}()
+	for _, l := range append([]net.Listener{ln}, h.extraLns...) {
+		go h.serve(l)
+	}
 	level.Info(h.logger).Log("msg", "HTTP server listening", "port", h.port)
 	return nil
 }
@@ -73,6 +81,12 @@ func (h *httpServer) Stop(ctx context.Context) error {
 	return nil
 }
 
+func (h *httpServer) serve(ln net.Listener) {
+	if err := h.srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+		level.Error(h.logger).Log("msg", "failed to serve HTTP", "err", err)
+	}
+}
+
 func (h *httpServer) healthCheck(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
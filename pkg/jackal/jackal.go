@@ -16,6 +16,7 @@ package jackal
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -23,6 +24,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"sync"
 	"syscall"
 	"time"
 
@@ -30,6 +32,8 @@ import (
 	"github.com/go-kit/log/level"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	adminserver "github.com/ortuman/jackal/pkg/admin/server"
+	"github.com/ortuman/jackal/pkg/auth"
+	"github.com/ortuman/jackal/pkg/auth/jwt"
 	"github.com/ortuman/jackal/pkg/auth/pepper"
 	"github.com/ortuman/jackal/pkg/c2s"
 	clusterconnmanager "github.com/ortuman/jackal/pkg/cluster/connmanager"
@@ -43,16 +47,21 @@ import (
 	"github.com/ortuman/jackal/pkg/component/xep0114"
 	"github.com/ortuman/jackal/pkg/hook"
 	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/ldap"
 	"github.com/ortuman/jackal/pkg/log"
 	"github.com/ortuman/jackal/pkg/module"
 	streamqueue "github.com/ortuman/jackal/pkg/module/xep0198/queue"
+	"github.com/ortuman/jackal/pkg/mux"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/s2s"
 	"github.com/ortuman/jackal/pkg/shaper"
 	"github.com/ortuman/jackal/pkg/storage"
+	externalrepository "github.com/ortuman/jackal/pkg/storage/external"
+	ldaprepository "github.com/ortuman/jackal/pkg/storage/ldap"
 	"github.com/ortuman/jackal/pkg/storage/repository"
 	"github.com/ortuman/jackal/pkg/util/crashreporter"
 	"github.com/ortuman/jackal/pkg/version"
+	"github.com/samber/lo"
 )
 
 const (
@@ -77,6 +86,7 @@ const usageStr = `
 Usage: jackal [options]
 Server Options:
     --config <file>    Configuration file path
+    --check-config     Validate configuration and print effective values
 Common Options:
     --help             Show this message
 `
@@ -99,7 +109,14 @@ type Jackal struct {
 	output io.Writer
 	args   []string
 
+	configFile string
+	cfg        *Config
+	reloadMu   sync.Mutex
+
 	peppers *pepper.Keys
+	extCls  map[string]*auth.ExternalClient
+	ldapCl  *ldap.Client
+	jwtVer  *jwt.Verifier
 	hk      *hook.Hooks
 
 	kv         kv.KV
@@ -108,25 +125,33 @@ type Jackal struct {
 
 	rep repository.Repository
 
-	shapers        shaper.Shapers
+	shapers        *shaper.Registry
 	hosts          *host.Hosts
+	certWatcher    *host.CertificateWatcher
+	hostMng        *host.KVManager
 	clusterConnMng *clusterconnmanager.Manager
 
 	localRouter    *c2s.LocalRouter
 	clusterRouter  *clusterrouter.Router
 	s2sOutProvider *s2s.OutProvider
+	s2sPeerAuth    *s2s.PeerAuthenticator
+	s2sFedPolicy   *s2s.FederationPolicy
+	s2sInHub       *s2s.InHub
 	router         router.Router
 	mods           *module.Modules
 	comps          *component.Components
 	stmQueueMap    *streamqueue.QueueMap
 	extCompMng     *extcomponentmanager.Manager
+	mux            *mux.Listener
 
 	starters []starter
 	stoppers []stopper
 
 	waitStopCh chan os.Signal
+	reloadCh   chan os.Signal
 
-	logger kitlog.Logger
+	rLogger *log.ReloadableLogger
+	logger  kitlog.Logger
 }
 
 // New makes a new Jackal.
@@ -135,6 +160,7 @@ func New(output io.Writer, args []string) *Jackal {
 		output:     output,
 		args:       args,
 		waitStopCh: make(chan os.Signal, 1),
+		reloadCh:   make(chan os.Signal, 1),
 		kv:         kv.NewNop(),
 		memberList: memberlist.NewNop(),
 	}
@@ -151,11 +177,12 @@ func (j *Jackal) Run() error {
 	fs.SetOutput(j.output)
 
 	var configFile string
-	var showVersion, showUsage bool
+	var showVersion, showUsage, checkConfig bool
 
 	fs.BoolVar(&showUsage, "help", false, "Show this message")
 	fs.BoolVar(&showVersion, "version", false, "Print version information.")
 	fs.StringVar(&configFile, "config", "config.yaml", "Configuration file path.")
+	fs.BoolVar(&checkConfig, "check-config", false, "Validate configuration and print effective values.")
 
 	fs.Usage = func() {
 		for i := range logoStr {
@@ -184,8 +211,16 @@ func (j *Jackal) Run() error {
 	if err != nil {
 		return err
 	}
+	// check configuration
+	if checkConfig {
+		return j.checkConfig(cfg)
+	}
+	j.configFile = configFile
+	j.cfg = cfg
+
 	// init logger
-	j.logger = log.NewDefaultLogger(cfg.Logger.Level, cfg.Logger.Format)
+	j.rLogger = log.NewReloadableLogger(cfg.Logger.Level, cfg.Logger.Format)
+	j.logger = j.rLogger.Logger()
 
 	level.Info(j.logger).Log("msg", "jackal is starting...",
 		"version", version.Version,
@@ -220,18 +255,31 @@ func (j *Jackal) Run() error {
 		return err
 	}
 
+	// init LDAP directory client
+	if err := j.initLDAP(cfg.LDAP); err != nil {
+		return err
+	}
+	// init JWT verifier
+	if err := j.initJWT(cfg.JWT); err != nil {
+		return err
+	}
+	// init external authenticator clients
+	j.initExternalAuth(cfg.C2S.Listeners)
+
 	// init repository
-	if err := j.initRepository(cfg.Storage); err != nil {
+	if err := j.initRepository(cfg.Storage, cfg.LDAP, cfg.C2S.Listeners); err != nil {
 		return err
 	}
 	// init C2S/S2S routers
-	if err := j.initHosts(cfg.Hosts); err != nil {
+	if err := j.initHosts(cfg.Hosts, cfg.Certs); err != nil {
 		return err
 	}
 	if err := j.initShapers(cfg.Shapers); err != nil {
 		return err
 	}
-	j.initS2SOut(cfg.S2S.Out)
+	if err := j.initS2SOut(cfg.S2S.Out, cfg.S2S.Auth, cfg.S2S.Federation); err != nil {
+		return err
+	}
 	j.initRouters()
 
 	// init components & modules
@@ -249,12 +297,26 @@ func (j *Jackal) Run() error {
 		j.initClusterServer(cfg.Cluster.Server)
 	}
 
+	// init port multiplexer
+	if cfg.Mux.Enabled {
+		j.mux = mux.New(cfg.Mux, j.hosts, j.logger)
+	}
 	// init C2S/S2S listeners
 	if err := j.initListeners(cfg.C2S.Listeners, cfg.S2S.Listeners, cfg.Components.Listeners, cfg.Components.Secret); err != nil {
 		return err
 	}
 	// init HTTP server
-	j.registerStartStopper(newHTTPServer(cfg.HTTP.Port, j.logger))
+	httpSrv := newHTTPServer(cfg.HTTP.Port, s2s.NewPOSHHandler(j.hosts, j.hostMng), j.logger)
+	if j.mux != nil {
+		httpLn := mux.NewConnListener()
+		j.mux.Handle(mux.HTTPService, httpLn)
+		httpSrv.addListener(httpLn)
+	}
+	j.registerStartStopper(httpSrv)
+
+	if j.mux != nil {
+		j.registerStartStopper(j.mux)
+	}
 
 	if err := j.bootstrap(); err != nil {
 		return err
@@ -301,33 +363,106 @@ func (j *Jackal) initKVStore(cfg kv.Config) error {
 	return nil
 }
 
-func (j *Jackal) initRepository(cfg storage.Config) error {
+func (j *Jackal) initLDAP(cfg ldap.Config) error {
+	if !cfg.IsEnabled() {
+		if cfg.UserRepository {
+			return errors.New("main: LDAP user repository requires a directory url")
+		}
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	j.ldapCl = ldap.NewClient(cfg, j.logger)
+	j.registerStartStopper(j.ldapCl)
+	return nil
+}
+
+func (j *Jackal) initJWT(cfg jwt.Config) error {
+	if !cfg.IsEnabled() {
+		return nil
+	}
+	v, err := jwt.NewVerifier(cfg)
+	if err != nil {
+		return err
+	}
+	j.jwtVer = v
+	return nil
+}
+
+func (j *Jackal) initExternalAuth(c2sListenersCfg c2s.ListenersConfig) {
+	j.extCls = make(map[string]*auth.ExternalClient)
+	for _, lnCfg := range c2sListenersCfg {
+		extCfg := lnCfg.SASL.External
+		if len(extCfg.Address) == 0 {
+			continue
+		}
+		if _, ok := j.extCls[extCfg.Address]; ok {
+			continue // share connection among listeners
+		}
+		extCl := auth.NewExternalClient(extCfg.Address, extCfg.IsSecure)
+		j.extCls[extCfg.Address] = extCl
+		j.registerStartStopper(extCl)
+	}
+}
+
+func (j *Jackal) initRepository(cfg storage.Config, ldapCfg ldap.Config, c2sListenersCfg c2s.ListenersConfig) error {
 	rep, err := storage.New(cfg, j.logger)
 	if err != nil {
 		return err
 	}
+	if j.ldapCl != nil && ldapCfg.UserRepository {
+		rep = ldaprepository.New(j.ldapCl, rep)
+	}
+	var extUserCls []*auth.ExternalClient
+	for _, lnCfg := range c2sListenersCfg {
+		extCfg := lnCfg.SASL.External
+		if !extCfg.UserExistence {
+			continue
+		}
+		if extCl := j.extCls[extCfg.Address]; !lo.Contains(extUserCls, extCl) {
+			extUserCls = append(extUserCls, extCl)
+		}
+	}
+	if len(extUserCls) > 0 {
+		rep = externalrepository.New(extUserCls, rep)
+	}
 	j.rep = rep
 	j.registerStartStopper(j.rep)
 	return nil
 }
 
-func (j *Jackal) initHosts(configs host.Configs) error {
+func (j *Jackal) initHosts(configs host.Configs, certsCfg host.CertificatesConfig) error {
 	h, err := host.NewHosts(configs)
 	if err != nil {
 		return err
 	}
 	j.hosts = h
+	j.certWatcher = host.NewCertificateWatcher(configs, h, certsCfg, j.logger)
+	j.hostMng = host.NewKVManager(configs, h, j.certWatcher, j.kv, j.logger)
+
+	j.registerStartStopper(j.certWatcher)
+	j.registerStartStopper(j.hostMng)
 	return nil
 }
 
 func (j *Jackal) initShapers(configs []shaper.Config) error {
-	j.shapers = make(shaper.Shapers, 0)
+	ss, err := j.buildShapers(configs)
+	if err != nil {
+		return err
+	}
+	j.shapers = shaper.NewRegistry(ss)
+	return nil
+}
+
+func (j *Jackal) buildShapers(configs []shaper.Config) (shaper.Shapers, error) {
+	ss := make(shaper.Shapers, 0)
 	for _, cfg := range configs {
 		shp, err := shaper.New(cfg)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		j.shapers = append(j.shapers, shp)
+		ss = append(ss, shp)
 
 		level.Info(j.logger).Log("msg", "registered shaper configuration",
 			"name", cfg.Name,
@@ -336,7 +471,7 @@ func (j *Jackal) initShapers(configs []shaper.Config) error {
 			"burst", cfg.Rate.Burst,
 		)
 	}
-	return nil
+	return ss, nil
 }
 
 func (j *Jackal) initListeners(
@@ -346,6 +481,14 @@ func (j *Jackal) initListeners(
 	cmpSecretKey string,
 ) error {
 	// c2s listeners
+	for _, lnCfg := range c2sListenersCfg {
+		if lnCfg.SASL.LDAP && j.ldapCl == nil {
+			return errors.New("main: C2S LDAP authentication requires LDAP configuration")
+		}
+		if lnCfg.SASL.OAuthBearer && j.jwtVer == nil {
+			return errors.New("main: C2S OAUTHBEARER authentication requires JWT configuration")
+		}
+	}
 	c2sListeners := c2s.NewListeners(
 		c2sListenersCfg,
 		j.hosts,
@@ -355,6 +498,9 @@ func (j *Jackal) initListeners(
 		j.resMng,
 		j.rep,
 		j.peppers,
+		j.extCls,
+		j.ldapCl,
+		j.jwtVer,
 		j.shapers,
 		j.hk,
 		j.logger,
@@ -362,11 +508,13 @@ func (j *Jackal) initListeners(
 	for _, ln := range c2sListeners {
 		j.registerStartStopper(ln)
 	}
+	if j.mux != nil && len(c2sListeners) > 0 {
+		j.mux.Handle(mux.C2SService, c2sListeners[0])
+	}
 
 	// s2s listeners
 	if len(s2sListenersCfg) > 0 {
-		s2sInHub := s2s.NewInHub(j.logger)
-		j.registerStartStopper(s2sInHub)
+		j.registerStartStopper(j.s2sInHub)
 
 		s2sListeners := s2s.NewListeners(
 			s2sListenersCfg,
@@ -375,7 +523,8 @@ func (j *Jackal) initListeners(
 			j.comps,
 			j.mods,
 			j.s2sOutProvider,
-			s2sInHub,
+			j.s2sPeerAuth,
+			j.s2sInHub,
 			j.kv,
 			j.shapers,
 			j.hk,
@@ -384,6 +533,9 @@ func (j *Jackal) initListeners(
 		for _, ln := range s2sListeners {
 			j.registerStartStopper(ln)
 		}
+		if j.mux != nil && len(s2sListeners) > 0 {
+			j.mux.Handle(mux.S2SService, s2sListeners[0])
+		}
 	}
 
 	// external component listeners
@@ -401,12 +553,32 @@ func (j *Jackal) initListeners(
 	for _, ln := range cmpListeners {
 		j.registerStartStopper(ln)
 	}
+	if j.mux != nil && len(cmpListeners) > 0 {
+		j.mux.Handle(mux.ComponentService, cmpListeners[0])
+	}
 	return nil
 }
 
-func (j *Jackal) initS2SOut(cfg s2s.OutConfig) {
-	j.s2sOutProvider = s2s.NewOutProvider(cfg, j.hosts, j.kv, j.shapers, j.hk, j.logger)
+func (j *Jackal) initS2SOut(cfg s2s.OutConfig, authCfg s2s.AuthConfig, fedCfg s2s.FederationConfig) error {
+	peerAuth, err := s2s.NewPeerAuthenticator(authCfg, nil)
+	if err != nil {
+		return err
+	}
+	j.s2sPeerAuth = peerAuth
+
+	fedPolicy, err := s2s.NewFederationPolicy(fedCfg, j.shapers)
+	if err != nil {
+		return err
+	}
+	j.s2sFedPolicy = fedPolicy
+	j.s2sInHub = s2s.NewInHub(j.s2sFedPolicy, j.logger)
+
+	j.s2sOutProvider, err = s2s.NewOutProvider(cfg, j.s2sPeerAuth, j.s2sFedPolicy, j.s2sInHub, j.hosts, j.kv, j.shapers, j.hk, j.logger)
+	if err != nil {
+		return err
+	}
 	j.registerStartStopper(j.s2sOutProvider)
+	return nil
 }
 
 func (j *Jackal) initRouters() {
@@ -420,6 +592,9 @@ func (j *Jackal) initRouters() {
 	// init global router
 	j.router = router.New(j.hosts, c2sRouter, s2sRouter)
 	j.registerStartStopper(j.router)
+
+	// bounce undelivered S2S stanzas through global router
+	j.s2sOutProvider.SetRouter(j.router)
 	return
 }
 
@@ -439,20 +614,37 @@ func (j *Jackal) initModules(cfg ModulesConfig) error {
 	if len(enabled) == 0 {
 		enabled = defaultModules
 	}
-	for _, mName := range enabled {
-		fn, ok := modFns[mName]
-		if !ok {
-			return fmt.Errorf("main: unrecognized module name: %s", mName)
+	modNameLists := [][]string{enabled}
+
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	hostEnabled := make(map[string][]string)
+	for _, hCfg := range cfg.Hosts {
+		hostEnabled[hCfg.Domain] = enabled
+		if len(hCfg.Enabled) > 0 {
+			hostEnabled[hCfg.Domain] = hCfg.Enabled
+			modNameLists = append(modNameLists, hCfg.Enabled)
 		}
-		mods = append(mods, fn(j, &cfg))
 	}
-	j.mods = module.NewModules(mods, j.hosts, j.router, j.hk, j.logger)
+	// instantiate every module enabled for at least one host
+	instantiated := make(map[string]struct{})
+	for _, modNames := range modNameLists {
+		for _, mName := range modNames {
+			if _, ok := instantiated[mName]; ok {
+				continue
+			}
+			mods = append(mods, modFns[mName](j, &cfg))
+			instantiated[mName] = struct{}{}
+		}
+	}
+	j.mods = module.NewModules(mods, enabled, hostEnabled, j.hosts, j.router, j.hk, j.logger)
 	j.registerStartStopper(j.mods)
 	return nil
 }
 
 func (j *Jackal) initAdminServer(cfg adminserver.Config) {
-	adminSrv := adminserver.New(cfg, j.rep, j.peppers, j.hk, j.logger)
+	adminSrv := adminserver.New(cfg, j.rep, j.peppers, j.s2sFedPolicy, j.hostMng, j, j.hk, j.logger)
 	j.registerStartStopper(adminSrv)
 }
 
@@ -519,8 +711,19 @@ func (j *Jackal) shutdown() error {
 }
 
 func (j *Jackal) waitForStopSignal() os.Signal {
-	signal.Notify(j.waitStopCh, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
-	return <-j.waitStopCh
+	signal.Notify(j.waitStopCh, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(j.reloadCh, syscall.SIGHUP)
+	for {
+		select {
+		case <-j.reloadCh:
+			level.Info(j.logger).Log("msg", "received reload signal... reloading configuration and host certificates...")
+			j.certWatcher.Reload()
+			_, _, _ = j.ReloadConfig(context.Background())
+
+		case sig := <-j.waitStopCh:
+			return sig
+		}
+	}
 }
 
 func setRLimit() error {
@@ -58,7 +58,7 @@ var modFns = map[string]func(a *Jackal, cfg *ModulesConfig) module.Module{
 	// Offline
 	// (https://xmpp.org/extensions/xep-0160.html)
 	offline.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
-		return offline.New(cfg.Offline, j.router, j.hosts, j.rep, j.hk, j.logger)
+		return offline.New(cfg.Offline, cfg.offlineHostConfigs(), j.router, j.hosts, j.rep, j.hk, j.logger)
 	},
 	// XEP-0012: Last Activity
 	// (https://xmpp.org/extensions/xep-0012.html)
@@ -104,7 +104,7 @@ var modFns = map[string]func(a *Jackal, cfg *ModulesConfig) module.Module{
 	// XEP-0199: XMPP Ping
 	// (https://xmpp.org/extensions/xep-0199.html)
 	xep0199.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
-		return xep0199.New(cfg.Ping, j.router, j.hk, j.logger)
+		return xep0199.New(cfg.Ping, cfg.pingHostConfigs(), j.router, j.hk, j.logger)
 	},
 	// XEP-0202: Entity Time
 	// (https://xmpp.org/extensions/xep-0202.html)
@@ -119,6 +119,25 @@ var modFns = map[string]func(a *Jackal, cfg *ModulesConfig) module.Module{
 	// XEP-0313: Message Archive Management
 	// (https://xmpp.org/extensions/xep-0313.html)
 	xep0313.ModuleName: func(j *Jackal, cfg *ModulesConfig) module.Module {
-		return xep0313.New(cfg.Mam, j.router, j.hosts, j.rep, j.hk, j.logger)
+		return xep0313.New(cfg.Mam, cfg.mamHostConfigs(), j.router, j.hosts, j.rep, j.hk, j.logger)
+	},
+}
+
+// modReloadFns contains the functions in charge of applying a new configuration to running modules.
+var modReloadFns = map[string]func(mod module.Module, cfg *ModulesConfig){
+	offline.ModuleName: func(mod module.Module, cfg *ModulesConfig) {
+		mod.(*offline.Offline).UpdateConfig(cfg.Offline, cfg.offlineHostConfigs())
+	},
+	xep0092.ModuleName: func(mod module.Module, cfg *ModulesConfig) {
+		mod.(*xep0092.Version).UpdateConfig(cfg.Version)
+	},
+	xep0198.ModuleName: func(mod module.Module, cfg *ModulesConfig) {
+		mod.(*xep0198.Stream).UpdateConfig(cfg.Stream)
+	},
+	xep0199.ModuleName: func(mod module.Module, cfg *ModulesConfig) {
+		mod.(*xep0199.Ping).UpdateConfig(cfg.Ping, cfg.pingHostConfigs())
+	},
+	xep0313.ModuleName: func(mod module.Module, cfg *ModulesConfig) {
+		mod.(*xep0313.Mam).UpdateConfig(cfg.Mam, cfg.mamHostConfigs())
 	},
 }
@@ -0,0 +1,239 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jackal
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/s2s"
+	"github.com/ortuman/jackal/pkg/shaper"
+)
+
+const (
+	loggerSection        = "logger"
+	shapersSection       = "shapers"
+	s2sFederationSection = "s2s.federation"
+	moduleHostsSection   = "modules.hosts"
+)
+
+// configSection describes a configuration section and how it can be reloaded.
+// Non-reloadable sections have a nil apply function.
+type configSection struct {
+	name  string
+	value func(cfg *Config) interface{}
+	apply func(dst, src *Config)
+}
+
+var configSections = []configSection{
+	{name: "memory_ballast_size", value: func(cfg *Config) interface{} { return cfg.MemoryBallastSize }},
+	{
+		name:  loggerSection,
+		value: func(cfg *Config) interface{} { return cfg.Logger },
+		apply: func(dst, src *Config) { dst.Logger = src.Logger },
+	},
+	{name: "cluster", value: func(cfg *Config) interface{} { return cfg.Cluster }},
+	{name: "http", value: func(cfg *Config) interface{} { return cfg.HTTP }},
+	{name: "peppers", value: func(cfg *Config) interface{} { return cfg.Peppers }},
+	{name: "ldap", value: func(cfg *Config) interface{} { return cfg.LDAP }},
+	{name: "jwt", value: func(cfg *Config) interface{} { return cfg.JWT }},
+	{name: "admin", value: func(cfg *Config) interface{} { return cfg.Admin }},
+	{name: "storage", value: func(cfg *Config) interface{} { return cfg.Storage }},
+	{name: "hosts", value: func(cfg *Config) interface{} { return cfg.Hosts }},
+	{name: "certificates", value: func(cfg *Config) interface{} { return cfg.Certs }},
+	{
+		name:  shapersSection,
+		value: func(cfg *Config) interface{} { return cfg.Shapers },
+		apply: func(dst, src *Config) { dst.Shapers = src.Shapers },
+	},
+	{name: "c2s", value: func(cfg *Config) interface{} { return cfg.C2S }},
+	{name: "s2s.listeners", value: func(cfg *Config) interface{} { return cfg.S2S.Listeners }},
+	{name: "s2s.out", value: func(cfg *Config) interface{} { return cfg.S2S.Out }},
+	{name: "s2s.auth", value: func(cfg *Config) interface{} { return cfg.S2S.Auth }},
+	{
+		name:  s2sFederationSection,
+		value: func(cfg *Config) interface{} { return cfg.S2S.Federation },
+		apply: func(dst, src *Config) { dst.S2S.Federation = src.S2S.Federation },
+	},
+	{name: "components", value: func(cfg *Config) interface{} { return cfg.Components }},
+	{name: "modules.enabled", value: func(cfg *Config) interface{} { return cfg.Modules.Enabled }},
+	{
+		name:  "modules.offline",
+		value: func(cfg *Config) interface{} { return cfg.Modules.Offline },
+		apply: func(dst, src *Config) { dst.Modules.Offline = src.Modules.Offline },
+	},
+	{
+		name:  "modules.version",
+		value: func(cfg *Config) interface{} { return cfg.Modules.Version },
+		apply: func(dst, src *Config) { dst.Modules.Version = src.Modules.Version },
+	},
+	{
+		name:  "modules.stream",
+		value: func(cfg *Config) interface{} { return cfg.Modules.Stream },
+		apply: func(dst, src *Config) { dst.Modules.Stream = src.Modules.Stream },
+	},
+	{
+		name:  "modules.ping",
+		value: func(cfg *Config) interface{} { return cfg.Modules.Ping },
+		apply: func(dst, src *Config) { dst.Modules.Ping = src.Modules.Ping },
+	},
+	{
+		name:  "modules.mam",
+		value: func(cfg *Config) interface{} { return cfg.Modules.Mam },
+		apply: func(dst, src *Config) { dst.Modules.Mam = src.Modules.Mam },
+	},
+	{name: "modules.hosts.enabled", value: func(cfg *Config) interface{} { return cfg.Modules.hostEnabled() }},
+	{
+		name:  moduleHostsSection,
+		value: func(cfg *Config) interface{} { return cfg.Modules.hostOverrides() },
+		apply: func(dst, src *Config) {
+			// keep enabled module sets, as they can't be reloaded
+			hostEnabled := dst.Modules.hostEnabled()
+
+			var hosts []HostModulesConfig
+			for _, hCfg := range src.Modules.hostOverrides() {
+				hCfg.Enabled = hostEnabled[hCfg.Domain]
+				delete(hostEnabled, hCfg.Domain)
+				hosts = append(hosts, hCfg)
+			}
+			for domain, enabled := range hostEnabled {
+				hosts = append(hosts, HostModulesConfig{Domain: domain, Enabled: enabled})
+			}
+			dst.Modules.Hosts = hosts
+		},
+	},
+	{name: "mux", value: func(cfg *Config) interface{} { return cfg.Mux }},
+}
+
+// configChanges returns the sections that differ between two configurations.
+func configChanges(oldCfg, newCfg *Config) []configSection {
+	var ret []configSection
+	for _, section := range configSections {
+		if !reflect.DeepEqual(section.value(oldCfg), section.value(newCfg)) {
+			ret = append(ret, section)
+		}
+	}
+	return ret
+}
+
+// ReloadConfig re-reads configuration file, applying all reloadable changes.
+// Changes to non-reloadable sections are reported and won't take effect until next restart.
+func (j *Jackal) ReloadConfig(ctx context.Context) (applied, nonReloadable []string, err error) {
+	j.reloadMu.Lock()
+	defer j.reloadMu.Unlock()
+
+	applied, nonReloadable, err = j.reloadConfig()
+	if err != nil {
+		level.Warn(j.logger).Log("msg", "failed to reload configuration", "err", err)
+	} else {
+		level.Info(j.logger).Log("msg", "reloaded configuration",
+			"applied", strings.Join(applied, ","),
+			"non_reloadable", strings.Join(nonReloadable, ","),
+		)
+	}
+	for _, name := range nonReloadable {
+		level.Warn(j.logger).Log("msg", "configuration change requires a restart", "section", name)
+	}
+	_, hErr := j.hk.Run(hook.ConfigReloaded, &hook.ExecutionContext{
+		Info: &hook.ConfigInfo{
+			Applied:       applied,
+			NonReloadable: nonReloadable,
+			Err:           err,
+		},
+		Sender:  j,
+		Context: ctx,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return applied, nonReloadable, hErr
+}
+
+func (j *Jackal) reloadConfig() (applied, nonReloadable []string, err error) {
+	newCfg, err := loadConfig(j.configFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	effectiveCfg := *j.cfg
+
+	// federation policy overridden via admin API is kept until restart
+	fedOverridden := j.s2sFedPolicy.Overridden()
+
+	changed := make(map[string]bool)
+	for _, section := range configChanges(j.cfg, newCfg) {
+		if section.apply == nil {
+			nonReloadable = append(nonReloadable, section.name)
+			continue
+		}
+		if section.name == s2sFederationSection && fedOverridden {
+			level.Warn(j.logger).Log("msg", "keeping s2s federation policy overridden via admin API")
+			nonReloadable = append(nonReloadable, section.name)
+			continue
+		}
+		section.apply(&effectiveCfg, newCfg)
+		applied = append(applied, section.name)
+		changed[section.name] = true
+	}
+	if len(applied) == 0 {
+		return nil, nonReloadable, nil
+	}
+	// validate reloadable sections before applying any change
+	fedCfg := effectiveCfg.S2S.Federation
+	if fedOverridden {
+		fedCfg = j.s2sFedPolicy.Config()
+	}
+	var ss shaper.Shapers
+	if changed[shapersSection] {
+		ss, err = j.buildShapers(effectiveCfg.Shapers)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", shapersSection, err)
+		}
+		if _, err := s2s.NewFederationPolicy(fedCfg, shaper.NewRegistry(ss)); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", s2sFederationSection, err)
+		}
+	} else if changed[s2sFederationSection] {
+		if _, err := s2s.NewFederationPolicy(effectiveCfg.S2S.Federation, j.shapers); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", s2sFederationSection, err)
+		}
+	}
+	// apply changes
+	if changed[loggerSection] {
+		j.rLogger.Update(effectiveCfg.Logger.Level, effectiveCfg.Logger.Format)
+	}
+	if changed[shapersSection] {
+		j.shapers.Update(ss)
+	}
+	switch {
+	case changed[s2sFederationSection]:
+		if err := j.s2sFedPolicy.Update(effectiveCfg.S2S.Federation); err != nil {
+			return nil, nil, err
+		}
+	case changed[shapersSection]:
+		if err := j.s2sFedPolicy.Refresh(); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, mod := range j.mods.AllModules() {
+		if fn, ok := modReloadFns[mod.Name()]; ok {
+			fn(mod, &effectiveCfg.Modules)
+		}
+	}
+	j.cfg = &effectiveCfg
+	return applied, nonReloadable, nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jackal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/log"
+	"github.com/ortuman/jackal/pkg/module"
+	"github.com/ortuman/jackal/pkg/module/offline"
+	"github.com/ortuman/jackal/pkg/s2s"
+	"github.com/ortuman/jackal/pkg/shaper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigChanges(t *testing.T) {
+	// given
+	oldCfg := &Config{}
+	oldCfg.Logger.Level = "debug"
+
+	newCfg := &Config{}
+	newCfg.Logger.Level = "info"
+	newCfg.MemoryBallastSize = 1024
+	newCfg.Modules.Hosts = []HostModulesConfig{
+		{Domain: "jackal.im", Offline: &offline.Config{QueueSize: 10}},
+		{Domain: "jabber.org", Enabled: []string{"roster"}},
+	}
+
+	// when
+	var names []string
+	for _, section := range configChanges(oldCfg, newCfg) {
+		names = append(names, section.name)
+	}
+
+	// then
+	require.Equal(t, []string{"memory_ballast_size", "logger", "modules.hosts.enabled", "modules.hosts"}, names)
+}
+
+func TestJackal_ReloadConfig(t *testing.T) {
+	// given
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(content string) {
+		require.NoError(t, os.WriteFile(cfgFile, []byte(content), 0600))
+	}
+	writeConfig(`
+logger:
+  level: info
+`)
+	cfg, err := loadConfig(cfgFile)
+	require.NoError(t, err)
+
+	shapers := shaper.NewRegistry(nil)
+	fedPolicy, _ := s2s.NewFederationPolicy(cfg.S2S.Federation, shapers)
+
+	var reloadInf *hook.ConfigInfo
+	hk := hook.NewHooks()
+	hk.AddHook(hook.ConfigReloaded, func(execCtx *hook.ExecutionContext) error {
+		reloadInf = execCtx.Info.(*hook.ConfigInfo)
+		return nil
+	}, hook.DefaultPriority)
+
+	j := &Jackal{
+		configFile:   cfgFile,
+		cfg:          cfg,
+		rLogger:      log.NewReloadableLogger("off", ""),
+		logger:       kitlog.NewNopLogger(),
+		hk:           hk,
+		shapers:      shapers,
+		s2sFedPolicy: fedPolicy,
+		mods:         module.NewModules(nil, nil, nil, nil, nil, hk, kitlog.NewNopLogger()),
+	}
+
+	// when
+	writeConfig(`
+logger:
+  level: off
+shapers:
+  - name: slow
+    rate:
+      limit: 10
+s2s:
+  federation:
+    shaping:
+      - domain: "*.example.org"
+        shaper: slow
+c2s:
+  listeners:
+    - port: 5223
+`)
+	applied, nonReloadable, err := j.ReloadConfig(context.Background())
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, []string{"logger", "shapers", "s2s.federation"}, applied)
+	require.Equal(t, []string{"c2s"}, nonReloadable)
+
+	require.NotNil(t, shapers.ByName("slow"))
+	require.Len(t, fedPolicy.Config().Shaping, 1)
+
+	require.NotNil(t, reloadInf)
+	require.Equal(t, applied, reloadInf.Applied)
+	require.Equal(t, nonReloadable, reloadInf.NonReloadable)
+	require.Nil(t, reloadInf.Err)
+}
+
+func TestJackal_ReloadKeepsFederationOverride(t *testing.T) {
+	// given
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte(`
+logger:
+  level: info
+`), 0600))
+
+	cfg, err := loadConfig(cfgFile)
+	require.NoError(t, err)
+
+	shapers := shaper.NewRegistry(nil)
+	fedPolicy, _ := s2s.NewFederationPolicy(cfg.S2S.Federation, shapers)
+	require.NoError(t, fedPolicy.Override(s2s.FederationConfig{Deny: []string{"konuro.net"}}))
+
+	j := &Jackal{
+		configFile:   cfgFile,
+		cfg:          cfg,
+		rLogger:      log.NewReloadableLogger("off", ""),
+		logger:       kitlog.NewNopLogger(),
+		hk:           hook.NewHooks(),
+		shapers:      shapers,
+		s2sFedPolicy: fedPolicy,
+		mods:         module.NewModules(nil, nil, nil, nil, nil, hook.NewHooks(), kitlog.NewNopLogger()),
+	}
+
+	// when
+	require.NoError(t, os.WriteFile(cfgFile, []byte(`
+logger:
+  level: info
+s2s:
+  federation:
+    deny:
+      - "*.spam.im"
+`), 0600))
+	applied, nonReloadable, err := j.ReloadConfig(context.Background())
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, applied, 0)
+	require.Equal(t, []string{"s2s.federation"}, nonReloadable)
+	require.Equal(t, []string{"konuro.net"}, fedPolicy.Config().Deny)
+	require.True(t, fedPolicy.Overridden())
+}
+
+func TestJackal_ReloadInvalidConfig(t *testing.T) {
+	// given
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, []byte(`
+s2s:
+  federation:
+    shaping:
+      - domain: "*.example.org"
+        shaper: unknown
+`), 0600))
+
+	shapers := shaper.NewRegistry(nil)
+	fedPolicy, _ := s2s.NewFederationPolicy(s2s.FederationConfig{}, shapers)
+
+	var reloadInf *hook.ConfigInfo
+	hk := hook.NewHooks()
+	hk.AddHook(hook.ConfigReloaded, func(execCtx *hook.ExecutionContext) error {
+		reloadInf = execCtx.Info.(*hook.ConfigInfo)
+		return nil
+	}, hook.DefaultPriority)
+
+	j := &Jackal{
+		configFile:   cfgFile,
+		cfg:          &Config{},
+		rLogger:      log.NewReloadableLogger("off", ""),
+		logger:       kitlog.NewNopLogger(),
+		hk:           hk,
+		shapers:      shapers,
+		s2sFedPolicy: fedPolicy,
+		mods:         module.NewModules(nil, nil, nil, nil, nil, hk, kitlog.NewNopLogger()),
+	}
+
+	// when
+	_, _, err := j.ReloadConfig(context.Background())
+
+	// then
+	require.Error(t, err)
+	require.Len(t, fedPolicy.Config().Shaping, 0)
+
+	require.NotNil(t, reloadInf)
+	require.Equal(t, err, reloadInf.Err)
+}
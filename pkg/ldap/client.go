@@ -0,0 +1,278 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+// Entry represents a directory user entry.
+type Entry struct {
+	// DN is the entry distinguished name.
+	DN string
+
+	// Username is the user name mapped from the configured username attribute.
+	Username string
+}
+
+type conn interface {
+	Bind(username, password string) error
+	UnauthenticatedBind(username string) error
+	Search(req *ldapv3.SearchRequest) (*ldapv3.SearchResult, error)
+	IsClosing() bool
+	Close()
+}
+
+// Client represents a pooled LDAP directory client.
+type Client struct {
+	cfg    Config
+	pool   chan conn
+	dialFn func() (conn, error)
+	logger kitlog.Logger
+}
+
+// NewClient returns a new LDAP directory client.
+func NewClient(cfg Config, logger kitlog.Logger) *Client {
+	c := &Client{
+		cfg:    cfg,
+		pool:   make(chan conn, cfg.PoolSize),
+		logger: kitlog.With(logger, "directory", cfg.URL),
+	}
+	c.dialFn = c.dial
+	return c
+}
+
+// Start verifies directory connectivity.
+// A failed check is logged without preventing the client from being used.
+func (c *Client) Start(_ context.Context) error {
+	cn, err := c.acquire()
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "failed to connect to LDAP directory", "err", err)
+		return nil
+	}
+	c.release(cn, nil)
+
+	level.Info(c.logger).Log("msg", "started LDAP directory client", "pool_size", c.cfg.PoolSize)
+	return nil
+}
+
+// Stop closes all pooled directory connections.
+func (c *Client) Stop(_ context.Context) error {
+	for {
+		select {
+		case cn := <-c.pool:
+			cn.Close()
+		default:
+			level.Info(c.logger).Log("msg", "stopped LDAP directory client")
+			return nil
+		}
+	}
+}
+
+// Authenticate verifies username credentials by binding as its directory entry.
+// A nil entry is returned if the user is not found, credentials are not valid or
+// the user is not a member of any of the groups allowed to log in.
+func (c *Client) Authenticate(ctx context.Context, domain, username, password string) (*Entry, error) {
+	if len(password) == 0 {
+		return nil, nil // never perform unauthenticated binds
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sCfg := c.cfg.searchConfig(domain)
+
+	cn, err := c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.authenticate(cn, sCfg, username, password)
+	c.release(cn, err)
+	return entry, err
+}
+
+// FindUser returns the directory entry associated to username, or nil if not found.
+func (c *Client) FindUser(ctx context.Context, domain, username string) (*Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cn, err := c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.searchUser(cn, c.cfg.searchConfig(domain), username)
+	c.release(cn, err)
+	return entry, err
+}
+
+func (c *Client) authenticate(cn conn, sCfg searchConfig, username, password string) (*Entry, error) {
+	entry, err := c.searchUser(cn, sCfg, username)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	err = cn.Bind(entry.DN, password)
+	switch {
+	case ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultInvalidCredentials):
+		return nil, c.bind(cn)
+	case err != nil:
+		return nil, err
+	}
+	// restore service account identity
+	if err := c.bind(cn); err != nil {
+		return nil, err
+	}
+	if len(sCfg.groups) > 0 {
+		isMember, err := c.isGroupMember(cn, sCfg.groups, entry.DN)
+		if err != nil || !isMember {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+func (c *Client) searchUser(cn conn, sCfg searchConfig, username string) (*Entry, error) {
+	res, err := cn.Search(ldapv3.NewSearchRequest(
+		sCfg.baseDN,
+		ldapv3.ScopeWholeSubtree,
+		ldapv3.NeverDerefAliases,
+		2, // any result beyond the first one makes search ambiguous
+		0,
+		false,
+		userFilter(sCfg.userFilter, username),
+		[]string{c.cfg.UsernameAttribute},
+		nil,
+	))
+	switch {
+	case ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultNoSuchObject):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	switch len(res.Entries) {
+	case 0:
+		return nil, nil
+	case 1:
+		break
+	default:
+		return nil, fmt.Errorf("ldap: multiple entries found for user: %s", username)
+	}
+	e := res.Entries[0]
+	entry := &Entry{
+		DN:       e.DN,
+		Username: e.GetEqualFoldAttributeValue(c.cfg.UsernameAttribute),
+	}
+	if len(entry.Username) == 0 {
+		entry.Username = username
+	}
+	return entry, nil
+}
+
+func (c *Client) isGroupMember(cn conn, groups []string, dn string) (bool, error) {
+	filter := fmt.Sprintf("(%s=%s)", c.cfg.GroupMemberAttribute, ldapv3.EscapeFilter(dn))
+	for _, groupDN := range groups {
+		res, err := cn.Search(ldapv3.NewSearchRequest(
+			groupDN,
+			ldapv3.ScopeBaseObject,
+			ldapv3.NeverDerefAliases,
+			1,
+			0,
+			false,
+			filter,
+			[]string{"1.1"}, // no attributes
+			nil,
+		))
+		switch {
+		case ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultNoSuchObject):
+			continue
+		case err != nil:
+			return false, err
+		}
+		if len(res.Entries) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *Client) acquire() (conn, error) {
+	for {
+		select {
+		case cn := <-c.pool:
+			if cn.IsClosing() {
+				continue
+			}
+			return cn, nil
+		default:
+			return c.dialFn()
+		}
+	}
+}
+
+func (c *Client) release(cn conn, err error) {
+	if err != nil || cn.IsClosing() {
+		cn.Close() // connection state can't be trusted anymore
+		return
+	}
+	select {
+	case c.pool <- cn:
+	default:
+		cn.Close() // pool is full
+	}
+}
+
+func (c *Client) dial() (conn, error) {
+	u, err := url.Parse(c.cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: c.cfg.InsecureSkipVerify,
+	}
+	cn, err := ldapv3.DialURL(c.cfg.URL,
+		ldapv3.DialWithDialer(&net.Dialer{Timeout: c.cfg.DialTimeout}),
+		ldapv3.DialWithTLSConfig(tlsCfg),
+	)
+	if err != nil {
+		return nil, err
+	}
+	cn.SetTimeout(c.cfg.RequestTimeout)
+
+	if c.cfg.StartTLS && u.Scheme == "ldap" {
+		if err := cn.StartTLS(tlsCfg); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	if err := c.bind(cn); err != nil {
+		cn.Close()
+		return nil, err
+	}
+	return cn, nil
+}
+
+func (c *Client) bind(cn conn) error {
+	if len(c.cfg.BindDN) == 0 {
+		return cn.UnauthenticatedBind("")
+	}
+	return cn.Bind(c.cfg.BindDN, c.cfg.BindPassword)
+}
@@ -0,0 +1,335 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	kitlog "github.com/go-kit/log"
+	ldapv3 "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBindDN       = "cn=jackal,dc=jackal,dc=im"
+	testBindPassword = "s3cr3t"
+)
+
+func TestClient_Authenticate(t *testing.T) {
+	srv := newTestDirectory(t)
+
+	tcs := map[string]struct {
+		domain   string
+		username string
+		password string
+		expected *Entry
+	}{
+		"Valid": {
+			domain:   "jackal.im",
+			username: "ortuman",
+			password: "1234",
+			expected: &Entry{DN: "uid=ortuman,ou=people,dc=jackal,dc=im", Username: "ortuman"},
+		},
+		"InvalidPassword": {
+			domain:   "jackal.im",
+			username: "ortuman",
+			password: "4321",
+		},
+		"EmptyPassword": {
+			domain:   "jackal.im",
+			username: "ortuman",
+		},
+		"UnknownUser": {
+			domain:   "jackal.im",
+			username: "noelia",
+			password: "1234",
+		},
+		"HostGroupMember": {
+			domain:   "jabber.org",
+			username: "ortuman",
+			password: "1234",
+			expected: &Entry{DN: "uid=ortuman,ou=people,dc=jackal,dc=im", Username: "ortuman"},
+		},
+		"HostGroupNonMember": {
+			domain:   "jabber.org",
+			username: "romeo",
+			password: "juliet",
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			cl := NewClient(srv.config(), kitlog.NewNopLogger())
+			defer func() { _ = cl.Stop(context.Background()) }()
+
+			// when
+			entry, err := cl.Authenticate(context.Background(), tc.domain, tc.username, tc.password)
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, entry)
+		})
+	}
+}
+
+func TestClient_FindUser(t *testing.T) {
+	// given
+	srv := newTestDirectory(t)
+
+	cfg := srv.config()
+	cfg.UsernameAttribute = "mail"
+	cl := NewClient(cfg, kitlog.NewNopLogger())
+	defer func() { _ = cl.Stop(context.Background()) }()
+
+	// when
+	entry, err := cl.FindUser(context.Background(), "jackal.im", "romeo")
+	notFoundEntry, notFoundErr := cl.FindUser(context.Background(), "jackal.im", "noelia")
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, &Entry{DN: "uid=romeo,ou=people,dc=jackal,dc=im", Username: "romeo@jackal.im"}, entry)
+
+	require.NoError(t, notFoundErr)
+	require.Nil(t, notFoundEntry)
+}
+
+func TestClient_ConnectionPool(t *testing.T) {
+	// given
+	srv := newTestDirectory(t)
+
+	cl := NewClient(srv.config(), kitlog.NewNopLogger())
+
+	// when
+	for i := 0; i < 5; i++ {
+		entry, err := cl.Authenticate(context.Background(), "jackal.im", "ortuman", "1234")
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+
+		_, err = cl.FindUser(context.Background(), "jackal.im", "romeo")
+		require.NoError(t, err)
+	}
+	_ = cl.Stop(context.Background())
+
+	// then
+	require.Equal(t, int32(1), atomic.LoadInt32(&srv.connCount))
+}
+
+type testEntry struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+// testDirectory is a minimal in-process LDAP server supporting simple binds
+// and equality filter searches.
+type testDirectory struct {
+	ln        net.Listener
+	entries   []testEntry
+	connCount int32
+}
+
+func newTestDirectory(t *testing.T) *testDirectory {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	d := &testDirectory{
+		ln: ln,
+		entries: []testEntry{
+			{
+				dn:       "uid=ortuman,ou=people,dc=jackal,dc=im",
+				password: "1234",
+				attrs:    map[string][]string{"uid": {"ortuman"}, "mail": {"ortuman@jackal.im"}},
+			},
+			{
+				dn:       "uid=romeo,ou=people,dc=jackal,dc=im",
+				password: "juliet",
+				attrs:    map[string][]string{"uid": {"romeo"}, "mail": {"romeo@jackal.im"}},
+			},
+			{
+				dn:    "cn=admins,ou=groups,dc=jackal,dc=im",
+				attrs: map[string][]string{"member": {"uid=ortuman,ou=people,dc=jackal,dc=im"}},
+			},
+		},
+	}
+	go d.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+	return d
+}
+
+func (d *testDirectory) config() Config {
+	return Config{
+		URL:                  "ldap://" + d.ln.Addr().String(),
+		BindDN:               testBindDN,
+		BindPassword:         testBindPassword,
+		BaseDN:               "ou=people,dc=jackal,dc=im",
+		UserFilter:           "(uid=%s)",
+		UsernameAttribute:    "uid",
+		GroupMemberAttribute: "member",
+		PoolSize:             2,
+		DialTimeout:          time.Second,
+		RequestTimeout:       time.Second,
+		Hosts: []HostConfig{
+			{Domain: "jabber.org", Groups: []string{"cn=admins,ou=groups,dc=jackal,dc=im"}},
+		},
+	}
+}
+
+func (d *testDirectory) serve() {
+	for {
+		conn, err := d.ln.Accept()
+		if err != nil {
+			return
+		}
+		atomic.AddInt32(&d.connCount, 1)
+		go d.handleConn(conn)
+	}
+}
+
+func (d *testDirectory) handleConn(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	for {
+		p, err := ber.ReadPacket(conn)
+		if err != nil || len(p.Children) < 2 {
+			return
+		}
+		msgID := p.Children[0].Value
+		op := p.Children[1]
+
+		var resps []*ber.Packet
+		switch op.Tag {
+		case ldapv3.ApplicationBindRequest:
+			resps = append(resps, testResult(ldapv3.ApplicationBindResponse, d.bind(op)))
+
+		case ldapv3.ApplicationSearchRequest:
+			entries, code := d.search(op)
+			resps = append(resps, entries...)
+			resps = append(resps, testResult(ldapv3.ApplicationSearchResultDone, code))
+
+		default: // unbind
+			return
+		}
+		for _, resp := range resps {
+			env := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			env.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, ""))
+			env.AppendChild(resp)
+			if _, err := conn.Write(env.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (d *testDirectory) bind(op *ber.Packet) uint16 {
+	dn := op.Children[1].Value.(string)
+	password := op.Children[2].Data.String()
+	switch {
+	case len(dn) == 0:
+		return ldapv3.LDAPResultSuccess
+	case dn == testBindDN && password == testBindPassword:
+		return ldapv3.LDAPResultSuccess
+	}
+	for _, e := range d.entries {
+		if e.dn == dn && len(e.password) > 0 && e.password == password {
+			return ldapv3.LDAPResultSuccess
+		}
+	}
+	return ldapv3.LDAPResultInvalidCredentials
+}
+
+var testFilterRegEx = regexp.MustCompile(`^\(([a-zA-Z]+)=(.*)\)$`)
+
+func (d *testDirectory) search(op *ber.Packet) ([]*ber.Packet, uint16) {
+	baseDN := op.Children[0].Value.(string)
+	scope := op.Children[1].Value.(int64)
+
+	filter, err := ldapv3.DecompileFilter(op.Children[6])
+	if err != nil {
+		return nil, ldapv3.LDAPResultProtocolError
+	}
+	m := testFilterRegEx.FindStringSubmatch(filter)
+	if m == nil {
+		return nil, ldapv3.LDAPResultUnwillingToPerform
+	}
+	var attrs []string
+	for _, attr := range op.Children[7].Children {
+		attrs = append(attrs, attr.Value.(string))
+	}
+	var found bool
+	var ret []*ber.Packet
+	for _, e := range d.entries {
+		switch {
+		case e.dn == baseDN:
+			found = true
+		case scope == ldapv3.ScopeBaseObject || !strings.HasSuffix(e.dn, ","+baseDN):
+			continue
+		}
+		if !contains(e.attrs[m[1]], m[2]) {
+			continue
+		}
+		ret = append(ret, testSearchEntry(e, attrs))
+	}
+	if !found && !strings.HasSuffix(baseDN, "dc=jackal,dc=im") {
+		return nil, ldapv3.LDAPResultNoSuchObject
+	}
+	return ret, ldapv3.LDAPResultSuccess
+}
+
+func testSearchEntry(e testEntry, attrs []string) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldapv3.ApplicationSearchResultEntry, nil, "")
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, ""))
+
+	attrsPacket := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for _, attr := range attrs {
+		values, ok := e.attrs[attr]
+		if !ok {
+			continue
+		}
+		attrPacket := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attrPacket.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, attr, ""))
+
+		valuesPacket := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, v := range values {
+			valuesPacket.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+		}
+		attrPacket.AppendChild(valuesPacket)
+		attrsPacket.AppendChild(attrPacket)
+	}
+	p.AppendChild(attrsPacket)
+	return p
+}
+
+func testResult(tag ber.Tag, code uint16) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	return p
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+// Config defines LDAP directory configuration.
+type Config struct {
+	// URL defines directory server address (ldap:// or ldaps:// scheme).
+	// An empty value disables LDAP support.
+	URL string `fig:"url"`
+
+	// StartTLS tells whether plain connections should be upgraded using StartTLS before binding.
+	StartTLS bool `fig:"start_tls"`
+
+	// InsecureSkipVerify disables directory server certificate verification.
+	InsecureSkipVerify bool `fig:"insecure_skip_verify"`
+
+	// BindDN defines the service account used to search the directory.
+	// If empty, searches will be performed anonymously.
+	BindDN string `fig:"bind_dn"`
+
+	// BindPassword defines the service account password.
+	BindPassword string `fig:"bind_password"`
+
+	// BaseDN defines the entry user searches are rooted at.
+	BaseDN string `fig:"base_dn"`
+
+	// UserFilter defines user search filter. Every '%s' is replaced by the escaped username.
+	UserFilter string `fig:"user_filter" default:"(uid=%s)"`
+
+	// UsernameAttribute defines the entry attribute user names are mapped from.
+	UsernameAttribute string `fig:"username_attribute" default:"uid"`
+
+	// Groups defines the set of group DNs a user must be a member of (any of them) in order to log in.
+	// If empty, no group restriction is applied.
+	Groups []string `fig:"groups"`
+
+	// GroupMemberAttribute defines the group entry attribute containing member DNs.
+	GroupMemberAttribute string `fig:"group_member_attribute" default:"member"`
+
+	// UserRepository tells whether user entities should be fetched from the directory instead of storage.
+	UserRepository bool `fig:"user_repository"`
+
+	// PoolSize defines the maximum number of idle pooled connections.
+	PoolSize int `fig:"pool_size" default:"8"`
+
+	// DialTimeout defines directory connection timeout.
+	DialTimeout time.Duration `fig:"dial_timeout" default:"5s"`
+
+	// RequestTimeout defines directory request timeout.
+	RequestTimeout time.Duration `fig:"req_timeout" default:"5s"`
+
+	// Hosts specifies per-host search overrides.
+	Hosts []HostConfig `fig:"hosts"`
+}
+
+// HostConfig defines LDAP search overrides for a single host.
+// Any specified value replaces the global one for that host.
+type HostConfig struct {
+	// Domain specifies the host domain these overrides apply to.
+	Domain string `fig:"domain"`
+
+	// BaseDN defines the entry user searches are rooted at.
+	BaseDN string `fig:"base_dn"`
+
+	// UserFilter defines user search filter.
+	UserFilter string `fig:"user_filter"`
+
+	// Groups defines the set of group DNs a user must be a member of in order to log in.
+	Groups []string `fig:"groups"`
+}
+
+// IsEnabled tells whether LDAP support is enabled.
+func (c Config) IsEnabled() bool {
+	return len(c.URL) > 0
+}
+
+// Validate checks whether LDAP configuration values are supported.
+func (c Config) Validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("ldap: invalid url: %v", err)
+	}
+	switch u.Scheme {
+	case "ldap", "ldaps":
+	default:
+		return fmt.Errorf("ldap: unrecognized url scheme: %s", u.Scheme)
+	}
+	if err := validateUserFilter(c.UserFilter); err != nil {
+		return err
+	}
+	if len(c.UsernameAttribute) == 0 {
+		return errors.New("ldap: username attribute must be specified")
+	}
+	domains := make(map[string]struct{})
+	for _, hCfg := range c.Hosts {
+		if len(hCfg.Domain) == 0 {
+			return errors.New("ldap: missing host overrides domain")
+		}
+		if _, ok := domains[hCfg.Domain]; ok {
+			return fmt.Errorf("ldap: duplicated host overrides: %s", hCfg.Domain)
+		}
+		domains[hCfg.Domain] = struct{}{}
+
+		if len(hCfg.UserFilter) == 0 {
+			continue
+		}
+		if err := validateUserFilter(hCfg.UserFilter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchConfig contains the effective search configuration for a given host.
+type searchConfig struct {
+	baseDN     string
+	userFilter string
+	groups     []string
+}
+
+func (c Config) searchConfig(domain string) searchConfig {
+	sCfg := searchConfig{
+		baseDN:     c.BaseDN,
+		userFilter: c.UserFilter,
+		groups:     c.Groups,
+	}
+	for _, hCfg := range c.Hosts {
+		if hCfg.Domain != domain {
+			continue
+		}
+		if len(hCfg.BaseDN) > 0 {
+			sCfg.baseDN = hCfg.BaseDN
+		}
+		if len(hCfg.UserFilter) > 0 {
+			sCfg.userFilter = hCfg.UserFilter
+		}
+		if len(hCfg.Groups) > 0 {
+			sCfg.groups = hCfg.Groups
+		}
+		break
+	}
+	return sCfg
+}
+
+func validateUserFilter(filter string) error {
+	if !strings.Contains(filter, "%s") {
+		return fmt.Errorf("ldap: user filter must contain a '%%s' placeholder: %s", filter)
+	}
+	if _, err := ldapv3.CompileFilter(userFilter(filter, "user")); err != nil {
+		return fmt.Errorf("ldap: invalid user filter '%s': %v", filter, err)
+	}
+	return nil
+}
+
+func userFilter(filter, username string) string {
+	return strings.ReplaceAll(filter, "%s", ldapv3.EscapeFilter(username))
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tcs := map[string]struct {
+		cfg         Config
+		expectedErr string
+	}{
+		"Valid": {
+			cfg: Config{URL: "ldaps://ldap.jackal.im", UserFilter: "(uid=%s)", UsernameAttribute: "uid"},
+		},
+		"InvalidScheme": {
+			cfg:         Config{URL: "http://ldap.jackal.im", UserFilter: "(uid=%s)", UsernameAttribute: "uid"},
+			expectedErr: "ldap: unrecognized url scheme: http",
+		},
+		"MissingPlaceholder": {
+			cfg:         Config{URL: "ldap://ldap.jackal.im", UserFilter: "(uid=ortuman)", UsernameAttribute: "uid"},
+			expectedErr: "ldap: user filter must contain a '%s' placeholder: (uid=ortuman)",
+		},
+		"InvalidHostFilter": {
+			cfg: Config{
+				URL:               "ldap://ldap.jackal.im",
+				UserFilter:        "(uid=%s)",
+				UsernameAttribute: "uid",
+				Hosts:             []HostConfig{{Domain: "jackal.im", UserFilter: "(uid=%s"}},
+			},
+			expectedErr: "ldap: invalid user filter '(uid=%s': LDAP Result Code 201 \"Filter Compile Error\": ldap: unexpected end of filter",
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if len(tc.expectedErr) > 0 {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
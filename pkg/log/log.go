@@ -15,6 +15,7 @@
 package log
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/go-kit/log"
@@ -27,15 +28,61 @@ const (
 	warningLevel = "warn"
 	errorLevel   = "error"
 	offLevel     = "off"
+
+	jsonFormat   = "json"
+	logfmtFormat = "logfmt"
 )
 
 // NewDefaultLogger creates a new go-kit logger with the configured level and format.
 func NewDefaultLogger(lv, format string) log.Logger {
+	return withDefaultContext(newLeveledLogger(lv, format))
+}
+
+// Validate checks whether lv and format are supported logger values.
+func Validate(lv, format string) error {
+	switch lv {
+	case debugLevel, infoLevel, warningLevel, errorLevel, offLevel:
+	default:
+		return fmt.Errorf("log: unrecognized level: %s", lv)
+	}
+	switch format {
+	case "", jsonFormat, logfmtFormat:
+	default:
+		return fmt.Errorf("log: unrecognized format: %s", format)
+	}
+	return nil
+}
+
+// ReloadableLogger represents a logger whose level and format can be replaced at runtime.
+type ReloadableLogger struct {
+	swapLogger log.SwapLogger
+	logger     log.Logger
+}
+
+// NewReloadableLogger creates a new reloadable logger with the configured level and format.
+func NewReloadableLogger(lv, format string) *ReloadableLogger {
+	l := &ReloadableLogger{}
+	l.swapLogger.Swap(newLeveledLogger(lv, format))
+	l.logger = withDefaultContext(&l.swapLogger)
+	return l
+}
+
+// Logger returns the underlying go-kit logger.
+func (l *ReloadableLogger) Logger() log.Logger {
+	return l.logger
+}
+
+// Update replaces logger level and format.
+func (l *ReloadableLogger) Update(lv, format string) {
+	l.swapLogger.Swap(newLeveledLogger(lv, format))
+}
+
+func newLeveledLogger(lv, format string) log.Logger {
 	var logger log.Logger
 	var allow level.Option
 
 	w := log.NewSyncWriter(os.Stderr)
-	if format == "json" {
+	if format == jsonFormat {
 		logger = log.NewJSONLogger(w)
 	} else {
 		logger = log.NewLogfmtLogger(w)
@@ -54,5 +101,9 @@ func NewDefaultLogger(lv, format string) log.Logger {
 	default:
 		allow = level.AllowAll()
 	}
-	return log.With(level.NewFilter(logger, allow), "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
+	return level.NewFilter(logger, allow)
+}
+
+func withDefaultContext(logger log.Logger) log.Logger {
+	return log.With(logger, "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
 }
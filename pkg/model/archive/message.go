@@ -0,0 +1,38 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archivemodel
+
+import (
+	"strings"
+
+	"github.com/jackal-xmpp/stravaganza"
+)
+
+// BodyText returns the text contained in all archived message body elements.
+func (x *Message) BodyText() string {
+	if x.GetMessage() == nil {
+		return ""
+	}
+	elem := stravaganza.NewBuilderFromProto(x.GetMessage()).Build()
+
+	var sb strings.Builder
+	for i, body := range elem.Children("body") {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(body.Text())
+	}
+	return sb.String()
+}
@@ -0,0 +1,26 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archivemodel
+
+const (
+	// AlwaysMode represents 'always' default archiving mode.
+	AlwaysMode = "always"
+
+	// NeverMode represents 'never' default archiving mode.
+	NeverMode = "never"
+
+	// RosterMode represents 'roster' default archiving mode.
+	RosterMode = "roster"
+)
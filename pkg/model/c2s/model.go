@@ -22,6 +22,9 @@ import (
 	"github.com/jackal-xmpp/stravaganza/jid"
 )
 
+// RemoteAddressInfoKey is the info key holding the remote network address of a C2S stream.
+const RemoteAddressInfoKey = "c2s:remote_address"
+
 // Info represents C2S immutable info set.
 type Info interface {
 	// String returns string value associated to k key.
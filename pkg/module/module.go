@@ -65,6 +65,8 @@ type IQProcessor interface {
 type Modules struct {
 	mods         []Module
 	iqProcessors []IQProcessor
+	enabled      map[string]struct{}
+	hostEnabled  map[string]map[string]struct{}
 	hosts        hosts
 	router       router.Router
 	hk           *hook.Hooks
@@ -72,8 +74,12 @@ type Modules struct {
 }
 
 // NewModules returns a new initialized Modules instance.
+// The enabled parameter specifies the names of the modules enabled by default, while hostEnabled
+// overrides this set for specific domains. A nil enabled value enables all mods by default.
 func NewModules(
 	mods []Module,
+	enabled []string,
+	hostEnabled map[string][]string,
 	hosts *host.Hosts,
 	router router.Router,
 	hk *hook.Hooks,
@@ -86,6 +92,15 @@ func NewModules(
 		hk:     hk,
 		logger: logger,
 	}
+	if enabled != nil {
+		m.enabled = nameSet(enabled)
+	}
+	for domain, modNames := range hostEnabled {
+		if m.hostEnabled == nil {
+			m.hostEnabled = make(map[string]map[string]struct{})
+		}
+		m.hostEnabled[domain] = nameSet(modNames)
+	}
 	m.setupModules()
 	return m
 }
@@ -148,10 +163,14 @@ func (m *Modules) IsModuleIQ(iq *stravaganza.IQ) bool {
 // ProcessIQ routes the iq to the corresponding iq handler module.
 func (m *Modules) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
 	ns := iq.AllChildren()[0].Attribute(stravaganza.Namespace)
+	domain := iq.ToJID().Domain()
 	for _, iqHnd := range m.iqProcessors {
 		if !iqHnd.MatchesNamespace(ns, iq.ToJID().IsServer()) {
 			continue
 		}
+		if !m.IsEnabledFor(domain, iqHnd.Name()) {
+			break
+		}
 		return iqHnd.ProcessIQ(ctx, iq)
 	}
 	// ...IQ not handled...
@@ -160,10 +179,10 @@ func (m *Modules) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
 	return nil
 }
 
-// StreamFeatures returns stream features of all registered modules.
+// StreamFeatures returns stream features of all modules enabled for domain.
 func (m *Modules) StreamFeatures(ctx context.Context, domain string) ([]stravaganza.Element, error) {
 	var sfs []stravaganza.Element
-	for _, mod := range m.mods {
+	for _, mod := range m.HostModules(domain) {
 		sf, err := mod.StreamFeature(ctx, domain)
 		if err != nil {
 			return nil, err
@@ -175,7 +194,7 @@ func (m *Modules) StreamFeatures(ctx context.Context, domain string) ([]stravaga
 	return sfs, nil
 }
 
-// IsEnabled tells whether a specific module it's been registered.
+// IsEnabled tells whether a specific module it's been registered for any domain.
 func (m *Modules) IsEnabled(moduleName string) bool {
 	for _, mod := range m.mods {
 		if mod.Name() == moduleName {
@@ -185,11 +204,35 @@ func (m *Modules) IsEnabled(moduleName string) bool {
 	return false
 }
 
+// IsEnabledFor tells whether a specific module it's been enabled for domain.
+func (m *Modules) IsEnabledFor(domain, moduleName string) bool {
+	modNames, ok := m.hostEnabled[domain]
+	if !ok {
+		modNames = m.enabled
+	}
+	if modNames == nil {
+		return m.IsEnabled(moduleName)
+	}
+	_, ok = modNames[moduleName]
+	return ok
+}
+
 // AllModules returns all configured modules.
 func (m *Modules) AllModules() []Module {
 	return m.mods
 }
 
+// HostModules returns all modules enabled for domain.
+func (m *Modules) HostModules(domain string) []Module {
+	var ret []Module
+	for _, mod := range m.mods {
+		if m.IsEnabledFor(domain, mod.Name()) {
+			ret = append(ret, mod)
+		}
+	}
+	return ret
+}
+
 func (m *Modules) setupModules() {
 	for _, mod := range m.mods {
 		iqPr, ok := mod.(IQProcessor)
@@ -198,3 +241,11 @@ func (m *Modules) setupModules() {
 		}
 	}
 }
+
+func nameSet(names []string) map[string]struct{} {
+	ret := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		ret[name] = struct{}{}
+	}
+	return ret
+}
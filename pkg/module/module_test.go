@@ -93,3 +93,47 @@ func TestModules_ProcessIQ(t *testing.T) {
 	require.Len(t, iqPrMock.MatchesNamespaceCalls(), 1)
 	require.Len(t, iqPrMock.ProcessIQCalls(), 1)
 }
+
+func TestModules_HostModules(t *testing.T) {
+	// given
+	iqPrMock := &iqProcessorMock{}
+	iqPrMock.NameFunc = func() string { return "m0" }
+	iqPrMock.StreamFeatureFunc = func(ctx context.Context, domain string) (stravaganza.Element, error) {
+		return stravaganza.NewBuilder("f0").Build(), nil
+	}
+
+	modMock := &moduleMock{}
+	modMock.NameFunc = func() string { return "m1" }
+	modMock.StreamFeatureFunc = func(ctx context.Context, domain string) (stravaganza.Element, error) {
+		return stravaganza.NewBuilder("f1").Build(), nil
+	}
+
+	mods := NewModules(
+		[]Module{iqPrMock, modMock},
+		[]string{"m0"},
+		map[string][]string{"jabber.org": {"m0", "m1"}},
+		nil,
+		nil,
+		hook.NewHooks(),
+		kitlog.NewNopLogger(),
+	)
+
+	// when
+	sfs0, err0 := mods.StreamFeatures(context.Background(), "jackal.im")
+	sfs1, err1 := mods.StreamFeatures(context.Background(), "jabber.org")
+
+	// then
+	require.True(t, mods.IsEnabled("m1"))
+	require.False(t, mods.IsEnabledFor("jackal.im", "m1"))
+	require.True(t, mods.IsEnabledFor("jabber.org", "m1"))
+
+	require.Len(t, mods.HostModules("jackal.im"), 1)
+	require.Len(t, mods.HostModules("jabber.org"), 2)
+
+	require.Nil(t, err0)
+	require.Len(t, sfs0, 1)
+	require.Equal(t, "f0", sfs0[0].Name())
+
+	require.Nil(t, err1)
+	require.Len(t, sfs1, 2)
+}
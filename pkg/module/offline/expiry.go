@@ -0,0 +1,236 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+)
+
+const (
+	ampNamespace       = "http://jabber.org/protocol/amp"
+	ampErrorsNamespace = "http://jabber.org/protocol/amp#errors"
+
+	expireNamespace = "jabber:x:expire"
+	delayNamespace  = "urn:xmpp:delay"
+
+	expireAtCondition = "expire-at"
+
+	dropAction  = "drop"
+	alertAction = "alert"
+	errorAction = "error"
+
+	dateTimeFormat = "2006-01-02T15:04:05Z07:00"
+)
+
+var ampFeatures = []string{
+	ampNamespace,
+	ampNamespace + "?condition=" + expireAtCondition,
+	ampNamespace + "?action=" + dropAction,
+	ampNamespace + "?action=" + alertAction,
+	ampNamespace + "?action=" + errorAction,
+}
+
+// messageExpiry returns the earliest expiration time requested by a message, either
+// through a XEP-0023 expire element (relative to storedAt) or an AMP expire-at rule.
+// In the latter case the triggered rule is returned as well.
+// A zero time value is returned if message never expires.
+func messageExpiry(msg *stravaganza.Message, storedAt time.Time) (expiresAt time.Time, rule stravaganza.Element) {
+	if x := msg.ChildNamespace("x", expireNamespace); x != nil {
+		secs, err := strconv.ParseUint(x.Attribute("seconds"), 10, 32)
+		if err == nil {
+			expiresAt = storedAt.Add(time.Duration(secs) * time.Second)
+		}
+	}
+	amp := msg.ChildNamespace("amp", ampNamespace)
+	if amp == nil {
+		return expiresAt, nil
+	}
+	for _, r := range amp.Children("rule") {
+		if r.Attribute("condition") != expireAtCondition {
+			continue
+		}
+		switch r.Attribute("action") {
+		case dropAction, alertAction, errorAction:
+		default:
+			continue
+		}
+		tm, err := time.Parse(dateTimeFormat, r.Attribute("value"))
+		if err != nil {
+			continue
+		}
+		if expiresAt.IsZero() || tm.Before(expiresAt) {
+			expiresAt = tm
+			rule = r
+		}
+	}
+	return expiresAt, rule
+}
+
+// storedMessageExpiry returns the expiration time of a message previously stored in the offline queue.
+func storedMessageExpiry(msg *stravaganza.Message) (time.Time, stravaganza.Element) {
+	storedAt := time.Now()
+	for _, d := range msg.ChildrenNamespace("delay", delayNamespace) {
+		if d.Attribute(stravaganza.From) != msg.ToJID().Domain() {
+			continue
+		}
+		if tm, err := time.Parse(dateTimeFormat, d.Attribute("stamp")); err == nil {
+			storedAt = tm
+			break
+		}
+	}
+	return messageExpiry(msg, storedAt)
+}
+
+// checkExpiry verifies whether a stored message already expired, notifying its sender when requested.
+// Otherwise, the message is returned with its XEP-0023 expiration value adjusted to the remaining time.
+func (m *Offline) checkExpiry(ctx context.Context, msg *stravaganza.Message, now time.Time) (*stravaganza.Message, bool) {
+	expiresAt, rule := storedMessageExpiry(msg)
+	if expiresAt.IsZero() {
+		return msg, true
+	}
+	if !now.Before(expiresAt) {
+		m.notifyExpiredMessage(ctx, msg, rule)
+		return nil, false
+	}
+	x := msg.ChildNamespace("x", expireNamespace)
+	if x == nil {
+		return msg, true
+	}
+	remaining := int64(expiresAt.Sub(now) / time.Second)
+	adjustedMsg, _ := stravaganza.NewBuilderFromElement(msg).
+		WithoutChildrenNamespace("x", expireNamespace).
+		WithChild(
+			stravaganza.NewBuilderFromElement(x).
+				WithAttribute("seconds", strconv.FormatInt(remaining, 10)).
+				Build(),
+		).
+		BuildMessage()
+	return adjustedMsg, true
+}
+
+func (m *Offline) notifyExpiredMessage(ctx context.Context, msg *stravaganza.Message, rule stravaganza.Element) {
+	if rule == nil {
+		return // XEP-0023 expired messages are silently discarded
+	}
+	action := rule.Attribute("action")
+	if action != alertAction && action != errorAction {
+		return // drop
+	}
+	b := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.ID, msg.Attribute(stravaganza.ID)).
+		WithAttribute(stravaganza.From, msg.ToJID().Domain()).
+		WithAttribute(stravaganza.To, msg.Attribute(stravaganza.From)).
+		WithChild(
+			stravaganza.NewBuilder("amp").
+				WithAttribute(stravaganza.Namespace, ampNamespace).
+				WithAttribute("status", action).
+				WithAttribute("to", msg.Attribute(stravaganza.To)).
+				WithAttribute("from", msg.Attribute(stravaganza.From)).
+				WithChild(rule).
+				Build(),
+		)
+	if action == errorAction {
+		b.WithAttribute(stravaganza.Type, stravaganza.ErrorType).
+			WithChild(
+				stravaganza.NewBuilder("error").
+					WithAttribute(stravaganza.Type, "modify").
+					WithAttribute("code", "500").
+					WithChildren(
+						stravaganza.NewBuilder("undefined-condition").
+							WithAttribute(stravaganza.Namespace, "urn:ietf:params:xml:ns:xmpp-stanzas").
+							Build(),
+						stravaganza.NewBuilder("failed-rules").
+							WithAttribute(stravaganza.Namespace, ampErrorsNamespace).
+							WithChild(rule).
+							Build(),
+					).
+					Build(),
+			)
+	}
+	notification, err := b.BuildMessage()
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "failed to build expired message notification", "err", err)
+		return
+	}
+	if _, err := m.router.Route(ctx, notification); err != nil {
+		level.Warn(m.logger).Log("msg", "failed to route expired message notification", "err", err)
+	}
+}
+
+func (m *Offline) sweepExpiredMessages(ctx context.Context) error {
+	now := time.Now()
+	usernames, err := m.rep.FetchExpiredOfflineUsernames(ctx, now)
+	if err != nil {
+		return err
+	}
+	var count int
+	for _, username := range usernames {
+		n, err := m.sweepUserExpiredMessages(ctx, username, now)
+		if err != nil {
+			return err
+		}
+		count += n
+	}
+	if count > 0 {
+		level.Info(m.logger).Log("msg", "purged expired offline messages", "count", count)
+	}
+	return nil
+}
+
+func (m *Offline) sweepUserExpiredMessages(ctx context.Context, username string, now time.Time) (int, error) {
+	lockID := offlineQueueLockID(username)
+
+	if err := m.rep.Lock(ctx, lockID); err != nil {
+		return 0, err
+	}
+	defer m.releaseLock(ctx, lockID)
+
+	ms, err := m.rep.DeleteExpiredOfflineMessages(ctx, username, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, om := range ms {
+		msg, err := stravaganza.NewBuilderFromProto(om.Message).BuildMessage()
+		if err != nil {
+			continue
+		}
+		_, rule := storedMessageExpiry(msg)
+		m.notifyExpiredMessage(ctx, msg, rule)
+	}
+	return len(ms), nil
+}
+
+func (m *Offline) sweepLoop(doneCh chan chan struct{}, interval time.Duration) {
+	tc := time.NewTicker(interval)
+	defer tc.Stop()
+
+	for {
+		select {
+		case <-tc.C:
+			if err := m.sweepExpiredMessages(context.Background()); err != nil {
+				level.Warn(m.logger).Log("msg", "failed to purge expired offline messages", "err", err)
+			}
+
+		case ch := <-doneCh:
+			close(ch)
+			return
+		}
+	}
+}
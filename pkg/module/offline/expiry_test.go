@@ -0,0 +1,281 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	offlinemodel "github.com/ortuman/jackal/pkg/model/offline"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffline_ArchiveExpiringMessage(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+
+	repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
+		return 0, nil
+	}
+	var expiresAt time.Time
+	repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string, eAt time.Time) error {
+		expiresAt = eAt
+		return nil
+	}
+	hostsMock := &hostsMock{}
+	hostsMock.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	hk := hook.NewHooks()
+	m := &Offline{
+		cfg:    Config{QueueSize: 100},
+		hosts:  hostsMock,
+		rep:    repMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+	msg := testExpiringMessage(
+		stravaganza.NewBuilder("x").
+			WithAttribute(stravaganza.Namespace, expireNamespace).
+			WithAttribute("seconds", "60").
+			Build(),
+	)
+
+	// when
+	_ = m.Start(context.Background())
+	defer func() { _ = m.Stop(context.Background()) }()
+
+	t0 := time.Now()
+	_, _ = hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	})
+
+	// then
+	require.Len(t, repMock.InsertOfflineMessageCalls(), 1)
+	require.WithinDuration(t, t0.Add(time.Minute), expiresAt, time.Second)
+}
+
+func TestOffline_ArchiveExpiredAMPMessage(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	output := bytes.NewBuffer(nil)
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		_ = stanza.ToXML(output, true)
+		return nil, nil
+	}
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+
+	repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
+		return 0, nil
+	}
+	hostsMock := &hostsMock{}
+	hostsMock.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	hk := hook.NewHooks()
+	m := &Offline{
+		cfg:    Config{QueueSize: 100},
+		router: routerMock,
+		hosts:  hostsMock,
+		rep:    repMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+	msg := testExpiringMessage(testAMPRule(errorAction, "2004-09-10T08:33:14Z"))
+
+	// when
+	_ = m.Start(context.Background())
+	defer func() { _ = m.Stop(context.Background()) }()
+
+	halted, err := hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	})
+
+	// then
+	require.Nil(t, err)
+	require.True(t, halted)
+	require.Len(t, repMock.InsertOfflineMessageCalls(), 0)
+
+	require.Equal(t, `<message id='m1' from='jackal.im' to='noelia@jackal.im/yard' type='error'><amp xmlns='http://jabber.org/protocol/amp' status='error' to='ortuman@jackal.im/balcony' from='noelia@jackal.im/yard'><rule condition='expire-at' action='error' value='2004-09-10T08:33:14Z'/></amp><error type='modify' code='500'><undefined-condition xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/><failed-rules xmlns='http://jabber.org/protocol/amp#errors'><rule condition='expire-at' action='error' value='2004-09-10T08:33:14Z'/></failed-rules></error></message>`, output.String())
+}
+
+func TestOffline_DeliverExpiringOfflineMessages(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		return nil, nil
+	}
+	hostsMock := &hostsMock{}
+	hostsMock.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+
+	storedAt := time.Now().Add(-time.Minute)
+	repMock.FetchOfflineMessagesFunc = func(ctx context.Context, username string) ([]*stravaganza.Message, error) {
+		expired := xmpputil.MakeDelayMessage(testExpiringMessage(
+			stravaganza.NewBuilder("x").
+				WithAttribute(stravaganza.Namespace, expireNamespace).
+				WithAttribute("seconds", "30").
+				Build(),
+		), storedAt, "jackal.im", "Offline Storage")
+
+		pending := xmpputil.MakeDelayMessage(testExpiringMessage(
+			stravaganza.NewBuilder("x").
+				WithAttribute(stravaganza.Namespace, expireNamespace).
+				WithAttribute("seconds", "3600").
+				Build(),
+		), storedAt, "jackal.im", "Offline Storage")
+
+		return []*stravaganza.Message{expired, pending}, nil
+	}
+	repMock.DeleteOfflineMessagesFunc = func(ctx context.Context, username string) error {
+		return nil
+	}
+
+	stmMock := &c2sStreamMock{}
+	stmMock.UsernameFunc = func() string {
+		return "ortuman"
+	}
+	stmMock.InfoFunc = func() c2smodel.Info {
+		return c2smodel.NewInfoMap()
+	}
+	var sent []stravaganza.Element
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		sent = append(sent, elem)
+		ch := make(chan error)
+		close(ch)
+		return ch
+	}
+
+	m := &Offline{
+		cfg:    Config{QueueSize: 100},
+		router: routerMock,
+		hosts:  hostsMock,
+		rep:    repMock,
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	err := m.deliverOfflineMessages(context.Background(), stmMock)
+
+	// then
+	require.Nil(t, err)
+	require.Len(t, sent, 1)
+
+	x := sent[0].ChildNamespace("x", expireNamespace)
+	require.NotNil(t, x)
+	require.Contains(t, []string{"3539", "3540"}, x.Attribute("seconds"))
+
+	require.Len(t, routerMock.RouteCalls(), 0) // XEP-0023 expirations are silent
+}
+
+func TestOffline_SweepExpiredMessages(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	output := bytes.NewBuffer(nil)
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		_ = stanza.ToXML(output, true)
+		return nil, nil
+	}
+	var lockID string
+	repMock := &repositoryMock{}
+	repMock.FetchExpiredOfflineUsernamesFunc = func(ctx context.Context, now time.Time) ([]string, error) {
+		return []string{"ortuman"}, nil
+	}
+	repMock.LockFunc = func(ctx context.Context, lID string) error {
+		lockID = lID
+		return nil
+	}
+	repMock.UnlockFunc = func(ctx context.Context, lID string) error {
+		return nil
+	}
+	repMock.DeleteExpiredOfflineMessagesFunc = func(ctx context.Context, username string, now time.Time) ([]*offlinemodel.Message, error) {
+		alertMsg := testExpiringMessage(testAMPRule(alertAction, "2004-09-10T08:33:14Z"))
+		dropMsg := testExpiringMessage(testAMPRule(dropAction, "2004-09-10T08:33:14Z"))
+		return []*offlinemodel.Message{
+			{Id: "1", Username: "ortuman", Message: alertMsg.Proto()},
+			{Id: "2", Username: "ortuman", Message: dropMsg.Proto()},
+		}, nil
+	}
+
+	m := &Offline{
+		router: routerMock,
+		rep:    repMock,
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	err := m.sweepExpiredMessages(context.Background())
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, offlineQueueLockID("ortuman"), lockID)
+	require.Len(t, repMock.UnlockCalls(), 1)
+	require.Len(t, repMock.DeleteExpiredOfflineMessagesCalls(), 1)
+	require.Equal(t, "ortuman", repMock.DeleteExpiredOfflineMessagesCalls()[0].Username)
+	require.Len(t, routerMock.RouteCalls(), 1)
+
+	require.Equal(t, `<message id='m1' from='jackal.im' to='noelia@jackal.im/yard'><amp xmlns='http://jabber.org/protocol/amp' status='alert' to='ortuman@jackal.im/balcony' from='noelia@jackal.im/yard'><rule condition='expire-at' action='alert' value='2004-09-10T08:33:14Z'/></amp></message>`, output.String())
+}
+
+func testAMPRule(action, value string) stravaganza.Element {
+	return stravaganza.NewBuilder("amp").
+		WithAttribute(stravaganza.Namespace, ampNamespace).
+		WithChild(
+			stravaganza.NewBuilder("rule").
+				WithAttribute("condition", expireAtCondition).
+				WithAttribute("action", action).
+				WithAttribute("value", value).
+				Build(),
+		).
+		Build()
+}
+
+func testExpiringMessage(hint stravaganza.Element) *stravaganza.Message {
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("id", "m1")
+	b.WithAttribute("from", "noelia@jackal.im/yard")
+	b.WithAttribute("to", "ortuman@jackal.im/balcony")
+	b.WithChild(
+		stravaganza.NewBuilder("body").
+			WithText("I'll give thee a wind.").
+			Build(),
+	)
+	b.WithChild(hint)
+	msg, _ := b.BuildMessage()
+	return msg
+}
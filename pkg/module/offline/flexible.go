@@ -0,0 +1,289 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	discomodel "github.com/ortuman/jackal/pkg/model/disco"
+	"github.com/ortuman/jackal/pkg/module/xep0004"
+	"github.com/ortuman/jackal/pkg/router"
+	"github.com/ortuman/jackal/pkg/router/stream"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+	xmpputil "github.com/ortuman/jackal/pkg/util/xmpp"
+	"github.com/samber/lo"
+)
+
+const (
+	flexibleOfflineNamespace = "http://jabber.org/protocol/offline"
+
+	flexibleOfflineRequestedCtxKey = "offline:flexible_requested"
+
+	viewAction   = "view"
+	removeAction = "remove"
+)
+
+// MatchesNamespace tells whether namespace matches flexible offline message retrieval.
+func (m *Offline) MatchesNamespace(namespace string, serverTarget bool) bool {
+	if serverTarget {
+		return false
+	}
+	return namespace == flexibleOfflineNamespace
+}
+
+// ProcessIQ process a flexible offline message retrieval (XEP-0013) iq.
+func (m *Offline) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
+	fromJID := iq.FromJID()
+	toJID := iq.ToJID()
+
+	if !fromJID.MatchesWithOptions(toJID, jid.MatchesBare) {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.Forbidden))
+		return nil
+	}
+	offline := iq.ChildNamespace("offline", flexibleOfflineNamespace)
+	if offline == nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+	stm, err := setFlexibleRequested(ctx, m.router, fromJID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case iq.IsGet() && offline.Child("fetch") != nil:
+		return m.sendOfflineMessages(ctx, iq, stm, nil)
+
+	case iq.IsSet() && offline.Child("purge") != nil:
+		return m.removeOfflineMessages(ctx, iq, nil)
+	}
+	ids, ok := itemNodes(offline, iq)
+	if !ok {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+	if iq.IsGet() {
+		return m.sendOfflineMessages(ctx, iq, stm, ids)
+	}
+	return m.removeOfflineMessages(ctx, iq, ids)
+}
+
+func (m *Offline) sendOfflineMessages(ctx context.Context, iq *stravaganza.IQ, stm stream.C2S, ids []string) error {
+	username := iq.FromJID().Node()
+
+	lockID := offlineQueueLockID(username)
+
+	if err := m.rep.Lock(ctx, lockID); err != nil {
+		return err
+	}
+	defer m.releaseLock(ctx, lockID)
+
+	ms, err := m.rep.FetchOfflineMessagesByID(ctx, username, ids)
+	if err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	if len(ids) > 0 && len(ms) != len(ids) {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.ItemNotFound))
+		return nil
+	}
+	var expiredIDs []string
+
+	now := time.Now()
+	for _, om := range ms {
+		storedMsg, err := stravaganza.NewBuilderFromProto(om.Message).BuildMessage()
+		if err != nil {
+			return err
+		}
+		storedMsg, ok := m.checkExpiry(ctx, storedMsg, now)
+		if !ok {
+			expiredIDs = append(expiredIDs, om.Id)
+			continue
+		}
+		msg := stravaganza.NewBuilderFromElement(storedMsg).
+			WithChild(
+				stravaganza.NewBuilder("offline").
+					WithAttribute(stravaganza.Namespace, flexibleOfflineNamespace).
+					WithChild(
+						stravaganza.NewBuilder("item").
+							WithAttribute("node", om.Id).
+							Build(),
+					).
+					Build(),
+			).
+			Build()
+		stm.SendElement(msg)
+	}
+	if len(expiredIDs) > 0 {
+		if err := m.rep.DeleteOfflineMessagesByID(ctx, username, expiredIDs); err != nil {
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+			return err
+		}
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, nil))
+
+	level.Info(m.logger).Log("msg", "sent offline messages", "count", len(ms)-len(expiredIDs), "username", username)
+
+	return nil
+}
+
+func (m *Offline) removeOfflineMessages(ctx context.Context, iq *stravaganza.IQ, ids []string) error {
+	username := iq.FromJID().Node()
+
+	lockID := offlineQueueLockID(username)
+
+	if err := m.rep.Lock(ctx, lockID); err != nil {
+		return err
+	}
+	defer m.releaseLock(ctx, lockID)
+
+	count := len(ids)
+	switch {
+	case len(ids) == 0:
+		n, err := m.rep.CountOfflineMessages(ctx, username)
+		if err != nil {
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+			return err
+		}
+		count = n
+
+		if err := m.rep.DeleteOfflineMessages(ctx, username); err != nil {
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+			return err
+		}
+
+	default:
+		ms, err := m.rep.FetchOfflineMessagesByID(ctx, username, ids)
+		if err != nil {
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+			return err
+		}
+		if len(ms) != len(ids) {
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.ItemNotFound))
+			return nil
+		}
+		if err := m.rep.DeleteOfflineMessagesByID(ctx, username, ids); err != nil {
+			_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+			return err
+		}
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, nil))
+
+	level.Info(m.logger).Log("msg", "removed offline messages", "count", count, "username", username)
+
+	return nil
+}
+
+// setFlexibleRequested marks jd stream as using flexible offline message retrieval,
+// so that from now on messages won't be flooded when the user becomes available.
+func setFlexibleRequested(ctx context.Context, rtr router.Router, jd *jid.JID) (stream.C2S, error) {
+	stm, err := rtr.C2S().LocalStream(jd.Node(), jd.Resource())
+	if err != nil {
+		return nil, err
+	}
+	if err := stm.SetInfoValue(ctx, flexibleOfflineRequestedCtxKey, true); err != nil {
+		return nil, err
+	}
+	return stm, nil
+}
+
+func itemNodes(offline stravaganza.Element, iq *stravaganza.IQ) ([]string, bool) {
+	expectedAction := viewAction
+	if iq.IsSet() {
+		expectedAction = removeAction
+	}
+	items := offline.Children("item")
+	if len(items) == 0 {
+		return nil, false
+	}
+	var ids []string
+	for _, item := range items {
+		node := item.Attribute("node")
+		if len(node) == 0 || item.Attribute("action") != expectedAction {
+			return nil, false
+		}
+		ids = append(ids, node)
+	}
+	return lo.Uniq(ids), true
+}
+
+type flexibleOfflineProvider struct {
+	router router.Router
+	rep    repository.Offline
+}
+
+func (p *flexibleOfflineProvider) Identities(_ context.Context, _, _ *jid.JID, _ string) []discomodel.Identity {
+	return []discomodel.Identity{{Category: "automation", Type: "message-list"}}
+}
+
+func (p *flexibleOfflineProvider) Items(ctx context.Context, toJID, fromJID *jid.JID, _ string) ([]discomodel.Item, error) {
+	if !fromJID.MatchesWithOptions(toJID, jid.MatchesBare) {
+		return nil, nil // only owner can retrieve message headers
+	}
+	// requesting message headers implies flexible retrieval (XEP-0013)
+	if _, err := setFlexibleRequested(ctx, p.router, fromJID); err != nil {
+		return nil, err
+	}
+	ms, err := p.rep.FetchOfflineMessagesByID(ctx, toJID.Node(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var items []discomodel.Item
+	for _, om := range ms {
+		msg := stravaganza.NewBuilderFromProto(om.Message).Build()
+		items = append(items, discomodel.Item{
+			Jid:  toJID.ToBareJID().String(),
+			Name: msg.Attribute(stravaganza.From),
+			Node: om.Id,
+		})
+	}
+	return items, nil
+}
+
+func (p *flexibleOfflineProvider) Features(_ context.Context, _, _ *jid.JID, _ string) ([]discomodel.Feature, error) {
+	return []discomodel.Feature{flexibleOfflineNamespace}, nil
+}
+
+func (p *flexibleOfflineProvider) Forms(ctx context.Context, toJID, fromJID *jid.JID, _ string) ([]xep0004.DataForm, error) {
+	if !fromJID.MatchesWithOptions(toJID, jid.MatchesBare) {
+		return nil, nil
+	}
+	if _, err := setFlexibleRequested(ctx, p.router, fromJID); err != nil {
+		return nil, err
+	}
+	count, err := p.rep.CountOfflineMessages(ctx, toJID.Node())
+	if err != nil {
+		return nil, err
+	}
+	return []xep0004.DataForm{{
+		Type: xep0004.Result,
+		Fields: xep0004.Fields{
+			{
+				Type:   xep0004.Hidden,
+				Var:    xep0004.FormType,
+				Values: []string{flexibleOfflineNamespace},
+			},
+			{
+				Var:    "number_of_messages",
+				Values: []string{strconv.Itoa(count)},
+			},
+		},
+	}}, nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	offlinemodel "github.com/ortuman/jackal/pkg/model/offline"
+	"github.com/ortuman/jackal/pkg/router"
+	"github.com/ortuman/jackal/pkg/router/stream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffline_FlexibleView(t *testing.T) {
+	// given
+	m, repMock, stmMock, respStanzas, output := setupFlexibleOffline()
+
+	repMock.FetchOfflineMessagesByIDFunc = func(ctx context.Context, username string, ids []string) ([]*offlinemodel.Message, error) {
+		require.Equal(t, []string{"1"}, ids)
+		return []*offlinemodel.Message{testOfflineMessage("1")}, nil
+	}
+
+	// when
+	err := m.ProcessIQ(context.Background(), testFlexibleOfflineIQ(stravaganza.GetType, testOfflineItem(viewAction, "1")))
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, *respStanzas, 1)
+	require.Equal(t, stravaganza.ResultType, (*respStanzas)[0].Attribute(stravaganza.Type))
+
+	require.Equal(t, `<message from='noelia@jackal.im/yard' to='ortuman@jackal.im'><body>I&#39;ll give thee a wind.</body><offline xmlns='http://jabber.org/protocol/offline'><item node='1'/></offline></message>`, output.String())
+	require.Len(t, stmMock.SetInfoValueCalls(), 1)
+}
+
+func TestOffline_FlexibleViewNotFound(t *testing.T) {
+	// given
+	m, repMock, _, respStanzas, _ := setupFlexibleOffline()
+
+	repMock.FetchOfflineMessagesByIDFunc = func(ctx context.Context, username string, ids []string) ([]*offlinemodel.Message, error) {
+		return nil, nil
+	}
+
+	// when
+	err := m.ProcessIQ(context.Background(), testFlexibleOfflineIQ(stravaganza.GetType, testOfflineItem(viewAction, "1")))
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, *respStanzas, 1)
+	require.Equal(t, stravaganza.ErrorType, (*respStanzas)[0].Attribute(stravaganza.Type))
+	require.NotNil(t, (*respStanzas)[0].Child("error").Child("item-not-found"))
+}
+
+func TestOffline_FlexibleRemove(t *testing.T) {
+	// given
+	m, repMock, _, respStanzas, _ := setupFlexibleOffline()
+
+	repMock.FetchOfflineMessagesByIDFunc = func(ctx context.Context, username string, ids []string) ([]*offlinemodel.Message, error) {
+		return []*offlinemodel.Message{testOfflineMessage("1"), testOfflineMessage("2")}, nil
+	}
+	repMock.DeleteOfflineMessagesByIDFunc = func(ctx context.Context, username string, ids []string) error {
+		return nil
+	}
+
+	// when
+	err := m.ProcessIQ(context.Background(), testFlexibleOfflineIQ(stravaganza.SetType, testOfflineItem(removeAction, "1"), testOfflineItem(removeAction, "2")))
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, *respStanzas, 1)
+	require.Equal(t, stravaganza.ResultType, (*respStanzas)[0].Attribute(stravaganza.Type))
+
+	require.Len(t, repMock.DeleteOfflineMessagesByIDCalls(), 1)
+	require.Equal(t, []string{"1", "2"}, repMock.DeleteOfflineMessagesByIDCalls()[0].Ids)
+}
+
+func TestOffline_FlexibleFetchAndPurge(t *testing.T) {
+	// given
+	m, repMock, _, respStanzas, output := setupFlexibleOffline()
+
+	repMock.FetchOfflineMessagesByIDFunc = func(ctx context.Context, username string, ids []string) ([]*offlinemodel.Message, error) {
+		require.Len(t, ids, 0)
+		return []*offlinemodel.Message{testOfflineMessage("1"), testOfflineMessage("2")}, nil
+	}
+	repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
+		return 2, nil
+	}
+	repMock.DeleteOfflineMessagesFunc = func(ctx context.Context, username string) error {
+		return nil
+	}
+
+	// when
+	fetchIQ := testFlexibleOfflineIQ(stravaganza.GetType, stravaganza.NewBuilder("fetch").Build())
+	purgeIQ := testFlexibleOfflineIQ(stravaganza.SetType, stravaganza.NewBuilder("purge").Build())
+
+	err := m.ProcessIQ(context.Background(), fetchIQ)
+	require.NoError(t, err)
+
+	err = m.ProcessIQ(context.Background(), purgeIQ)
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, *respStanzas, 2)
+	require.Equal(t, stravaganza.ResultType, (*respStanzas)[0].Attribute(stravaganza.Type))
+	require.Equal(t, stravaganza.ResultType, (*respStanzas)[1].Attribute(stravaganza.Type))
+
+	require.Contains(t, output.String(), `<item node='1'/>`)
+	require.Contains(t, output.String(), `<item node='2'/>`)
+	require.Len(t, repMock.CountOfflineMessagesCalls(), 1)
+	require.Len(t, repMock.DeleteOfflineMessagesCalls(), 1)
+}
+
+func TestOffline_FlexibleBadRequest(t *testing.T) {
+	// given
+	m, _, _, respStanzas, _ := setupFlexibleOffline()
+
+	// when
+	err := m.ProcessIQ(context.Background(), testFlexibleOfflineIQ(stravaganza.GetType, testOfflineItem(removeAction, "1")))
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, *respStanzas, 1)
+	require.NotNil(t, (*respStanzas)[0].Child("error").Child("bad-request"))
+}
+
+func TestOffline_FlexibleDiscoProvider(t *testing.T) {
+	// given
+	m, repMock, stmMock, _, _ := setupFlexibleOffline()
+
+	repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
+		return 2, nil
+	}
+	repMock.FetchOfflineMessagesByIDFunc = func(ctx context.Context, username string, ids []string) ([]*offlinemodel.Message, error) {
+		return []*offlinemodel.Message{testOfflineMessage("1"), testOfflineMessage("2")}, nil
+	}
+	prov := &flexibleOfflineProvider{router: m.router, rep: repMock}
+
+	toJID, _ := jid.NewWithString("ortuman@jackal.im", true)
+	fromJID, _ := jid.NewWithString("ortuman@jackal.im/balcony", true)
+	otherJID, _ := jid.NewWithString("noelia@jackal.im/yard", true)
+
+	// when
+	items, err := prov.Items(context.Background(), toJID, fromJID, flexibleOfflineNamespace)
+	require.NoError(t, err)
+
+	forms, err := prov.Forms(context.Background(), toJID, fromJID, flexibleOfflineNamespace)
+	require.NoError(t, err)
+
+	otherItems, err := prov.Items(context.Background(), toJID, otherJID, flexibleOfflineNamespace)
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, items, 2)
+	require.Equal(t, "ortuman@jackal.im", items[0].Jid)
+	require.Equal(t, "noelia@jackal.im/yard", items[0].Name)
+	require.Equal(t, "1", items[0].Node)
+
+	require.Len(t, forms, 1)
+	require.Equal(t, "2", forms[0].Fields.ValueForField("number_of_messages"))
+
+	require.Len(t, otherItems, 0)
+
+	// disco requests switch the stream to flexible retrieval
+	require.Len(t, stmMock.SetInfoValueCalls(), 2)
+	require.Equal(t, flexibleOfflineRequestedCtxKey, stmMock.SetInfoValueCalls()[0].K)
+	require.Equal(t, true, stmMock.SetInfoValueCalls()[0].Val)
+}
+
+func setupFlexibleOffline() (*Offline, *repositoryMock, *c2sStreamMock, *[]stravaganza.Stanza, *bytes.Buffer) {
+	var respStanzas []stravaganza.Stanza
+	output := bytes.NewBuffer(nil)
+
+	stmMock := &c2sStreamMock{}
+	stmMock.SetInfoValueFunc = func(ctx context.Context, k string, val interface{}) error {
+		return nil
+	}
+	stmMock.InfoFunc = func() c2smodel.Info {
+		return c2smodel.NewInfoMap()
+	}
+	stmMock.SendElementFunc = func(elem stravaganza.Element) <-chan error {
+		_ = elem.ToXML(output, true)
+		ch := make(chan error)
+		close(ch)
+		return ch
+	}
+	c2sRouterMock := &c2sRouterMock{}
+	c2sRouterMock.LocalStreamFunc = func(username string, resource string) (stream.C2S, error) {
+		return stmMock, nil
+	}
+	routerMock := &routerMock{}
+	routerMock.C2SFunc = func() router.C2SRouter {
+		return c2sRouterMock
+	}
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+
+	m := &Offline{
+		cfg:    Config{QueueSize: 100},
+		router: routerMock,
+		rep:    repMock,
+		logger: kitlog.NewNopLogger(),
+	}
+	return m, repMock, stmMock, &respStanzas, output
+}
+
+func testFlexibleOfflineIQ(typ string, children ...stravaganza.Element) *stravaganza.IQ {
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "off1").
+		WithAttribute(stravaganza.Type, typ).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/balcony").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("offline").
+				WithAttribute(stravaganza.Namespace, flexibleOfflineNamespace).
+				WithChildren(children...).
+				Build(),
+		).
+		BuildIQ()
+	return iq
+}
+
+func testOfflineItem(action, node string) stravaganza.Element {
+	return stravaganza.NewBuilder("item").
+		WithAttribute("action", action).
+		WithAttribute("node", node).
+		Build()
+}
+
+func testOfflineMessage(id string) *offlinemodel.Message {
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "noelia@jackal.im/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText("I'll give thee a wind.").
+				Build(),
+		).
+		BuildMessage()
+	return &offlinemodel.Message{Id: id, Message: msg.Proto()}
+}
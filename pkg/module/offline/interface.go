@@ -31,6 +31,11 @@ type globalRouter interface {
 	router.Router
 }
 
+//go:generate moq -out c2s_router.mock_test.go . globalC2SRouter:c2sRouterMock
+type globalC2SRouter interface {
+	router.C2SRouter
+}
+
 //go:generate moq -out hosts.mock_test.go . hosts
 type hosts interface {
 	IsLocalHost(h string) bool
@@ -45,3 +50,8 @@ type resourceManager interface {
 type c2sStream interface {
 	stream.C2S
 }
+
+//go:generate moq -out modules.mock_test.go . modules
+type modules interface {
+	IsEnabledFor(domain, moduleName string) bool
+}
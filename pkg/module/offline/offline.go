@@ -17,6 +17,7 @@ package offline
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	kitlog "github.com/go-kit/log"
@@ -26,6 +27,7 @@ import (
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/hook"
 	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/module/xep0030"
 	"github.com/ortuman/jackal/pkg/module/xep0313"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
@@ -46,21 +48,32 @@ const ModuleName = "offline"
 type Config struct {
 	// QueueSize defines maximum offline queue size.
 	QueueSize int `fig:"queue_size" default:"200"`
+
+	// SweepInterval defines how often expired offline messages are purged.
+	// A zero value disables the background sweeper. This value can't be overridden per host.
+	SweepInterval time.Duration `fig:"sweep_interval" default:"1m"`
 }
 
 // Offline represents offline module type.
 type Offline struct {
-	cfg    Config
 	hosts  hosts
 	router router.Router
 	rep    repository.Repository
 	hk     *hook.Hooks
 	logger kitlog.Logger
+
+	mu       sync.RWMutex
+	mods     modules
+	cfg      Config
+	hostCfgs map[string]Config
+	started  bool
+	doneCh   chan chan struct{}
 }
 
 // New creates and initializes a new Offline instance.
 func New(
 	cfg Config,
+	hostCfgs map[string]Config,
 	router router.Router,
 	hosts *host.Hosts,
 	rep repository.Repository,
@@ -68,12 +81,13 @@ func New(
 	logger kitlog.Logger,
 ) *Offline {
 	return &Offline{
-		cfg:    cfg,
-		router: router,
-		hosts:  hosts,
-		rep:    rep,
-		hk:     hk,
-		logger: kitlog.With(logger, "module", ModuleName),
+		cfg:      cfg,
+		hostCfgs: hostCfgs,
+		router:   router,
+		hosts:    hosts,
+		rep:      rep,
+		hk:       hk,
+		logger:   kitlog.With(logger, "module", ModuleName),
 	}
 }
 
@@ -87,7 +101,7 @@ func (m *Offline) StreamFeature(_ context.Context, _ string) (stravaganza.Elemen
 
 // ServerFeatures returns offline module server disco features.
 func (m *Offline) ServerFeatures(_ context.Context) ([]string, error) {
-	return []string{offlineFeature}, nil
+	return append([]string{offlineFeature, flexibleOfflineNamespace}, ampFeatures...), nil
 }
 
 // AccountFeatures returns offline module account disco features.
@@ -100,6 +114,13 @@ func (m *Offline) Start(_ context.Context) error {
 
 	m.hk.AddHook(hook.C2SStreamPresenceReceived, m.onC2SPresenceRecv, hook.DefaultPriority)
 	m.hk.AddHook(hook.UserDeleted, m.onUserDeleted, hook.DefaultPriority)
+	m.hk.AddHook(hook.DiscoProvidersStarted, m.onDiscoProvidersStarted, hook.DefaultPriority)
+	m.hk.AddHook(hook.ModulesStarted, m.onModulesStarted, hook.DefaultPriority)
+
+	m.mu.Lock()
+	m.started = true
+	m.startSweeper()
+	m.mu.Unlock()
 
 	level.Info(m.logger).Log("msg", "started offline module")
 	return nil
@@ -112,11 +133,33 @@ func (m *Offline) Stop(_ context.Context) error {
 
 	m.hk.RemoveHook(hook.C2SStreamPresenceReceived, m.onC2SPresenceRecv)
 	m.hk.RemoveHook(hook.UserDeleted, m.onUserDeleted)
+	m.hk.RemoveHook(hook.DiscoProvidersStarted, m.onDiscoProvidersStarted)
+	m.hk.RemoveHook(hook.ModulesStarted, m.onModulesStarted)
+
+	m.mu.Lock()
+	m.started = false
+	m.stopSweeper()
+	m.mu.Unlock()
 
 	level.Info(m.logger).Log("msg", "stopped offline module")
 	return nil
 }
 
+// UpdateConfig replaces offline module configuration along with its per-domain overrides.
+func (m *Offline) UpdateConfig(cfg Config, hostCfgs map[string]Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sweepIntervalChanged := cfg.SweepInterval != m.cfg.SweepInterval
+	m.cfg = cfg
+	m.hostCfgs = hostCfgs
+
+	if sweepIntervalChanged && m.started {
+		m.stopSweeper()
+		m.startSweeper()
+	}
+}
+
 func (m *Offline) onMessageRouted(execCtx *hook.ExecutionContext) error {
 	var elem stravaganza.Element
 	var targets []jid.JID
@@ -139,7 +182,7 @@ func (m *Offline) onMessageRouted(execCtx *hook.ExecutionContext) error {
 		return nil
 	}
 	toJID := msg.ToJID()
-	if !m.hosts.IsLocalHost(toJID.Domain()) {
+	if !m.isLocalHost(toJID.Domain()) {
 		return nil
 	}
 	return m.archiveMessage(execCtx.Context, msg)
@@ -151,11 +194,15 @@ func (m *Offline) onC2SPresenceRecv(execCtx *hook.ExecutionContext) error {
 		// user has already queried the MAM archive.
 		return nil
 	}
+	if stm.Info().Bool(flexibleOfflineRequestedCtxKey) {
+		// user is retrieving offline messages on demand (XEP-0013).
+		return nil
+	}
 	inf := execCtx.Info.(*hook.C2SStreamInfo)
 
 	pr := inf.Element.(*stravaganza.Presence)
 	toJID := pr.ToJID()
-	if toJID.IsFull() || !m.hosts.IsLocalHost(toJID.Domain()) {
+	if toJID.IsFull() || !m.isLocalHost(toJID.Domain()) {
 		return nil
 	}
 	if !pr.IsAvailable() || pr.Priority() < 0 {
@@ -178,6 +225,31 @@ func (m *Offline) onUserDeleted(execCtx *hook.ExecutionContext) error {
 	return m.rep.DeleteOfflineMessages(ctx, inf.Username)
 }
 
+func (m *Offline) onModulesStarted(execCtx *hook.ExecutionContext) error {
+	m.mu.Lock()
+	m.mods = execCtx.Sender.(modules)
+	m.mu.Unlock()
+	return nil
+}
+
+// isLocalHost tells whether domain is a local host for which offline module has been enabled.
+func (m *Offline) isLocalHost(domain string) bool {
+	if !m.hosts.IsLocalHost(domain) {
+		return false
+	}
+	m.mu.RLock()
+	mods := m.mods
+	m.mu.RUnlock()
+
+	return mods == nil || mods.IsEnabledFor(domain, ModuleName)
+}
+
+func (m *Offline) onDiscoProvidersStarted(execCtx *hook.ExecutionContext) error {
+	disc := execCtx.Sender.(*xep0030.Disco)
+	disc.RegisterAccountNodeProvider(flexibleOfflineNamespace, &flexibleOfflineProvider{router: m.router, rep: m.rep})
+	return nil
+}
+
 func (m *Offline) deliverOfflineMessages(ctx context.Context, stm stream.C2S) error {
 	username := stm.Username()
 
@@ -200,10 +272,18 @@ func (m *Offline) deliverOfflineMessages(ctx context.Context, stm stream.C2S) er
 		return err
 	}
 	// route offline messages
+	var count int
+
+	now := time.Now()
 	for _, msg := range ms {
+		msg, ok := m.checkExpiry(ctx, msg, now)
+		if !ok {
+			continue // expired message
+		}
 		stm.SendElement(msg)
+		count++
 	}
-	level.Info(m.logger).Log("msg", "delivered offline messages", "queue_size", len(ms), "username", username)
+	level.Info(m.logger).Log("msg", "delivered offline messages", "queue_size", count, "username", username)
 
 	return nil
 }
@@ -223,15 +303,22 @@ func (m *Offline) archiveMessage(ctx context.Context, msg *stravaganza.Message)
 	if err != nil {
 		return err
 	}
-	if qSize == m.cfg.QueueSize { // offline queue is full
+	if qSize >= m.config(toJID.Domain()).QueueSize { // offline queue is full
 		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(msg, stanzaerror.ServiceUnavailable))
 		return hook.ErrStopped // already handled
 	}
+	now := time.Now()
+
+	expiresAt, rule := messageExpiry(msg, now)
+	if !expiresAt.IsZero() && !now.Before(expiresAt) {
+		m.notifyExpiredMessage(ctx, msg, rule)
+		return hook.ErrStopped // already handled
+	}
 	// add delay info
-	dMsg := xmpputil.MakeDelayMessage(msg, time.Now(), toJID.Domain(), "Offline Storage")
+	dMsg := xmpputil.MakeDelayMessage(msg, now, toJID.Domain(), "Offline Storage")
 
 	// enqueue offline message
-	if err := m.rep.InsertOfflineMessage(ctx, dMsg, username); err != nil {
+	if err := m.rep.InsertOfflineMessage(ctx, dMsg, username, expiresAt); err != nil {
 		return err
 	}
 	_, err = m.hk.Run(hook.OfflineMessageArchived, &hook.ExecutionContext{
@@ -269,3 +356,32 @@ func isMessageArchievable(msg *stravaganza.Message) bool {
 func offlineQueueLockID(username string) string {
 	return fmt.Sprintf("offline:lock:%s", username)
 }
+
+// config returns the offline configuration to be applied to domain.
+func (m *Offline) config(domain string) Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if cfg, ok := m.hostCfgs[domain]; ok {
+		return cfg
+	}
+	return m.cfg
+}
+
+func (m *Offline) startSweeper() {
+	if m.cfg.SweepInterval <= 0 {
+		return
+	}
+	m.doneCh = make(chan chan struct{})
+	go m.sweepLoop(m.doneCh, m.cfg.SweepInterval)
+}
+
+func (m *Offline) stopSweeper() {
+	if m.doneCh == nil {
+		return
+	}
+	ch := make(chan struct{})
+	m.doneCh <- ch
+	<-ch
+	m.doneCh = nil
+}
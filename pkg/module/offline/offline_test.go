@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"testing"
+	"time"
 
 	kitlog "github.com/go-kit/log"
 	"github.com/jackal-xmpp/stravaganza"
@@ -37,7 +38,7 @@ func TestOffline_ArchiveOfflineMessage(t *testing.T) {
 	repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
 		return 0, nil
 	}
-	repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string) error {
+	repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string, expiresAt time.Time) error {
 		return nil
 	}
 	hostsMock := &hostsMock{}
@@ -77,6 +78,66 @@ func TestOffline_ArchiveOfflineMessage(t *testing.T) {
 	require.Len(t, repMock.InsertOfflineMessageCalls(), 1)
 }
 
+func TestOffline_ArchiveOfflineMessageHostDisabled(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.LockFunc = func(ctx context.Context, lockID string) error { return nil }
+	repMock.UnlockFunc = func(ctx context.Context, lockID string) error { return nil }
+
+	repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
+		return 0, nil
+	}
+	repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string, expiresAt time.Time) error {
+		return nil
+	}
+	hostsMock := &hostsMock{}
+	hostsMock.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	modsMock := &modulesMock{}
+	modsMock.IsEnabledForFunc = func(domain, moduleName string) bool { return false }
+
+	hk := hook.NewHooks()
+	m := &Offline{
+		cfg:    Config{QueueSize: 100},
+		hosts:  hostsMock,
+		rep:    repMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "noelia@jackal.im/yard")
+	b.WithAttribute("to", "ortuman@jackal.im/balcony")
+	b.WithChild(
+		stravaganza.NewBuilder("body").
+			WithText("I'll give thee a wind.").
+			Build(),
+	)
+	msg, _ := b.BuildMessage()
+
+	// when
+	_ = m.Start(context.Background())
+	defer func() { _ = m.Stop(context.Background()) }()
+
+	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
+		Sender:  modsMock,
+		Context: context.Background(),
+	})
+	_, _ = hk.Run(hook.C2SStreamMessageRouted, &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	})
+
+	// then
+	require.Len(t, modsMock.IsEnabledForCalls(), 1)
+	require.Equal(t, "jackal.im", modsMock.IsEnabledForCalls()[0].Domain)
+	require.Equal(t, ModuleName, modsMock.IsEnabledForCalls()[0].ModuleName)
+
+	require.Len(t, repMock.CountOfflineMessagesCalls(), 0)
+	require.Len(t, repMock.InsertOfflineMessageCalls(), 0)
+}
+
 func TestOffline_ArchiveOfflineMessageQueueFull(t *testing.T) {
 	// given
 	routerMock := &routerMock{}
@@ -96,7 +157,7 @@ func TestOffline_ArchiveOfflineMessageQueueFull(t *testing.T) {
 	repMock.CountOfflineMessagesFunc = func(ctx context.Context, username string) (int, error) {
 		return 100, nil
 	}
-	repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string) error {
+	repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string, expiresAt time.Time) error {
 		return nil
 	}
 	resManagerMock := &resourceManagerMock{}
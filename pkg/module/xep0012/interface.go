@@ -39,3 +39,8 @@ type resourceManager interface {
 type hosts interface {
 	IsLocalHost(h string) bool
 }
+
+//go:generate moq -out modules.mock_test.go . modules
+type modules interface {
+	IsEnabledFor(domain, moduleName string) bool
+}
@@ -17,6 +17,7 @@ package xep0012
 import (
 	"context"
 	"strconv"
+	"sync"
 	"time"
 
 	kitlog "github.com/go-kit/log"
@@ -53,6 +54,9 @@ type Last struct {
 	hk        *hook.Hooks
 	logger    kitlog.Logger
 	startedAt int64
+
+	mu   sync.RWMutex
+	mods modules
 }
 
 // New returns a new initialized Last instance.
@@ -114,6 +118,7 @@ func (m *Last) Start(_ context.Context) error {
 	m.hk.AddHook(hook.S2SInStreamElementReceived, m.onElementRecv, hook.DefaultPriority)
 	m.hk.AddHook(hook.C2SStreamPresenceReceived, m.onC2SPresenceRecv, hook.DefaultPriority)
 	m.hk.AddHook(hook.UserDeleted, m.onUserDeleted, hook.DefaultPriority)
+	m.hk.AddHook(hook.ModulesStarted, m.onModulesStarted, hook.DefaultPriority)
 
 	m.startedAt = time.Now().Unix()
 
@@ -127,6 +132,7 @@ func (m *Last) Stop(_ context.Context) error {
 	m.hk.RemoveHook(hook.S2SInStreamElementReceived, m.onElementRecv)
 	m.hk.RemoveHook(hook.C2SStreamPresenceReceived, m.onC2SPresenceRecv)
 	m.hk.RemoveHook(hook.UserDeleted, m.onUserDeleted)
+	m.hk.RemoveHook(hook.ModulesStarted, m.onModulesStarted)
 
 	level.Info(m.logger).Log("msg", "stopped last module")
 	return nil
@@ -153,7 +159,7 @@ func (m *Last) onElementRecv(execCtx *hook.ExecutionContext) error {
 func (m *Last) processIncomingIQ(ctx context.Context, iq *stravaganza.IQ) error {
 	toJID := iq.ToJID()
 
-	isLocalTo := m.hosts.IsLocalHost(toJID.Domain())
+	isLocalTo := m.isLocalHost(toJID.Domain())
 	if !isLocalTo || !toJID.IsFullWithUser() || iq.ChildNamespace("query", lastActivityNamespace) == nil {
 		return nil
 	}
@@ -169,6 +175,26 @@ func (m *Last) processIncomingIQ(ctx context.Context, iq *stravaganza.IQ) error
 	return nil
 }
 
+func (m *Last) onModulesStarted(execCtx *hook.ExecutionContext) error {
+	m.mu.Lock()
+	m.mods = execCtx.Sender.(modules)
+	m.mu.Unlock()
+	return nil
+}
+
+// isLocalHost tells whether domain is a local host for which last activity module has been enabled.
+func (m *Last) isLocalHost(domain string) bool {
+	return m.hosts.IsLocalHost(domain) && m.isEnabledFor(domain)
+}
+
+func (m *Last) isEnabledFor(domain string) bool {
+	m.mu.RLock()
+	mods := m.mods
+	m.mu.RUnlock()
+
+	return mods == nil || mods.IsEnabledFor(domain, ModuleName)
+}
+
 func (m *Last) onUserDeleted(execCtx *hook.ExecutionContext) error {
 	inf := execCtx.Info.(*hook.UserInfo)
 	return m.rep.DeleteLast(execCtx.Context, inf.Username)
@@ -183,7 +209,7 @@ func (m *Last) onC2SPresenceRecv(execCtx *hook.ExecutionContext) error {
 func (m *Last) processC2SPresence(ctx context.Context, pr *stravaganza.Presence) error {
 	fromJID := pr.FromJID()
 	toJID := pr.ToJID()
-	if !pr.IsUnavailable() || !toJID.IsBare() || fromJID.Node() != toJID.Node() {
+	if !pr.IsUnavailable() || !toJID.IsBare() || fromJID.Node() != toJID.Node() || !m.isEnabledFor(toJID.Domain()) {
 		return nil
 	}
 	username := fromJID.Node()
@@ -22,19 +22,18 @@ import (
 	"github.com/jackal-xmpp/stravaganza/jid"
 	discomodel "github.com/ortuman/jackal/pkg/model/disco"
 	rostermodel "github.com/ortuman/jackal/pkg/model/roster"
-	"github.com/ortuman/jackal/pkg/module"
 	"github.com/ortuman/jackal/pkg/module/xep0004"
 	"github.com/ortuman/jackal/pkg/storage/repository"
 )
 
 type accountProvider struct {
-	mods   []module.Module
+	mods   modules
 	rosRep repository.Roster
 	resMng resourceManager
 }
 
 func newAccountProvider(
-	mods []module.Module,
+	mods modules,
 	rosRep repository.Roster,
 	resMng resourceManager,
 ) *accountProvider {
@@ -73,7 +72,7 @@ func (p *accountProvider) Features(ctx context.Context, toJID, fromJID *jid.JID,
 		return nil, err
 	}
 	var features []discomodel.Feature
-	for _, mod := range p.mods {
+	for _, mod := range p.mods.HostModules(toJID.Domain()) {
 		accFeatures, err := mod.AccountFeatures(ctx)
 		if err != nil {
 			return nil, err
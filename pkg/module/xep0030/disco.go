@@ -73,9 +73,10 @@ type Disco struct {
 	hk         *hook.Hooks
 	logger     kitlog.Logger
 
-	mu      sync.RWMutex
-	srvProv InfoProvider
-	accProv InfoProvider
+	mu           sync.RWMutex
+	srvProv      InfoProvider
+	accProv      InfoProvider
+	accNodeProvs map[string]InfoProvider
 }
 
 // New returns a new initialized disco module instance.
@@ -161,12 +162,22 @@ func (m *Disco) AccountProvider() InfoProvider {
 	return m.accProv
 }
 
+// RegisterAccountNodeProvider registers a disco info provider in charge of serving account requests addressed to node.
+func (m *Disco) RegisterAccountNodeProvider(node string, prov InfoProvider) {
+	m.mu.Lock()
+	if m.accNodeProvs == nil {
+		m.accNodeProvs = make(map[string]InfoProvider)
+	}
+	m.accNodeProvs[node] = prov
+	m.mu.Unlock()
+}
+
 func (m *Disco) onModulesStarted(execCtx *hook.ExecutionContext) error {
 	mods := execCtx.Sender.(modules)
 
 	m.mu.Lock()
-	m.srvProv = newServerProvider(mods.AllModules(), m.components)
-	m.accProv = newAccountProvider(mods.AllModules(), m.rosRep, m.resMng)
+	m.srvProv = newServerProvider(mods, m.components)
+	m.accProv = newAccountProvider(mods, m.rosRep, m.resMng)
 	m.mu.Unlock()
 
 	_, err := m.hk.Run(hook.DiscoProvidersStarted, &hook.ExecutionContext{
@@ -184,12 +195,17 @@ func (m *Disco) getDiscoInfo(ctx context.Context, iq *stravaganza.IQ) error {
 	}
 	var prov InfoProvider
 
+	node := q.Attribute("node")
+
 	m.mu.RLock()
 	switch {
 	case iq.ToJID().IsServer():
 		prov = m.srvProv
 	default:
 		prov = m.accProv
+		if nodeProv, ok := m.accNodeProvs[node]; ok && len(node) > 0 {
+			prov = nodeProv
+		}
 	}
 	m.mu.RUnlock()
 
@@ -199,7 +215,6 @@ func (m *Disco) getDiscoInfo(ctx context.Context, iq *stravaganza.IQ) error {
 	fromJID := iq.FromJID()
 	toJID := iq.ToJID()
 
-	node := q.Attribute("node")
 	switch q.Attribute(stravaganza.Namespace) {
 	case discoInfoNamespace:
 		return m.sendDiscoInfo(ctx, prov, toJID, fromJID, node, iq)
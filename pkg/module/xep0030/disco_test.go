@@ -25,8 +25,10 @@ import (
 	"github.com/ortuman/jackal/pkg/component"
 	"github.com/ortuman/jackal/pkg/hook"
 	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	discomodel "github.com/ortuman/jackal/pkg/model/disco"
 	rostermodel "github.com/ortuman/jackal/pkg/model/roster"
 	"github.com/ortuman/jackal/pkg/module"
+	"github.com/ortuman/jackal/pkg/module/xep0004"
 	"github.com/stretchr/testify/require"
 )
 
@@ -53,7 +55,7 @@ func TestDisco_GetServerInfo(t *testing.T) {
 	defer func() { _ = d.Stop(context.Background()) }()
 
 	modsMock := &modulesMock{}
-	modsMock.AllModulesFunc = func() []module.Module {
+	modsMock.HostModulesFunc = func(_ string) []module.Module {
 		return []module.Module{modMock, d}
 	}
 	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
@@ -124,7 +126,7 @@ func TestDisco_GetServerItems(t *testing.T) {
 	defer func() { _ = d.Stop(context.Background()) }()
 
 	modsMock := &modulesMock{}
-	modsMock.AllModulesFunc = func() []module.Module {
+	modsMock.HostModulesFunc = func(_ string) []module.Module {
 		return nil
 	}
 	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
@@ -196,7 +198,7 @@ func TestDisco_GetAccountInfo(t *testing.T) {
 	defer func() { _ = d.Stop(context.Background()) }()
 
 	modsMock := &modulesMock{}
-	modsMock.AllModulesFunc = func() []module.Module {
+	modsMock.HostModulesFunc = func(_ string) []module.Module {
 		return []module.Module{modMock, d}
 	}
 	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
@@ -236,6 +238,75 @@ func TestDisco_GetAccountInfo(t *testing.T) {
 	require.Len(t, features, 4)
 }
 
+func TestDisco_GetAccountNodeInfo(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	nodeProvMock := &infoProviderMock{}
+	nodeProvMock.FeaturesFunc = func(ctx context.Context, toJID, fromJID *jid.JID, node string) ([]discomodel.Feature, error) {
+		return []discomodel.Feature{node}, nil
+	}
+	nodeProvMock.IdentitiesFunc = func(ctx context.Context, toJID, fromJID *jid.JID, node string) []discomodel.Identity {
+		return []discomodel.Identity{{Category: "automation", Type: "message-list"}}
+	}
+	nodeProvMock.FormsFunc = func(ctx context.Context, toJID, fromJID *jid.JID, node string) ([]xep0004.DataForm, error) {
+		return nil, nil
+	}
+
+	hk := hook.NewHooks()
+	d := &Disco{
+		router: routerMock,
+		hk:     hk,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = d.Start(context.Background())
+	defer func() { _ = d.Stop(context.Background()) }()
+
+	modsMock := &modulesMock{}
+	modsMock.HostModulesFunc = func(_ string) []module.Module {
+		return []module.Module{d}
+	}
+	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
+		Sender:  modsMock,
+		Context: context.Background(),
+	})
+	d.RegisterAccountNodeProvider("http://jabber.org/protocol/offline", nodeProvMock)
+
+	// when
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "id1234").
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, discoInfoNamespace).
+				WithAttribute("node", "http://jabber.org/protocol/offline").
+				Build(),
+		).
+		BuildIQ()
+	_ = d.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, respStanzas, 1)
+	require.Len(t, nodeProvMock.FeaturesCalls(), 1)
+
+	query := respStanzas[0].ChildNamespace("query", discoInfoNamespace)
+	require.NotNil(t, query)
+
+	identity := query.Child("identity")
+	require.NotNil(t, identity)
+	require.Equal(t, "automation", identity.Attribute("category"))
+
+	features := query.Children("feature")
+	require.Len(t, features, 1)
+	require.Equal(t, "http://jabber.org/protocol/offline", features[0].Attribute("var"))
+}
+
 func TestDisco_GetAccountItems(t *testing.T) {
 	// given
 	routerMock := &routerMock{}
@@ -271,7 +342,7 @@ func TestDisco_GetAccountItems(t *testing.T) {
 	defer func() { _ = d.Stop(context.Background()) }()
 
 	modsMock := &modulesMock{}
-	modsMock.AllModulesFunc = func() []module.Module {
+	modsMock.HostModulesFunc = func(_ string) []module.Module {
 		return nil
 	}
 	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
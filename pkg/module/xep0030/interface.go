@@ -39,7 +39,7 @@ type resourceManager interface {
 
 //go:generate moq -out modules.mock_test.go . modules
 type modules interface {
-	AllModules() []module.Module
+	HostModules(domain string) []module.Module
 }
 
 //go:generate moq -out components.mock_test.go . components
@@ -56,3 +56,8 @@ type discoComponent interface {
 type discoModule interface {
 	module.Module
 }
+
+//go:generate moq -out info_provider.mock_test.go . infoProvider
+type infoProvider interface {
+	InfoProvider
+}
@@ -20,17 +20,16 @@ import (
 
 	"github.com/jackal-xmpp/stravaganza/jid"
 	discomodel "github.com/ortuman/jackal/pkg/model/disco"
-	"github.com/ortuman/jackal/pkg/module"
 	"github.com/ortuman/jackal/pkg/module/xep0004"
 )
 
 type serverProvider struct {
-	mods  []module.Module
+	mods  modules
 	comps components
 }
 
 func newServerProvider(
-	mods []module.Module,
+	mods modules,
 	comps components,
 ) *serverProvider {
 	return &serverProvider{
@@ -55,9 +54,9 @@ func (p *serverProvider) Items(_ context.Context, _, _ *jid.JID, _ string) ([]di
 	return items, nil
 }
 
-func (p *serverProvider) Features(ctx context.Context, _, _ *jid.JID, _ string) ([]discomodel.Feature, error) {
+func (p *serverProvider) Features(ctx context.Context, toJID, _ *jid.JID, _ string) ([]discomodel.Feature, error) {
 	var features []discomodel.Feature
-	for _, mod := range p.mods {
+	for _, mod := range p.mods.HostModules(toJID.Domain()) {
 		srvFeatures, err := mod.ServerFeatures(ctx)
 		if err != nil {
 			return nil, err
@@ -18,6 +18,7 @@ import (
 	"context"
 	"os/exec"
 	"strings"
+	"sync"
 
 	kitlog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -53,8 +54,10 @@ type Config struct {
 type Version struct {
 	router router.Router
 	osInfo string
-	cfg    Config
 	logger kitlog.Logger
+
+	mu  sync.RWMutex
+	cfg Config
 }
 
 // New returns a new initialized version instance.
@@ -69,6 +72,13 @@ func New(cfg Config, router router.Router, logger kitlog.Logger) *Version {
 // Name returns version module name.
 func (v *Version) Name() string { return ModuleName }
 
+// UpdateConfig replaces version module configuration.
+func (v *Version) UpdateConfig(cfg Config) {
+	v.mu.Lock()
+	v.cfg = cfg
+	v.mu.Unlock()
+}
+
 // StreamFeature returns version module stream feature.
 func (v *Version) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
 	return nil, nil
@@ -135,7 +145,11 @@ func (v *Version) getVersion(ctx context.Context, iq *stravaganza.IQ) error {
 			WithText(strings.TrimPrefix(version.Version.String(), "v")).
 			Build(),
 	)
-	if v.cfg.ShowOS {
+	v.mu.RLock()
+	showOS := v.cfg.ShowOS
+	v.mu.RUnlock()
+
+	if showOS {
 		qb.WithChild(
 			stravaganza.NewBuilder("os").
 				WithText(v.osInfo).
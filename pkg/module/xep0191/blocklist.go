@@ -16,6 +16,7 @@ package xep0191
 
 import (
 	"context"
+	"sync"
 
 	kitlog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -59,6 +60,9 @@ type BlockList struct {
 	resMng resourcemanager.Manager
 	hk     *hook.Hooks
 	logger kitlog.Logger
+
+	mu   sync.RWMutex
+	mods modules
 }
 
 // New returns a new initialized BlockList instance.
@@ -131,6 +135,7 @@ func (m *BlockList) Start(_ context.Context) error {
 	m.hk.AddHook(hook.C2SStreamWillRouteElement, m.onC2SElementWillRoute, hook.HighestPriority)
 	m.hk.AddHook(hook.S2SInStreamWillRouteElement, m.onS2SElementWillRoute, hook.HighestPriority)
 	m.hk.AddHook(hook.UserDeleted, m.onUserDeleted, hook.DefaultPriority)
+	m.hk.AddHook(hook.ModulesStarted, m.onModulesStarted, hook.DefaultPriority)
 
 	level.Info(m.logger).Log("msg", "started blocklist module")
 	return nil
@@ -143,6 +148,7 @@ func (m *BlockList) Stop(_ context.Context) error {
 	m.hk.RemoveHook(hook.C2SStreamWillRouteElement, m.onC2SElementWillRoute)
 	m.hk.RemoveHook(hook.S2SInStreamWillRouteElement, m.onS2SElementWillRoute)
 	m.hk.RemoveHook(hook.UserDeleted, m.onUserDeleted)
+	m.hk.RemoveHook(hook.ModulesStarted, m.onModulesStarted)
 
 	level.Info(m.logger).Log("msg", "stopped blocklist module")
 	return nil
@@ -197,11 +203,30 @@ func (m *BlockList) onUserDeleted(execCtx *hook.ExecutionContext) error {
 	return m.rep.DeleteBlockListItems(execCtx.Context, inf.Username)
 }
 
+func (m *BlockList) onModulesStarted(execCtx *hook.ExecutionContext) error {
+	m.mu.Lock()
+	m.mods = execCtx.Sender.(modules)
+	m.mu.Unlock()
+	return nil
+}
+
+// isLocalHost tells whether domain is a local host for which blocklist module has been enabled.
+func (m *BlockList) isLocalHost(domain string) bool {
+	if !m.hosts.IsLocalHost(domain) {
+		return false
+	}
+	m.mu.RLock()
+	mods := m.mods
+	m.mu.RUnlock()
+
+	return mods == nil || mods.IsEnabledFor(domain, ModuleName)
+}
+
 func (m *BlockList) processIncomingStanza(ctx context.Context, stanza stravaganza.Stanza) error {
 	fromJID := stanza.FromJID()
 	toJID := stanza.ToJID()
 
-	isLocalTo := m.hosts.IsLocalHost(toJID.Domain())
+	isLocalTo := m.isLocalHost(toJID.Domain())
 	if len(toJID.Node()) == 0 || !isLocalTo || (isLocalTo && toJID.MatchesWithOptions(fromJID, jid.MatchesBare)) {
 		return nil
 	}
@@ -232,7 +257,7 @@ func (m *BlockList) processOutgoingStanza(ctx context.Context, stanza stravaganz
 	fromJID := stanza.FromJID()
 	toJID := stanza.ToJID()
 
-	isLocalFrom := m.hosts.IsLocalHost(fromJID.Domain())
+	isLocalFrom := m.isLocalHost(fromJID.Domain())
 	if !isLocalFrom || (isLocalFrom && fromJID.MatchesWithOptions(toJID, jid.MatchesBare)) {
 		return nil
 	}
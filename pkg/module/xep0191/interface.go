@@ -55,3 +55,8 @@ type hosts interface {
 type resourceManager interface {
 	resourcemanager.Manager
 }
+
+//go:generate moq -out modules.mock_test.go . modules
+type modules interface {
+	IsEnabledFor(domain, moduleName string) bool
+}
@@ -21,7 +21,6 @@ import (
 
 	"github.com/jackal-xmpp/stravaganza"
 	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
-	"github.com/ortuman/jackal/pkg/router/stream"
 )
 
 const streamNamespace = "urn:xmpp:sm:3"
@@ -73,9 +72,18 @@ type Element struct {
 	H uint32
 }
 
-// Queue represents and c2s resumable queue.
+// Stream represents a stream whose outgoing stanzas are tracked by a Queue.
+type Stream interface {
+	// SendElement writes element string representation to the underlying stream transport.
+	SendElement(elem stravaganza.Element) <-chan error
+
+	// Disconnect performs disconnection over the stream.
+	Disconnect(streamErr *streamerror.Error) <-chan error
+}
+
+// Queue represents a resumable stream queue.
 type Queue struct {
-	stm               stream.C2S
+	stm               Stream
 	nc                []byte
 	reqAckInterval    time.Duration
 	waitForAckTimeout time.Duration
@@ -90,7 +98,7 @@ type Queue struct {
 
 // New creates and initializes a new Queue instance.
 func New(
-	stm stream.C2S,
+	stm Stream,
 	nonce []byte,
 	elements []Element,
 	inH uint32,
@@ -137,14 +145,14 @@ func (q *Queue) HandleOut(stanza stravaganza.Stanza) {
 }
 
 // SetStream sets queue internal stream.
-func (q *Queue) SetStream(stm stream.C2S) {
+func (q *Queue) SetStream(stm Stream) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	q.stm = stm
 }
 
 // GetStream returns queue internal stream.
-func (q *Queue) GetStream() stream.C2S {
+func (q *Queue) GetStream() Stream {
 	q.mu.RLock()
 	defer q.mu.RUnlock()
 	return q.stm
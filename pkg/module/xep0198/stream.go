@@ -87,7 +87,6 @@ type Config struct {
 
 // Stream represents a stream (XEP-0198) module type.
 type Stream struct {
-	cfg    Config
 	router router.Router
 	hosts  *host.Hosts
 	resMng resourcemanager.Manager
@@ -97,6 +96,9 @@ type Stream struct {
 	stmQueueMap    *streamqueue.QueueMap
 	clusterConnMng clusterConnManager
 
+	cfgMu sync.RWMutex
+	cfg   Config
+
 	mu      sync.RWMutex
 	termTms map[string]*time.Timer
 }
@@ -128,6 +130,14 @@ func New(
 // Name returns stream module name.
 func (m *Stream) Name() string { return ModuleName }
 
+// UpdateConfig replaces stream module configuration.
+// New values will be applied to stream queues registered from now on.
+func (m *Stream) UpdateConfig(cfg Config) {
+	m.cfgMu.Lock()
+	m.cfg = cfg
+	m.cfgMu.Unlock()
+}
+
 // StreamFeature returns stream module stream feature.
 func (m *Stream) StreamFeature(_ context.Context, _ string) (stravaganza.Element, error) {
 	return stravaganza.NewBuilder("sm").
@@ -206,7 +216,7 @@ func (m *Stream) onElementSent(execCtx *hook.ExecutionContext) error {
 
 	qLen := sq.Len()
 	switch {
-	case qLen >= m.cfg.MaxQueueSize:
+	case qLen >= m.config().MaxQueueSize:
 		_ = sq.GetStream().Disconnect(streamerror.E(streamerror.PolicyViolation))
 
 		level.Info(m.logger).Log("msg", "max queue size reached",
@@ -242,7 +252,7 @@ func (m *Stream) onDisconnect(execCtx *hook.ExecutionContext) error {
 	}
 	// schedule stream termination
 	m.mu.Lock()
-	m.termTms[inf.ID] = time.AfterFunc(m.cfg.HibernateTime, func() {
+	m.termTms[inf.ID] = time.AfterFunc(m.config().HibernateTime, func() {
 		_ = stm.Disconnect(nil)
 
 		level.Info(m.logger).Log("msg", "hibernated stream terminated",
@@ -325,14 +335,15 @@ func (m *Stream) handleEnable(ctx context.Context, stm stream.C2S) error {
 		nonce[i] = byte(rand.Intn(255) + 1)
 	}
 	// register stream queue
+	cfg := m.config()
 	sq := streamqueue.New(
 		stm,
 		nonce,
 		nil,
 		0,
 		0,
-		m.cfg.RequestAckInterval,
-		m.cfg.WaitForAckTimeout,
+		cfg.RequestAckInterval,
+		cfg.WaitForAckTimeout,
 	)
 	m.stmQueueMap.Set(queueKey(stm.JID()), sq)
 
@@ -395,14 +406,15 @@ func (m *Stream) handleResume(ctx context.Context, stm stream.C2S, h uint32, pre
 		if err != nil {
 			return err
 		}
+		cfg := m.config()
 		sq = streamqueue.New(
 			stm,
 			resp.Nonce,
 			resp.Elements,
 			resp.InH,
 			resp.OutH,
-			m.cfg.RequestAckInterval,
-			m.cfg.WaitForAckTimeout,
+			cfg.RequestAckInterval,
+			cfg.WaitForAckTimeout,
 		)
 
 		level.Info(m.logger).Log(
@@ -519,3 +531,9 @@ func decodeSMID(smID string) (jd *jid.JID, nonce []byte, err error) {
 func queueKey(jd *jid.JID) string {
 	return jd.String()
 }
+
+func (m *Stream) config() Config {
+	m.cfgMu.RLock()
+	defer m.cfgMu.RUnlock()
+	return m.cfg
+}
@@ -33,3 +33,8 @@ type globalRouter interface {
 type c2sRouter interface {
 	router.C2SRouter
 }
+
+//go:generate moq -out modules.mock_test.go . modules
+type modules interface {
+	IsEnabledFor(domain, moduleName string) bool
+}
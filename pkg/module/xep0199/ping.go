@@ -16,6 +16,7 @@ package xep0199
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -47,6 +48,7 @@ const (
 const (
 	modRequestTimeout = time.Second * 5
 
+	noneAction = "none"
 	killAction = "kill"
 )
 
@@ -62,22 +64,40 @@ type Config struct {
 	TimeoutAction string `fig:"timeout_action" default:"none"`
 }
 
+// Validate checks whether ping configuration values are supported.
+func (c Config) Validate() error {
+	switch c.TimeoutAction {
+	case noneAction, killAction:
+		return nil
+	}
+	return fmt.Errorf("xep0199: unrecognized timeout action: %s", c.TimeoutAction)
+}
+
 // Ping represents ping (XEP-0199) module type.
 type Ping struct {
-	cfg    Config
 	router router.Router
 	hk     *hook.Hooks
 	logger kitlog.Logger
 
+	cfgMu    sync.RWMutex
+	cfg      Config
+	hostCfgs map[string]Config
+	mods     modules
+
+	hooksMu  sync.Mutex
+	started  bool
+	hooksSet bool
+
 	mu         sync.RWMutex
 	pingTimers map[string]*time.Timer
 	ackTimers  map[string]*time.Timer
 }
 
 // New returns a new initialized ping instance.
-func New(cfg Config, router router.Router, hk *hook.Hooks, logger kitlog.Logger) *Ping {
+func New(cfg Config, hostCfgs map[string]Config, router router.Router, hk *hook.Hooks, logger kitlog.Logger) *Ping {
 	return &Ping{
 		cfg:        cfg,
+		hostCfgs:   hostCfgs,
 		router:     router,
 		hk:         hk,
 		logger:     kitlog.With(logger, "module", ModuleName, "xep", XEPNumber),
@@ -106,26 +126,43 @@ func (p *Ping) AccountFeatures(_ context.Context) ([]string, error) {
 
 // Start starts ping module.
 func (p *Ping) Start(_ context.Context) error {
-	if p.cfg.SendPings {
-		p.hk.AddHook(hook.C2SStreamBinded, p.onBinded, hook.DefaultPriority)
-		p.hk.AddHook(hook.C2SStreamDisconnected, p.onDisconnect, hook.HighestPriority)
-		p.hk.AddHook(hook.C2SStreamElementReceived, p.onRecvElement, hook.HighestPriority)
-	}
+	p.hooksMu.Lock()
+	p.started = true
+	p.updateHooks()
+	p.hooksMu.Unlock()
+
+	p.hk.AddHook(hook.ModulesStarted, p.onModulesStarted, hook.DefaultPriority)
+
 	level.Info(p.logger).Log("msg", "started ping module")
 	return nil
 }
 
 // Stop stops ping module.
 func (p *Ping) Stop(_ context.Context) error {
-	if p.cfg.SendPings {
-		p.hk.RemoveHook(hook.C2SStreamBinded, p.onBinded)
-		p.hk.RemoveHook(hook.C2SStreamDisconnected, p.onDisconnect)
-		p.hk.RemoveHook(hook.C2SStreamElementReceived, p.onRecvElement)
-	}
+	p.hooksMu.Lock()
+	p.started = false
+	p.updateHooks()
+	p.hooksMu.Unlock()
+
+	p.hk.RemoveHook(hook.ModulesStarted, p.onModulesStarted)
+
 	level.Info(p.logger).Log("msg", "stopped ping module")
 	return nil
 }
 
+// UpdateConfig replaces ping module configuration along with its per-domain overrides.
+// New values will be applied to pings scheduled from now on.
+func (p *Ping) UpdateConfig(cfg Config, hostCfgs map[string]Config) {
+	p.cfgMu.Lock()
+	p.cfg = cfg
+	p.hostCfgs = hostCfgs
+	p.cfgMu.Unlock()
+
+	p.hooksMu.Lock()
+	p.updateHooks()
+	p.hooksMu.Unlock()
+}
+
 // MatchesNamespace tells whether namespace matches ping module.
 func (p *Ping) MatchesNamespace(namespace string, _ bool) bool {
 	return namespace == pingNamespace
@@ -148,6 +185,13 @@ func (p *Ping) sendPongReply(ctx context.Context, pingIQ *stravaganza.IQ) error
 	return nil
 }
 
+func (p *Ping) onModulesStarted(execCtx *hook.ExecutionContext) error {
+	p.cfgMu.Lock()
+	p.mods = execCtx.Sender.(modules)
+	p.cfgMu.Unlock()
+	return nil
+}
+
 func (p *Ping) onBinded(execCtx *hook.ExecutionContext) error {
 	inf := execCtx.Info.(*hook.C2SStreamInfo)
 	p.schedulePing(inf.JID)
@@ -174,8 +218,15 @@ func (p *Ping) onDisconnect(execCtx *hook.ExecutionContext) error {
 }
 
 func (p *Ping) schedulePing(jd *jid.JID) {
+	if !p.isEnabledFor(jd.Domain()) {
+		return
+	}
+	cfg := p.config(jd.Domain())
+	if !cfg.SendPings {
+		return
+	}
 	p.mu.Lock()
-	p.pingTimers[jd.String()] = time.AfterFunc(p.cfg.Interval, func() {
+	p.pingTimers[jd.String()] = time.AfterFunc(cfg.Interval, func() {
 		p.sendPing(jd)
 	})
 	p.mu.Unlock()
@@ -202,7 +253,7 @@ func (p *Ping) sendPing(jd *jid.JID) {
 
 	// schedule ack timeout
 	p.mu.Lock()
-	p.ackTimers[jd.String()] = time.AfterFunc(p.cfg.AckTimeout, func() {
+	p.ackTimers[jd.String()] = time.AfterFunc(p.config(jd.Domain()).AckTimeout, func() {
 		p.timeout(jd)
 	})
 	p.mu.Unlock()
@@ -212,7 +263,7 @@ func (p *Ping) sendPing(jd *jid.JID) {
 
 func (p *Ping) timeout(jd *jid.JID) {
 	// perform timeout action
-	switch p.cfg.TimeoutAction {
+	switch p.config(jd.Domain()).TimeoutAction {
 	case killAction:
 		stm, _ := p.router.C2S().LocalStream(jd.Node(), jd.Resource())
 		if stm != nil {
@@ -236,6 +287,58 @@ func (p *Ping) cancelTimers(jd *jid.JID) {
 	p.mu.Unlock()
 }
 
+// config returns the ping configuration to be applied to domain.
+func (p *Ping) isEnabledFor(domain string) bool {
+	p.cfgMu.RLock()
+	mods := p.mods
+	p.cfgMu.RUnlock()
+
+	return mods == nil || mods.IsEnabledFor(domain, ModuleName)
+}
+
+func (p *Ping) config(domain string) Config {
+	p.cfgMu.RLock()
+	defer p.cfgMu.RUnlock()
+
+	if cfg, ok := p.hostCfgs[domain]; ok {
+		return cfg
+	}
+	return p.cfg
+}
+
+// updateHooks registers stream hooks only while module is running and server pings should be sent.
+func (p *Ping) updateHooks() {
+	setHooks := p.started && p.sendsPings()
+	switch {
+	case setHooks && !p.hooksSet:
+		p.hk.AddHook(hook.C2SStreamBinded, p.onBinded, hook.DefaultPriority)
+		p.hk.AddHook(hook.C2SStreamDisconnected, p.onDisconnect, hook.HighestPriority)
+		p.hk.AddHook(hook.C2SStreamElementReceived, p.onRecvElement, hook.HighestPriority)
+
+	case !setHooks && p.hooksSet:
+		p.hk.RemoveHook(hook.C2SStreamBinded, p.onBinded)
+		p.hk.RemoveHook(hook.C2SStreamDisconnected, p.onDisconnect)
+		p.hk.RemoveHook(hook.C2SStreamElementReceived, p.onRecvElement)
+	}
+	p.hooksSet = setHooks
+}
+
+// sendsPings tells whether server pings should be sent to any domain.
+func (p *Ping) sendsPings() bool {
+	p.cfgMu.RLock()
+	defer p.cfgMu.RUnlock()
+
+	if p.cfg.SendPings {
+		return true
+	}
+	for _, cfg := range p.hostCfgs {
+		if cfg.SendPings {
+			return true
+		}
+	}
+	return false
+}
+
 func isPingIQ(iq *stravaganza.IQ) bool {
 	return iq.IsGet() && iq.ChildNamespace("ping", pingNamespace) != nil
 }
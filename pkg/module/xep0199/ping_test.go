@@ -41,7 +41,7 @@ func TestPing_Pong(t *testing.T) {
 		_ = stanza.ToXML(outBuf, true)
 		return nil, nil
 	}
-	p := New(Config{}, routerMock, &hook.Hooks{}, kitlog.NewNopLogger())
+	p := New(Config{}, nil, routerMock, &hook.Hooks{}, kitlog.NewNopLogger())
 
 	// when
 	iq, _ := stravaganza.NewIQBuilder().
@@ -77,7 +77,7 @@ func TestPing_SendPing(t *testing.T) {
 	p := New(Config{
 		Interval:  time.Millisecond * 500,
 		SendPings: true,
-	}, routerMock, hk, kitlog.NewNopLogger())
+	}, nil, routerMock, hk, kitlog.NewNopLogger())
 	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
 
 	// when
@@ -124,7 +124,7 @@ func TestPing_Timeout(t *testing.T) {
 		AckTimeout:    time.Millisecond * 250,
 		SendPings:     true,
 		TimeoutAction: killAction,
-	}, routerMock, hk, kitlog.NewNopLogger())
+	}, nil, routerMock, hk, kitlog.NewNopLogger())
 	jd, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
 
 	// when
@@ -141,3 +141,46 @@ func TestPing_Timeout(t *testing.T) {
 	// then
 	require.Len(t, c2sStream.DisconnectCalls(), 1)
 }
+
+func TestPing_HostConfig(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var mu sync.Mutex
+	var outStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		outStanzas = append(outStanzas, stanza)
+		return nil, nil
+	}
+	hk := hook.NewHooks()
+	p := New(Config{}, map[string]Config{
+		"jackal.im": {
+			Interval:  time.Millisecond * 500,
+			SendPings: true,
+		},
+	}, routerMock, hk, kitlog.NewNopLogger())
+	jd0, _ := jid.NewWithString("ortuman@jackal.im/yard", true)
+	jd1, _ := jid.NewWithString("noelia@jabber.org/balcony", true)
+
+	// when
+	_ = p.Start(context.Background())
+	for _, jd := range []*jid.JID{jd0, jd1} {
+		_, _ = hk.Run(hook.C2SStreamBinded, &hook.ExecutionContext{
+			Info: &hook.C2SStreamInfo{
+				ID:  "c2s1",
+				JID: jd,
+			},
+			Context: context.Background(),
+		})
+	}
+	time.Sleep(time.Second) // wait until ping is triggered
+
+	// then
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, outStanzas, 1)
+	require.Equal(t, "ortuman@jackal.im/yard", outStanzas[0].Attribute(stravaganza.To))
+}
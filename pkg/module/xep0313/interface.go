@@ -48,4 +48,10 @@ type c2sStream interface {
 //go:generate moq -out hosts.mock_test.go . hosts
 type hosts interface {
 	IsLocalHost(h string) bool
+	HostNames() []string
+}
+
+//go:generate moq -out modules.mock_test.go . modules
+type modules interface {
+	IsEnabledFor(domain, moduleName string) bool
 }
@@ -16,12 +16,12 @@ package xep0313
 
 import (
 	"context"
+	"sync"
 
 	kitlog "github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/google/uuid"
 	"github.com/jackal-xmpp/stravaganza"
-	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/hook"
 	"github.com/ortuman/jackal/pkg/host"
@@ -40,6 +40,13 @@ const (
 
 	mamNamespace         = "urn:xmpp:mam:2"
 	extendedMamNamespace = "urn:xmpp:mam:2#extended"
+	fullTextNamespace    = "urn:xmpp:fulltext:0"
+
+	stanzaIDNamespace      = "urn:xmpp:sid:0"
+	retractNamespace       = "urn:xmpp:message-retract:1"
+	legacyRetractNamespace = "urn:xmpp:message-retract:0"
+	fastenNamespace        = "urn:xmpp:fasten:0"
+	correctNamespace       = "urn:xmpp:message-correct:0"
 
 	archiveRequestedCtxKey = "mam:requested"
 )
@@ -60,30 +67,37 @@ type Config struct {
 
 // Mam represents a mam (XEP-0313) module type.
 type Mam struct {
-	svc    *Service
 	hk     *hook.Hooks
 	router router.Router
+	rep    repository.Repository
 	hosts  hosts
 	logger kitlog.Logger
+
+	mu       sync.RWMutex
+	mods     modules
+	svc      *Service
+	hostSvcs map[string]*Service
 }
 
 // New returns a new initialized mam instance.
 func New(
 	cfg Config,
+	hostCfgs map[string]Config,
 	router router.Router,
 	hosts *host.Hosts,
 	rep repository.Repository,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) *Mam {
-	logger = kitlog.With(logger, "module", ModuleName, "xep", XEPNumber)
-	return &Mam{
-		svc:    NewService(router, hk, rep, cfg.QueueSize, logger),
+	m := &Mam{
 		router: router,
+		rep:    rep,
 		hosts:  hosts,
 		hk:     hk,
-		logger: logger,
+		logger: kitlog.With(logger, "module", ModuleName, "xep", XEPNumber),
 	}
+	m.UpdateConfig(cfg, hostCfgs)
+	return m
 }
 
 // Name returns mam module name.
@@ -101,7 +115,7 @@ func (m *Mam) ServerFeatures(_ context.Context) ([]string, error) {
 
 // AccountFeatures returns mam account disco features.
 func (m *Mam) AccountFeatures(_ context.Context) ([]string, error) {
-	return []string{mamNamespace, extendedMamNamespace}, nil
+	return []string{mamNamespace, extendedMamNamespace, fullTextNamespace}, nil
 }
 
 // Start starts mam module.
@@ -112,6 +126,7 @@ func (m *Mam) Start(_ context.Context) error {
 	m.hk.AddHook(hook.C2SStreamMessageRouted, m.onMessageRouted, hook.LowestPriority+2)
 	m.hk.AddHook(hook.S2SInStreamMessageRouted, m.onMessageRouted, hook.LowestPriority+2)
 	m.hk.AddHook(hook.UserDeleted, m.onUserDeleted, hook.DefaultPriority)
+	m.hk.AddHook(hook.ModulesStarted, m.onModulesStarted, hook.DefaultPriority)
 
 	level.Info(m.logger).Log("msg", "started mam module")
 	return nil
@@ -124,6 +139,7 @@ func (m *Mam) Stop(_ context.Context) error {
 	m.hk.RemoveHook(hook.C2SStreamMessageRouted, m.onMessageRouted)
 	m.hk.RemoveHook(hook.S2SInStreamMessageRouted, m.onMessageRouted)
 	m.hk.RemoveHook(hook.UserDeleted, m.onUserDeleted)
+	m.hk.RemoveHook(hook.ModulesStarted, m.onModulesStarted)
 
 	level.Info(m.logger).Log("msg", "stopped mam module")
 	return nil
@@ -139,14 +155,7 @@ func (m *Mam) MatchesNamespace(namespace string, serverTarget bool) bool {
 
 // ProcessIQ process a mam iq.
 func (m *Mam) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
-	fromJID := iq.FromJID()
-	toJID := iq.ToJID()
-
-	if !fromJID.MatchesWithOptions(toJID, jid.MatchesBare) {
-		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.Forbidden))
-		return nil
-	}
-	return m.svc.ProcessIQ(ctx, iq, func(_ string) error {
+	return m.service(iq.ToJID().Domain()).ProcessIQ(ctx, iq, func(_ string) error {
 		fromJID := iq.FromJID()
 
 		stm, err := m.router.C2S().LocalStream(fromJID.Node(), fromJID.Resource())
@@ -158,17 +167,21 @@ func (m *Mam) ProcessIQ(ctx context.Context, iq *stravaganza.IQ) error {
 }
 
 func (m *Mam) onMessageReceived(execCtx *hook.ExecutionContext) error {
-	var msg *stravaganza.Message
-
 	switch inf := execCtx.Info.(type) {
 	case *hook.C2SStreamInfo:
-		msg = inf.Element.(*stravaganza.Message)
-		inf.Element = m.addRecipientStanzaID(msg)
+		msg, err := m.addRecipientStanzaID(execCtx.Context, inf.Element.(*stravaganza.Message))
+		if err != nil {
+			return err
+		}
+		inf.Element = msg
 		execCtx.Info = inf
 
 	case *hook.S2SStreamInfo:
-		msg = inf.Element.(*stravaganza.Message)
-		inf.Element = m.addRecipientStanzaID(msg)
+		msg, err := m.addRecipientStanzaID(execCtx.Context, inf.Element.(*stravaganza.Message))
+		if err != nil {
+			return err
+		}
+		inf.Element = msg
 		execCtx.Info = inf
 	}
 	return nil
@@ -188,7 +201,37 @@ func (m *Mam) onMessageRouted(execCtx *hook.ExecutionContext) error {
 
 func (m *Mam) onUserDeleted(execCtx *hook.ExecutionContext) error {
 	inf := execCtx.Info.(*hook.UserInfo)
-	return m.svc.DeleteArchive(execCtx.Context, inf.Username)
+
+	// user archives are keyed by bare JID, which may belong to any local host
+	for _, domain := range m.hosts.HostNames() {
+		archiveJID, err := jid.New(inf.Username, domain, "", true)
+		if err != nil {
+			return err
+		}
+		if err := m.service(domain).DeleteArchive(execCtx.Context, archiveJID.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Mam) onModulesStarted(execCtx *hook.ExecutionContext) error {
+	m.mu.Lock()
+	m.mods = execCtx.Sender.(modules)
+	m.mu.Unlock()
+	return nil
+}
+
+// isLocalHost tells whether domain is a local host for which mam module has been enabled.
+func (m *Mam) isLocalHost(domain string) bool {
+	if !m.hosts.IsLocalHost(domain) {
+		return false
+	}
+	m.mu.RLock()
+	mods := m.mods
+	m.mu.RUnlock()
+
+	return mods == nil || mods.IsEnabledFor(domain, ModuleName)
 }
 
 func (m *Mam) handleRoutedMessage(execCtx *hook.ExecutionContext, elem stravaganza.Element) error {
@@ -196,38 +239,85 @@ func (m *Mam) handleRoutedMessage(execCtx *hook.ExecutionContext, elem stravagan
 	if !ok {
 		return nil
 	}
-	if !IsMessageArchievable(msg) {
+	if !IsMessageArchievable(msg) && !isMessageAmendment(msg) {
 		return nil
 	}
 
 	fromJID := msg.FromJID()
-	if m.hosts.IsLocalHost(fromJID.Domain()) {
+	if m.isLocalHost(fromJID.Domain()) {
 		sentArchiveID := uuid.New().String()
 		archiveMsg := xmpputil.MakeStanzaIDMessage(msg, sentArchiveID, fromJID.ToBareJID().String())
-		if err := m.svc.ArchiveMessage(execCtx.Context, archiveMsg, fromJID.ToBareJID().String(), sentArchiveID); err != nil {
+		archived, err := m.service(fromJID.Domain()).ArchiveMessage(execCtx.Context, archiveMsg, fromJID.ToBareJID().String(), sentArchiveID)
+		if err != nil {
 			return err
 		}
-		execCtx.Context = context.WithValue(execCtx.Context, sentArchiveIDKey, sentArchiveID)
+		if archived {
+			execCtx.Context = context.WithValue(execCtx.Context, sentArchiveIDKey, sentArchiveID)
+		}
 	}
 	toJID := msg.ToJID()
-	if !m.hosts.IsLocalHost(toJID.Domain()) {
+	if !m.isLocalHost(toJID.Domain()) {
 		return nil
 	}
 	recievedArchiveID := xmpputil.MessageStanzaID(msg)
-	if err := m.svc.ArchiveMessage(execCtx.Context, msg, toJID.ToBareJID().String(), recievedArchiveID); err != nil {
+	if len(recievedArchiveID) == 0 && !isMessageAmendment(msg) {
+		return nil // excluded by recipient archive preferences
+	}
+	archived, err := m.service(toJID.Domain()).ArchiveMessage(execCtx.Context, msg, toJID.ToBareJID().String(), recievedArchiveID)
+	if err != nil {
 		return err
 	}
-	execCtx.Context = context.WithValue(execCtx.Context, receivedArchiveIDKey, recievedArchiveID)
+	if archived {
+		execCtx.Context = context.WithValue(execCtx.Context, receivedArchiveIDKey, recievedArchiveID)
+	}
 	return nil
 }
 
-func (m *Mam) addRecipientStanzaID(originalMsg *stravaganza.Message) *stravaganza.Message {
+func (m *Mam) addRecipientStanzaID(ctx context.Context, originalMsg *stravaganza.Message) (*stravaganza.Message, error) {
 	toJID := originalMsg.ToJID()
-	if !m.hosts.IsLocalHost(toJID.Domain()) {
-		return originalMsg
+	if !m.isLocalHost(toJID.Domain()) {
+		return originalMsg, nil
+	}
+	if !IsMessageArchievable(originalMsg) || isMessageAmendment(originalMsg) {
+		return originalMsg, nil
+	}
+	allowed, err := m.service(toJID.Domain()).IsArchivingAllowed(ctx, originalMsg, toJID.ToBareJID().String())
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return originalMsg, nil
 	}
 	archiveID := uuid.New().String()
-	return xmpputil.MakeStanzaIDMessage(originalMsg, archiveID, toJID.ToBareJID().String())
+	return xmpputil.MakeStanzaIDMessage(originalMsg, archiveID, toJID.ToBareJID().String()), nil
+}
+
+// UpdateConfig replaces mam module configuration along with its per-domain overrides.
+func (m *Mam) UpdateConfig(cfg Config, hostCfgs map[string]Config) {
+	svc := NewService(m.router, m.hk, m.rep, cfg.QueueSize, nil, m.logger)
+
+	var hostSvcs map[string]*Service
+	for domain, hostCfg := range hostCfgs {
+		if hostSvcs == nil {
+			hostSvcs = make(map[string]*Service)
+		}
+		hostSvcs[domain] = NewService(m.router, m.hk, m.rep, hostCfg.QueueSize, nil, m.logger)
+	}
+	m.mu.Lock()
+	m.svc = svc
+	m.hostSvcs = hostSvcs
+	m.mu.Unlock()
+}
+
+// service returns the archive service in charge of domain archives.
+func (m *Mam) service(domain string) *Service {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if svc, ok := m.hostSvcs[domain]; ok {
+		return svc
+	}
+	return m.svc
 }
 
 // IsArchiveRequested determines whether archive has been requested over a C2S stream by inspecting inf parameter.
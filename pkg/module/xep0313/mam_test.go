@@ -26,6 +26,7 @@ import (
 	"github.com/ortuman/jackal/pkg/hook"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	c2smodel "github.com/ortuman/jackal/pkg/model/c2s"
+	rostermodel "github.com/ortuman/jackal/pkg/model/roster"
 	"github.com/ortuman/jackal/pkg/module/xep0004"
 	"github.com/ortuman/jackal/pkg/module/xep0059"
 	"github.com/ortuman/jackal/pkg/router"
@@ -45,7 +46,7 @@ func TestMam_FormFields(t *testing.T) {
 		return nil, nil
 	}
 	mam := &Mam{
-		svc:    NewService(routerMock, nil, nil, 100, kitlog.NewNopLogger()),
+		svc:    NewService(routerMock, nil, nil, 100, nil, kitlog.NewNopLogger()),
 		router: routerMock,
 		logger: kitlog.NewNopLogger(),
 	}
@@ -79,7 +80,7 @@ func TestMam_FormFields(t *testing.T) {
 	form, _ := xep0004.NewFormFromElement(x)
 	require.NotNil(t, form)
 
-	require.Len(t, form.Fields, 7)
+	require.Len(t, form.Fields, 8)
 }
 
 func TestMam_Metadata(t *testing.T) {
@@ -101,7 +102,7 @@ func TestMam_Metadata(t *testing.T) {
 		}, nil
 	}
 	mam := &Mam{
-		svc:    NewService(routerMock, hook.NewHooks(), repMock, 100, kitlog.NewNopLogger()),
+		svc:    NewService(routerMock, hook.NewHooks(), repMock, 100, nil, kitlog.NewNopLogger()),
 		hk:     hook.NewHooks(),
 		router: routerMock,
 		logger: kitlog.NewNopLogger(),
@@ -158,13 +159,16 @@ func TestMam_ArchiveMessage(t *testing.T) {
 	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
 		return f(ctx, txMock)
 	}
+	repMock.FetchArchivePreferencesFunc = func(ctx context.Context, archiveID string) (*archivemodel.Preferences, error) {
+		return nil, nil
+	}
 
 	hosts := &hostsMock{}
 	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
 
 	hk := hook.NewHooks()
 	mam := &Mam{
-		svc:    NewService(nil, hk, repMock, 100, kitlog.NewNopLogger()),
+		svc:    NewService(nil, hk, repMock, 100, nil, kitlog.NewNopLogger()),
 		hk:     hk,
 		hosts:  hosts,
 		logger: kitlog.NewNopLogger(),
@@ -203,6 +207,72 @@ func TestMam_ArchiveMessage(t *testing.T) {
 	require.True(t, len(ExtractReceivedArchiveID(execCtx.Context)) > 0)
 }
 
+func TestMam_ArchiveMessageHostDisabled(t *testing.T) {
+	// given
+	var archivedMessages []*archivemodel.Message
+
+	txMock := &txMock{}
+	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
+		return nil
+	}
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) error {
+		archivedMessages = append(archivedMessages, message)
+		return nil
+	}
+
+	repMock := &repositoryMock{}
+	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+	repMock.FetchArchivePreferencesFunc = func(ctx context.Context, archiveID string) (*archivemodel.Preferences, error) {
+		return nil, nil
+	}
+
+	hosts := &hostsMock{}
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" || h == "jabber.org" }
+
+	modsMock := &modulesMock{}
+	modsMock.IsEnabledForFunc = func(domain, moduleName string) bool { return domain == "jackal.im" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		svc:    NewService(nil, hk, repMock, 100, nil, kitlog.NewNopLogger()),
+		hk:     hk,
+		hosts:  hosts,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	t.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+	_, _ = hk.Run(hook.ModulesStarted, &hook.ExecutionContext{
+		Sender:  modsMock,
+		Context: context.Background(),
+	})
+
+	msg := testMessageStanzaWithParameters("b0", "ortuman@jackal.im/chamber", "noelia@jabber.org/yard")
+
+	// when
+	execCtx := &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	}
+	_, err := hk.Run(hook.C2SStreamMessageReceived, execCtx)
+	require.NoError(t, err)
+
+	_, err = hk.Run(hook.C2SStreamMessageRouted, execCtx)
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, archivedMessages, 1)
+	require.Equal(t, "ortuman@jackal.im", archivedMessages[0].ArchiveId)
+
+	require.True(t, len(ExtractSentArchiveID(execCtx.Context)) > 0)
+	require.Len(t, ExtractReceivedArchiveID(execCtx.Context), 0)
+}
+
 func TestMam_SendArchiveMessages(t *testing.T) {
 	// given
 	archiveMessages := []*archivemodel.Message{
@@ -275,7 +345,7 @@ func TestMam_SendArchiveMessages(t *testing.T) {
 	}
 
 	mam := &Mam{
-		svc:    NewService(routerMock, hook.NewHooks(), repMock, 100, kitlog.NewNopLogger()),
+		svc:    NewService(routerMock, hook.NewHooks(), repMock, 100, nil, kitlog.NewNopLogger()),
 		hk:     hook.NewHooks(),
 		router: routerMock,
 		logger: kitlog.NewNopLogger(),
@@ -321,6 +391,106 @@ func TestMam_SendArchiveMessages(t *testing.T) {
 	require.True(t, IsArchiveRequested(c2sInf))
 }
 
+func TestMam_SendArchiveMessagesByFullText(t *testing.T) {
+	// given
+	archiveMessages := []*archivemodel.Message{
+		{
+			ArchiveId: "ortuman@jackal.im",
+			Id:        "id0",
+			Stamp:     timestamppb.New(time.Date(2022, 01, 01, 00, 00, 00, 00, time.UTC)),
+			FromJid:   "noelia@jackal.im/yard",
+			ToJid:     "ortuman@jackal.im/chamber",
+			Message:   testMessageStanzaWithParameters("wherefore art thou", "noelia@jackal.im/yard", "ortuman@jackal.im/chamber").Proto(),
+		},
+		{
+			ArchiveId: "ortuman@jackal.im",
+			Id:        "id1",
+			Stamp:     timestamppb.New(time.Date(2022, 01, 01, 01, 00, 00, 00, time.UTC)),
+			FromJid:   "noelia@jackal.im/yard",
+			ToJid:     "ortuman@jackal.im/chamber",
+			Message:   testMessageStanzaWithParameters("art thou not Romeo", "noelia@jackal.im/yard", "ortuman@jackal.im/chamber").Proto(),
+		},
+	}
+
+	stmMock := &c2sStreamMock{}
+	stmMock.SetInfoValueFunc = func(ctx context.Context, k string, val interface{}) error { return nil }
+
+	c2sRouterMock := &c2sRouterMock{}
+	c2sRouterMock.LocalStreamFunc = func(username string, resource string) (stream.C2S, error) {
+		return stmMock, nil
+	}
+
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	routerMock.C2SFunc = func() router.C2SRouter {
+		return c2sRouterMock
+	}
+
+	var requestedFilters *archivemodel.Filters
+
+	repMock := &repositoryMock{}
+	repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, archiveID string) ([]*archivemodel.Message, error) {
+		requestedFilters = f
+		return archiveMessages, nil
+	}
+
+	mam := &Mam{
+		svc:    NewService(routerMock, hook.NewHooks(), repMock, 100, nil, kitlog.NewNopLogger()),
+		hk:     hook.NewHooks(),
+		router: routerMock,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	form := xep0004.DataForm{
+		Type: xep0004.Submit,
+		Fields: []xep0004.Field{
+			{Var: xep0004.FormType, Type: xep0004.Hidden, Values: []string{mamNamespace}},
+			{Var: "{urn:xmpp:fulltext:0}fulltext", Values: []string{"art thou"}},
+		},
+	}
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "ortuman1").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("query").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				WithChild(form.Element()).
+				WithChild(
+					stravaganza.NewBuilder("set").
+						WithAttribute(stravaganza.Namespace, xep0059.RSMNamespace).
+						WithChild(stravaganza.NewBuilder("max").WithText("1").Build()).
+						Build(),
+				).
+				Build(),
+		).
+		BuildIQ()
+
+	// when
+	_ = mam.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.NotNil(t, requestedFilters)
+	require.Equal(t, "art thou", requestedFilters.FullText)
+
+	require.Len(t, respStanzas, 2) // 1 message + result iq
+
+	finElem := respStanzas[1].ChildNamespace("fin", mamNamespace)
+	require.NotNil(t, finElem)
+	require.Empty(t, finElem.Attribute("complete"))
+
+	rsmRes := finElem.ChildNamespace("set", xep0059.RSMNamespace)
+	require.NotNil(t, rsmRes)
+	require.Equal(t, "1", rsmRes.Child("count").Text())
+	require.Equal(t, "id0", rsmRes.Child("first").Text())
+}
+
 func TestMam_Forbidden(t *testing.T) {
 	routerMock := &routerMock{}
 
@@ -333,7 +503,7 @@ func TestMam_Forbidden(t *testing.T) {
 	repMock := &repositoryMock{}
 
 	mam := &Mam{
-		svc:    NewService(routerMock, hook.NewHooks(), repMock, 100, kitlog.NewNopLogger()),
+		svc:    NewService(routerMock, hook.NewHooks(), repMock, 100, nil, kitlog.NewNopLogger()),
 		router: routerMock,
 		logger: kitlog.NewNopLogger(),
 	}
@@ -359,20 +529,28 @@ func TestMam_Forbidden(t *testing.T) {
 
 func TestMam_DeleteArchive(t *testing.T) {
 	// given
-	var deletedArchiveID string
+	var deletedArchiveIDs, deletedPrefsIDs []string
 
 	repMock := &repositoryMock{}
 	repMock.DeleteArchiveFunc = func(ctx context.Context, archiveID string) error {
-		deletedArchiveID = archiveID
+		deletedArchiveIDs = append(deletedArchiveIDs, archiveID)
+		return nil
+	}
+	repMock.DeleteArchivePreferencesFunc = func(ctx context.Context, archiveID string) error {
+		deletedPrefsIDs = append(deletedPrefsIDs, archiveID)
 		return nil
 	}
 
 	hosts := &hostsMock{}
-	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" || h == "jabber.org" }
+	hosts.HostNamesFunc = func() []string { return []string{"jackal.im", "jabber.org"} }
 
 	hk := hook.NewHooks()
 	mam := &Mam{
-		svc:    NewService(nil, hk, repMock, 100, kitlog.NewNopLogger()),
+		svc: NewService(nil, hk, repMock, 100, nil, kitlog.NewNopLogger()),
+		hostSvcs: map[string]*Service{
+			"jabber.org": NewService(nil, hk, repMock, 10, nil, kitlog.NewNopLogger()),
+		},
 		hk:     hk,
 		hosts:  hosts,
 		logger: kitlog.NewNopLogger(),
@@ -393,9 +571,374 @@ func TestMam_DeleteArchive(t *testing.T) {
 
 	// then
 	require.NoError(t, err)
-	require.Len(t, repMock.DeleteArchiveCalls(), 1)
+	require.Equal(t, []string{"ortuman@jackal.im", "ortuman@jabber.org"}, deletedArchiveIDs)
+	require.Equal(t, []string{"ortuman@jackal.im", "ortuman@jabber.org"}, deletedPrefsIDs)
+}
+
+func TestMam_ArchiveMessageWithPreferences(t *testing.T) {
+	// given
+	var archivedMessages []*archivemodel.Message
+
+	txMock := &txMock{}
+	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
+		return nil
+	}
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) error {
+		archivedMessages = append(archivedMessages, message)
+		return nil
+	}
+
+	repMock := &repositoryMock{}
+	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+	repMock.FetchArchivePreferencesFunc = func(ctx context.Context, archiveID string) (*archivemodel.Preferences, error) {
+		switch archiveID {
+		case "ortuman@jackal.im":
+			return &archivemodel.Preferences{
+				ArchiveId:   archiveID,
+				DefaultMode: archivemodel.RosterMode,
+			}, nil
+		case "noelia@jackal.im":
+			return &archivemodel.Preferences{
+				ArchiveId:   archiveID,
+				DefaultMode: archivemodel.AlwaysMode,
+				Never:       []string{"ortuman@jackal.im"},
+			}, nil
+		}
+		return nil, nil
+	}
+	repMock.FetchRosterItemFunc = func(ctx context.Context, username string, jid string) (*rostermodel.Item, error) {
+		if username == "ortuman" && jid == "noelia@jackal.im" {
+			return &rostermodel.Item{Username: username, Jid: jid, Subscription: rostermodel.Both}, nil
+		}
+		return nil, nil
+	}
+
+	hosts := &hostsMock{}
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		svc:    NewService(nil, hk, repMock, 100, nil, kitlog.NewNopLogger()),
+		hk:     hk,
+		hosts:  hosts,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	t.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+
+	msg := testMessageStanzaWithParameters("b0", "ortuman@jackal.im/chamber", "noelia@jackal.im/yard")
+
+	// when
+	execCtx := &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	}
+	_, err := hk.Run(hook.C2SStreamMessageReceived, execCtx)
+	require.NoError(t, err)
+
+	_, err = hk.Run(hook.C2SStreamMessageRouted, execCtx)
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, archivedMessages, 1)
+	require.Equal(t, "ortuman@jackal.im", archivedMessages[0].ArchiveId)
+
+	routedMsg := execCtx.Info.(*hook.C2SStreamInfo).Element.(*stravaganza.Message)
+	require.Nil(t, routedMsg.ChildNamespace("stanza-id", "urn:xmpp:sid:0"))
+
+	require.True(t, len(ExtractSentArchiveID(execCtx.Context)) > 0)
+	require.Len(t, ExtractReceivedArchiveID(execCtx.Context), 0)
+}
+
+func TestMam_RetractArchivedMessage(t *testing.T) {
+	// given
+	type tombstoneCall struct {
+		archiveID, fromJID, id string
+		tombstone              *stravaganza.PBElement
+	}
+	var tombstoneCalls []tombstoneCall
+
+	repMock := &repositoryMock{}
+	repMock.TombstoneArchiveMessageFunc = func(ctx context.Context, archiveID, fromJID, id string, tombstone *stravaganza.PBElement) error {
+		tombstoneCalls = append(tombstoneCalls, tombstoneCall{archiveID: archiveID, fromJID: fromJID, id: id, tombstone: tombstone})
+		return nil
+	}
+
+	hosts := &hostsMock{}
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		svc:    NewService(nil, hk, repMock, 100, nil, kitlog.NewNopLogger()),
+		hk:     hk,
+		hosts:  hosts,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	t.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "noelia@jackal.im/yard").
+		WithAttribute(stravaganza.Type, stravaganza.ChatType).
+		WithChild(
+			stravaganza.NewBuilder("retract").
+				WithAttribute(stravaganza.Namespace, retractNamespace).
+				WithAttribute("id", "origin-1").
+				Build(),
+		).
+		BuildMessage()
+
+	// when
+	execCtx := &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	}
+	_, err := hk.Run(hook.C2SStreamMessageReceived, execCtx)
+	require.NoError(t, err)
+
+	_, err = hk.Run(hook.C2SStreamMessageRouted, execCtx)
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, tombstoneCalls, 2)
+	require.Equal(t, "ortuman@jackal.im", tombstoneCalls[0].archiveID)
+	require.Equal(t, "noelia@jackal.im", tombstoneCalls[1].archiveID)
+
+	for _, call := range tombstoneCalls {
+		require.Equal(t, "ortuman@jackal.im", call.fromJID)
+		require.Equal(t, "origin-1", call.id)
+
+		tombstone := stravaganza.NewBuilderFromProto(call.tombstone).Build()
+		require.NotNil(t, tombstone.ChildNamespace("retracted", retractNamespace))
+		require.Nil(t, tombstone.Child("body"))
+	}
+	require.Len(t, ExtractSentArchiveID(execCtx.Context), 0)
+	require.Len(t, ExtractReceivedArchiveID(execCtx.Context), 0)
+}
+
+func TestMam_CorrectArchivedMessage(t *testing.T) {
+	// given
+	var updatedMessages []*stravaganza.PBElement
+
+	repMock := &repositoryMock{}
+	repMock.UpdateArchiveMessageFunc = func(ctx context.Context, archiveID, fromJID, id string, message *stravaganza.PBElement) error {
+		require.Equal(t, "ortuman@jackal.im", fromJID)
+		require.Equal(t, "m1", id)
+		updatedMessages = append(updatedMessages, message)
+		return nil
+	}
+
+	hosts := &hostsMock{}
+	hosts.IsLocalHostFunc = func(h string) bool { return h == "jackal.im" }
+
+	hk := hook.NewHooks()
+	mam := &Mam{
+		svc:    NewService(nil, hk, repMock, 100, nil, kitlog.NewNopLogger()),
+		hk:     hk,
+		hosts:  hosts,
+		logger: kitlog.NewNopLogger(),
+	}
+	_ = mam.Start(context.Background())
+	t.Cleanup(func() {
+		_ = mam.Stop(context.Background())
+	})
+
+	msg, _ := stravaganza.NewBuilderFromElement(testMessageStanzaWithParameters("b1", "ortuman@jackal.im/chamber", "noelia@jackal.im/yard")).
+		WithChild(
+			stravaganza.NewBuilder("replace").
+				WithAttribute(stravaganza.Namespace, correctNamespace).
+				WithAttribute("id", "m1").
+				Build(),
+		).
+		BuildMessage()
+
+	// when
+	execCtx := &hook.ExecutionContext{
+		Info: &hook.C2SStreamInfo{
+			Element: msg,
+		},
+		Context: context.Background(),
+	}
+	_, err := hk.Run(hook.C2SStreamMessageReceived, execCtx)
+	require.NoError(t, err)
+
+	_, err = hk.Run(hook.C2SStreamMessageRouted, execCtx)
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, updatedMessages, 2)
 
-	require.Equal(t, "ortuman", deletedArchiveID)
+	for _, updatedMsg := range updatedMessages {
+		corrected := stravaganza.NewBuilderFromProto(updatedMsg).Build()
+		require.Equal(t, "b1", corrected.Child("body").Text())
+		require.Nil(t, corrected.ChildNamespace("replace", correctNamespace))
+		require.Nil(t, corrected.ChildNamespace("stanza-id", stanzaIDNamespace))
+	}
+	routedMsg := execCtx.Info.(*hook.C2SStreamInfo).Element.(*stravaganza.Message)
+	require.Nil(t, routedMsg.ChildNamespace("stanza-id", stanzaIDNamespace))
+}
+
+func TestMam_GetPreferences(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	repMock := &repositoryMock{}
+	repMock.FetchArchivePreferencesFunc = func(ctx context.Context, archiveID string) (*archivemodel.Preferences, error) {
+		return &archivemodel.Preferences{
+			ArchiveId:   archiveID,
+			DefaultMode: archivemodel.RosterMode,
+			Always:      []string{"noelia@jackal.im"},
+			Never:       []string{"witch1@jackal.im", "witch2@jackal.im"},
+		}, nil
+	}
+	mam := &Mam{
+		svc:    NewService(routerMock, hook.NewHooks(), repMock, 100, nil, kitlog.NewNopLogger()),
+		router: routerMock,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "juliet2").
+		WithAttribute(stravaganza.Type, stravaganza.GetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("prefs").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				Build(),
+		).
+		BuildIQ()
+
+	// when
+	_ = mam.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ResultType, respStanzas[0].Type())
+
+	prefs := respStanzas[0].ChildNamespace("prefs", mamNamespace)
+	require.NotNil(t, prefs)
+	require.Equal(t, archivemodel.RosterMode, prefs.Attribute("default"))
+
+	require.Len(t, prefs.Child("always").Children("jid"), 1)
+	require.Len(t, prefs.Child("never").Children("jid"), 2)
+
+	require.Len(t, repMock.FetchArchivePreferencesCalls(), 1)
+	require.Equal(t, "ortuman@jackal.im", repMock.FetchArchivePreferencesCalls()[0].ArchiveID)
+}
+
+func TestMam_SetPreferences(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	var storedPrefs *archivemodel.Preferences
+
+	repMock := &repositoryMock{}
+	repMock.UpsertArchivePreferencesFunc = func(ctx context.Context, prefs *archivemodel.Preferences) error {
+		storedPrefs = prefs
+		return nil
+	}
+	mam := &Mam{
+		svc:    NewService(routerMock, hook.NewHooks(), repMock, 100, nil, kitlog.NewNopLogger()),
+		router: routerMock,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "juliet3").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("prefs").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				WithAttribute("default", "never").
+				WithChild(
+					stravaganza.NewBuilder("always").
+						WithChild(stravaganza.NewBuilder("jid").WithText("noelia@jackal.im").Build()).
+						Build(),
+				).
+				WithChild(stravaganza.NewBuilder("never").Build()).
+				Build(),
+		).
+		BuildIQ()
+
+	// when
+	_ = mam.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ResultType, respStanzas[0].Type())
+
+	require.NotNil(t, storedPrefs)
+	require.Equal(t, "ortuman@jackal.im", storedPrefs.ArchiveId)
+	require.Equal(t, archivemodel.NeverMode, storedPrefs.DefaultMode)
+	require.Equal(t, []string{"noelia@jackal.im"}, storedPrefs.Always)
+	require.Len(t, storedPrefs.Never, 0)
+
+	prefs := respStanzas[0].ChildNamespace("prefs", mamNamespace)
+	require.NotNil(t, prefs)
+	require.Equal(t, archivemodel.NeverMode, prefs.Attribute("default"))
+}
+
+func TestMam_SetInvalidPreferences(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	repMock := &repositoryMock{}
+
+	mam := &Mam{
+		svc:    NewService(routerMock, hook.NewHooks(), repMock, 100, nil, kitlog.NewNopLogger()),
+		router: routerMock,
+		logger: kitlog.NewNopLogger(),
+	}
+
+	iq, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "juliet3").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("prefs").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				WithAttribute("default", "sometimes").
+				Build(),
+		).
+		BuildIQ()
+
+	// when
+	_ = mam.ProcessIQ(context.Background(), iq)
+
+	// then
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ErrorType, respStanzas[0].Type())
+	require.Len(t, repMock.UpsertArchivePreferencesCalls(), 0)
 }
 
 func testMessageStanzaWithParameters(body, from, to string) *stravaganza.Message {
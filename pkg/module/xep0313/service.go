@@ -17,6 +17,7 @@ package xep0313
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	kitlog "github.com/go-kit/log"
@@ -24,6 +25,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackal-xmpp/stravaganza"
 	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/hook"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	"github.com/ortuman/jackal/pkg/module/xep0004"
@@ -40,66 +42,118 @@ const (
 
 	defaultPageSize = 50
 	maxPageSize     = 250
+
+	fullTextFieldVar = "{" + fullTextNamespace + "}fulltext"
 )
 
+// AccessCheckerFunc tells whether the requester of a MAM IQ is allowed to access the archive identified by archiveID.
+type AccessCheckerFunc func(ctx context.Context, iq *stravaganza.IQ, archiveID string) (bool, error)
+
 // Service represents a MAM service.
 type Service struct {
-	router       router.Router
-	hk           *hook.Hooks
-	rep          repository.Repository
-	logger       kitlog.Logger
-	maxQueueSize int
+	router          router.Router
+	hk              *hook.Hooks
+	rep             repository.Repository
+	logger          kitlog.Logger
+	maxQueueSize    int
+	accessCheckerFn AccessCheckerFunc
+	userArchives    bool
 }
 
 // NewService returns a new archive service instance.
+//
+// Archives are keyed by the bare JID of the entity owning them, so that a service instance can manage
+// either user archives or those owned by a different entity (e.g., a group chat room).
+// maxQueueSize sets the retention of every managed archive, while accessCheckerFn is consulted before
+// serving any MAM IQ. If no access checker is provided, archive access will be granted only to its owner,
+// and archives are considered to be owned by local users, which is required to support 'roster' archiving mode.
 func NewService(
 	router router.Router,
 	hk *hook.Hooks,
 	rep repository.Repository,
 	maxQueueSize int,
+	accessCheckerFn AccessCheckerFunc,
 	logger kitlog.Logger,
 ) *Service {
+	userArchives := accessCheckerFn == nil
+	if userArchives {
+		accessCheckerFn = isArchiveOwner
+	}
 	return &Service{
-		router:       router,
-		hk:           hk,
-		rep:          rep,
-		maxQueueSize: maxQueueSize,
-		logger:       logger,
+		router:          router,
+		hk:              hk,
+		rep:             rep,
+		maxQueueSize:    maxQueueSize,
+		accessCheckerFn: accessCheckerFn,
+		userArchives:    userArchives,
+		logger:          logger,
 	}
 }
 
-// ProcessIQ processes a MAM IQ.
+// ProcessIQ processes a MAM IQ addressed to the archive owner entity.
 func (m *Service) ProcessIQ(ctx context.Context, iq *stravaganza.IQ, onArchiveRequestedFn func(archiveID string) error) error {
+	archiveID := iq.ToJID().ToBareJID().String()
+
+	allowed, err := m.accessCheckerFn(ctx, iq, archiveID)
+	if err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	if !allowed {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.Forbidden))
+		return nil
+	}
 	switch {
 	case iq.IsGet() && iq.ChildNamespace("metadata", mamNamespace) != nil:
-		return m.queryMetadata(ctx, iq)
+		return m.queryMetadata(ctx, iq, archiveID)
 
 	case iq.IsGet() && iq.ChildNamespace("query", mamNamespace) != nil:
 		return m.formFields(ctx, iq)
 
+	case iq.IsGet() && iq.ChildNamespace("prefs", mamNamespace) != nil:
+		return m.getPreferences(ctx, iq, archiveID)
+
+	case iq.IsSet() && iq.ChildNamespace("prefs", mamNamespace) != nil:
+		return m.setPreferences(ctx, iq, archiveID)
+
 	case iq.IsSet() && iq.ChildNamespace("query", mamNamespace) != nil:
-		if err := m.queryArchive(ctx, iq); err != nil {
+		if err := m.queryArchive(ctx, iq, archiveID); err != nil {
 			return err
 		}
 		if onArchiveRequestedFn != nil {
-			archiveID := iq.ToJID().ToBareJID().String()
 			return onArchiveRequestedFn(archiveID)
 		}
 	}
 	return nil
 }
 
-// ArchiveMessage archives a message.
-func (m *Service) ArchiveMessage(ctx context.Context, message *stravaganza.Message, archiveID, id string) error {
+// ArchiveMessage archives a message honoring archive owner preferences.
+// Retractions (XEP-0424) and corrections (XEP-0308) are applied to the referenced archived message instead of being stored.
+// The returned boolean value tells whether the message was finally stored.
+func (m *Service) ArchiveMessage(ctx context.Context, message *stravaganza.Message, archiveID, id string) (bool, error) {
+	if refID := retractedMessageID(message); len(refID) > 0 {
+		return false, m.retractMessage(ctx, message, archiveID, refID)
+	}
+	if refID := correctedMessageID(message); len(refID) > 0 {
+		return false, m.correctMessage(ctx, message, archiveID, refID)
+	}
+	allowed, err := m.IsArchivingAllowed(ctx, message, archiveID)
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		return false, nil
+	}
 	archiveMsg := &archivemodel.Message{
 		ArchiveId: archiveID,
 		Id:        id,
+		OriginId:  messageOriginID(message),
 		FromJid:   message.FromJID().String(),
 		ToJid:     message.ToJID().String(),
 		Message:   message.Proto(),
 		Stamp:     timestamppb.Now(),
 	}
-	err := m.rep.InTransaction(ctx, func(ctx context.Context, tx repository.Transaction) error {
+	err = m.rep.InTransaction(ctx, func(ctx context.Context, tx repository.Transaction) error {
 		err := tx.InsertArchiveMessage(ctx, archiveMsg)
 		if err != nil {
 			return err
@@ -107,19 +161,172 @@ func (m *Service) ArchiveMessage(ctx context.Context, message *stravaganza.Messa
 		return tx.DeleteArchiveOldestMessages(ctx, archiveID, m.maxQueueSize)
 	})
 	if err != nil {
-		return err
+		return false, err
 	}
-	return m.runHook(ctx, hook.ArchiveMessageArchived, &hook.MamInfo{
+	err = m.runHook(ctx, hook.ArchiveMessageArchived, &hook.MamInfo{
 		ArchiveID: archiveID,
 		Message:   archiveMsg,
 	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *Service) retractMessage(ctx context.Context, message *stravaganza.Message, archiveID, refID string) error {
+	tombstone, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, message.FromJID().String()).
+		WithAttribute(stravaganza.To, message.ToJID().String()).
+		WithAttribute(stravaganza.Type, message.Type()).
+		WithAttribute(stravaganza.ID, refID).
+		WithChild(
+			stravaganza.NewBuilder("retracted").
+				WithAttribute(stravaganza.Namespace, retractNamespace).
+				WithAttribute("stamp", time.Now().UTC().Format(dateTimeFormat)).
+				Build(),
+		).
+		BuildMessage()
+
+	fromJID := message.FromJID().ToBareJID().String()
+	if err := m.rep.TombstoneArchiveMessage(ctx, archiveID, fromJID, refID, tombstone.Proto()); err != nil {
+		return err
+	}
+	return m.runHook(ctx, hook.ArchiveMessageRetracted, &hook.MamInfo{
+		ArchiveID: archiveID,
+		Message: &archivemodel.Message{
+			ArchiveId: archiveID,
+			Id:        refID,
+			FromJid:   message.FromJID().String(),
+			ToJid:     message.ToJID().String(),
+			Message:   tombstone.Proto(),
+			Stamp:     timestamppb.Now(),
+		},
+	})
+}
+
+func (m *Service) correctMessage(ctx context.Context, message *stravaganza.Message, archiveID, refID string) error {
+	corrected, _ := stravaganza.NewBuilderFromElement(message).
+		WithoutChildrenNamespace("replace", correctNamespace).
+		WithoutChildrenNamespace("stanza-id", stanzaIDNamespace).
+		BuildMessage()
+
+	fromJID := message.FromJID().ToBareJID().String()
+	if err := m.rep.UpdateArchiveMessage(ctx, archiveID, fromJID, refID, corrected.Proto()); err != nil {
+		return err
+	}
+	return m.runHook(ctx, hook.ArchiveMessageCorrected, &hook.MamInfo{
+		ArchiveID: archiveID,
+		Message: &archivemodel.Message{
+			ArchiveId: archiveID,
+			Id:        refID,
+			FromJid:   message.FromJID().String(),
+			ToJid:     message.ToJID().String(),
+			Message:   corrected.Proto(),
+			Stamp:     timestamppb.Now(),
+		},
+	})
+}
+
+// IsArchivingAllowed tells whether a message can be stored into an archive according to its owner preferences.
+func (m *Service) IsArchivingAllowed(ctx context.Context, message *stravaganza.Message, archiveID string) (bool, error) {
+	prefs, err := m.rep.FetchArchivePreferences(ctx, archiveID)
+	if err != nil {
+		return false, err
+	}
+	if prefs == nil {
+		return true, nil // archive everything by default
+	}
+	withJID := message.FromJID()
+	if withJID.ToBareJID().String() == archiveID {
+		withJID = message.ToJID()
+	}
+	// 'never' list takes precedence over 'always' one
+	switch {
+	case matchesAnyJID(withJID, prefs.Never):
+		return false, nil
+	case matchesAnyJID(withJID, prefs.Always):
+		return true, nil
+	}
+	switch prefs.DefaultMode {
+	case archivemodel.NeverMode:
+		return false, nil
+
+	case archivemodel.RosterMode:
+		if !m.userArchives {
+			return false, nil // no roster to match against
+		}
+		archiveJID, err := jid.NewWithString(archiveID, true)
+		if err != nil {
+			return false, err
+		}
+		ri, err := m.rep.FetchRosterItem(ctx, archiveJID.Node(), withJID.ToBareJID().String())
+		if err != nil {
+			return false, err
+		}
+		return ri != nil, nil
+
+	default:
+		return true, nil
+	}
 }
 
 // DeleteArchive deletes an archive.
 func (m *Service) DeleteArchive(ctx context.Context, archiveID string) error {
+	if err := m.rep.DeleteArchivePreferences(ctx, archiveID); err != nil {
+		return err
+	}
 	return m.rep.DeleteArchive(ctx, archiveID)
 }
 
+func (m *Service) getPreferences(ctx context.Context, iq *stravaganza.IQ, archiveID string) error {
+	prefs, err := m.rep.FetchArchivePreferences(ctx, archiveID)
+	if err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	if prefs == nil {
+		prefs = &archivemodel.Preferences{
+			ArchiveId:   archiveID,
+			DefaultMode: archivemodel.AlwaysMode,
+		}
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, preferencesToElement(prefs)))
+
+	level.Info(m.logger).Log("msg", "requested archive preferences", "archive_id", archiveID)
+
+	return nil
+}
+
+func (m *Service) setPreferences(ctx context.Context, iq *stravaganza.IQ, archiveID string) error {
+	prefs, err := elementToPreferences(iq.ChildNamespace("prefs", mamNamespace))
+	if err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.BadRequest))
+		return nil
+	}
+	if prefs.DefaultMode == archivemodel.RosterMode && !m.userArchives {
+		// only user archives have an owner roster
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.FeatureNotImplemented))
+		return nil
+	}
+	prefs.ArchiveId = archiveID
+
+	if err := m.rep.UpsertArchivePreferences(ctx, prefs); err != nil {
+		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
+		return err
+	}
+	if err := m.runHook(ctx, hook.ArchivePreferencesUpdated, &hook.MamInfo{
+		ArchiveID:   archiveID,
+		Preferences: prefs,
+	}); err != nil {
+		return err
+	}
+	_, _ = m.router.Route(ctx, xmpputil.MakeResultIQ(iq, preferencesToElement(prefs)))
+
+	level.Info(m.logger).Log("msg", "updated archive preferences", "archive_id", archiveID, "default", prefs.DefaultMode)
+
+	return nil
+}
+
 func (m *Service) formFields(ctx context.Context, iq *stravaganza.IQ) error {
 	form := xep0004.DataForm{
 		Type: xep0004.Form,
@@ -150,6 +357,10 @@ func (m *Service) formFields(ctx context.Context, iq *stravaganza.IQ) error {
 		Type: xep0004.TextSingle,
 		Var:  "after-id",
 	})
+	form.Fields = append(form.Fields, xep0004.Field{
+		Type: xep0004.TextSingle,
+		Var:  fullTextFieldVar,
+	})
 	form.Fields = append(form.Fields, xep0004.Field{
 		Type: xep0004.ListMulti,
 		Var:  "ids",
@@ -171,9 +382,7 @@ func (m *Service) formFields(ctx context.Context, iq *stravaganza.IQ) error {
 	return nil
 }
 
-func (m *Service) queryMetadata(ctx context.Context, iq *stravaganza.IQ) error {
-	archiveID := iq.FromJID().ToBareJID().String()
-
+func (m *Service) queryMetadata(ctx context.Context, iq *stravaganza.IQ, archiveID string) error {
 	metadata, err := m.rep.FetchArchiveMetadata(ctx, archiveID)
 	if err != nil {
 		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
@@ -203,7 +412,7 @@ func (m *Service) queryMetadata(ctx context.Context, iq *stravaganza.IQ) error {
 	return nil
 }
 
-func (m *Service) queryArchive(ctx context.Context, iq *stravaganza.IQ) error {
+func (m *Service) queryArchive(ctx context.Context, iq *stravaganza.IQ, archiveID string) error {
 	qChild := iq.ChildNamespace("query", mamNamespace)
 
 	// filter archive result
@@ -218,8 +427,6 @@ func (m *Service) queryArchive(ctx context.Context, iq *stravaganza.IQ) error {
 			return err
 		}
 	}
-	archiveID := iq.FromJID().ToBareJID().String()
-
 	messages, err := m.rep.FetchArchiveMessages(ctx, filters, archiveID)
 	if err != nil {
 		_, _ = m.router.Route(ctx, xmpputil.MakeErrorStanza(iq, stanzaerror.InternalServerError))
@@ -325,6 +532,10 @@ func (m *Service) runHook(ctx context.Context, hookName string, inf *hook.MamInf
 	return err
 }
 
+func isArchiveOwner(_ context.Context, iq *stravaganza.IQ, archiveID string) (bool, error) {
+	return iq.FromJID().ToBareJID().String() == archiveID, nil
+}
+
 func formToFilters(fm *xep0004.DataForm) (*archivemodel.Filters, error) {
 	var retVal archivemodel.Filters
 
@@ -358,9 +569,109 @@ func formToFilters(fm *xep0004.DataForm) (*archivemodel.Filters, error) {
 	if ids := fm.Fields.ValuesForField("ids"); len(ids) > 0 {
 		retVal.Ids = ids
 	}
+	if fullText := fm.Fields.ValueForField(fullTextFieldVar); len(fullText) > 0 {
+		retVal.FullText = fullText
+	}
+	return &retVal, nil
+}
+
+func elementToPreferences(elem stravaganza.Element) (*archivemodel.Preferences, error) {
+	var retVal archivemodel.Preferences
+
+	defaultMode := elem.Attribute("default")
+	switch defaultMode {
+	case archivemodel.AlwaysMode, archivemodel.NeverMode, archivemodel.RosterMode:
+		retVal.DefaultMode = defaultMode
+	default:
+		return nil, fmt.Errorf("xep0313: unrecognized default preference mode: %s", defaultMode)
+	}
+	var err error
+	if always := elem.Child("always"); always != nil {
+		retVal.Always, err = elementJIDs(always)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if never := elem.Child("never"); never != nil {
+		retVal.Never, err = elementJIDs(never)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &retVal, nil
 }
 
+func elementJIDs(elem stravaganza.Element) ([]string, error) {
+	var retVal []string
+	for _, jidElem := range elem.Children("jid") {
+		jd, err := jid.NewWithString(jidElem.Text(), false)
+		if err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, jd.String())
+	}
+	return retVal, nil
+}
+
+func preferencesToElement(prefs *archivemodel.Preferences) stravaganza.Element {
+	alwaysB := stravaganza.NewBuilder("always")
+	for _, j := range prefs.Always {
+		alwaysB.WithChild(stravaganza.NewBuilder("jid").WithText(j).Build())
+	}
+	neverB := stravaganza.NewBuilder("never")
+	for _, j := range prefs.Never {
+		neverB.WithChild(stravaganza.NewBuilder("jid").WithText(j).Build())
+	}
+	return stravaganza.NewBuilder("prefs").
+		WithAttribute(stravaganza.Namespace, mamNamespace).
+		WithAttribute("default", prefs.DefaultMode).
+		WithChildren(alwaysB.Build(), neverB.Build()).
+		Build()
+}
+
+func matchesAnyJID(jd *jid.JID, jids []string) bool {
+	for _, j := range jids {
+		if j == jd.String() || j == jd.ToBareJID().String() {
+			return true
+		}
+	}
+	return false
+}
+
+func messageOriginID(msg *stravaganza.Message) string {
+	if originID := msg.ChildNamespace("origin-id", stanzaIDNamespace); originID != nil {
+		return originID.Attribute("id")
+	}
+	return msg.Attribute(stravaganza.ID)
+}
+
+func retractedMessageID(msg *stravaganza.Message) string {
+	if retract := msg.ChildNamespace("retract", retractNamespace); retract != nil {
+		return retract.Attribute("id")
+	}
+	// message fastening based retraction (XEP-0424 v0.3 and earlier)
+	applyTo := msg.ChildNamespace("apply-to", fastenNamespace)
+	if applyTo != nil && applyTo.ChildNamespace("retract", legacyRetractNamespace) != nil {
+		return applyTo.Attribute("id")
+	}
+	return ""
+}
+
+func correctedMessageID(msg *stravaganza.Message) string {
+	replace := msg.ChildNamespace("replace", correctNamespace)
+	if replace == nil {
+		return ""
+	}
+	return replace.Attribute("id")
+}
+
+func isMessageAmendment(msg *stravaganza.Message) bool {
+	if !msg.IsNormal() && !msg.IsChat() {
+		return false
+	}
+	return len(retractedMessageID(msg)) > 0 || len(correctedMessageID(msg)) > 0
+}
+
 // IsMessageArchievable returns true if the message is archievable.
 func IsMessageArchievable(msg *stravaganza.Message) bool {
 	return (msg.IsNormal() || msg.IsChat()) && msg.IsMessageWithBody()
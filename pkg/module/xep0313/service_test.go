@@ -15,11 +15,17 @@
 package xep0313
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	"github.com/ortuman/jackal/pkg/module/xep0004"
+	"github.com/ortuman/jackal/pkg/storage/repository"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -81,6 +87,18 @@ func TestManager_FormToFields(t *testing.T) {
 				Ids: []string{"28482-98726-73623", "09af3-cc343-b409f"},
 			},
 		},
+		"full text": {
+			form: &xep0004.DataForm{
+				Type: xep0004.Submit,
+				Fields: []xep0004.Field{
+					{Var: xep0004.FormType, Type: xep0004.Hidden, Values: []string{mamNamespace}},
+					{Var: "{urn:xmpp:fulltext:0}fulltext", Values: []string{"where art thou"}},
+				},
+			},
+			filters: &archivemodel.Filters{
+				FullText: "where art thou",
+			},
+		},
 	}
 	for tn, tc := range tcs {
 		t.Run(tn, func(t *testing.T) {
@@ -91,3 +109,150 @@ func TestManager_FormToFields(t *testing.T) {
 		})
 	}
 }
+
+func TestService_EntityArchive(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+
+	var archivedMessages []*archivemodel.Message
+
+	txMock := &txMock{}
+	txMock.InsertArchiveMessageFunc = func(ctx context.Context, message *archivemodel.Message) error {
+		archivedMessages = append(archivedMessages, message)
+		return nil
+	}
+	txMock.DeleteArchiveOldestMessagesFunc = func(ctx context.Context, archiveID string, maxElements int) error {
+		require.Equal(t, 10, maxElements)
+		return nil
+	}
+
+	repMock := &repositoryMock{}
+	repMock.InTransactionFunc = func(ctx context.Context, f func(ctx context.Context, tx repository.Transaction) error) error {
+		return f(ctx, txMock)
+	}
+	repMock.FetchArchivePreferencesFunc = func(ctx context.Context, archiveID string) (*archivemodel.Preferences, error) {
+		return nil, nil
+	}
+	repMock.FetchArchiveMessagesFunc = func(ctx context.Context, f *archivemodel.Filters, archiveID string) ([]*archivemodel.Message, error) {
+		return archivedMessages, nil
+	}
+
+	svc := NewService(routerMock, hook.NewHooks(), repMock, 10, func(_ context.Context, iq *stravaganza.IQ, archiveID string) (bool, error) {
+		return archiveID == "lobby@muc.jackal.im" && iq.FromJID().Node() == "ortuman", nil
+	}, kitlog.NewNopLogger())
+
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "lobby@muc.jackal.im/ortuman").
+		WithAttribute(stravaganza.To, "lobby@muc.jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.GroupChatType).
+		WithChild(
+			stravaganza.NewBuilder("body").
+				WithText("Hi all!").
+				Build(),
+		).
+		BuildMessage()
+
+	queryIQ := func(from string) *stravaganza.IQ {
+		iq, _ := stravaganza.NewIQBuilder().
+			WithAttribute(stravaganza.ID, "q1").
+			WithAttribute(stravaganza.Type, stravaganza.SetType).
+			WithAttribute(stravaganza.From, from).
+			WithAttribute(stravaganza.To, "lobby@muc.jackal.im").
+			WithChild(
+				stravaganza.NewBuilder("query").
+					WithAttribute(stravaganza.Namespace, mamNamespace).
+					Build(),
+			).
+			BuildIQ()
+		return iq
+	}
+
+	// when
+	archived, err := svc.ArchiveMessage(context.Background(), msg, "lobby@muc.jackal.im", "m1")
+	require.NoError(t, err)
+
+	var requestedArchiveID string
+	err = svc.ProcessIQ(context.Background(), queryIQ("ortuman@jackal.im/chamber"), func(archiveID string) error {
+		requestedArchiveID = archiveID
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = svc.ProcessIQ(context.Background(), queryIQ("noelia@jackal.im/yard"), nil)
+	require.NoError(t, err)
+
+	// then
+	require.True(t, archived)
+	require.Len(t, archivedMessages, 1)
+	require.Equal(t, "lobby@muc.jackal.im", archivedMessages[0].ArchiveId)
+	require.Equal(t, "lobby@muc.jackal.im", requestedArchiveID)
+
+	require.Len(t, repMock.FetchArchiveMessagesCalls(), 1)
+	require.Equal(t, "lobby@muc.jackal.im", repMock.FetchArchiveMessagesCalls()[0].ArchiveID)
+
+	require.Len(t, respStanzas, 3)
+	require.Equal(t, "message", respStanzas[0].Name())
+	require.Equal(t, "lobby@muc.jackal.im", respStanzas[0].Attribute(stravaganza.From))
+	require.Equal(t, stravaganza.ResultType, respStanzas[1].Attribute(stravaganza.Type))
+	require.Equal(t, stravaganza.ErrorType, respStanzas[2].Attribute(stravaganza.Type))
+	require.Equal(t, "noelia@jackal.im/yard", respStanzas[2].Attribute(stravaganza.To))
+}
+
+func TestService_EntityArchiveRosterMode(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	var respStanzas []stravaganza.Stanza
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		respStanzas = append(respStanzas, stanza)
+		return nil, nil
+	}
+	repMock := &repositoryMock{}
+	repMock.FetchArchivePreferencesFunc = func(ctx context.Context, archiveID string) (*archivemodel.Preferences, error) {
+		return &archivemodel.Preferences{ArchiveId: archiveID, DefaultMode: archivemodel.RosterMode}, nil
+	}
+	svc := NewService(routerMock, hook.NewHooks(), repMock, 10, func(_ context.Context, _ *stravaganza.IQ, _ string) (bool, error) {
+		return true, nil
+	}, kitlog.NewNopLogger())
+
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "lobby@muc.jackal.im/ortuman").
+		WithAttribute(stravaganza.To, "lobby@muc.jackal.im").
+		WithAttribute(stravaganza.Type, stravaganza.GroupChatType).
+		BuildMessage()
+
+	prefsIQ, _ := stravaganza.NewIQBuilder().
+		WithAttribute(stravaganza.ID, "p1").
+		WithAttribute(stravaganza.Type, stravaganza.SetType).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/chamber").
+		WithAttribute(stravaganza.To, "lobby@muc.jackal.im").
+		WithChild(
+			stravaganza.NewBuilder("prefs").
+				WithAttribute(stravaganza.Namespace, mamNamespace).
+				WithAttribute("default", archivemodel.RosterMode).
+				Build(),
+		).
+		BuildIQ()
+
+	// when
+	allowed, err := svc.IsArchivingAllowed(context.Background(), msg, "lobby@muc.jackal.im")
+	require.NoError(t, err)
+
+	err = svc.ProcessIQ(context.Background(), prefsIQ, nil)
+	require.NoError(t, err)
+
+	// then
+	require.False(t, allowed)
+	require.Len(t, repMock.FetchRosterItemCalls(), 0)
+	require.Len(t, repMock.UpsertArchivePreferencesCalls(), 0)
+
+	require.Len(t, respStanzas, 1)
+	require.Equal(t, stravaganza.ErrorType, respStanzas[0].Attribute(stravaganza.Type))
+	require.NotNil(t, respStanzas[0].Child("error").Child("feature-not-implemented"))
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Config defines port multiplexing listener configuration.
+type Config struct {
+	// Enabled tells whether the multiplexing listener should be started.
+	Enabled bool `fig:"enabled"`
+
+	// BindAddr defines listener incoming connections address.
+	BindAddr string `fig:"bind_addr"`
+
+	// Port defines listener incoming connections port.
+	Port int `fig:"port" default:"443"`
+
+	// HandshakeTimeout defines the maximum amount of time a TLS handshake may take.
+	HandshakeTimeout time.Duration `fig:"handshake_timeout" default:"10s"`
+
+	// DefaultService defines the service connections are dispatched to whenever
+	// neither SNI nor ALPN identify a target (c2s, s2s, component or http).
+	DefaultService string `fig:"default_service" default:"c2s"`
+
+	// SNI defines the set of server name dispatching rules.
+	// These take precedence over the ALPN protocol negotiated by the client.
+	SNI []SNIConfig `fig:"sni"`
+}
+
+// SNIConfig defines a server name dispatching rule.
+type SNIConfig struct {
+	// ServerName defines the server name to be matched. A leading '*.' matches any subdomain.
+	ServerName string `fig:"server_name"`
+
+	// Service defines the target service (c2s, s2s, component or http).
+	Service string `fig:"service"`
+}
+
+// Validate checks whether multiplexing configuration values are supported.
+func (c Config) Validate() error {
+	if _, ok := serviceProtocols[c.DefaultService]; !ok {
+		return fmt.Errorf("mux: unrecognized default service: %s", c.DefaultService)
+	}
+	for _, rule := range c.SNI {
+		if len(rule.ServerName) == 0 {
+			return errors.New("mux: SNI rule server name must be specified")
+		}
+		if _, ok := serviceProtocols[rule.Service]; !ok {
+			return fmt.Errorf("mux: unrecognized SNI rule service: %s", rule.Service)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"net"
+	"sync"
+)
+
+type connListenerAddr struct{}
+
+func (connListenerAddr) Network() string { return "mux" }
+func (connListenerAddr) String() string  { return "mux" }
+
+// ConnListener is a net.Listener whose connections are handed over by a multiplexing listener.
+type ConnListener struct {
+	connCh    chan net.Conn
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewConnListener returns a new initialized ConnListener instance.
+func NewConnListener() *ConnListener {
+	return &ConnListener{
+		connCh:  make(chan net.Conn),
+		closeCh: make(chan struct{}),
+	}
+}
+
+// HandleConn hands over conn to the listener accepting goroutine.
+func (l *ConnListener) HandleConn(conn net.Conn) {
+	select {
+	case l.connCh <- conn:
+	case <-l.closeCh:
+		_ = conn.Close()
+	}
+}
+
+// Accept waits for and returns the next handed over connection.
+func (l *ConnListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.connCh:
+		return conn, nil
+	case <-l.closeCh:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes the listener.
+func (l *ConnListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closeCh) })
+	return nil
+}
+
+// Addr returns the listener network address.
+func (l *ConnListener) Addr() net.Addr {
+	return connListenerAddr{}
+}
@@ -0,0 +1,244 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/ortuman/jackal/pkg/host"
+)
+
+const (
+	// C2SService identifies client-to-server connections.
+	C2SService = "c2s"
+
+	// S2SService identifies server-to-server connections.
+	S2SService = "s2s"
+
+	// ComponentService identifies external component (XEP-0114) connections.
+	ComponentService = "component"
+
+	// HTTPService identifies HTTP connections.
+	HTTPService = "http"
+)
+
+const listenKeepAlive = time.Second * 15
+
+// ALPN protocol identifiers associated to each service (XEP-0368).
+var serviceProtocols = map[string][]string{
+	C2SService:       {"xmpp-client"},
+	S2SService:       {"xmpp-server"},
+	ComponentService: nil,
+	HTTPService:      {"h2", "http/1.1"},
+}
+
+// ConnHandler defines the interface implemented by services receiving multiplexed connections.
+type ConnHandler interface {
+	// HandleConn handles a connection whose TLS handshake has already been completed.
+	HandleConn(conn net.Conn)
+}
+
+// Listener represents a direct TLS listener that dispatches incoming connections to
+// C2S, S2S, component or HTTP services based on SNI and ALPN.
+type Listener struct {
+	cfg    Config
+	hosts  *host.Hosts
+	logger kitlog.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]ConnHandler
+
+	ln     net.Listener
+	active uint32
+}
+
+// New returns a new initialized multiplexing Listener.
+func New(cfg Config, hosts *host.Hosts, logger kitlog.Logger) *Listener {
+	return &Listener{
+		cfg:      cfg,
+		hosts:    hosts,
+		handlers: make(map[string]ConnHandler),
+		logger:   logger,
+	}
+}
+
+// Handle registers the connection handler associated to a service.
+func (l *Listener) Handle(service string, hnd ConnHandler) {
+	l.mu.Lock()
+	l.handlers[service] = hnd
+	l.mu.Unlock()
+}
+
+// Start starts listening on a TCP network address to dispatch incoming connections.
+func (l *Listener) Start(ctx context.Context) error {
+	if err := l.cfg.Validate(); err != nil {
+		return err
+	}
+	lc := net.ListenConfig{
+		KeepAlive: listenKeepAlive,
+	}
+	ln, err := lc.Listen(ctx, "tcp", l.getAddress())
+	if err != nil {
+		return err
+	}
+	l.ln = ln
+	l.active = 1
+
+	go func() {
+		for atomic.LoadUint32(&l.active) == 1 {
+			conn, err := l.ln.Accept()
+			if err != nil {
+				continue
+			}
+			go l.handleConn(conn)
+		}
+	}()
+	level.Info(l.logger).Log("msg", "accepting multiplexed socket connections",
+		"bind_addr", l.getAddress(),
+		"services", strings.Join(l.services(), ","),
+	)
+	return nil
+}
+
+// Stop stops dispatching incoming connections and closes underlying TCP listener.
+func (l *Listener) Stop(_ context.Context) error {
+	atomic.StoreUint32(&l.active, 0)
+	if err := l.ln.Close(); err != nil {
+		return err
+	}
+	level.Info(l.logger).Log("msg", "stopped multiplexing listener", "bind_addr", l.getAddress())
+	return nil
+}
+
+func (l *Listener) handleConn(conn net.Conn) {
+	tlsConn := tls.Server(conn, &tls.Config{
+		GetConfigForClient: l.getConfigForClient,
+		MinVersion:         tls.VersionTLS12,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), l.cfg.HandshakeTimeout)
+	defer cancel()
+
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		level.Debug(l.logger).Log("msg", "failed to complete multiplexed TLS handshake",
+			"remote_address", conn.RemoteAddr().String(),
+			"err", err,
+		)
+		_ = conn.Close()
+		return
+	}
+	st := tlsConn.ConnectionState()
+
+	var protos []string
+	if len(st.NegotiatedProtocol) > 0 {
+		protos = []string{st.NegotiatedProtocol}
+	}
+	service := l.route(st.ServerName, protos)
+	hnd := l.handler(service)
+	if hnd == nil {
+		_ = tlsConn.Close()
+		return
+	}
+	level.Debug(l.logger).Log("msg", "dispatching multiplexed connection",
+		"service", service,
+		"server_name", st.ServerName,
+		"alpn", st.NegotiatedProtocol,
+		"remote_address", conn.RemoteAddr().String(),
+	)
+	hnd.HandleConn(tlsConn)
+}
+
+func (l *Listener) getConfigForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	service := l.route(hello.ServerName, hello.SupportedProtos)
+	if l.handler(service) == nil {
+		return nil, fmt.Errorf("mux: no handler registered for service '%s'", service)
+	}
+	cfg := &tls.Config{
+		GetCertificate: l.hosts.GetCertificate,
+		NextProtos:     serviceProtocols[service],
+		MinVersion:     tls.VersionTLS12,
+	}
+	if service == S2SService {
+		cfg.ClientAuth = tls.RequestClientCert // required by SASL EXTERNAL authentication
+	}
+	return cfg, nil
+}
+
+// route returns the service a connection should be dispatched to.
+// SNI rules are evaluated first, then the client offered ALPN protocols in preference order.
+func (l *Listener) route(serverName string, protos []string) string {
+	serverName = strings.ToLower(serverName)
+	for _, rule := range l.cfg.SNI {
+		if matchServerName(strings.ToLower(rule.ServerName), serverName) {
+			return rule.Service
+		}
+	}
+	for _, proto := range protos {
+		for service, serviceProtos := range serviceProtocols {
+			if !contains(serviceProtos, proto) || l.handler(service) == nil {
+				continue
+			}
+			return service
+		}
+	}
+	return l.cfg.DefaultService
+}
+
+func (l *Listener) handler(service string) ConnHandler {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.handlers[service]
+}
+
+func (l *Listener) services() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var ret []string
+	for _, service := range []string{C2SService, S2SService, ComponentService, HTTPService} {
+		if _, ok := l.handlers[service]; ok {
+			ret = append(ret, service)
+		}
+	}
+	return ret
+}
+
+func (l *Listener) getAddress() string {
+	return l.cfg.BindAddr + ":" + strconv.Itoa(l.cfg.Port)
+}
+
+func matchServerName(pattern, serverName string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(serverName, pattern[1:])
+	}
+	return pattern == serverName
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/stretchr/testify/require"
+)
+
+type testConnHandler struct {
+	service string
+	connCh  chan string
+}
+
+func (h *testConnHandler) HandleConn(conn net.Conn) {
+	h.connCh <- h.service
+	_ = conn.Close()
+}
+
+func TestListener_Dispatch(t *testing.T) {
+	var tcs = map[string]struct {
+		serverName      string
+		protos          []string
+		expectedService string
+		expectedProto   string
+	}{
+		"C2S ALPN": {
+			serverName:      "jackal.im",
+			protos:          []string{"xmpp-client"},
+			expectedService: C2SService,
+			expectedProto:   "xmpp-client",
+		},
+		"S2S ALPN": {
+			serverName:      "jackal.im",
+			protos:          []string{"xmpp-server"},
+			expectedService: S2SService,
+			expectedProto:   "xmpp-server",
+		},
+		"HTTP ALPN": {
+			serverName:      "jackal.im",
+			protos:          []string{"h2", "http/1.1"},
+			expectedService: HTTPService,
+			expectedProto:   "h2",
+		},
+		"Component SNI": {
+			serverName:      "muc.jackal.im",
+			expectedService: ComponentService,
+		},
+		"SNI precedence": {
+			serverName:      "upload.jackal.im",
+			protos:          []string{"http/1.1"},
+			expectedService: HTTPService,
+			expectedProto:   "http/1.1",
+		},
+		"Default": {
+			serverName:      "jackal.im",
+			expectedService: C2SService,
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			ln, connCh := testListener(t, Config{
+				HandshakeTimeout: time.Second,
+				DefaultService:   C2SService,
+				SNI: []SNIConfig{
+					{ServerName: "muc.jackal.im", Service: ComponentService},
+					{ServerName: "*.jackal.im", Service: HTTPService},
+				},
+			})
+			defer func() { _ = ln.Stop(context.Background()) }()
+
+			// when
+			conn, err := tls.Dial("tcp", ln.ln.Addr().String(), &tls.Config{
+				ServerName:         tc.serverName,
+				NextProtos:         tc.protos,
+				InsecureSkipVerify: true,
+			})
+			require.Nil(t, err)
+			defer func() { _ = conn.Close() }()
+
+			// then
+			select {
+			case service := <-connCh:
+				require.Equal(t, tc.expectedService, service)
+			case <-time.After(time.Second * 5):
+				require.Fail(t, "connection not dispatched")
+			}
+			require.Equal(t, tc.expectedProto, conn.ConnectionState().NegotiatedProtocol)
+		})
+	}
+}
+
+func TestListener_HostCertificate(t *testing.T) {
+	// given
+	ln, connCh := testListener(t, Config{
+		HandshakeTimeout: time.Second,
+		DefaultService:   C2SService,
+	})
+	defer func() { _ = ln.Stop(context.Background()) }()
+
+	// when
+	conn, err := tls.Dial("tcp", ln.ln.Addr().String(), &tls.Config{
+		ServerName:         "jackal.org",
+		NextProtos:         []string{"xmpp-client"},
+		InsecureSkipVerify: true,
+	})
+	require.Nil(t, err)
+	defer func() { _ = conn.Close() }()
+	<-connCh
+
+	// then
+	peerCerts := conn.ConnectionState().PeerCertificates
+	require.Len(t, peerCerts, 1)
+	require.Equal(t, []string{"jackal.org"}, peerCerts[0].DNSNames)
+}
+
+func TestListener_InvalidConfig(t *testing.T) {
+	// given
+	ln := New(Config{
+		DefaultService: C2SService,
+		SNI:            []SNIConfig{{ServerName: "jackal.im", Service: "bosh"}},
+	}, nil, kitlog.NewNopLogger())
+
+	// when
+	err := ln.Start(context.Background())
+
+	// then
+	require.NotNil(t, err)
+}
+
+func TestConnListener_Close(t *testing.T) {
+	// given
+	ln := NewConnListener()
+
+	// when
+	_ = ln.Close()
+	_, err := ln.Accept()
+
+	// then
+	require.Equal(t, net.ErrClosed, err)
+}
+
+func testListener(t *testing.T, cfg Config) (*Listener, chan string) {
+	t.Helper()
+
+	hosts, err := host.NewHosts(host.Configs{
+		testHostConfig(t, "jackal.im"),
+		testHostConfig(t, "jackal.org"),
+	})
+	require.Nil(t, err)
+
+	connCh := make(chan string, 1)
+
+	ln := New(cfg, hosts, kitlog.NewNopLogger())
+	for _, service := range []string{C2SService, S2SService, ComponentService, HTTPService} {
+		ln.Handle(service, &testConnHandler{service: service, connCh: connCh})
+	}
+	require.Nil(t, ln.Start(context.Background()))
+	return ln, connCh
+}
+
+func testHostConfig(t *testing.T, domain string) host.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{domain},
+	}
+	b, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	keyB, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	var cfg host.Config
+	cfg.Domain = domain
+	cfg.TLS.CertFile = filepath.Join(t.TempDir(), "cert.pem")
+	cfg.TLS.PrivateKeyFile = filepath.Join(t.TempDir(), "key.pem")
+
+	err = os.WriteFile(cfg.TLS.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: b}), 0600)
+	require.Nil(t, err)
+	err = os.WriteFile(cfg.TLS.PrivateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyB}), 0600)
+	require.Nil(t, err)
+	return cfg
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"errors"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/runqueue/v2"
+	"github.com/jackal-xmpp/stravaganza"
+	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/cluster/kv"
+	"github.com/ortuman/jackal/pkg/component"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/module"
+	"github.com/ortuman/jackal/pkg/router"
+	"github.com/ortuman/jackal/pkg/router/stream"
+	"github.com/ortuman/jackal/pkg/shaper"
+)
+
+var errBidiStreamClosed = errors.New("s2s: bidirectional stream closed")
+
+// bidiOut is an outgoing stream carried over an incoming bidirectional one (XEP-0288).
+type bidiOut struct {
+	id  stream.S2SOutID
+	stm *inS2S
+}
+
+func (b *bidiOut) ID() stream.S2SOutID {
+	return b.id
+}
+
+func (b *bidiOut) SendElement(elem stravaganza.Element) <-chan error {
+	return b.stm.sendBidiElement(b.id, elem)
+}
+
+func (b *bidiOut) Disconnect(streamErr *streamerror.Error) <-chan error {
+	return b.stm.Disconnect(streamErr)
+}
+
+// newBidiInS2S returns an incoming stream processing the stanzas a remote server sends
+// over out bidirectional stream (XEP-0288).
+// Returned stream shares out transport and session, and it's considered authenticated
+// as long as out is.
+func newBidiInS2S(
+	out *outS2S,
+	hosts *host.Hosts,
+	router router.Router,
+	comps *component.Components,
+	mods *module.Modules,
+	outProvider *OutProvider,
+	peerAuth *PeerAuthenticator,
+	inHub *InHub,
+	kv kv.KV,
+	shapers *shaper.Registry,
+	hk *hook.Hooks,
+	logger kitlog.Logger,
+	cfg inConfig,
+) *inS2S {
+	id := nextStreamID()
+
+	stm := &inS2S{
+		id:          id,
+		cfg:         cfg,
+		tr:          out.tr,
+		session:     out.session,
+		hosts:       hosts,
+		router:      router,
+		comps:       comps,
+		mods:        mods,
+		outProvider: outProvider,
+		peerAuth:    peerAuth,
+		inHub:       inHub,
+		kv:          kv,
+		shapers:     shapers,
+		hk:          hk,
+		logger:      kitlog.With(logger, "id", id, "sender", out.target, "target", out.sender),
+		rq:          runqueue.New(id.String()),
+		doneCh:      make(chan struct{}),
+		state:       inConnected,
+		sender:      out.target,
+		target:      out.sender,
+	}
+	stm.jd, _ = jid.New("", out.target, "", true)
+	stm.flags.setSecured()
+	stm.flags.setAuthenticated()
+	return stm
+}
@@ -16,6 +16,8 @@ package s2s
 
 import (
 	"time"
+
+	"github.com/ortuman/jackal/pkg/transport/proxyproto"
 )
 
 // ListenersConfig defines a set of S2S listener configurations.
@@ -43,6 +45,13 @@ type ListenerConfig struct {
 
 	// DirectTLS, if true, tls.Listen will be used as network listener.
 	DirectTLS bool `fig:"direct_tls"`
+
+	// ProxyProtocol defines PROXY protocol (v1/v2) configuration.
+	ProxyProtocol proxyproto.Config `fig:"proxy_protocol"`
+
+	// ResumeTimeout defines how long a stream management (XEP-0198) session can be resumed
+	// once its incoming stream is gone.
+	ResumeTimeout time.Duration `fig:"resume_timeout" default:"2m"`
 }
 
 // OutConfig defines S2S out configuration.
@@ -61,4 +70,122 @@ type OutConfig struct {
 
 	// MaxStanzaSize is the maximum size a listener incoming stanza may have.
 	MaxStanzaSize int `fig:"max_stanza_size" default:"131072"`
+
+	// DANE defines outgoing connections DANE (RFC 7673) configuration.
+	DANE DANEConfig `fig:"dane"`
+
+	// StreamManagement defines outgoing streams stream management (XEP-0198) configuration.
+	StreamManagement StreamManagementConfig `fig:"stream_management"`
+
+	// PendingQueue defines the queue holding stanzas addressed to a remote domain
+	// while its outgoing stream is being established.
+	PendingQueue PendingQueueConfig `fig:"pending_queue"`
+}
+
+// PendingQueueConfig defines S2S out pending stanza queue configuration.
+type PendingQueueConfig struct {
+	// MaxSize defines the maximum number of stanzas queued per remote domain.
+	MaxSize int `fig:"max_size" default:"1000"`
+
+	// Timeout defines the maximum amount of time a stanza may remain queued before being bounced.
+	Timeout time.Duration `fig:"timeout" default:"1m"`
+
+	// InitialBackoff defines the delay applied before the first redial attempt, doubled after each failure.
+	InitialBackoff time.Duration `fig:"initial_backoff" default:"1s"`
+
+	// MaxBackoff defines the maximum delay applied between redial attempts.
+	MaxBackoff time.Duration `fig:"max_backoff" default:"30s"`
+}
+
+// StreamManagementConfig defines S2S out stream management configuration.
+type StreamManagementConfig struct {
+	// Enabled tells whether stream management should be negotiated on outgoing streams.
+	Enabled bool `fig:"enabled"`
+
+	// RequestAckInterval defines the period of time after which an ack is requested to the remote server.
+	RequestAckInterval time.Duration `fig:"request_ack_interval" default:"1m"`
+
+	// WaitForAckTimeout defines the maximum amount of time to wait for a requested ack before closing the stream.
+	WaitForAckTimeout time.Duration `fig:"wait_for_ack_timeout" default:"30s"`
+
+	// MaxRetries defines how many times an outgoing stream is re-established in order to resend
+	// unacknowledged stanzas before bouncing them back to their senders.
+	MaxRetries int `fig:"max_retries" default:"3"`
+
+	// RetryInterval defines the period of time to wait before re-establishing an outgoing stream.
+	RetryInterval time.Duration `fig:"retry_interval" default:"5s"`
+}
+
+// DANEConfig defines S2S out DANE configuration.
+type DANEConfig struct {
+	// DefaultPolicy defines the DANE policy applied to remote domains with no specific policy.
+	// Allowed values are "disabled", "opportunistic" and "required".
+	DefaultPolicy string `fig:"default_policy" default:"disabled"`
+
+	// Domains defines per remote domain DANE policies.
+	Domains []DANEDomainConfig `fig:"domains"`
+
+	// Resolvers defines the set of DNSSEC validating resolvers used to look up TLSA records.
+	// If empty, system configured name servers will be used.
+	Resolvers []string `fig:"resolvers"`
+}
+
+// DANEDomainConfig defines a remote domain DANE policy.
+type DANEDomainConfig struct {
+	// Domain is the remote domain name (wildcards allowed).
+	Domain string `fig:"domain"`
+
+	// Policy is the DANE policy applied to Domain.
+	Policy string `fig:"policy"`
+}
+
+// AuthConfig defines S2S peer authentication configuration.
+type AuthConfig struct {
+	// CACertFiles defines the set of PEM encoded CA certificate files used to validate peer certificates.
+	// If empty, system root certificate pool will be used.
+	CACertFiles []string `fig:"ca_cert_files"`
+
+	// RequireCertAuth defines the set of remote domains (wildcards allowed) that must be authenticated
+	// by means of SASL EXTERNAL, not allowing to fall back to dialback.
+	RequireCertAuth []string `fig:"require_cert_auth"`
+
+	// POSH defines delegated domain certificate validation configuration.
+	POSH POSHConfig `fig:"posh"`
+}
+
+// POSHConfig defines POSH (RFC 7711) delegated certificate validation configuration.
+type POSHConfig struct {
+	// Enabled tells whether peer certificates not matching remote domain should be validated through POSH.
+	Enabled bool `fig:"enabled"`
+
+	// FetchTimeout defines POSH document fetch timeout.
+	FetchTimeout time.Duration `fig:"fetch_timeout" default:"5s"`
+}
+
+// FederationConfig defines S2S federation policy configuration.
+type FederationConfig struct {
+	// Allow, if not empty, restricts federation to the set of matching remote domains (wildcards allowed).
+	Allow []string `fig:"allow"`
+
+	// Deny defines the set of remote domains (wildcards allowed) local server refuses to federate with.
+	// Deny rules take precedence over allow ones.
+	Deny []string `fig:"deny"`
+
+	// Shaping defines per remote domain traffic shaping rules.
+	Shaping []FederationShapingConfig `fig:"shaping"`
+}
+
+// FederationShapingConfig defines a remote domain shaping rule.
+type FederationShapingConfig struct {
+	// Domain is the remote domain name (wildcards allowed).
+	Domain string `fig:"domain"`
+
+	// Shaper is the name of the shaper applied to Domain connections.
+	Shaper string `fig:"shaper"`
+}
+
+// Validate checks whether outgoing connections configuration values are supported.
+func (c OutConfig) Validate() error {
+	_, err := newDANEPolicies(c.DANE)
+	return err
 }
@@ -0,0 +1,298 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+
+	dnsutil "github.com/ortuman/jackal/pkg/util/dns"
+	"github.com/ortuman/jackal/pkg/util/stringmatcher"
+)
+
+type danePolicy int8
+
+const (
+	daneDisabled danePolicy = iota
+	daneOpportunistic
+	daneRequired
+)
+
+// TLSA certificate usages (RFC 7218)
+const (
+	tlsaPKIXTA = 0
+	tlsaPKIXEE = 1
+	tlsaDANETA = 2
+	tlsaDANEEE = 3
+)
+
+var errDANEMismatch = errors.New("s2s: peer certificate does not match TLSA records")
+
+func parseDANEPolicy(s string) (danePolicy, error) {
+	switch s {
+	case "", "disabled":
+		return daneDisabled, nil
+	case "opportunistic":
+		return daneOpportunistic, nil
+	case "required":
+		return daneRequired, nil
+	}
+	return daneDisabled, fmt.Errorf("s2s: unrecognized DANE policy: %s", s)
+}
+
+func (p danePolicy) String() string {
+	switch p {
+	case daneOpportunistic:
+		return "opportunistic"
+	case daneRequired:
+		return "required"
+	}
+	return "disabled"
+}
+
+type daneDomainPolicy struct {
+	matcher stringmatcher.Matcher
+	policy  danePolicy
+}
+
+type danePolicies struct {
+	defaultPolicy  danePolicy
+	domainPolicies []daneDomainPolicy
+	resolver       tlsaResolver
+}
+
+func newDANEPolicies(cfg DANEConfig) (*danePolicies, error) {
+	defaultPolicy, err := parseDANEPolicy(cfg.DefaultPolicy)
+	if err != nil {
+		return nil, err
+	}
+	dp := &danePolicies{
+		defaultPolicy: defaultPolicy,
+		resolver:      dnsutil.NewTLSAResolver(cfg.Resolvers),
+	}
+	for _, domainCfg := range cfg.Domains {
+		policy, err := parseDANEPolicy(domainCfg.Policy)
+		if err != nil {
+			return nil, err
+		}
+		m, err := stringmatcher.NewWildcardMatcher([]string{domainCfg.Domain})
+		if err != nil {
+			return nil, err
+		}
+		dp.domainPolicies = append(dp.domainPolicies, daneDomainPolicy{matcher: m, policy: policy})
+	}
+	return dp, nil
+}
+
+// verifier returns the DANE verifier to be used for connections to a remote domain.
+func (dp *danePolicies) verifier(domain string) *daneVerifier {
+	if dp == nil {
+		return nil
+	}
+	policy := dp.defaultPolicy
+	for _, domainPolicy := range dp.domainPolicies {
+		if domainPolicy.matcher.Matches(domain) {
+			policy = domainPolicy.policy
+			break
+		}
+	}
+	if policy == daneDisabled {
+		return nil
+	}
+	return &daneVerifier{policy: policy, resolver: dp.resolver}
+}
+
+type daneVerifier struct {
+	policy   danePolicy
+	resolver tlsaResolver
+}
+
+// lookupSRV resolves domain service SRV records, reporting whether the answer has been DNSSEC validated.
+func (v *daneVerifier) lookupSRV(ctx context.Context, service, domain string) ([]*net.SRV, bool, error) {
+	return v.resolver.LookupSRV(ctx, service, "tcp", domain)
+}
+
+// configure looks up host TLSA records and sets up tlsCfg so that the peer certificate
+// presented on the next TLS handshake gets verified against them.
+// hostSecure tells whether host was securely obtained, that is, it's either the remote domain
+// itself or the target of a DNSSEC validated SRV record (RFC 7673).
+func (v *daneVerifier) configure(ctx context.Context, tlsCfg *tls.Config, host string, port int, hostSecure bool) error {
+	if v == nil {
+		return nil
+	}
+	if !hostSecure {
+		reportDANEVerification(v.policy, "insecure_srv")
+		if v.policy == daneRequired {
+			return errors.New("s2s: SRV records not DNSSEC validated")
+		}
+		return nil
+	}
+	records, secure, err := v.resolver.LookupTLSA(ctx, port, "tcp", host)
+	if err != nil {
+		reportDANEVerification(v.policy, "lookup_error")
+		if v.policy == daneRequired {
+			return fmt.Errorf("s2s: failed to look up TLSA records: %w", err)
+		}
+		return nil
+	}
+	records = usableTLSARecords(records)
+	switch {
+	case !secure:
+		reportDANEVerification(v.policy, "insecure")
+		if v.policy == daneRequired {
+			return errors.New("s2s: TLSA records not DNSSEC validated")
+		}
+		return nil
+
+	case len(records) == 0:
+		reportDANEVerification(v.policy, "no_records")
+		if v.policy == daneRequired {
+			return errors.New("s2s: no usable TLSA records found")
+		}
+		return nil
+	}
+	// certificate chain is verified against TLSA records
+	roots := tlsCfg.RootCAs
+	names := []string{tlsCfg.ServerName, host}
+	verifyConn := tlsCfg.VerifyConnection
+
+	tlsCfg.InsecureSkipVerify = true
+	tlsCfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		usage, err := verifyTLSA(cs.PeerCertificates, records, roots, names)
+		if err != nil {
+			reportDANEVerification(v.policy, "invalid")
+			return err
+		}
+		// PKIX usages constrain regular peer authentication rather than replacing it
+		if verifyConn != nil && (usage == tlsaPKIXTA || usage == tlsaPKIXEE) {
+			if err := verifyConn(cs); err != nil {
+				reportDANEVerification(v.policy, "invalid")
+				return err
+			}
+		}
+		reportDANEVerification(v.policy, "valid")
+		return nil
+	}
+	return nil
+}
+
+func usableTLSARecords(records []dnsutil.TLSARecord) []dnsutil.TLSARecord {
+	var retVal []dnsutil.TLSARecord
+	for _, r := range records {
+		if r.Usage > tlsaDANEEE || r.Selector > 1 || r.MatchingType > 2 {
+			continue
+		}
+		retVal = append(retVal, r)
+	}
+	return retVal
+}
+
+// verifyTLSA verifies peer certificate chain against TLSA records, returning the certificate usage
+// of the first matching one.
+func verifyTLSA(certs []*x509.Certificate, records []dnsutil.TLSARecord, roots *x509.CertPool, names []string) (uint8, error) {
+	if len(certs) == 0 {
+		return 0, errNoPeerCertificate
+	}
+	leaf := certs[0]
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	for _, r := range records {
+		switch r.Usage {
+		case tlsaDANEEE:
+			// no name or expiration checks (RFC 7671)
+			if matchesTLSARecord(leaf, r) {
+				return r.Usage, nil
+			}
+
+		case tlsaDANETA:
+			for _, cert := range certs[1:] {
+				if !matchesTLSARecord(cert, r) {
+					continue
+				}
+				taPool := x509.NewCertPool()
+				taPool.AddCert(cert)
+				if _, err := verifyChain(leaf, taPool, intermediates, names); err == nil {
+					return r.Usage, nil
+				}
+			}
+
+		case tlsaPKIXEE:
+			if !matchesTLSARecord(leaf, r) {
+				continue
+			}
+			if _, err := verifyChain(leaf, roots, intermediates, names); err == nil {
+				return r.Usage, nil
+			}
+
+		case tlsaPKIXTA:
+			chains, err := verifyChain(leaf, roots, intermediates, names)
+			if err != nil {
+				continue
+			}
+			for _, chain := range chains {
+				for _, cert := range chain[1:] {
+					if matchesTLSARecord(cert, r) {
+						return r.Usage, nil
+					}
+				}
+			}
+		}
+	}
+	return 0, errDANEMismatch
+}
+
+func verifyChain(leaf *x509.Certificate, roots, intermediates *x509.CertPool, names []string) (chains [][]*x509.Certificate, err error) {
+	for _, name := range names {
+		chains, err = leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			DNSName:       name,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err == nil {
+			return chains, nil
+		}
+	}
+	return nil, err
+}
+
+func matchesTLSARecord(cert *x509.Certificate, r dnsutil.TLSARecord) bool {
+	var data []byte
+	switch r.Selector {
+	case 0:
+		data = cert.Raw
+	default:
+		data = cert.RawSubjectPublicKeyInfo
+	}
+	switch r.MatchingType {
+	case 1:
+		h := sha256.Sum256(data)
+		data = h[:]
+	case 2:
+		h := sha512.Sum512(data)
+		data = h[:]
+	}
+	return bytes.Equal(data, r.Data)
+}
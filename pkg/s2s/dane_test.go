@@ -0,0 +1,279 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	dnsutil "github.com/ortuman/jackal/pkg/util/dns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDANE_ParsePolicies(t *testing.T) {
+	// given
+	dp, err := newDANEPolicies(DANEConfig{
+		DefaultPolicy: "opportunistic",
+		Domains: []DANEDomainConfig{
+			{Domain: "*.jabber.org", Policy: "required"},
+			{Domain: "jackal.im", Policy: "disabled"},
+		},
+	})
+	require.Nil(t, err)
+
+	// then
+	require.Equal(t, daneRequired, dp.verifier("xmpp.jabber.org").policy)
+	require.Equal(t, daneOpportunistic, dp.verifier("example.org").policy)
+	require.Nil(t, dp.verifier("jackal.im"))
+
+	_, err = newDANEPolicies(DANEConfig{DefaultPolicy: "mandatory"})
+	require.NotNil(t, err)
+}
+
+func TestDANE_VerifyTLSA(t *testing.T) {
+	caCert, caKey := testCACertificate(t)
+	leafCert := testLeafCertificate(t, caCert, caKey, "jabber.org")
+
+	otherCACert, otherCAKey := testCACertificate(t)
+	otherLeafCert := testLeafCertificate(t, otherCACert, otherCAKey, "jabber.org")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+
+	spkiHash := sha256.Sum256(leafCert.RawSubjectPublicKeyInfo)
+	caHash := sha256.Sum256(caCert.Raw)
+
+	var tests = []struct {
+		name    string
+		certs   []*x509.Certificate
+		record  dnsutil.TLSARecord
+		roots   *x509.CertPool
+		wantErr bool
+	}{
+		{
+			name:   "DANE-EE SPKI sha256",
+			certs:  []*x509.Certificate{leafCert},
+			record: dnsutil.TLSARecord{Usage: tlsaDANEEE, Selector: 1, MatchingType: 1, Data: spkiHash[:]},
+		},
+		{
+			name:   "DANE-EE full certificate",
+			certs:  []*x509.Certificate{leafCert},
+			record: dnsutil.TLSARecord{Usage: tlsaDANEEE, Selector: 0, MatchingType: 0, Data: leafCert.Raw},
+		},
+		{
+			name:    "DANE-EE mismatch",
+			certs:   []*x509.Certificate{otherLeafCert},
+			record:  dnsutil.TLSARecord{Usage: tlsaDANEEE, Selector: 1, MatchingType: 1, Data: spkiHash[:]},
+			wantErr: true,
+		},
+		{
+			name:   "DANE-TA",
+			certs:  []*x509.Certificate{leafCert, caCert},
+			record: dnsutil.TLSARecord{Usage: tlsaDANETA, Selector: 0, MatchingType: 1, Data: caHash[:]},
+		},
+		{
+			name:    "DANE-TA not in chain",
+			certs:   []*x509.Certificate{otherLeafCert, otherCACert},
+			record:  dnsutil.TLSARecord{Usage: tlsaDANETA, Selector: 0, MatchingType: 1, Data: caHash[:]},
+			wantErr: true,
+		},
+		{
+			name:   "PKIX-EE",
+			certs:  []*x509.Certificate{leafCert},
+			record: dnsutil.TLSARecord{Usage: tlsaPKIXEE, Selector: 1, MatchingType: 1, Data: spkiHash[:]},
+			roots:  roots,
+		},
+		{
+			name:    "PKIX-EE untrusted",
+			certs:   []*x509.Certificate{leafCert},
+			record:  dnsutil.TLSARecord{Usage: tlsaPKIXEE, Selector: 1, MatchingType: 1, Data: spkiHash[:]},
+			roots:   x509.NewCertPool(),
+			wantErr: true,
+		},
+		{
+			name:   "PKIX-TA",
+			certs:  []*x509.Certificate{leafCert},
+			record: dnsutil.TLSARecord{Usage: tlsaPKIXTA, Selector: 0, MatchingType: 1, Data: caHash[:]},
+			roots:  roots,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			_, err := verifyTLSA(tt.certs, []dnsutil.TLSARecord{tt.record}, tt.roots, []string{"jabber.org"})
+
+			// then
+			if tt.wantErr {
+				require.Equal(t, errDANEMismatch, err)
+			} else {
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestDANE_ConfigureSecureRecords(t *testing.T) {
+	// given
+	caCert, caKey := testCACertificate(t)
+	leafCert := testLeafCertificate(t, caCert, caKey, "jabber.org")
+	spkiHash := sha256.Sum256(leafCert.RawSubjectPublicKeyInfo)
+
+	resMock := &tlsaResolverMock{}
+	resMock.LookupTLSAFunc = func(ctx context.Context, port int, proto, host string) ([]dnsutil.TLSARecord, bool, error) {
+		return []dnsutil.TLSARecord{{Usage: tlsaDANEEE, Selector: 1, MatchingType: 1, Data: spkiHash[:]}}, true, nil
+	}
+	v := &daneVerifier{policy: daneRequired, resolver: resMock}
+	tlsCfg := &tls.Config{ServerName: "jabber.org"}
+
+	// when
+	err := v.configure(context.Background(), tlsCfg, "xmpp.jabber.org", 5269, true)
+
+	// then
+	require.Nil(t, err)
+	require.True(t, tlsCfg.InsecureSkipVerify)
+	require.NotNil(t, tlsCfg.VerifyConnection)
+
+	require.Nil(t, tlsCfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{leafCert}}))
+
+	require.Len(t, resMock.LookupTLSACalls(), 1)
+	require.Equal(t, 5269, resMock.LookupTLSACalls()[0].Port)
+	require.Equal(t, "tcp", resMock.LookupTLSACalls()[0].Proto)
+	require.Equal(t, "xmpp.jabber.org", resMock.LookupTLSACalls()[0].Host)
+}
+
+func TestDANE_ConfigurePolicies(t *testing.T) {
+	var tests = []struct {
+		name    string
+		policy  danePolicy
+		secure  bool
+		lookErr error
+		wantErr bool
+	}{
+		{name: "opportunistic insecure", policy: daneOpportunistic},
+		{name: "opportunistic lookup error", policy: daneOpportunistic, lookErr: errors.New("servfail")},
+		{name: "opportunistic no records", policy: daneOpportunistic, secure: true},
+		{name: "required insecure", policy: daneRequired, wantErr: true},
+		{name: "required lookup error", policy: daneRequired, lookErr: errors.New("servfail"), wantErr: true},
+		{name: "required no records", policy: daneRequired, secure: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			resMock := &tlsaResolverMock{}
+			resMock.LookupTLSAFunc = func(ctx context.Context, port int, proto, host string) ([]dnsutil.TLSARecord, bool, error) {
+				return nil, tt.secure, tt.lookErr
+			}
+			v := &daneVerifier{policy: tt.policy, resolver: resMock}
+			tlsCfg := &tls.Config{}
+
+			// when
+			err := v.configure(context.Background(), tlsCfg, "jabber.org", 5269, true)
+
+			// then
+			if tt.wantErr {
+				require.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+			}
+			require.False(t, tlsCfg.InsecureSkipVerify)
+			require.Nil(t, tlsCfg.VerifyConnection)
+		})
+	}
+}
+
+func TestDANE_ConfigureInsecureSRVTarget(t *testing.T) {
+	var tests = []struct {
+		name    string
+		policy  danePolicy
+		wantErr bool
+	}{
+		{name: "opportunistic", policy: daneOpportunistic},
+		{name: "required", policy: daneRequired, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			resMock := &tlsaResolverMock{}
+			v := &daneVerifier{policy: tt.policy, resolver: resMock}
+			tlsCfg := &tls.Config{}
+
+			// when
+			err := v.configure(context.Background(), tlsCfg, "xmpp.jabber.org", 5269, false)
+
+			// then
+			if tt.wantErr {
+				require.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+			}
+			require.False(t, tlsCfg.InsecureSkipVerify)
+			require.Nil(t, tlsCfg.VerifyConnection)
+			require.Len(t, resMock.LookupTLSACalls(), 0)
+		})
+	}
+}
+
+func TestDANE_ConfigureChainsPeerVerification(t *testing.T) {
+	caCert, caKey := testCACertificate(t)
+	leafCert := testLeafCertificate(t, caCert, caKey, "jabber.org")
+	spkiHash := sha256.Sum256(leafCert.RawSubjectPublicKeyInfo)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+
+	errPeerAuth := errors.New("peer authentication failed")
+
+	var tests = []struct {
+		name    string
+		usage   uint8
+		wantErr error
+	}{
+		{name: "PKIX-EE", usage: tlsaPKIXEE, wantErr: errPeerAuth},
+		{name: "DANE-EE", usage: tlsaDANEEE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			resMock := &tlsaResolverMock{}
+			resMock.LookupTLSAFunc = func(ctx context.Context, port int, proto, host string) ([]dnsutil.TLSARecord, bool, error) {
+				return []dnsutil.TLSARecord{{Usage: tt.usage, Selector: 1, MatchingType: 1, Data: spkiHash[:]}}, true, nil
+			}
+			v := &daneVerifier{policy: daneRequired, resolver: resMock}
+
+			var verified bool
+			tlsCfg := &tls.Config{
+				ServerName:         "jabber.org",
+				RootCAs:            roots,
+				InsecureSkipVerify: true,
+				VerifyConnection: func(_ tls.ConnectionState) error {
+					verified = true
+					return errPeerAuth
+				},
+			}
+			require.Nil(t, v.configure(context.Background(), tlsCfg, "jabber.org", 5269, true))
+
+			// when
+			err := tlsCfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{leafCert}})
+
+			// then
+			require.Equal(t, tt.wantErr, err)
+			require.Equal(t, tt.wantErr != nil, verified)
+		})
+	}
+}
@@ -17,8 +17,9 @@ package s2s
 import (
 	"context"
 	"crypto/tls"
-	"errors"
+	"math/rand"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -28,7 +29,14 @@ const (
 	s2sService    = "xmpp-server"
 	s2sTLSService = "xmpps-server"
 
+	// s2sALPNProtocol is the ALPN protocol identifier negotiated over direct TLS connections (XEP-0368).
+	s2sALPNProtocol = "xmpp-server"
+
 	outKeepAlive = time.Second * 15
+
+	// happyEyeballsDelay is the time to wait before falling back to the secondary address family
+	// when a dual-stack target is being dialed (RFC 8305).
+	happyEyeballsDelay = time.Millisecond * 250
 )
 
 type dialer interface {
@@ -38,64 +46,176 @@ type dialer interface {
 type srvResolveFunc func(service, proto, name string) (cname string, addrs []*net.SRV, err error)
 type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
 
+type srvTarget struct {
+	host      string
+	port      uint16
+	priority  uint16
+	weight    uint16
+	directTLS bool
+	secure    bool
+}
+
 type outDialer struct {
 	srvResolve srvResolveFunc
 	dialCtx    dialFunc
 	dialTLSCtx dialFunc
+	randIntn   func(n int) int
+
+	netDialer  *net.Dialer
+	tlsCfg     *tls.Config
+	skipVerify bool
+	verifyConn func(tls.ConnectionState) error
+	dane       *daneVerifier
 }
 
-func newDialer(timeout time.Duration, tlsCfg *tls.Config) *outDialer {
-	d := net.Dialer{
-		Timeout:   timeout,
-		KeepAlive: outKeepAlive,
-	}
-	dTLS := tls.Dialer{
-		NetDialer: &d,
-		Config:    tlsCfg,
-	}
-	return &outDialer{
+func newDialer(timeout time.Duration, tlsCfg *tls.Config, dane *daneVerifier) *outDialer {
+	d := &outDialer{
 		srvResolve: net.LookupSRV,
-		dialCtx:    d.DialContext,
-		dialTLSCtx: dTLS.DialContext,
+		randIntn:   rand.Intn,
+		netDialer: &net.Dialer{
+			Timeout:       timeout,
+			KeepAlive:     outKeepAlive,
+			FallbackDelay: happyEyeballsDelay,
+		},
+		tlsCfg:     tlsCfg,
+		skipVerify: tlsCfg.InsecureSkipVerify,
+		verifyConn: tlsCfg.VerifyConnection,
+		dane:       dane,
 	}
+	d.dialCtx = d.netDialer.DialContext
+	d.dialTLSCtx = d.dialTLS
+	return d
 }
 
 func (d *outDialer) DialContext(ctx context.Context, remoteDomain string) (conn net.Conn, usesTLS bool, err error) {
-	conn, err = d.dialSRV(ctx, remoteDomain, s2sTLSService, true)
-	if err == nil {
-		return conn, true, nil
+	for _, target := range d.lookupTargets(ctx, remoteDomain) {
+		// set up DANE verification for this target
+		if err := d.configureDANE(ctx, target.host, int(target.port), target.secure); err != nil {
+			continue
+		}
+		dialFn := d.dialCtx
+		if target.directTLS {
+			dialFn = d.dialTLSCtx
+		}
+		conn, err := dialFn(ctx, "tcp", net.JoinHostPort(target.host, strconv.Itoa(int(target.port))))
+		if err == nil {
+			return conn, target.directTLS, nil
+		}
 	}
-	conn, err = d.dialSRV(ctx, remoteDomain, s2sService, false)
-	if err == nil {
-		return conn, false, nil
+	if err := d.configureDANE(ctx, remoteDomain, 5269, true); err != nil {
+		return nil, false, err
 	}
 	conn, err = d.dialCtx(ctx, "tcp", net.JoinHostPort(remoteDomain, "5269"))
 	return conn, false, err
 }
 
-func (d *outDialer) dialSRV(ctx context.Context, remoteDomain, service string, dialTLS bool) (net.Conn, error) {
+// lookupTargets resolves both direct TLS and STARTTLS SRV records (XEP-0368), merging them
+// into a single list ordered by priority and weight.
+func (d *outDialer) lookupTargets(ctx context.Context, remoteDomain string) []srvTarget {
+	var targets []srvTarget
+	for _, service := range []string{s2sTLSService, s2sService} {
+		addrs, secure, err := d.lookupSRV(ctx, service, remoteDomain)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if addr.Target == "." {
+				continue // service not available
+			}
+			targets = append(targets, srvTarget{
+				host:      strings.TrimSuffix(addr.Target, "."),
+				port:      addr.Port,
+				priority:  addr.Priority,
+				weight:    addr.Weight,
+				directTLS: service == s2sTLSService,
+				secure:    secure,
+			})
+		}
+	}
+	return sortSRVTargets(targets, d.randIntn)
+}
+
+func (d *outDialer) lookupSRV(ctx context.Context, service, remoteDomain string) ([]*net.SRV, bool, error) {
+	if d.dane != nil {
+		// TLSA records of SRV targets can only be trusted if the SRV answer itself was DNSSEC validated
+		return d.dane.lookupSRV(ctx, service, remoteDomain)
+	}
 	_, addrs, err := d.srvResolve(service, "tcp", remoteDomain)
-	if err != nil {
-		return nil, err
+	return addrs, false, err
+}
+
+func (d *outDialer) dialTLS(ctx context.Context, network, address string) (net.Conn, error) {
+	dTLS := tls.Dialer{
+		NetDialer: d.netDialer,
+		Config:    d.directTLSConfig(),
 	}
-	for _, addr := range addrs {
-		if addr.Target == "." {
-			continue
+	return dTLS.DialContext(ctx, network, address)
+}
+
+func (d *outDialer) directTLSConfig() *tls.Config {
+	tlsCfg := d.tlsCfg.Clone()
+	tlsCfg.NextProtos = []string{s2sALPNProtocol}
+	return tlsCfg
+}
+
+func (d *outDialer) configureDANE(ctx context.Context, host string, port int, hostSecure bool) error {
+	// restore default peer certificate validation, so that a previous target DANE setup
+	// never leaks into the next one
+	d.tlsCfg.InsecureSkipVerify = d.skipVerify
+	d.tlsCfg.VerifyConnection = d.verifyConn
+
+	return d.dane.configure(ctx, d.tlsCfg, host, port, hostSecure)
+}
+
+// sortSRVTargets orders targets by ascending priority, picking equal priority ones
+// by weighted random selection as described in RFC 2782.
+func sortSRVTargets(targets []srvTarget, randIntn func(n int) int) []srvTarget {
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].priority < targets[j].priority
+	})
+	sorted := make([]srvTarget, 0, len(targets))
+	for i := 0; i < len(targets); {
+		j := i
+		for j < len(targets) && targets[j].priority == targets[i].priority {
+			j++
 		}
-		host := strings.TrimSuffix(addr.Target, ".")
-		port := strconv.Itoa(int(addr.Port))
+		sorted = append(sorted, weightedOrder(targets[i:j], randIntn)...)
+		i = j
+	}
+	return sorted
+}
 
-		var dialFn dialFunc
-		switch dialTLS {
-		case true:
-			dialFn = d.dialTLSCtx
-		default:
-			dialFn = d.dialCtx
+func weightedOrder(targets []srvTarget, randIntn func(n int) int) []srvTarget {
+	remaining := make([]srvTarget, 0, len(targets))
+
+	// zero weight targets are placed first, so they have a small chance of being selected
+	for _, t := range targets {
+		if t.weight == 0 {
+			remaining = append(remaining, t)
 		}
-		conn, err := dialFn(ctx, "tcp", net.JoinHostPort(host, port))
-		if err == nil {
-			return conn, nil
+	}
+	for _, t := range targets {
+		if t.weight > 0 {
+			remaining = append(remaining, t)
+		}
+	}
+	ordered := make([]srvTarget, 0, len(targets))
+	for len(remaining) > 0 {
+		var total int
+		for _, t := range remaining {
+			total += int(t.weight)
+		}
+		r := randIntn(total + 1)
+
+		var sum, k int
+		for k = range remaining {
+			sum += int(remaining[k].weight)
+			if sum >= r {
+				break
+			}
 		}
+		ordered = append(ordered, remaining[k])
+		remaining = append(remaining[:k], remaining[k+1:]...)
 	}
-	return nil, errors.New("s2s: failed to dial SRV")
+	return ordered
 }
@@ -22,12 +22,13 @@ import (
 	"testing"
 	"time"
 
+	dnsutil "github.com/ortuman/jackal/pkg/util/dns"
 	"github.com/stretchr/testify/require"
 )
 
 func TestDialer_ResolverError(t *testing.T) {
 	// given
-	d := newDialer(time.Minute, &tls.Config{})
+	d := newDialer(time.Minute, &tls.Config{}, nil)
 
 	mockedErr := errors.New("dialer mocked error")
 	d.srvResolve = func(_, _, _ string) (cname string, addrs []*net.SRV, err error) {
@@ -44,7 +45,7 @@ func TestDialer_ResolverError(t *testing.T) {
 
 func TestDialer_DialError(t *testing.T) {
 	// given
-	d := newDialer(time.Minute, &tls.Config{})
+	d := newDialer(time.Minute, &tls.Config{}, nil)
 
 	errFoo := errors.New("foo error")
 	d.srvResolve = func(service, proto, name string) (cname string, addrs []*net.SRV, err error) {
@@ -66,7 +67,7 @@ func TestDialer_DialError(t *testing.T) {
 
 func TestDialer_Success(t *testing.T) {
 	// given
-	d := newDialer(time.Minute, &tls.Config{})
+	d := newDialer(time.Minute, &tls.Config{}, nil)
 
 	conn := &netConnMock{}
 	d.srvResolve = func(service, proto, name string) (cname string, addrs []*net.SRV, err error) {
@@ -89,7 +90,7 @@ func TestDialer_Success(t *testing.T) {
 
 func TestDialer_TLSSuccess(t *testing.T) {
 	// given
-	d := newDialer(time.Minute, &tls.Config{})
+	d := newDialer(time.Minute, &tls.Config{}, nil)
 
 	conn := &netConnMock{}
 	d.srvResolve = func(service, proto, name string) (cname string, addrs []*net.SRV, err error) {
@@ -109,3 +110,119 @@ func TestDialer_TLSSuccess(t *testing.T) {
 	require.NotNil(t, out)
 	require.True(t, isTLS)
 }
+
+func TestDialer_DANERequired(t *testing.T) {
+	var tests = []struct {
+		name       string
+		srvSecure  bool
+		tlsaLookUp int
+	}{
+		{name: "secure SRV", srvSecure: true, tlsaLookUp: 2},    // SRV target + domain fallback
+		{name: "insecure SRV", srvSecure: false, tlsaLookUp: 1}, // domain fallback only
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			resMock := &tlsaResolverMock{}
+			resMock.LookupSRVFunc = func(ctx context.Context, service, proto, name string) ([]*net.SRV, bool, error) {
+				if service != s2sService {
+					return nil, tt.srvSecure, nil
+				}
+				return []*net.SRV{{Target: "xmpp.jabber.org", Port: 5269}}, tt.srvSecure, nil
+			}
+			resMock.LookupTLSAFunc = func(ctx context.Context, port int, proto, host string) ([]dnsutil.TLSARecord, bool, error) {
+				return nil, false, nil
+			}
+			d := newDialer(time.Minute, &tls.Config{}, &daneVerifier{policy: daneRequired, resolver: resMock})
+
+			d.srvResolve = func(service, proto, name string) (cname string, addrs []*net.SRV, err error) {
+				return "", nil, errors.New("unexpected insecure SRV lookup")
+			}
+			d.dialCtx = func(_ context.Context, _, _ string) (net.Conn, error) {
+				return &netConnMock{}, nil
+			}
+			// when
+			out, _, err := d.DialContext(context.Background(), "jabber.org")
+
+			// then
+			require.Nil(t, out)
+			require.NotNil(t, err)
+			require.Len(t, resMock.LookupSRVCalls(), 2)
+			require.Len(t, resMock.LookupTLSACalls(), tt.tlsaLookUp)
+		})
+	}
+}
+
+func TestDialer_MergedSRVTargets(t *testing.T) {
+	// given
+	d := newDialer(time.Minute, &tls.Config{}, nil)
+
+	d.srvResolve = func(service, proto, name string) (cname string, addrs []*net.SRV, err error) {
+		switch service {
+		case s2sTLSService:
+			return "", []*net.SRV{
+				{Target: "xmpps-b.jabber.org.", Port: 5270, Priority: 20},
+				{Target: "xmpps-a.jabber.org.", Port: 5270, Priority: 5},
+			}, nil
+		default:
+			return "", []*net.SRV{{Target: "xmpp.jabber.org.", Port: 5269, Priority: 10}}, nil
+		}
+	}
+	errFoo := errors.New("foo error")
+
+	var dialed []string
+	d.dialCtx = func(_ context.Context, _, address string) (net.Conn, error) {
+		dialed = append(dialed, "tcp://"+address)
+		return nil, errFoo
+	}
+	d.dialTLSCtx = func(_ context.Context, _, address string) (net.Conn, error) {
+		dialed = append(dialed, "tls://"+address)
+		return nil, errFoo
+	}
+
+	// when
+	_, _, err := d.DialContext(context.Background(), "jabber.org")
+
+	// then
+	require.Equal(t, errFoo, err)
+	require.Equal(t, []string{
+		"tls://xmpps-a.jabber.org:5270",
+		"tcp://xmpp.jabber.org:5269",
+		"tls://xmpps-b.jabber.org:5270",
+		"tcp://jabber.org:5269",
+	}, dialed)
+}
+
+func TestDialer_DirectTLSConfig(t *testing.T) {
+	// given
+	d := newDialer(time.Minute, &tls.Config{ServerName: "jabber.org"}, nil)
+
+	// when
+	tlsCfg := d.directTLSConfig()
+
+	// then
+	require.Equal(t, "jabber.org", tlsCfg.ServerName)
+	require.Equal(t, []string{"xmpp-server"}, tlsCfg.NextProtos)
+	require.Nil(t, d.tlsCfg.NextProtos)
+	require.Equal(t, happyEyeballsDelay, d.netDialer.FallbackDelay)
+}
+
+func TestDialer_SortSRVTargets(t *testing.T) {
+	// given
+	targets := []srvTarget{
+		{host: "c", priority: 20, weight: 10},
+		{host: "a", priority: 10, weight: 0},
+		{host: "b", priority: 10, weight: 60},
+		{host: "d", priority: 10, weight: 40},
+	}
+
+	// when
+	sorted := sortSRVTargets(targets, func(n int) int { return n - 1 })
+
+	// then
+	var hosts []string
+	for _, t := range sorted {
+		hosts = append(hosts, t.host)
+	}
+	require.Equal(t, []string{"d", "b", "a", "c"}, hosts)
+}
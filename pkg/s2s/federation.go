@@ -0,0 +1,184 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/shaper"
+	"github.com/ortuman/jackal/pkg/util/stringmatcher"
+)
+
+var errFederationDenied = errors.New("s2s: federation with remote domain denied by policy")
+
+type federationShapingRule struct {
+	matcher stringmatcher.Matcher
+	shaper  *shaper.Shaper
+}
+
+type federationRules struct {
+	cfg        FederationConfig
+	overridden bool
+	allow      stringmatcher.Matcher
+	deny       stringmatcher.Matcher
+	shaping    []federationShapingRule
+}
+
+// FederationPolicy decides which remote domains local server federates with,
+// and which traffic shaper applies to each of them.
+// Policy can be updated at runtime, in which case existing streams not satisfying it will be closed.
+type FederationPolicy struct {
+	shapers *shaper.Registry
+
+	mu        sync.RWMutex
+	rules     *federationRules
+	observers []func()
+}
+
+// NewFederationPolicy creates and initializes a new FederationPolicy instance.
+func NewFederationPolicy(cfg FederationConfig, shapers *shaper.Registry) (*FederationPolicy, error) {
+	p := &FederationPolicy{shapers: shapers}
+	rules, err := p.compile(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.rules = rules
+	return p, nil
+}
+
+// Config returns current policy configuration.
+func (p *FederationPolicy) Config() FederationConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.rules.cfg
+}
+
+// Overridden tells whether current policy configuration has been set at runtime via Override.
+func (p *FederationPolicy) Overridden() bool {
+	if p == nil {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.rules.overridden
+}
+
+// Update replaces current policy configuration with the one read from configuration file,
+// discarding any runtime override.
+func (p *FederationPolicy) Update(cfg FederationConfig) error {
+	return p.set(cfg, false)
+}
+
+// Override replaces current policy configuration at runtime.
+// Overridden configuration is kept across configuration reloads until the next Update call.
+func (p *FederationPolicy) Override(cfg FederationConfig) error {
+	return p.set(cfg, true)
+}
+
+// Refresh recompiles current policy configuration, so that shapers updated in registry get picked up.
+func (p *FederationPolicy) Refresh() error {
+	p.mu.RLock()
+	rules := p.rules
+	p.mu.RUnlock()
+	return p.set(rules.cfg, rules.overridden)
+}
+
+func (p *FederationPolicy) set(cfg FederationConfig, overridden bool) error {
+	rules, err := p.compile(cfg)
+	if err != nil {
+		return err
+	}
+	rules.overridden = overridden
+
+	p.mu.Lock()
+	p.rules = rules
+	observers := p.observers
+	p.mu.Unlock()
+
+	for _, fn := range observers {
+		fn()
+	}
+	return nil
+}
+
+// IsAllowed tells whether federation with domain is allowed.
+func (p *FederationPolicy) IsAllowed(domain string) bool {
+	if p == nil {
+		return true
+	}
+	p.mu.RLock()
+	rules := p.rules
+	p.mu.RUnlock()
+
+	if rules.deny.Matches(domain) {
+		return false
+	}
+	return rules.allow == nil || rules.allow.Matches(domain)
+}
+
+// Shaper returns the shaper to be applied to a remote domain connection.
+func (p *FederationPolicy) Shaper(domainJID *jid.JID) *shaper.Shaper {
+	if p == nil {
+		return nil
+	}
+	p.mu.RLock()
+	rules := p.rules
+	p.mu.RUnlock()
+
+	for _, rule := range rules.shaping {
+		if rule.matcher.Matches(domainJID.Domain()) {
+			return rule.shaper
+		}
+	}
+	return p.shapers.MatchingJID(domainJID)
+}
+
+func (p *FederationPolicy) observe(fn func()) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.observers = append(p.observers, fn)
+	p.mu.Unlock()
+}
+
+func (p *FederationPolicy) compile(cfg FederationConfig) (*federationRules, error) {
+	deny, err := stringmatcher.NewWildcardMatcher(cfg.Deny)
+	if err != nil {
+		return nil, err
+	}
+	rules := &federationRules{cfg: cfg, deny: deny}
+	if len(cfg.Allow) > 0 {
+		rules.allow, err = stringmatcher.NewWildcardMatcher(cfg.Allow)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, shapingCfg := range cfg.Shaping {
+		shp := p.shapers.ByName(shapingCfg.Shaper)
+		if shp == nil {
+			return nil, fmt.Errorf("s2s: shaper not found: %s", shapingCfg.Shaper)
+		}
+		m, err := stringmatcher.NewWildcardMatcher([]string{shapingCfg.Domain})
+		if err != nil {
+			return nil, err
+		}
+		rules.shaping = append(rules.shaping, federationShapingRule{matcher: m, shaper: shp})
+	}
+	return rules, nil
+}
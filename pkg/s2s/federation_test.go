@@ -0,0 +1,111 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"testing"
+
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/shaper"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestFederationPolicy_IsAllowed(t *testing.T) {
+	// given
+	p, err := NewFederationPolicy(FederationConfig{
+		Allow: []string{"*.jabber.org", "jackal.im"},
+		Deny:  []string{"spam.jabber.org"},
+	}, nil)
+	require.Nil(t, err)
+
+	// then
+	require.True(t, p.IsAllowed("xmpp.jabber.org"))
+	require.True(t, p.IsAllowed("jackal.im"))
+	require.False(t, p.IsAllowed("spam.jabber.org"))
+	require.False(t, p.IsAllowed("konuro.net"))
+
+	var nilPolicy *FederationPolicy
+	require.True(t, nilPolicy.IsAllowed("konuro.net"))
+}
+
+func TestFederationPolicy_Shaper(t *testing.T) {
+	// given
+	var shpCfg shaper.Config
+	shpCfg.Name = "slow"
+	shpCfg.Rate.Limit = 512
+	shpCfg.Rate.Burst = 256
+
+	slowShp, _ := shaper.New(shpCfg)
+	shapers := shaper.NewRegistry(shaper.Shapers{slowShp})
+
+	p, err := NewFederationPolicy(FederationConfig{
+		Shaping: []FederationShapingConfig{{Domain: "*.jabber.org", Shaper: "slow"}},
+	}, shapers)
+	require.Nil(t, err)
+
+	j0, _ := jid.New("", "xmpp.jabber.org", "", true)
+
+	// when
+	shp := p.Shaper(j0)
+
+	// then
+	require.Equal(t, "slow", shp.Name)
+	require.Equal(t, rate.Limit(512), shp.RateLimiter().Limit())
+
+	_, err = NewFederationPolicy(FederationConfig{
+		Shaping: []FederationShapingConfig{{Domain: "*.jabber.org", Shaper: "unknown"}},
+	}, shapers)
+	require.NotNil(t, err)
+}
+
+func TestFederationPolicy_Update(t *testing.T) {
+	// given
+	p, _ := NewFederationPolicy(FederationConfig{}, nil)
+
+	var notified bool
+	p.observe(func() { notified = true })
+
+	// when
+	err0 := p.Update(FederationConfig{Deny: []string{"konuro.net"}})
+	err1 := p.Update(FederationConfig{Deny: []string{"[konuro.net"}})
+
+	// then
+	require.Nil(t, err0)
+	require.NotNil(t, err1)
+	require.True(t, notified)
+	require.False(t, p.IsAllowed("konuro.net"))
+	require.Equal(t, []string{"konuro.net"}, p.Config().Deny)
+}
+
+func TestFederationPolicy_Override(t *testing.T) {
+	// given
+	p, _ := NewFederationPolicy(FederationConfig{}, nil)
+
+	// when
+	err0 := p.Override(FederationConfig{Deny: []string{"konuro.net"}})
+	err1 := p.Refresh()
+	overridden := p.Overridden()
+
+	err2 := p.Update(FederationConfig{})
+
+	// then
+	require.Nil(t, err0)
+	require.Nil(t, err1)
+	require.Nil(t, err2)
+	require.True(t, overridden)
+	require.False(t, p.Overridden())
+	require.True(t, p.IsAllowed("konuro.net"))
+}
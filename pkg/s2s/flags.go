@@ -20,6 +20,7 @@ const (
 	fSecured               uint8 = 1 << 0
 	fAuthenticated               = 1 << 2
 	fDialbackKeyAuthorized       = 1 << 3
+	fBidi                        = 1 << 4
 )
 
 type flags struct {
@@ -63,6 +64,18 @@ func (f *flags) setDialbackKeyAuthorized() {
 	f.fs = f.fs | fDialbackKeyAuthorized
 }
 
+func (f *flags) isBidi() bool {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+	return f.fs&fBidi > 0
+}
+
+func (f *flags) setBidi() {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.fs = f.fs | fBidi
+}
+
 func (f *flags) get() uint8 {
 	f.mtx.RLock()
 	defer f.mtx.RUnlock()
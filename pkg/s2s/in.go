@@ -17,6 +17,7 @@ package s2s
 import (
 	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"errors"
 	"sync"
 	"sync/atomic"
@@ -60,6 +61,7 @@ type inConfig struct {
 	maxStanzaSize int
 	directTLS     bool
 	tlsConfig     *tls.Config
+	resumeTimeout time.Duration
 }
 
 type inS2S struct {
@@ -72,15 +74,18 @@ type inS2S struct {
 	comps        components
 	mods         modules
 	outProvider  outProvider
+	peerAuth     *PeerAuthenticator
 	inHub        *InHub
 	kv           kv.KV
-	shapers      shaper.Shapers
+	shapers      *shaper.Registry
 	hk           *hook.Hooks
 	logger       kitlog.Logger
 	rq           *runqueue.RunQueue
 	discTm       *time.Timer
 	doneCh       chan struct{}
 	sendDisabled bool
+	smID         string
+	smH          uint32
 
 	mu     sync.RWMutex
 	state  inState
@@ -97,9 +102,10 @@ func newInS2S(
 	comps *component.Components,
 	mods *module.Modules,
 	outProvider *OutProvider,
+	peerAuth *PeerAuthenticator,
 	inHub *InHub,
 	kv kv.KV,
-	shapers shaper.Shapers,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 	cfg inConfig,
@@ -112,7 +118,7 @@ func newInS2S(
 	// create session
 	id := nextStreamID()
 
-	sLogger := kitlog.With(logger, "id", id)
+	sLogger := kitlog.With(logger, "id", id, "remote_address", tr.RemoteAddr())
 	session := xmppsession.New(
 		xmppsession.S2SSession,
 		id.String(),
@@ -134,6 +140,7 @@ func newInS2S(
 		comps:       comps,
 		mods:        mods,
 		outProvider: outProvider,
+		peerAuth:    peerAuth,
 		inHub:       inHub,
 		kv:          kv,
 		shapers:     shapers,
@@ -260,12 +267,22 @@ func (s *inS2S) handleConnecting(ctx context.Context, elem stravaganza.Element)
 	if len(s.target) == 0 {
 		s.target = s.hosts.DefaultHostName()
 	}
+	s.mu.Lock()
 	s.sender = elem.Attribute(stravaganza.From)
+	s.mu.Unlock()
 
 	// set remote domain JID
 	s.jd, _ = jid.New("", s.sender, "", true)
 	s.session.SetFromJID(s.jd)
 
+	if len(s.sender) > 0 && !s.inHub.isAllowed(s.sender) {
+		level.Info(s.logger).Log("msg", "rejected S2S incoming stream: denied by federation policy",
+			"sender", s.sender,
+			"target", s.target,
+		)
+		return s.disconnect(ctx, streamerror.E(streamerror.PolicyViolation))
+	}
+
 	fb := stravaganza.NewBuilder("stream:features")
 	fb.WithAttribute("xmlns:stream", streamNamespace)
 	fb.WithAttribute("version", "1.0")
@@ -285,19 +302,48 @@ func (s *inS2S) handleConnecting(ctx context.Context, elem stravaganza.Element)
 		}
 		return s.session.Send(ctx, fb.Build())
 	}
+	certAuthRequired := s.peerAuth.IsCertAuthRequired(s.sender)
+
 	if !s.flags.isAuthenticated() {
-		fb.WithChild(stravaganza.NewBuilder("mechanisms").
-			WithAttribute(stravaganza.Namespace, saslNamespace).
-			WithChild(
-				stravaganza.NewBuilder("mechanism").
-					WithText("EXTERNAL").
-					Build(),
-			).
+		// only offer SASL EXTERNAL in case peer certificate is acceptable (XEP-0178)
+		// or might be once its POSH document is retrieved.
+		switch err := s.peerAuth.PrecheckCertificates(s.tr.PeerCertificates(), s.sender); {
+		case err == nil, errors.Is(err, ErrPOSHPending):
+			fb.WithChild(stravaganza.NewBuilder("mechanisms").
+				WithAttribute(stravaganza.Namespace, saslNamespace).
+				WithChild(
+					stravaganza.NewBuilder("mechanism").
+						WithText("EXTERNAL").
+						Build(),
+				).
+				Build(),
+			)
+
+		case certAuthRequired:
+			level.Info(s.logger).Log("msg", "rejected S2S incoming stream: invalid peer certificate",
+				"sender", s.sender,
+				"target", s.target,
+				"err", err,
+			)
+			return s.disconnect(ctx, streamerror.E(streamerror.PolicyViolation))
+		}
+	}
+	if !certAuthRequired {
+		fb.WithChild(stravaganza.NewBuilder("dialback").
+			WithAttribute(stravaganza.Namespace, dialbackNamespace).
+			Build(),
+		)
+	}
+	if !s.flags.isAuthenticated() {
+		// offer bidirectional stream (XEP-0288)
+		fb.WithChild(stravaganza.NewBuilder("bidi").
+			WithAttribute(stravaganza.Namespace, bidiFeatureNamespace).
 			Build(),
 		)
 	}
-	fb.WithChild(stravaganza.NewBuilder("dialback").
-		WithAttribute(stravaganza.Namespace, dialbackNamespace).
+	// offer stream management (XEP-0198)
+	fb.WithChild(stravaganza.NewBuilder("sm").
+		WithAttribute(stravaganza.Namespace, smNamespace).
 		Build(),
 	)
 	s.setState(inConnected)
@@ -321,8 +367,18 @@ func (s *inS2S) handleConnected(ctx context.Context, elem stravaganza.Element) e
 	case elem.Name() == "db:verify":
 		return s.verifyDialbackKey(ctx, elem)
 
+	case elem.Name() == "bidi" && elem.Attribute(stravaganza.Namespace) == bidiNamespace:
+		s.flags.setBidi()
+		return nil
+
+	case elem.Attribute(stravaganza.Namespace) == smNamespace && (s.flags.isAuthenticated() || s.flags.isDialbackKeyAuthorized()):
+		return s.handleStreamManagement(ctx, elem)
+
 	default:
 		if s.flags.isAuthenticated() || s.flags.isDialbackKeyAuthorized() {
+			if _, ok := elem.(stravaganza.Stanza); ok {
+				s.handleInStanza()
+			}
 			// post element received event
 			hi := &hook.S2SStreamInfo{
 				ID:      s.ID().String(),
@@ -553,16 +609,28 @@ func (s *inS2S) authenticate(ctx context.Context, elem stravaganza.Element) erro
 	if elem.Attribute("mechanism") != "EXTERNAL" {
 		return s.failAuthentication(ctx, "invalid-mechanism", "")
 	}
-	// validate initiating server certificate
-	certs := s.tr.PeerCertificates()
-	for _, cert := range certs {
-		for _, dnsName := range cert.DNSNames {
-			if dnsName == s.sender {
-				return s.finishAuthentication(ctx)
-			}
+	// validate authorization identity
+	if authzID := elem.Text(); len(authzID) > 0 && authzID != "=" {
+		b, err := base64.StdEncoding.DecodeString(authzID)
+		if err != nil {
+			return s.failAuthentication(ctx, "incorrect-encoding", "")
+		}
+		if string(b) != s.sender {
+			return s.failAuthentication(ctx, "invalid-authzid", "")
 		}
 	}
-	return s.failAuthentication(ctx, "bad-protocol", "Failed to get peer certificate")
+	// validate initiating server certificate
+	switch err := s.peerAuth.VerifyCertificates(ctx, s.tr.PeerCertificates(), s.sender); {
+	case err == nil:
+		return s.finishAuthentication(ctx)
+
+	case errors.Is(err, errNoPeerCertificate):
+		return s.failAuthentication(ctx, "bad-protocol", "Failed to get peer certificate")
+
+	default:
+		level.Info(s.logger).Log("msg", "failed to verify S2S peer certificate", "sender", s.sender, "err", err)
+		return s.failAuthentication(ctx, "not-authorized", "")
+	}
 }
 
 func (s *inS2S) failAuthentication(ctx context.Context, reason, text string) error {
@@ -596,6 +664,9 @@ func (s *inS2S) finishAuthentication(ctx context.Context) error {
 	s.flags.setAuthenticated()
 	s.restartSession()
 
+	if s.flags.isBidi() {
+		s.registerBidi(s.target, s.sender)
+	}
 	return s.sendElement(ctx, stravaganza.NewBuilder("success").
 		WithAttribute(stravaganza.Namespace, saslNamespace).
 		Build(),
@@ -606,6 +677,13 @@ func (s *inS2S) authorizeDialbackKey(ctx context.Context, elem stravaganza.Eleme
 	if !s.hosts.IsLocalHost(elem.Attribute(stravaganza.To)) {
 		return s.sendElement(ctx, stanzaerror.E(stanzaerror.ItemNotFound, elem).Element())
 	}
+	if s.peerAuth.IsCertAuthRequired(elem.Attribute(stravaganza.From)) {
+		// dialback not allowed for this domain
+		return s.sendElement(ctx, stanzaerror.E(stanzaerror.NotAuthorized, elem).Element())
+	}
+	if !s.inHub.isAllowed(elem.Attribute(stravaganza.From)) {
+		return s.sendElement(ctx, stanzaerror.E(stanzaerror.NotAllowed, elem).Element())
+	}
 	elemFrom := elem.Attribute(stravaganza.From)
 	elemTo := elem.Attribute(stravaganza.To)
 
@@ -652,6 +730,10 @@ func (s *inS2S) handleDialbackResult(ctx context.Context, from, to string, dbRes
 			"target", s.target,
 		)
 		s.flags.setDialbackKeyAuthorized()
+
+		if s.flags.isBidi() {
+			s.registerBidi(to, from)
+		}
 	} else {
 		sb.WithAttribute(stravaganza.Type, "invalid")
 	}
@@ -720,10 +802,11 @@ func (s *inS2S) proceedStartTLS(ctx context.Context, elem stravaganza.Element) e
 	if err != nil {
 		return err
 	}
+	// peer certificate is validated on SASL EXTERNAL authentication, so that dialback can still be used as a fallback
 	s.tr.StartTLS(&tls.Config{
-		ServerName:   s.target,
-		ClientAuth:   tls.VerifyClientCertIfGiven,
-		Certificates: s.hosts.Certificates(),
+		ServerName:     s.target,
+		ClientAuth:     tls.RequestClientCert,
+		GetCertificate: s.hosts.GetCertificate,
 	}, false)
 	s.flags.setSecured()
 
@@ -750,8 +833,11 @@ func (s *inS2S) restartSession() {
 }
 
 func (s *inS2S) updateRateLimiter() error {
-	rLim := s.shapers.MatchingJID(s.jd).RateLimiter()
-	return s.tr.SetReadRateLimiter(rLim)
+	shp := s.inHub.shaper(s.jd)
+	if shp == nil {
+		shp = s.shapers.MatchingJID(s.jd)
+	}
+	return s.tr.SetReadRateLimiter(shp.RateLimiter())
 }
 
 func (s *inS2S) disconnect(ctx context.Context, streamErr *streamerror.Error) error {
@@ -794,6 +880,10 @@ func (s *inS2S) close(ctx context.Context) error {
 	// unregister S2S stream
 	s.inHub.unregister(s)
 
+	if len(s.smID) > 0 {
+		s.inHub.detachSM(s.smID, s.smH, s.cfg.resumeTimeout)
+	}
+
 	level.Info(s.logger).Log("msg", "unregistered S2S incoming stream",
 		"sender", s.sender,
 		"target", s.target,
@@ -812,6 +902,39 @@ func (s *inS2S) close(ctx context.Context) error {
 	return nil
 }
 
+func (s *inS2S) registerBidi(sender, target string) {
+	s.inHub.registerBidi(s.id, &bidiOut{
+		id:  stream.S2SOutID{Sender: sender, Target: target},
+		stm: s,
+	})
+	level.Info(s.logger).Log("msg", "registered S2S bidirectional stream", "sender", sender, "target", target)
+}
+
+func (s *inS2S) sendBidiElement(id stream.S2SOutID, elem stravaganza.Element) <-chan error {
+	errCh := make(chan error, 1)
+	s.rq.Run(func() {
+		ctx, cancel := s.requestContext()
+		defer cancel()
+
+		if s.getState() == inDisconnected {
+			errCh <- errBidiStreamClosed
+			return
+		}
+		if err := s.sendElement(ctx, elem); err != nil {
+			errCh <- err
+			return
+		}
+		_, err := s.runHook(ctx, hook.S2SOutStreamElementSent, &hook.S2SStreamInfo{
+			ID:      id.String(),
+			Sender:  id.Sender,
+			Target:  id.Target,
+			Element: elem,
+		})
+		errCh <- err
+	})
+	return errCh
+}
+
 func (s *inS2S) sendElement(ctx context.Context, elem stravaganza.Element) error {
 	if s.sendDisabled {
 		return nil
@@ -831,6 +954,12 @@ func (s *inS2S) setState(state inState) {
 	s.state = state
 }
 
+func (s *inS2S) remoteDomain() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sender
+}
+
 func (s *inS2S) getState() inState {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -24,24 +24,40 @@ import (
 	"github.com/go-kit/log/level"
 
 	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
+	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/router/stream"
+	"github.com/ortuman/jackal/pkg/shaper"
 )
 
+type bidiEntry struct {
+	inID stream.S2SInID
+	out  stream.S2SOut
+}
+
 // InHub represents an S2S incoming connection hub.
 type InHub struct {
-	mu      sync.RWMutex
-	streams map[stream.S2SInID]stream.S2SIn
-	doneCh  chan chan struct{}
-	logger  kitlog.Logger
+	mu          sync.RWMutex
+	streams     map[stream.S2SInID]s2sIn
+	bidiStreams map[string]bidiEntry
+	smSessions  map[string]*inSMSession
+	bidiInFn    func(out *outS2S) *inS2S
+	fedPolicy   *FederationPolicy
+	doneCh      chan chan struct{}
+	logger      kitlog.Logger
 }
 
 // NewInHub creates and initializes a new InHub instance.
-func NewInHub(logger kitlog.Logger) *InHub {
-	return &InHub{
-		streams: make(map[stream.S2SInID]stream.S2SIn),
-		doneCh:  make(chan chan struct{}),
-		logger:  logger,
+func NewInHub(fedPolicy *FederationPolicy, logger kitlog.Logger) *InHub {
+	h := &InHub{
+		streams:     make(map[stream.S2SInID]s2sIn),
+		bidiStreams: make(map[string]bidiEntry),
+		smSessions:  make(map[string]*inSMSession),
+		fedPolicy:   fedPolicy,
+		doneCh:      make(chan chan struct{}),
+		logger:      logger,
 	}
+	fedPolicy.observe(h.disconnectDenied)
+	return h
 }
 
 // Start starts InHub instance.
@@ -95,18 +111,145 @@ func (h *InHub) Stop(ctx context.Context) error {
 	return err
 }
 
-func (h *InHub) register(stm stream.S2SIn) {
+func (h *InHub) register(stm s2sIn) {
 	h.mu.Lock()
 	h.streams[stm.ID()] = stm
 	h.mu.Unlock()
 }
 
-func (h *InHub) unregister(stm stream.S2SIn) {
+func (h *InHub) unregister(stm s2sIn) {
 	h.mu.Lock()
 	delete(h.streams, stm.ID())
+	for domainPair, entry := range h.bidiStreams {
+		if entry.inID == stm.ID() {
+			delete(h.bidiStreams, domainPair)
+		}
+	}
+	h.mu.Unlock()
+}
+
+// registerBidi makes an incoming stream available to carry outgoing traffic.
+func (h *InHub) registerBidi(inID stream.S2SInID, out stream.S2SOut) {
+	id := out.ID()
+	h.mu.Lock()
+	h.bidiStreams[getDomainPair(id.Sender, id.Target)] = bidiEntry{inID: inID, out: out}
+	h.mu.Unlock()
+}
+
+// bidiOut returns an outgoing stream carried over a bidirectional incoming one, if any.
+func (h *InHub) bidiOut(sender, target string) stream.S2SOut {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	entry, ok := h.bidiStreams[getDomainPair(sender, target)]
+	if !ok {
+		return nil
+	}
+	return entry.out
+}
+
+// registerSM registers a resumable stream management session bound to an incoming stream.
+func (h *InHub) registerSM(smID string, sender, target string) {
+	h.mu.Lock()
+	h.smSessions[smID] = &inSMSession{
+		sender:   sender,
+		target:   target,
+		attached: true,
+	}
 	h.mu.Unlock()
 }
 
+// detachSM keeps a stream management session available for resumption during timeout
+// once its incoming stream is gone.
+func (h *InHub) detachSM(smID string, handled uint32, timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sess := h.smSessions[smID]
+	if sess == nil {
+		return
+	}
+	sess.h = handled
+	sess.attached = false
+	sess.tm = time.AfterFunc(timeout, func() {
+		h.mu.Lock()
+		if h.smSessions[smID] == sess && !sess.attached {
+			delete(h.smSessions, smID)
+		}
+		h.mu.Unlock()
+	})
+}
+
+// resumeSM binds a detached stream management session to a new incoming stream,
+// returning the count of stanzas handled by the former one.
+func (h *InHub) resumeSM(smID string, sender, target string) (uint32, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sess := h.smSessions[smID]
+	if sess == nil || sess.attached || sess.sender != sender || sess.target != target {
+		return 0, false
+	}
+	if sess.tm != nil {
+		sess.tm.Stop()
+	}
+	sess.attached = true
+	return sess.h, true
+}
+
+// setBidiInFactory sets the function used to create incoming streams carried over
+// bidirectional outgoing ones.
+func (h *InHub) setBidiInFactory(fn func(out *outS2S) *inS2S) {
+	h.mu.Lock()
+	h.bidiInFn = fn
+	h.mu.Unlock()
+}
+
+// supportsBidi tells whether incoming traffic can be processed over outgoing streams.
+func (h *InHub) supportsBidi() bool {
+	if h == nil {
+		return false
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.bidiInFn != nil
+}
+
+// newBidiIn returns an incoming stream processing stanzas received over out bidirectional stream.
+func (h *InHub) newBidiIn(out *outS2S) *inS2S {
+	h.mu.RLock()
+	fn := h.bidiInFn
+	h.mu.RUnlock()
+	return fn(out)
+}
+
+func (h *InHub) isAllowed(domain string) bool {
+	return h.fedPolicy.IsAllowed(domain)
+}
+
+func (h *InHub) shaper(domainJID *jid.JID) *shaper.Shaper {
+	return h.fedPolicy.Shaper(domainJID)
+}
+
+// disconnectDenied closes all incoming streams whose remote domain is no longer allowed.
+func (h *InHub) disconnectDenied() {
+	var denied []s2sIn
+	h.mu.RLock()
+	for _, stm := range h.streams {
+		if domain := stm.remoteDomain(); len(domain) > 0 && !h.fedPolicy.IsAllowed(domain) {
+			denied = append(denied, stm)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, stm := range denied {
+		level.Info(h.logger).Log("msg", "closing S2S incoming stream denied by federation policy", "sender", stm.remoteDomain())
+		stm.Disconnect(streamerror.E(streamerror.PolicyViolation))
+	}
+}
+
 func (h *InHub) reportMetrics() {
 	tc := time.NewTicker(reportTotalConnectionsInterval)
 	defer tc.Stop()
@@ -42,7 +42,7 @@ func TestInHub_StartStop(t *testing.T) {
 	}
 
 	h := &InHub{
-		streams: make(map[stream.S2SInID]stream.S2SIn),
+		streams: make(map[stream.S2SInID]s2sIn),
 		doneCh:  make(chan chan struct{}),
 		logger:  kitlog.NewNopLogger(),
 	}
@@ -58,3 +58,31 @@ func TestInHub_StartStop(t *testing.T) {
 	require.Len(t, mockStm.DisconnectCalls(), 1)
 	require.Equal(t, discReason, streamerror.SystemShutdown)
 }
+
+func TestInHub_DisconnectDenied(t *testing.T) {
+	// given
+	fedPolicy, _ := NewFederationPolicy(FederationConfig{}, nil)
+	h := NewInHub(fedPolicy, kitlog.NewNopLogger())
+
+	newStm := func(id stream.S2SInID, domain string) *s2sInMock {
+		stm := &s2sInMock{}
+		stm.IDFunc = func() stream.S2SInID { return id }
+		stm.remoteDomainFunc = func() string { return domain }
+		stm.DisconnectFunc = func(streamErr *streamerror.Error) <-chan error { return nil }
+		return stm
+	}
+	stm1 := newStm(1, "jabber.org")
+	stm2 := newStm(2, "xmpp.spam.im")
+
+	h.register(stm1)
+	h.register(stm2)
+
+	// when
+	err := fedPolicy.Update(FederationConfig{Deny: []string{"*.spam.im"}})
+
+	// then
+	require.Nil(t, err)
+	require.Len(t, stm1.DisconnectCalls(), 0)
+	require.Len(t, stm2.DisconnectCalls(), 1)
+	require.Equal(t, streamerror.PolicyViolation, stm2.DisconnectCalls()[0].StreamErr.Reason)
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
 	"github.com/ortuman/jackal/pkg/transport"
+	"github.com/ortuman/jackal/pkg/util/stringmatcher"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/time/rate"
 )
@@ -68,7 +69,7 @@ func TestInS2S_Disconnect(t *testing.T) {
 		tr:      trMock,
 		rq:      runqueue.New("in_s2s:test"),
 		doneCh:  make(chan struct{}),
-		inHub:   NewInHub(kitlog.NewNopLogger()),
+		inHub:   NewInHub(nil, kitlog.NewNopLogger()),
 		hk:      hook.NewHooks(),
 		logger:  kitlog.NewNopLogger(),
 	}
@@ -86,6 +87,74 @@ func TestInS2S_Disconnect(t *testing.T) {
 	require.Len(t, trMock.CloseCalls(), 1)
 }
 
+func TestInS2S_BidiOut(t *testing.T) {
+	// given
+	trMock := &transportMock{}
+	trMock.CloseFunc = func() error { return nil }
+
+	sessMock := &sessionMock{}
+
+	var mtx sync.RWMutex
+
+	sendBuf := bytes.NewBuffer(nil)
+	sessMock.SendFunc = func(ctx context.Context, element stravaganza.Element) error {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		_ = element.ToXML(sendBuf, true)
+		return nil
+	}
+	inHub := NewInHub(nil, kitlog.NewNopLogger())
+
+	s := &inS2S{
+		id:      1,
+		state:   inConnected,
+		flags:   flags{fs: fSecured | fAuthenticated | fBidi},
+		sender:  "jabber.org",
+		target:  "jackal.im",
+		session: sessMock,
+		tr:      trMock,
+		rq:      runqueue.New("in_s2s:test"),
+		doneCh:  make(chan struct{}),
+		inHub:   inHub,
+		hk:      hook.NewHooks(),
+		logger:  kitlog.NewNopLogger(),
+	}
+	inHub.register(s)
+	s.registerBidi("jackal.im", "jabber.org")
+
+	op := &OutProvider{
+		inHub:      inHub,
+		outStreams: make(map[string]s2sOut),
+		newOutFn: func(sender, target string) s2sOut {
+			require.Fail(t, "unexpected outgoing stream dial")
+			return nil
+		},
+	}
+
+	// when
+	out, err := op.GetOut(context.Background(), "jackal.im", "jabber.org")
+	require.Nil(t, err)
+
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "noelia@jabber.org/balcony").
+		BuildMessage()
+	sendErr := <-out.SendElement(msg)
+
+	_ = s.close(context.Background())
+
+	// then
+	require.Nil(t, sendErr)
+	require.Equal(t, stream.S2SOutID{Sender: "jackal.im", Target: "jabber.org"}, out.ID())
+
+	mtx.Lock()
+	require.Equal(t, `<message from='ortuman@jackal.im/yard' to='noelia@jabber.org/balcony'/>`, sendBuf.String())
+	mtx.Unlock()
+
+	require.Nil(t, inHub.bidiOut("jackal.im", "jabber.org"))
+}
+
 func TestInS2S_HandleSessionElement(t *testing.T) {
 	var tests = []struct {
 		name string
@@ -98,6 +167,7 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 		kvGetFn          func(ctx context.Context, key string) ([]byte, error)
 		routeError       error
 		flags            uint8
+		requireCertAuth  []string
 		waitBeforeAssert time.Duration
 
 		// expectations
@@ -128,13 +198,47 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 				return stravaganza.NewBuilder("stream:stream").
 					WithAttribute(stravaganza.Namespace, "jabber:server").
 					WithAttribute(stravaganza.StreamNamespace, "http://etherx.jabber.org/streams").
+					WithAttribute(stravaganza.From, "jabber.org").
 					WithAttribute(stravaganza.To, "localhost").
 					WithAttribute(stravaganza.Version, "1.0").
 					Build(), nil
 			},
-			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><mechanisms xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><mechanism>EXTERNAL</mechanism></mechanisms><dialback xmlns='urn:xmpp:features:dialback'/></stream:features>`,
+			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><mechanisms xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><mechanism>EXTERNAL</mechanism></mechanisms><dialback xmlns='urn:xmpp:features:dialback'/><bidi xmlns='urn:xmpp:features:bidi'/><sm xmlns='urn:xmpp:sm:3'/></stream:features>`,
 			expectedState:  inConnected,
 		},
+		{
+			name:  "Connecting/SecuredUntrustedCertificate",
+			state: inConnecting,
+			flags: fSecured,
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("stream:stream").
+					WithAttribute(stravaganza.Namespace, "jabber:server").
+					WithAttribute(stravaganza.StreamNamespace, "http://etherx.jabber.org/streams").
+					WithAttribute(stravaganza.From, "konuro.net").
+					WithAttribute(stravaganza.To, "localhost").
+					WithAttribute(stravaganza.Version, "1.0").
+					Build(), nil
+			},
+			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><dialback xmlns='urn:xmpp:features:dialback'/><bidi xmlns='urn:xmpp:features:bidi'/><sm xmlns='urn:xmpp:sm:3'/></stream:features>`,
+			expectedState:  inConnected,
+		},
+		{
+			name:            "Connecting/SecuredCertAuthRequired",
+			state:           inConnecting,
+			flags:           fSecured,
+			requireCertAuth: []string{"*.net"},
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("stream:stream").
+					WithAttribute(stravaganza.Namespace, "jabber:server").
+					WithAttribute(stravaganza.StreamNamespace, "http://etherx.jabber.org/streams").
+					WithAttribute(stravaganza.From, "konuro.net").
+					WithAttribute(stravaganza.To, "localhost").
+					WithAttribute(stravaganza.Version, "1.0").
+					Build(), nil
+			},
+			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:error><policy-violation xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></stream:error></stream:stream>`,
+			expectedState:  inDisconnected,
+		},
 		{
 			name:  "Connecting/SecuredAndAuthenticated",
 			state: inConnecting,
@@ -147,7 +251,7 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 					WithAttribute(stravaganza.Version, "1.0").
 					Build(), nil
 			},
-			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><dialback xmlns='urn:xmpp:features:dialback'/></stream:features>`,
+			expectedOutput: `<?xml version='1.0'?><stream:stream xmlns='jabber:server' xmlns:stream='http://etherx.jabber.org/streams' id='s2s1' from='localhost' version='1.0'><stream:features xmlns:stream='http://etherx.jabber.org/streams' version='1.0'><dialback xmlns='urn:xmpp:features:dialback'/><sm xmlns='urn:xmpp:sm:3'/></stream:features>`,
 			expectedState:  inConnected,
 		},
 		{
@@ -161,6 +265,18 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 			expectedOutput: `<proceed xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>`,
 			expectedState:  inConnecting,
 		},
+		{
+			name:  "Connected/Bidi",
+			state: inConnected,
+			flags: fSecured,
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("bidi").
+					WithAttribute(stravaganza.Namespace, bidiNamespace).
+					Build(), nil
+			},
+			expectedState: inConnected,
+			expectedFlags: fSecured | fBidi,
+		},
 		{
 			name:   "Connected/Authenticate",
 			state:  inConnected,
@@ -190,7 +306,23 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 					WithText("=").
 					Build(), nil
 			},
-			expectedOutput: `<failure xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><bad-protocol/><text>Failed to get peer certificate</text></failure>`,
+			expectedOutput: `<failure xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><not-authorized/></failure>`,
+			expectedState:  inConnected,
+		},
+		{
+			name:   "Connected/FailAuthenticateInvalidAuthzID",
+			state:  inConnected,
+			sender: "jabber.org",
+			target: "jackal.im",
+			flags:  fSecured,
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("auth").
+					WithAttribute(stravaganza.Namespace, saslNamespace).
+					WithAttribute("mechanism", "EXTERNAL").
+					WithText("a29udXJvLm5ldA==").
+					Build(), nil
+			},
+			expectedOutput: `<failure xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><invalid-authzid/></failure>`,
 			expectedState:  inConnected,
 		},
 		{
@@ -232,6 +364,24 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 			expectedState:    inConnected,
 			expectedFlags:    fSecured | fAuthenticated | fDialbackKeyAuthorized,
 		},
+		{
+			name:            "Connected/AuthorizeDialbackKeyCertAuthRequired",
+			state:           inConnected,
+			sender:          "jabber.org",
+			target:          "jackal.im",
+			flags:           fSecured,
+			requireCertAuth: []string{"jabber.org"},
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("db:result").
+					WithAttribute(stravaganza.ID, "abc1234").
+					WithAttribute(stravaganza.From, "jabber.org").
+					WithAttribute(stravaganza.To, "jackal.im").
+					WithText("7b909f82401feae55b75289e73d73d0889f1713ae838817feed18bdf427eb03c").
+					Build(), nil
+			},
+			expectedOutput: `<db:result id='abc1234' from='jackal.im' to='jabber.org' type='error'>7b909f82401feae55b75289e73d73d0889f1713ae838817feed18bdf427eb03c<error code='405' type='auth'><not-authorized xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></error></db:result>`,
+			expectedState:  inConnected,
+		},
 		{
 			name:  "Connected/RouteIQSuccess",
 			state: inConnected,
@@ -313,6 +463,9 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 			expectRouted:  true,
 		},
 	}
+	caCert, caKey := testCACertificate(t)
+	leafCert := testLeafCertificate(t, caCert, caKey, "jabber.org")
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// given
@@ -326,10 +479,7 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 
 			// transport mock
 			trMock.PeerCertificatesFunc = func() []*x509.Certificate {
-				cert := &x509.Certificate{
-					DNSNames: []string{"jabber.org"},
-				}
-				return []*x509.Certificate{cert}
+				return []*x509.Certificate{leafCert}
 			}
 			trMock.TypeFunc = func() transport.Type { return transport.Socket }
 			trMock.StartTLSFunc = func(cfg *tls.Config, asClient bool) {}
@@ -412,6 +562,14 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 				return dbStreamMock, nil
 			}
 
+			// peer authenticator
+			certRequired, _ := stringmatcher.NewWildcardMatcher(tt.requireCertAuth)
+			peerAuth := &PeerAuthenticator{
+				roots:        x509.NewCertPool(),
+				certRequired: certRequired,
+			}
+			peerAuth.roots.AddCert(caCert)
+
 			stm := &inS2S{
 				cfg: inConfig{
 					reqTimeout:    time.Minute,
@@ -431,6 +589,8 @@ func TestInS2S_HandleSessionElement(t *testing.T) {
 				comps:       compsMock,
 				session:     ssMock,
 				outProvider: outProviderMock,
+				peerAuth:    peerAuth,
+				inHub:       NewInHub(nil, kitlog.NewNopLogger()),
 				hk:          hook.NewHooks(),
 				logger:      kitlog.NewNopLogger(),
 			}
@@ -514,7 +674,7 @@ func TestInS2S_HandleSessionError(t *testing.T) {
 				tr:      trMock,
 				session: ssMock,
 				router:  routerMock,
-				inHub:   NewInHub(kitlog.NewNopLogger()),
+				inHub:   NewInHub(nil, kitlog.NewNopLogger()),
 				hk:      hook.NewHooks(),
 				logger:  kitlog.NewNopLogger(),
 			}
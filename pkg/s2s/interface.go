@@ -22,9 +22,11 @@ import (
 	"github.com/jackal-xmpp/stravaganza"
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/cluster/kv"
+	"github.com/ortuman/jackal/pkg/host"
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
 	"github.com/ortuman/jackal/pkg/transport"
+	dnsutil "github.com/ortuman/jackal/pkg/util/dns"
 )
 
 //go:generate moq -out kv.mock_test.go . kvStorage:kvMock
@@ -52,6 +54,7 @@ type hosts interface {
 	DefaultHostName() string
 
 	Certificates() []tls.Certificate
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
 	IsLocalHost(host string) bool
 }
 
@@ -91,6 +94,7 @@ type outProvider interface {
 //go:generate moq -out s2sin.mock_test.go . s2sIn
 type s2sIn interface {
 	stream.S2SIn
+	remoteDomain() string
 }
 
 //go:generate moq -out s2sout.mock_test.go . s2sOut
@@ -106,3 +110,19 @@ type s2sDialback interface {
 	dial(ctx context.Context) error
 	start() error
 }
+
+//go:generate moq -out tlsaresolver.mock_test.go . tlsaResolver
+type tlsaResolver interface {
+	LookupTLSA(ctx context.Context, port int, proto, host string) (records []dnsutil.TLSARecord, secure bool, err error)
+	LookupSRV(ctx context.Context, service, proto, name string) (addrs []*net.SRV, secure bool, err error)
+}
+
+//go:generate moq -out poshconfigs.mock_test.go . poshConfigs
+type poshConfigs interface {
+	POSHConfig(domain string) (host.POSHConfig, bool)
+}
+
+//go:generate moq -out poshfetcher.mock_test.go . poshFetcher
+type poshFetcher interface {
+	POSHFetcher
+}
@@ -23,6 +23,11 @@ import (
 
 const reportTotalConnectionsInterval = time.Second * 30
 
+const (
+	pendingResultSent    = "sent"
+	pendingResultBounced = "bounced"
+)
+
 var (
 	s2sIncomingConnectionRegistered = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -106,6 +111,43 @@ var (
 		},
 		[]string{"instance"},
 	)
+	s2sDANEVerifications = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "jackal",
+			Subsystem: "s2s",
+			Name:      "dane_verifications_total",
+			Help:      "The total number of outgoing connection DANE verifications.",
+		},
+		[]string{"instance", "policy", "result"},
+	)
+	s2sOutgoingPendingStanzas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "jackal",
+			Subsystem: "s2s",
+			Name:      "outgoing_pending_stanzas",
+			Help:      "Total stanzas queued waiting for an outgoing connection to be established.",
+		},
+		[]string{"instance"},
+	)
+	s2sOutgoingPendingOldestAge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "jackal",
+			Subsystem: "s2s",
+			Name:      "outgoing_pending_oldest_age_seconds",
+			Help:      "Age of the oldest stanza queued waiting for an outgoing connection to be established.",
+		},
+		[]string{"instance"},
+	)
+	s2sOutgoingPendingWaitDurationBucket = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "jackal",
+			Subsystem: "s2s",
+			Name:      "outgoing_pending_wait_duration_bucket",
+			Help:      "Bucketed histogram of the time stanzas spent queued waiting for an outgoing connection.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+		},
+		[]string{"instance", "result"},
+	)
 )
 
 func init() {
@@ -118,6 +160,10 @@ func init() {
 	prometheus.MustRegister(s2sIncomingRequestDurationBucket)
 	prometheus.MustRegister(s2sIncomingTotalConnections)
 	prometheus.MustRegister(s2sOutgoingTotalConnections)
+	prometheus.MustRegister(s2sDANEVerifications)
+	prometheus.MustRegister(s2sOutgoingPendingStanzas)
+	prometheus.MustRegister(s2sOutgoingPendingOldestAge)
+	prometheus.MustRegister(s2sOutgoingPendingWaitDurationBucket)
 }
 
 func reportIncomingConnectionRegistered() {
@@ -182,3 +228,28 @@ func reportTotalOutgoingConnections(totalConns int) {
 	}
 	s2sOutgoingTotalConnections.With(metricLabel).Set(float64(totalConns))
 }
+
+func reportDANEVerification(policy danePolicy, result string) {
+	metricLabel := prometheus.Labels{
+		"instance": instance.ID(),
+		"policy":   policy.String(),
+		"result":   result,
+	}
+	s2sDANEVerifications.With(metricLabel).Inc()
+}
+
+func reportOutgoingPendingStanzas(total int, oldestAge time.Duration) {
+	metricLabel := prometheus.Labels{
+		"instance": instance.ID(),
+	}
+	s2sOutgoingPendingStanzas.With(metricLabel).Set(float64(total))
+	s2sOutgoingPendingOldestAge.With(metricLabel).Set(oldestAge.Seconds())
+}
+
+func reportPendingStanzaWait(result string, durationInSecs float64) {
+	metricLabel := prometheus.Labels{
+		"instance": instance.ID(),
+		"result":   result,
+	}
+	s2sOutgoingPendingWaitDurationBucket.With(metricLabel).Observe(durationInSecs)
+}
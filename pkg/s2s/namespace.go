@@ -19,4 +19,10 @@ const (
 	saslNamespace     = "urn:ietf:params:xml:ns:xmpp-sasl"
 	tlsNamespace      = "urn:ietf:params:xml:ns:xmpp-tls"
 	dialbackNamespace = "urn:xmpp:features:dialback"
+
+	bidiFeatureNamespace = "urn:xmpp:features:bidi"
+	bidiNamespace        = "urn:xmpp:bidi"
+
+	smNamespace          = "urn:xmpp:sm:3"
+	stanzaErrorNamespace = "urn:ietf:params:xml:ns:xmpp-stanzas"
 )
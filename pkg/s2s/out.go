@@ -39,10 +39,13 @@ import (
 	xmppsession "github.com/ortuman/jackal/pkg/session"
 	"github.com/ortuman/jackal/pkg/shaper"
 	"github.com/ortuman/jackal/pkg/transport"
+	"golang.org/x/time/rate"
 )
 
 var (
 	errServerTimeout = errors.New("s2s: remote server timeout")
+
+	errRemoteConnectionClosed = errors.New("s2s: remote connection closed before authentication")
 )
 
 type outType int8
@@ -70,6 +73,7 @@ const (
 	outAuthenticated
 	outVerifyingDialbackKey
 	outAuthorizingDialbackKey
+	outNegotiatingSM
 	outDisconnected
 )
 
@@ -93,58 +97,75 @@ type outConfig struct {
 	dialTimeout   time.Duration
 	reqTimeout    time.Duration
 	maxStanzaSize int
+	dane          *daneVerifier
+	pending       *pendingQueue
+
+	sm                  *outSMState
+	smReqAckInterval    time.Duration
+	smWaitForAckTimeout time.Duration
 }
 
 type outS2S struct {
-	typ      outType
-	cfg      outConfig
-	sender   string
-	target   string
-	tr       transport.Transport
-	kv       kv.KV
-	session  session
-	dbParams DialbackParams
-	dialer   dialer
-	hosts    *host.Hosts
-	tlsCfg   *tls.Config
-	onClose  func(s *outS2S)
-	dbResCh  chan stream.DialbackResult
-	shapers  shaper.Shapers
-	hk       *hook.Hooks
-	logger   kitlog.Logger
-	rq       *runqueue.RunQueue
-
-	mu           sync.RWMutex
-	state        outState
-	flags        flags
-	pendingQueue []stravaganza.Element
+	typ       outType
+	cfg       outConfig
+	sender    string
+	target    string
+	tr        transport.Transport
+	kv        kv.KV
+	session   session
+	dbParams  DialbackParams
+	dialer    dialer
+	hosts     *host.Hosts
+	tlsCfg    *tls.Config
+	peerAuth  *PeerAuthenticator
+	fedPolicy *FederationPolicy
+	inHub     *InHub
+	bidiIn    *inS2S
+	onClose   func(s *outS2S)
+	dbResCh   chan stream.DialbackResult
+	shapers   *shaper.Registry
+	hk        *hook.Hooks
+	logger    kitlog.Logger
+	rq        *runqueue.RunQueue
+
+	mu              sync.RWMutex
+	state           outState
+	flags           flags
+	dialbackOffered bool
+	smOffered       bool
 }
 
 func newOutS2S(
 	sender string,
 	target string,
 	tlsCfg *tls.Config,
+	peerAuth *PeerAuthenticator,
+	fedPolicy *FederationPolicy,
+	inHub *InHub,
 	hosts *host.Hosts,
 	kv kv.KV,
-	shapers shaper.Shapers,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 	onClose func(s *outS2S),
 	cfg outConfig,
 ) *outS2S {
 	stm := &outS2S{
-		typ:     defaultType,
-		sender:  sender,
-		target:  target,
-		hosts:   hosts,
-		tlsCfg:  tlsCfg,
-		cfg:     cfg,
-		onClose: onClose,
-		kv:      kv,
-		shapers: shapers,
-		hk:      hk,
-		logger:  kitlog.With(logger, "sender", sender, "target", target),
-		dialer:  newDialer(cfg.dialTimeout, tlsCfg),
+		typ:       defaultType,
+		sender:    sender,
+		target:    target,
+		hosts:     hosts,
+		tlsCfg:    tlsCfg,
+		peerAuth:  peerAuth,
+		fedPolicy: fedPolicy,
+		inHub:     inHub,
+		cfg:       cfg,
+		onClose:   onClose,
+		kv:        kv,
+		shapers:   shapers,
+		hk:        hk,
+		logger:    kitlog.With(logger, "sender", sender, "target", target),
+		dialer:    newDialer(cfg.dialTimeout, tlsCfg, cfg.dane),
 	}
 	stm.rq = runqueue.New(stm.ID().String())
 	return stm
@@ -155,7 +176,7 @@ func newDialbackS2S(
 	target string,
 	tlsCfg *tls.Config,
 	hosts *host.Hosts,
-	shapers shaper.Shapers,
+	shapers *shaper.Registry,
 	logger kitlog.Logger,
 	cfg outConfig,
 	dbParams DialbackParams,
@@ -168,7 +189,7 @@ func newDialbackS2S(
 		tlsCfg:   tlsCfg,
 		cfg:      cfg,
 		dbParams: dbParams,
-		dialer:   newDialer(cfg.dialTimeout, tlsCfg),
+		dialer:   newDialer(cfg.dialTimeout, tlsCfg, cfg.dane),
 		dbResCh:  make(chan stream.DialbackResult, 1),
 		shapers:  shapers,
 		logger:   logger,
@@ -177,6 +198,16 @@ func newDialbackS2S(
 	return stm
 }
 
+func (s *outS2S) rateLimiter() *rate.Limiter {
+	if s.typ == defaultType {
+		targetJID, _ := jid.New("", s.target, "", true)
+		if shp := s.fedPolicy.Shaper(targetJID); shp != nil {
+			return shp.RateLimiter()
+		}
+	}
+	return s.shapers.DefaultS2S().RateLimiter()
+}
+
 func (s *outS2S) ID() stream.S2SOutID {
 	return stream.S2SOutID{Sender: s.sender, Target: s.target}
 }
@@ -220,9 +251,8 @@ func (s *outS2S) dial(ctx context.Context) error {
 
 	s.tr = transport.NewSocketTransport(conn, 0, 0)
 
-	// set default rate limiter
-	rLim := s.shapers.DefaultS2S().RateLimiter()
-	if err := s.tr.SetReadRateLimiter(rLim); err != nil {
+	// set rate limiter
+	if err := s.tr.SetReadRateLimiter(s.rateLimiter()); err != nil {
 		return err
 	}
 	s.session = xmppsession.New(
@@ -332,6 +362,10 @@ func (s *outS2S) handleElement(ctx context.Context, elem stravaganza.Element) er
 		err = s.handleVerifyingDialbackKey(ctx, elem)
 	case outAuthorizingDialbackKey:
 		err = s.handleAuthorizingDialbackKey(ctx, elem)
+	case outNegotiatingSM:
+		err = s.handleNegotiatingSM(ctx, elem)
+	case outAuthenticated:
+		err = s.handleAuthenticated(ctx, elem)
 	}
 	reportIncomingRequest(
 		elem.Name(),
@@ -362,11 +396,26 @@ func (s *outS2S) handleConnected(ctx context.Context, elem stravaganza.Element)
 			Build()
 		return s.sendElement(ctx, startTLS)
 	}
+	s.smOffered = elem.ChildNamespace("sm", smNamespace) != nil
+
 	if s.flags.isAuthenticated() {
 		return s.finishAuthentication(ctx)
 	}
 	switch s.typ {
 	case defaultType:
+		s.dialbackOffered = hasDialbackFeature(elem) && !s.peerAuth.IsCertAuthRequired(s.target)
+
+		if hasBidiFeature(elem) && s.inHub.supportsBidi() {
+			// request bidirectional stream (XEP-0288)
+			s.flags.setBidi()
+			err := s.sendElement(ctx, stravaganza.NewBuilder("bidi").
+				WithAttribute(stravaganza.Namespace, bidiNamespace).
+				Build(),
+			)
+			if err != nil {
+				return err
+			}
+		}
 		switch {
 		case hasExternalAuthMechanism(elem):
 			s.setState(outAuthenticating)
@@ -377,27 +426,12 @@ func (s *outS2S) handleConnected(ctx context.Context, elem stravaganza.Element)
 				Build(),
 			)
 
-		case hasDialbackFeature(elem):
-			streamID := s.session.StreamID()
+		case s.dialbackOffered:
+			return s.requestDialbackKeyVerification(ctx)
 
-			// register dialback request
-			if err := registerDbRequest(ctx, s.target, s.sender, streamID, s.kv); err != nil {
-				return err
-			}
-			s.setState(outVerifyingDialbackKey)
-			return s.sendElement(ctx, stravaganza.NewBuilder("db:result").
-				WithAttribute(stravaganza.From, s.sender).
-				WithAttribute(stravaganza.To, s.target).
-				WithText(
-					dbKey(
-						s.cfg.dbSecret,
-						s.target,
-						s.sender,
-						streamID,
-					),
-				).
-				Build(),
-			)
+		case hasDialbackFeature(elem):
+			level.Info(s.logger).Log("msg", "remote server must be authenticated via SASL EXTERNAL")
+			return s.disconnect(ctx, streamerror.E(streamerror.PolicyViolation))
 
 		default:
 			return s.disconnect(ctx, streamerror.E(streamerror.RemoteConnectionFailed))
@@ -443,6 +477,11 @@ func (s *outS2S) handleAuthenticating(ctx context.Context, elem stravaganza.Elem
 		return s.session.OpenStream(ctx)
 
 	case "failure":
+		if s.dialbackOffered {
+			// fall back to dialback
+			level.Info(s.logger).Log("msg", "S2S SASL EXTERNAL authentication failed, falling back to dialback")
+			return s.requestDialbackKeyVerification(ctx)
+		}
 		return s.disconnect(ctx, streamerror.E(streamerror.RemoteConnectionFailed))
 
 	default:
@@ -450,6 +489,29 @@ func (s *outS2S) handleAuthenticating(ctx context.Context, elem stravaganza.Elem
 	}
 }
 
+func (s *outS2S) requestDialbackKeyVerification(ctx context.Context) error {
+	streamID := s.session.StreamID()
+
+	// register dialback request
+	if err := registerDbRequest(ctx, s.target, s.sender, streamID, s.kv); err != nil {
+		return err
+	}
+	s.setState(outVerifyingDialbackKey)
+	return s.sendElement(ctx, stravaganza.NewBuilder("db:result").
+		WithAttribute(stravaganza.From, s.sender).
+		WithAttribute(stravaganza.To, s.target).
+		WithText(
+			dbKey(
+				s.cfg.dbSecret,
+				s.target,
+				s.sender,
+				streamID,
+			),
+		).
+		Build(),
+	)
+}
+
 func (s *outS2S) handleVerifyingDialbackKey(ctx context.Context, elem stravaganza.Element) error {
 	switch elem.Name() {
 	case "db:result":
@@ -485,6 +547,21 @@ func (s *outS2S) handleAuthorizingDialbackKey(ctx context.Context, elem stravaga
 	}
 }
 
+func (s *outS2S) handleAuthenticated(ctx context.Context, elem stravaganza.Element) error {
+	if s.cfg.sm != nil && elem.Attribute(stravaganza.Namespace) == smNamespace {
+		return s.handleStreamManagement(ctx, elem)
+	}
+	stanza, ok := elem.(stravaganza.Stanza)
+	if !ok || s.bidiIn == nil {
+		return nil
+	}
+	// process stanza sent by remote server over bidirectional stream
+	if stanza.ToJID().Domain() != s.sender {
+		return s.disconnect(ctx, streamerror.E(streamerror.HostUnknown))
+	}
+	return s.bidiIn.handleElement(ctx, stanza)
+}
+
 func (s *outS2S) handleSessionError(ctx context.Context, err error) {
 	switch err {
 	case xmppparser.ErrStreamClosedByPeer:
@@ -497,15 +574,36 @@ func (s *outS2S) handleSessionError(ctx context.Context, err error) {
 }
 
 func (s *outS2S) finishAuthentication(ctx context.Context) error {
+	if s.flags.isBidi() && s.bidiIn == nil {
+		s.bidiIn = s.inHub.newBidiIn(s)
+		level.Info(s.logger).Log("msg", "registered S2S bidirectional stream")
+	}
+	if s.cfg.sm != nil && s.smOffered {
+		// negotiate stream management before sending pending elements (XEP-0198)
+		s.setState(outNegotiatingSM)
+		return s.requestStreamManagement(ctx)
+	}
+	return s.sendPendingElements(ctx, nil)
+}
+
+func (s *outS2S) sendPendingElements(ctx context.Context, unacked []stravaganza.Stanza) error {
 	s.setState(outAuthenticated)
 
-	// send pending elements
-	for _, elem := range s.pendingQueue {
-		if err := s.sendElement(ctx, elem); err != nil {
+	// resend unacknowledged stanzas
+	for _, stanza := range unacked {
+		if err := s.sendElement(ctx, stanza); err != nil {
+			return err
+		}
+	}
+	// send pending stanzas
+	if s.cfg.pending == nil {
+		return nil
+	}
+	for _, stanza := range s.cfg.pending.drain() {
+		if err := s.sendElement(ctx, stanza); err != nil {
 			return err
 		}
 	}
-	s.pendingQueue = nil
 	return nil
 }
 
@@ -532,7 +630,10 @@ func (s *outS2S) sendOrEnqueueElement(ctx context.Context, elem stravaganza.Elem
 	case outAuthenticated:
 		return s.sendElement(ctx, elem)
 	default:
-		s.pendingQueue = append(s.pendingQueue, elem)
+		// hold stanzas until stream gets authenticated
+		if stanza, ok := elem.(stravaganza.Stanza); ok && s.cfg.pending != nil {
+			s.cfg.pending.push(stanza)
+		}
 	}
 	return nil
 }
@@ -542,6 +643,9 @@ func (s *outS2S) sendElement(ctx context.Context, elem stravaganza.Element) erro
 	if err != nil {
 		return err
 	}
+	if stanza, ok := elem.(stravaganza.Stanza); ok && s.cfg.sm != nil {
+		s.cfg.sm.handleOut(stanza)
+	}
 	reportOutgoingRequest(
 		elem.Name(),
 		elem.Attribute(stravaganza.Type),
@@ -558,6 +662,9 @@ func (s *outS2S) close(ctx context.Context) error {
 	// unregister S2S out stream
 	s.setState(outDisconnected)
 
+	if s.cfg.sm != nil {
+		s.cfg.sm.cancelTimers()
+	}
 	if s.onClose != nil {
 		s.onClose(s)
 	}
@@ -625,3 +732,7 @@ func hasExternalAuthMechanism(streamFeatures stravaganza.Element) bool {
 func hasDialbackFeature(streamFeatures stravaganza.Element) bool {
 	return streamFeatures.ChildrenNamespace("dialback", dialbackNamespace) != nil
 }
+
+func hasBidiFeature(streamFeatures stravaganza.Element) bool {
+	return streamFeatures.ChildNamespace("bidi", bidiFeatureNamespace) != nil
+}
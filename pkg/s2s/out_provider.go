@@ -24,26 +24,37 @@ import (
 
 	"github.com/go-kit/log/level"
 
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
 	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
 	"github.com/ortuman/jackal/pkg/cluster/kv"
 	"github.com/ortuman/jackal/pkg/hook"
 	"github.com/ortuman/jackal/pkg/host"
+	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/router/stream"
 	"github.com/ortuman/jackal/pkg/shaper"
 )
 
 // OutProvider is an outgoing S2S stream provider.
 type OutProvider struct {
-	cfg     OutConfig
-	hosts   *host.Hosts
-	kv      kv.KV
-	shapers shaper.Shapers
-	hk      *hook.Hooks
-	logger  kitlog.Logger
+	cfg       OutConfig
+	peerAuth  *PeerAuthenticator
+	fedPolicy *FederationPolicy
+	inHub     *InHub
+	dane      *danePolicies
+	hosts     *host.Hosts
+	kv        kv.KV
+	shapers   *shaper.Registry
+	hk        *hook.Hooks
+	logger    kitlog.Logger
 
 	mu         sync.RWMutex
+	router     router.Router
 	outStreams map[string]s2sOut
+	pendingQs  map[string]*pendingQueue
+	smStates   map[string]*outSMState
 	doneCh     chan chan struct{}
+	stopCh     chan struct{}
 
 	newOutFn func(sender, target string) s2sOut
 	newDbFn  func(sender, target string, dbParam DialbackParams) s2sDialback
@@ -52,25 +63,41 @@ type OutProvider struct {
 // NewOutProvider creates and initializes a new OutProvider instance.
 func NewOutProvider(
 	cfg OutConfig,
+	peerAuth *PeerAuthenticator,
+	fedPolicy *FederationPolicy,
+	inHub *InHub,
 	hosts *host.Hosts,
 	kv kv.KV,
-	shapers shaper.Shapers,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
-) *OutProvider {
+) (*OutProvider, error) {
+	dane, err := newDANEPolicies(cfg.DANE)
+	if err != nil {
+		return nil, err
+	}
 	op := &OutProvider{
 		cfg:        cfg,
+		peerAuth:   peerAuth,
+		fedPolicy:  fedPolicy,
+		inHub:      inHub,
+		dane:       dane,
 		hosts:      hosts,
 		shapers:    shapers,
 		kv:         kv,
 		hk:         hk,
 		logger:     logger,
 		outStreams: make(map[string]s2sOut),
+		pendingQs:  make(map[string]*pendingQueue),
+		smStates:   make(map[string]*outSMState),
 		doneCh:     make(chan chan struct{}),
+		stopCh:     make(chan struct{}),
 	}
 	op.newOutFn = op.newOutS2S
 	op.newDbFn = op.newDialbackS2S
-	return op
+
+	fedPolicy.observe(op.disconnectDenied)
+	return op, nil
 }
 
 // DialbackSecret returns dialback secret value.
@@ -78,8 +105,22 @@ func (p *OutProvider) DialbackSecret() string {
 	return p.cfg.DialbackSecret
 }
 
+// SetRouter sets the router used to bounce undelivered stanzas back to their senders.
+func (p *OutProvider) SetRouter(rtr router.Router) {
+	p.mu.Lock()
+	p.router = rtr
+	p.mu.Unlock()
+}
+
 // GetOut returns associated outgoing S2S stream given a sender-target pair domain.
 func (p *OutProvider) GetOut(ctx context.Context, sender, target string) (stream.S2SOut, error) {
+	if !p.fedPolicy.IsAllowed(target) {
+		return nil, errFederationDenied
+	}
+	// reuse bidirectional incoming stream (XEP-0288)
+	if bidiStm := p.inHub.bidiOut(sender, target); bidiStm != nil {
+		return bidiStm, nil
+	}
 	domainPair := getDomainPair(sender, target)
 
 	p.mu.RLock()
@@ -99,31 +140,17 @@ func (p *OutProvider) GetOut(ctx context.Context, sender, target string) (stream
 	p.outStreams[domainPair] = outStm
 	p.mu.Unlock()
 
-	if err := outStm.dial(ctx); err != nil {
-		p.mu.Lock()
-		delete(p.outStreams, domainPair)
-		p.mu.Unlock()
-		level.Warn(p.logger).Log("msg", "failed to dial outgoing S2S stream",
-			"err", err, "sender", sender, "target", target,
-		)
-		return nil, err
-	}
-	go func() {
-		if err := outStm.start(); err != nil {
-			p.mu.Lock()
-			delete(p.outStreams, domainPair)
-			p.mu.Unlock()
-			level.Warn(p.logger).Log("msg", "failed to start outgoing S2S stream",
-				"err", err, "sender", sender, "target", target,
-			)
-			return
-		}
-	}()
+	// stanzas will remain queued until stream gets established
+	go p.connect(sender, target, outStm)
+
 	return outStm, nil
 }
 
 // GetDialback returns associated dialback S2S stream given a sender-target pair domain and a parameters set.
 func (p *OutProvider) GetDialback(ctx context.Context, sender, target string, params DialbackParams) (stream.S2SDialback, error) {
+	if !p.fedPolicy.IsAllowed(target) {
+		return nil, errFederationDenied
+	}
 	outStm := p.newDbFn(sender, target, params)
 	if err := outStm.dial(ctx); err != nil {
 		level.Warn(p.logger).Log("msg", "failed to dial S2S dialback stream",
@@ -156,6 +183,9 @@ func (p *OutProvider) Stop(ctx context.Context) error {
 	p.doneCh <- ch
 	<-ch
 
+	// cancel pending reconnections
+	close(p.stopCh)
+
 	var stms []s2sOut
 
 	// grab all connections
@@ -185,19 +215,207 @@ func (p *OutProvider) Stop(ctx context.Context) error {
 	return nil
 }
 
+// disconnectDenied closes all outgoing streams whose target domain is no longer allowed.
+func (p *OutProvider) disconnectDenied() {
+	var denied []s2sOut
+	p.mu.RLock()
+	for _, stm := range p.outStreams {
+		if !p.fedPolicy.IsAllowed(stm.ID().Target) {
+			denied = append(denied, stm)
+		}
+	}
+	p.mu.RUnlock()
+
+	for _, stm := range denied {
+		level.Info(p.logger).Log("msg", "closing S2S outgoing stream denied by federation policy", "target", stm.ID().Target)
+		stm.Disconnect(streamerror.E(streamerror.PolicyViolation))
+	}
+}
+
+func (p *OutProvider) connect(sender, target string, outStm s2sOut) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.DialTimeout)
+	err := outStm.dial(ctx)
+	cancel()
+	if err != nil {
+		level.Warn(p.logger).Log("msg", "failed to dial outgoing S2S stream",
+			"err", err, "sender", sender, "target", target,
+		)
+		p.closed(sender, target, err)
+		return
+	}
+	if err := outStm.start(); err != nil {
+		level.Warn(p.logger).Log("msg", "failed to start outgoing S2S stream",
+			"err", err, "sender", sender, "target", target,
+		)
+		p.closed(sender, target, err)
+	}
+}
+
 func (p *OutProvider) unregister(stm *outS2S) {
 	id := stm.ID()
-	domainPair := getDomainPair(id.Sender, id.Target)
+	p.closed(id.Sender, id.Target, nil)
+}
+
+// closed releases the state associated to a domain pair whose outgoing stream is gone,
+// unless stanzas are still pending to be delivered, in which case the stream is re-established.
+func (p *OutProvider) closed(sender, target string, connErr error) {
+	domainPair := getDomainPair(sender, target)
+
 	p.mu.Lock()
 	delete(p.outStreams, domainPair)
+
+	pq := p.pendingQs[domainPair]
+	redial := pq != nil && pq.len() > 0
+	if pq != nil && !redial {
+		delete(p.pendingQs, domainPair)
+	}
+	sm := p.smStates[domainPair]
+	resume := !redial && sm != nil && sm.pendingCount() > 0
+	if sm != nil && !redial && !resume {
+		delete(p.smStates, domainPair)
+	}
 	p.mu.Unlock()
+
+	if redial {
+		if connErr == nil {
+			connErr = errRemoteConnectionClosed
+		}
+		backoff := pq.setConnectError(connErr, p.cfg.PendingQueue.InitialBackoff, p.cfg.PendingQueue.MaxBackoff)
+		time.AfterFunc(backoff, func() {
+			p.redial(sender, target)
+		})
+		return
+	}
+	if pq != nil {
+		pq.close(stanzaerror.RemoteServerTimeout)
+	}
+	if resume {
+		go p.resumeOut(sender, target)
+	}
+}
+
+// redial re-establishes an outgoing stream in order to deliver its pending stanzas.
+func (p *OutProvider) redial(sender, target string) {
+	select {
+	case <-p.stopCh:
+		return
+	default:
+		break
+	}
+	domainPair := getDomainPair(sender, target)
+
+	p.mu.Lock()
+	pq := p.pendingQs[domainPair]
+	if pq == nil || p.outStreams[domainPair] != nil {
+		p.mu.Unlock()
+		return
+	}
+	if pq.len() == 0 {
+		// pending stanzas already expired
+		delete(p.pendingQs, domainPair)
+		p.mu.Unlock()
+		pq.close(stanzaerror.RemoteServerTimeout)
+		return
+	}
+	p.mu.Unlock()
+
+	level.Info(p.logger).Log("msg", "redialing outgoing S2S stream", "sender", sender, "target", target, "pending", pq.len())
+
+	if _, err := p.GetOut(context.Background(), sender, target); err != nil {
+		p.mu.Lock()
+		delete(p.pendingQs, domainPair)
+		p.mu.Unlock()
+		pq.close(stanzaerror.RemoteServerNotFound)
+	}
+}
+
+// resumeOut re-establishes an outgoing stream in order to resend its unacknowledged stanzas (XEP-0198),
+// bouncing them back once retry budget is exhausted.
+func (p *OutProvider) resumeOut(sender, target string) {
+	domainPair := getDomainPair(sender, target)
+	for {
+		select {
+		case <-time.After(p.cfg.StreamManagement.RetryInterval):
+			break
+		case <-p.stopCh:
+			return
+		}
+		p.mu.Lock()
+		sm := p.smStates[domainPair]
+		if sm == nil || p.outStreams[domainPair] != nil {
+			p.mu.Unlock()
+			return // already re-established
+		}
+		retry := p.fedPolicy.IsAllowed(target) && sm.incRetries(p.cfg.StreamManagement.MaxRetries)
+		if !retry {
+			delete(p.smStates, domainPair)
+		}
+		p.mu.Unlock()
+
+		if !retry {
+			unacked := sm.disable()
+			level.Info(p.logger).Log("msg", "bouncing unacknowledged S2S stanzas",
+				"sender", sender, "target", target, "count", len(unacked),
+			)
+			p.bounce(unacked, stanzaerror.RemoteServerTimeout)
+			return
+		}
+		if _, err := p.GetOut(context.Background(), sender, target); err == nil {
+			return
+		}
+	}
+}
+
+// bounce replies to the senders of a set of undelivered stanzas with an error.
+func (p *OutProvider) bounce(stanzas []stravaganza.Stanza, reason stanzaerror.Reason) {
+	p.mu.RLock()
+	rtr := p.router
+	p.mu.RUnlock()
+	if rtr == nil {
+		return
+	}
+	for _, stanza := range stanzas {
+		if stanza.Attribute(stravaganza.Type) == stravaganza.ErrorType {
+			continue
+		}
+		if iq, ok := stanza.(*stravaganza.IQ); ok && iq.IsResult() {
+			continue
+		}
+		errStanza, err := stanzaerror.E(reason, stanza).Stanza(false)
+		if err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), p.cfg.RequestTimeout)
+		if _, err := rtr.Route(ctx, errStanza); err != nil {
+			level.Warn(p.logger).Log("msg", "failed to bounce S2S stanza", "err", err)
+		}
+		cancel()
+	}
 }
 
 func (p *OutProvider) newOutS2S(sender, target string) s2sOut {
+	domainPair := getDomainPair(sender, target)
+
+	// invoked while holding provider lock
+	pq := p.pendingQs[domainPair]
+	if pq == nil {
+		pq = newPendingQueue(p.cfg.PendingQueue.MaxSize, p.cfg.PendingQueue.Timeout, p.bounce)
+		p.pendingQs[domainPair] = pq
+	}
+	var sm *outSMState
+	if p.cfg.StreamManagement.Enabled {
+		if sm = p.smStates[domainPair]; sm == nil {
+			sm = &outSMState{}
+			p.smStates[domainPair] = sm
+		}
+	}
 	return newOutS2S(
 		sender,
 		target,
-		p.tlsConfig(target),
+		p.tlsConfig(sender, target),
+		p.peerAuth,
+		p.fedPolicy,
+		p.inHub,
 		p.hosts,
 		p.kv,
 		p.shapers,
@@ -209,6 +427,12 @@ func (p *OutProvider) newOutS2S(sender, target string) s2sOut {
 			dialTimeout:   p.cfg.DialTimeout,
 			reqTimeout:    p.cfg.RequestTimeout,
 			maxStanzaSize: p.cfg.MaxStanzaSize,
+			dane:          p.dane.verifier(target),
+			pending:       pq,
+
+			sm:                  sm,
+			smReqAckInterval:    p.cfg.StreamManagement.RequestAckInterval,
+			smWaitForAckTimeout: p.cfg.StreamManagement.WaitForAckTimeout,
 		},
 	)
 }
@@ -217,7 +441,7 @@ func (p *OutProvider) newDialbackS2S(sender, target string, dbParams DialbackPar
 	return newDialbackS2S(
 		sender,
 		target,
-		p.tlsConfig(target),
+		p.tlsConfig(sender, target),
 		p.hosts,
 		p.shapers,
 		p.logger,
@@ -226,15 +450,31 @@ func (p *OutProvider) newDialbackS2S(sender, target string, dbParams DialbackPar
 			dialTimeout:   p.cfg.DialTimeout,
 			reqTimeout:    p.cfg.RequestTimeout,
 			maxStanzaSize: p.cfg.MaxStanzaSize,
+			dane:          p.dane.verifier(target),
 		},
 		dbParams,
 	)
 }
 
-func (p *OutProvider) tlsConfig(serverName string) *tls.Config {
-	return &tls.Config{
-		ServerName:   serverName,
-		Certificates: p.hosts.Certificates(),
+func (p *OutProvider) tlsConfig(sender, serverName string) *tls.Config {
+	tlsCfg := &tls.Config{
+		ServerName:           serverName,
+		GetClientCertificate: p.clientCertificate(sender),
+		RootCAs:              p.peerAuth.RootCAs(),
+	}
+	if p.peerAuth.isPOSHEnabled() {
+		// peer certificate may belong to a delegated domain (RFC 7711)
+		tlsCfg.InsecureSkipVerify = true
+		tlsCfg.VerifyConnection = p.peerAuth.verifyConnection(serverName)
+	}
+	return tlsCfg
+}
+
+// clientCertificate returns a callback that picks the sender host certificate at handshake time,
+// so that reloaded certificates are used by subsequent outgoing connections.
+func (p *OutProvider) clientCertificate(sender string) func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return func(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return p.hosts.GetCertificate(&tls.ClientHelloInfo{ServerName: sender})
 	}
 }
 
@@ -245,10 +485,20 @@ func (p *OutProvider) reportMetrics() {
 	for {
 		select {
 		case <-tc.C:
+			var totalPending int
+			var oldestAge time.Duration
+
 			p.mu.RLock()
 			totalConns := len(p.outStreams)
+			for _, pq := range p.pendingQs {
+				totalPending += pq.len()
+				if age := pq.oldestAge(); age > oldestAge {
+					oldestAge = age
+				}
+			}
 			p.mu.RUnlock()
 			reportTotalOutgoingConnections(totalConns)
+			reportOutgoingPendingStanzas(totalPending, oldestAge)
 
 		case ch := <-p.doneCh:
 			close(ch)
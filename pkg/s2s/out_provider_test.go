@@ -19,6 +19,9 @@ import (
 	"testing"
 	"time"
 
+	kitlog "github.com/go-kit/log"
+	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
+	"github.com/ortuman/jackal/pkg/router/stream"
 	"github.com/stretchr/testify/require"
 )
 
@@ -75,3 +78,37 @@ func TestOutProvider_GetDialback(t *testing.T) {
 	require.Len(t, conn2.(*s2sDialbackMock).startCalls(), 1)
 	require.Len(t, conn2.(*s2sDialbackMock).dialCalls(), 1)
 }
+
+func TestOutProvider_FederationPolicy(t *testing.T) {
+	// given
+	fedPolicy, _ := NewFederationPolicy(FederationConfig{Deny: []string{"*.spam.im"}}, nil)
+
+	op := &OutProvider{
+		fedPolicy:  fedPolicy,
+		outStreams: make(map[string]s2sOut),
+		logger:     kitlog.NewNopLogger(),
+	}
+	fedPolicy.observe(op.disconnectDenied)
+
+	op.newOutFn = func(sender, target string) s2sOut {
+		out := &s2sOutMock{}
+		out.IDFunc = func() stream.S2SOutID { return stream.S2SOutID{Sender: sender, Target: target} }
+		out.DisconnectFunc = func(streamErr *streamerror.Error) <-chan error { return nil }
+		out.dialFunc = func(ctx context.Context) error { return nil }
+		out.startFunc = func() error { return nil }
+		return out
+	}
+
+	// when
+	_, err := op.GetOut(context.Background(), "jackal.im", "xmpp.spam.im")
+	conn, _ := op.GetOut(context.Background(), "jackal.im", "jabber.org")
+
+	time.Sleep(time.Millisecond * 250) // wait until started
+
+	_ = fedPolicy.Update(FederationConfig{Deny: []string{"jabber.org"}})
+
+	// then
+	require.Equal(t, errFederationDenied, err)
+	require.Len(t, conn.(*s2sOutMock).DisconnectCalls(), 1)
+	require.Equal(t, streamerror.PolicyViolation, conn.(*s2sOutMock).DisconnectCalls()[0].StreamErr.Reason)
+}
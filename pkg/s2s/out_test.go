@@ -29,10 +29,12 @@ import (
 	"github.com/jackal-xmpp/runqueue/v2"
 	"github.com/jackal-xmpp/stravaganza"
 	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
+	"github.com/jackal-xmpp/stravaganza/jid"
 	xmppparser "github.com/jackal-xmpp/stravaganza/parser"
 	"github.com/ortuman/jackal/pkg/hook"
 	"github.com/ortuman/jackal/pkg/router/stream"
 	"github.com/ortuman/jackal/pkg/transport"
+	"github.com/ortuman/jackal/pkg/util/stringmatcher"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/time/rate"
 )
@@ -119,11 +121,13 @@ func TestOutS2S_HandleSessionElement(t *testing.T) {
 		name string
 
 		// input
-		state        outState
-		sender       string
-		target       string
-		sessionResFn func() (stravaganza.Element, error)
-		flags        uint8
+		state           outState
+		sender          string
+		target          string
+		sessionResFn    func() (stravaganza.Element, error)
+		flags           uint8
+		dialbackOffered bool
+		requireCertAuth []string
 
 		// expectations
 		expectedOutput string
@@ -219,6 +223,37 @@ func TestOutS2S_HandleSessionElement(t *testing.T) {
 			expectedOutput: `<stream:error><remote-connection-failed xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></stream:error></stream:stream>`,
 			expectedState:  outDisconnected,
 		},
+		{
+			name:            "Authenticating/FailedDialbackFallback",
+			state:           outAuthenticating,
+			flags:           fSecured,
+			dialbackOffered: true,
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("failure").
+					WithAttribute(stravaganza.Namespace, saslNamespace).
+					Build(), nil
+			},
+			expectedOutput: `<db:result from='jackal.im' to='jabber.org'>21bd4eb62f7d70d22b545f38a40a023ad6fa385905f36d889612fcb4cdb4966c</db:result>`,
+			expectedState:  outVerifyingDialbackKey,
+		},
+		{
+			name:            "Connected/DialbackCertAuthRequired",
+			state:           outConnected,
+			flags:           fSecured,
+			requireCertAuth: []string{"jabber.org"},
+			sessionResFn: func() (stravaganza.Element, error) {
+				return stravaganza.NewBuilder("stream:features").
+					WithAttribute(stravaganza.StreamNamespace, "http://etherx.jabber.org/streams").
+					WithChild(
+						stravaganza.NewBuilder("dialback").
+							WithAttribute(stravaganza.Namespace, dialbackNamespace).
+							Build(),
+					).
+					Build(), nil
+			},
+			expectedOutput: `<stream:error><policy-violation xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></stream:error></stream:stream>`,
+			expectedState:  outDisconnected,
+		},
 		{
 			name:  "Connected/Dialback",
 			state: outConnected,
@@ -297,6 +332,8 @@ func TestOutS2S_HandleSessionElement(t *testing.T) {
 			trMock.SetReadRateLimiterFunc = func(rLim *rate.Limiter) error { return nil }
 			trMock.CloseFunc = func() error { return nil }
 
+			certRequired, _ := stringmatcher.NewWildcardMatcher(tt.requireCertAuth)
+
 			stm := &outS2S{
 				sender: "jackal.im",
 				target: "jabber.org",
@@ -304,15 +341,17 @@ func TestOutS2S_HandleSessionElement(t *testing.T) {
 					reqTimeout:    time.Minute,
 					maxStanzaSize: 8192,
 				},
-				typ:     defaultType,
-				state:   tt.state,
-				flags:   flags{fs: tt.flags},
-				rq:      runqueue.New(tt.name),
-				tr:      trMock,
-				session: ssMock,
-				kv:      kvMock,
-				hk:      hook.NewHooks(),
-				logger:  kitlog.NewNopLogger(),
+				typ:             defaultType,
+				state:           tt.state,
+				flags:           flags{fs: tt.flags},
+				dialbackOffered: tt.dialbackOffered,
+				peerAuth:        &PeerAuthenticator{certRequired: certRequired},
+				rq:              runqueue.New(tt.name),
+				tr:              trMock,
+				session:         ssMock,
+				kv:              kvMock,
+				hk:              hook.NewHooks(),
+				logger:          kitlog.NewNopLogger(),
 			}
 			// when
 			stm.handleSessionResult(tt.sessionResFn())
@@ -328,6 +367,160 @@ func TestOutS2S_HandleSessionElement(t *testing.T) {
 	}
 }
 
+func TestOutS2S_Bidi(t *testing.T) {
+	// given
+	trMock := &transportMock{}
+	trMock.CloseFunc = func() error { return nil }
+
+	ssMock := &sessionMock{}
+	ssMock.ResetFunc = func(tr transport.Transport) error { return nil }
+	ssMock.OpenStreamFunc = func(_ context.Context) error { return nil }
+	ssMock.CloseFunc = func(_ context.Context) error { return nil }
+
+	outBuf := bytes.NewBuffer(nil)
+	ssMock.SendFunc = func(_ context.Context, element stravaganza.Element) error {
+		return element.ToXML(outBuf, true)
+	}
+	routerMock := &routerMock{}
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		return nil, nil
+	}
+	compsMock := &componentsMock{}
+	compsMock.IsComponentHostFunc = func(cHost string) bool { return false }
+
+	hk := hook.NewHooks()
+
+	inHub := NewInHub(nil, kitlog.NewNopLogger())
+	inHub.setBidiInFactory(func(out *outS2S) *inS2S {
+		return &inS2S{
+			state:   inConnected,
+			flags:   flags{fs: fSecured | fAuthenticated},
+			sender:  out.target,
+			target:  out.sender,
+			session: out.session,
+			tr:      out.tr,
+			router:  routerMock,
+			comps:   compsMock,
+			rq:      runqueue.New("in_s2s:bidi"),
+			doneCh:  make(chan struct{}),
+			inHub:   inHub,
+			hk:      hk,
+			logger:  kitlog.NewNopLogger(),
+		}
+	})
+	stm := &outS2S{
+		sender: "jackal.im",
+		target: "jabber.org",
+		cfg: outConfig{
+			reqTimeout:    time.Minute,
+			maxStanzaSize: 8192,
+		},
+		typ:      defaultType,
+		state:    outConnected,
+		flags:    flags{fs: fSecured},
+		peerAuth: &PeerAuthenticator{certRequired: stringmatcher.Any},
+		inHub:    inHub,
+		rq:       runqueue.New("out_s2s:bidi"),
+		tr:       trMock,
+		session:  ssMock,
+		hk:       hk,
+		logger:   kitlog.NewNopLogger(),
+	}
+	features := stravaganza.NewBuilder("stream:features").
+		WithAttribute(stravaganza.StreamNamespace, "http://etherx.jabber.org/streams").
+		WithChild(
+			stravaganza.NewBuilder("mechanisms").
+				WithAttribute(stravaganza.Namespace, saslNamespace).
+				WithChild(
+					stravaganza.NewBuilder("mechanism").
+						WithText("EXTERNAL").
+						Build(),
+				).
+				Build(),
+		).
+		WithChild(
+			stravaganza.NewBuilder("bidi").
+				WithAttribute(stravaganza.Namespace, bidiFeatureNamespace).
+				Build(),
+		).
+		Build()
+
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.From, "noelia@jabber.org/balcony").
+		WithAttribute(stravaganza.To, "ortuman@jackal.im/yard").
+		BuildMessage()
+
+	// when
+	stm.handleSessionResult(features, nil)
+	negotiationOutput := outBuf.String()
+
+	stm.flags.setAuthenticated()
+	stm.setState(outConnected)
+	stm.handleSessionResult(features, nil)
+
+	stm.handleSessionResult(msg, nil)
+
+	// then
+	require.Equal(t, `<bidi xmlns='urn:xmpp:bidi'/><auth xmlns='urn:ietf:params:xml:ns:xmpp-sasl' mechanism='EXTERNAL'>amFja2FsLmlt</auth>`, negotiationOutput)
+	require.True(t, stm.flags.isBidi())
+	require.Equal(t, outAuthenticated, stm.getState())
+
+	require.NotNil(t, stm.bidiIn)
+	require.Len(t, routerMock.RouteCalls(), 1)
+	require.Equal(t, msg, routerMock.RouteCalls()[0].Stanza)
+}
+
+func TestOutS2S_BidiNotSupported(t *testing.T) {
+	// given
+	ssMock := &sessionMock{}
+
+	outBuf := bytes.NewBuffer(nil)
+	ssMock.SendFunc = func(_ context.Context, element stravaganza.Element) error {
+		return element.ToXML(outBuf, true)
+	}
+	stm := &outS2S{
+		sender: "jackal.im",
+		target: "jabber.org",
+		cfg: outConfig{
+			reqTimeout:    time.Minute,
+			maxStanzaSize: 8192,
+		},
+		typ:      defaultType,
+		state:    outConnected,
+		flags:    flags{fs: fSecured},
+		peerAuth: &PeerAuthenticator{certRequired: stringmatcher.Any},
+		inHub:    NewInHub(nil, kitlog.NewNopLogger()), // no incoming stream factory
+		rq:       runqueue.New("out_s2s:bidi"),
+		session:  ssMock,
+		hk:       hook.NewHooks(),
+		logger:   kitlog.NewNopLogger(),
+	}
+
+	// when
+	stm.handleSessionResult(stravaganza.NewBuilder("stream:features").
+		WithAttribute(stravaganza.StreamNamespace, "http://etherx.jabber.org/streams").
+		WithChild(
+			stravaganza.NewBuilder("mechanisms").
+				WithAttribute(stravaganza.Namespace, saslNamespace).
+				WithChild(
+					stravaganza.NewBuilder("mechanism").
+						WithText("EXTERNAL").
+						Build(),
+				).
+				Build(),
+		).
+		WithChild(
+			stravaganza.NewBuilder("bidi").
+				WithAttribute(stravaganza.Namespace, bidiFeatureNamespace).
+				Build(),
+		).
+		Build(), nil)
+
+	// then
+	require.Equal(t, `<auth xmlns='urn:ietf:params:xml:ns:xmpp-sasl' mechanism='EXTERNAL'>amFja2FsLmlt</auth>`, outBuf.String())
+	require.False(t, stm.flags.isBidi())
+}
+
 func TestDialbackS2S_HandleSessionElement(t *testing.T) {
 	var tests = []struct {
 		name string
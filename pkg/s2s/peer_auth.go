@@ -0,0 +1,173 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ortuman/jackal/pkg/util/stringmatcher"
+)
+
+var errNoPeerCertificate = errors.New("s2s: no peer certificate")
+
+// PeerAuthenticator validates remote server certificates (XEP-0178) and tells
+// whether a remote domain is allowed to be authenticated through dialback.
+type PeerAuthenticator struct {
+	roots            *x509.CertPool
+	certRequired     stringmatcher.Matcher
+	poshFetcher      POSHFetcher
+	poshFetchTimeout time.Duration
+}
+
+// NewPeerAuthenticator creates and initializes a new PeerAuthenticator instance.
+// In case POSH is enabled, poshFetcher will be used to retrieve delegated domain documents,
+// defaulting to an HTTPPOSHFetcher if nil.
+func NewPeerAuthenticator(cfg AuthConfig, poshFetcher POSHFetcher) (*PeerAuthenticator, error) {
+	var roots *x509.CertPool
+	if len(cfg.CACertFiles) > 0 {
+		roots = x509.NewCertPool()
+		for _, caFile := range cfg.CACertFiles {
+			b, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, err
+			}
+			if !roots.AppendCertsFromPEM(b) {
+				return nil, fmt.Errorf("s2s: no valid CA certificates found in %s", caFile)
+			}
+		}
+	}
+	certRequired, err := stringmatcher.NewWildcardMatcher(cfg.RequireCertAuth)
+	if err != nil {
+		return nil, err
+	}
+	pa := &PeerAuthenticator{
+		roots:        roots,
+		certRequired: certRequired,
+	}
+	if cfg.POSH.Enabled {
+		if poshFetcher == nil {
+			poshFetcher = NewHTTPPOSHFetcher(cfg.POSH.FetchTimeout)
+		}
+		pa.poshFetcher = poshFetcher
+		pa.poshFetchTimeout = cfg.POSH.FetchTimeout
+	}
+	return pa, nil
+}
+
+// RootCAs returns the certificate pool used to validate peer certificates.
+// A nil value means system root pool.
+func (a *PeerAuthenticator) RootCAs() *x509.CertPool {
+	if a == nil {
+		return nil
+	}
+	return a.roots
+}
+
+// VerifyCertificates validates a peer certificate chain against the configured CA pool,
+// checking that leaf certificate identifies domain.
+// When POSH is enabled, a certificate not identifying domain is accepted in case domain
+// has been delegated to it (RFC 7711).
+func (a *PeerAuthenticator) VerifyCertificates(ctx context.Context, certs []*x509.Certificate, domain string) error {
+	return a.verifyCertificates(certs, domain, func(cert *x509.Certificate) error {
+		return a.verifyPOSH(ctx, cert, domain)
+	})
+}
+
+// PrecheckCertificates behaves like VerifyCertificates, but never waits for a POSH document
+// to be retrieved. In that case the fetch is started in background and ErrPOSHPending is returned.
+func (a *PeerAuthenticator) PrecheckCertificates(certs []*x509.Certificate, domain string) error {
+	return a.verifyCertificates(certs, domain, func(cert *x509.Certificate) error {
+		doc, err := a.poshFetcher.PrefetchPOSH(domain)
+		if err != nil {
+			return err
+		}
+		if !matchesPOSH(cert, doc) {
+			return errPOSHMismatch
+		}
+		return nil
+	})
+}
+
+func (a *PeerAuthenticator) verifyCertificates(certs []*x509.Certificate, domain string, verifyPOSH func(*x509.Certificate) error) error {
+	if len(certs) == 0 {
+		return errNoPeerCertificate
+	}
+	if len(domain) == 0 {
+		return errors.New("s2s: unknown peer domain")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         a.RootCAs(),
+		Intermediates: intermediates,
+		DNSName:       domain,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err == nil || !a.isPOSHEnabled() {
+		return err
+	}
+	switch poshErr := verifyPOSH(certs[0]); {
+	case poshErr == nil:
+		return nil
+	case errors.Is(poshErr, ErrPOSHPending):
+		return poshErr
+	default:
+		return err
+	}
+}
+
+// verifyConnection returns a tls.Config VerifyConnection callback validating
+// the certificate chain presented by domain.
+func (a *PeerAuthenticator) verifyConnection(domain string) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		return a.VerifyCertificates(context.Background(), cs.PeerCertificates, domain)
+	}
+}
+
+func (a *PeerAuthenticator) isPOSHEnabled() bool {
+	return a != nil && a.poshFetcher != nil
+}
+
+func (a *PeerAuthenticator) verifyPOSH(ctx context.Context, cert *x509.Certificate, domain string) error {
+	if a.poshFetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.poshFetchTimeout)
+		defer cancel()
+	}
+	doc, err := a.poshFetcher.FetchPOSH(ctx, domain)
+	if err != nil {
+		return err
+	}
+	if !matchesPOSH(cert, doc) {
+		return errPOSHMismatch
+	}
+	return nil
+}
+
+// IsCertAuthRequired tells whether domain must be authenticated by means of its certificate.
+func (a *PeerAuthenticator) IsCertAuthRequired(domain string) bool {
+	if a == nil {
+		return false
+	}
+	return a.certRequired.Matches(domain)
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerAuthenticator_VerifyCertificates(t *testing.T) {
+	// given
+	caCert, caKey := testCACertificate(t)
+	leafCert := testLeafCertificate(t, caCert, caKey, "jabber.org")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}), 0600)
+	require.Nil(t, err)
+
+	pa, err := NewPeerAuthenticator(AuthConfig{CACertFiles: []string{caFile}}, nil)
+	require.Nil(t, err)
+
+	// when
+	err0 := pa.VerifyCertificates(context.Background(), []*x509.Certificate{leafCert}, "jabber.org")
+	err1 := pa.VerifyCertificates(context.Background(), []*x509.Certificate{leafCert}, "konuro.net")
+	err2 := pa.VerifyCertificates(context.Background(), nil, "jabber.org")
+	err3 := pa.VerifyCertificates(context.Background(), []*x509.Certificate{leafCert}, "")
+
+	// then
+	require.Nil(t, err0)
+	require.NotNil(t, err1)
+	require.Equal(t, errNoPeerCertificate, err2)
+	require.NotNil(t, err3)
+}
+
+func TestPeerAuthenticator_UntrustedCertificate(t *testing.T) {
+	// given
+	caCert, caKey := testCACertificate(t)
+	leafCert := testLeafCertificate(t, caCert, caKey, "jabber.org")
+
+	otherCACert, _ := testCACertificate(t)
+	pa := &PeerAuthenticator{roots: x509.NewCertPool()}
+	pa.roots.AddCert(otherCACert)
+
+	// when
+	err := pa.VerifyCertificates(context.Background(), []*x509.Certificate{leafCert}, "jabber.org")
+
+	// then
+	require.NotNil(t, err)
+}
+
+func TestPeerAuthenticator_IsCertAuthRequired(t *testing.T) {
+	// given
+	pa, err := NewPeerAuthenticator(AuthConfig{RequireCertAuth: []string{"*.jabber.org", "konuro.net"}}, nil)
+	require.Nil(t, err)
+
+	var nilPA *PeerAuthenticator
+
+	// then
+	require.True(t, pa.IsCertAuthRequired("muc.jabber.org"))
+	require.True(t, pa.IsCertAuthRequired("konuro.net"))
+	require.False(t, pa.IsCertAuthRequired("jackal.im"))
+	require.False(t, nilPA.IsCertAuthRequired("konuro.net"))
+}
+
+func TestPeerAuthenticator_InvalidCAFile(t *testing.T) {
+	// given
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, []byte("not a certificate"), 0600)
+	require.Nil(t, err)
+
+	// when
+	_, err = NewPeerAuthenticator(AuthConfig{CACertFiles: []string{caFile}}, nil)
+
+	// then
+	require.NotNil(t, err)
+}
+
+func testCACertificate(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "jackal test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	b, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	cert, err := x509.ParseCertificate(b)
+	require.Nil(t, err)
+	return cert, key
+}
+
+func testLeafCertificate(t *testing.T, caCert *x509.Certificate, caKey *ecdsa.PrivateKey, domain string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{domain},
+	}
+	b, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+	require.Nil(t, err)
+
+	cert, err := x509.ParseCertificate(b)
+	require.Nil(t, err)
+	return cert
+}
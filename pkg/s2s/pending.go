@@ -0,0 +1,187 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+)
+
+type pendingElement struct {
+	stanza   stravaganza.Stanza
+	queuedAt time.Time
+}
+
+// pendingQueue holds the stanzas addressed to a remote domain while its outgoing stream is being established.
+type pendingQueue struct {
+	maxSize  int
+	timeout  time.Duration
+	bounceFn func(stanzas []stravaganza.Stanza, reason stanzaerror.Reason)
+
+	mu       sync.Mutex
+	elements []pendingElement
+	attempts int
+	lastErr  error
+	tm       *time.Timer
+	closed   bool
+}
+
+func newPendingQueue(maxSize int, timeout time.Duration, bounceFn func(stanzas []stravaganza.Stanza, reason stanzaerror.Reason)) *pendingQueue {
+	return &pendingQueue{
+		maxSize:  maxSize,
+		timeout:  timeout,
+		bounceFn: bounceFn,
+	}
+}
+
+// push enqueues a stanza, bouncing it in case the queue is full or no longer in use.
+func (q *pendingQueue) push(stanza stravaganza.Stanza) {
+	q.mu.Lock()
+	switch {
+	case q.closed:
+		q.mu.Unlock()
+		q.bounceFn([]stravaganza.Stanza{stanza}, stanzaerror.RemoteServerTimeout)
+		return
+
+	case q.maxSize > 0 && len(q.elements) >= q.maxSize:
+		q.mu.Unlock()
+		q.bounceFn([]stravaganza.Stanza{stanza}, stanzaerror.ResourceConstraint)
+		return
+	}
+	q.elements = append(q.elements, pendingElement{
+		stanza:   stanza,
+		queuedAt: time.Now(),
+	})
+	if q.tm == nil {
+		q.tm = time.AfterFunc(q.timeout, q.expire)
+	}
+	q.mu.Unlock()
+}
+
+// drain returns all queued stanzas once the remote domain stream has been established.
+func (q *pendingQueue) drain() []stravaganza.Stanza {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.attempts = 0
+	q.lastErr = nil
+	return q.takeAll(pendingResultSent)
+}
+
+// setConnectError records a failed connection attempt, returning the delay to apply before redialing.
+func (q *pendingQueue) setConnectError(err error, initialBackoff, maxBackoff time.Duration) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lastErr = err
+	backoff := initialBackoff << q.attempts
+	if backoff <= 0 || backoff > maxBackoff {
+		backoff = maxBackoff
+	} else {
+		q.attempts++
+	}
+	return backoff
+}
+
+func (q *pendingQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.elements)
+}
+
+func (q *pendingQueue) oldestAge() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.elements) == 0 {
+		return 0
+	}
+	return time.Since(q.elements[0].queuedAt)
+}
+
+// close bounces any queued stanza and makes the queue reject subsequent ones.
+func (q *pendingQueue) close(reason stanzaerror.Reason) {
+	q.mu.Lock()
+	q.closed = true
+	stanzas := q.takeAll(pendingResultBounced)
+	q.mu.Unlock()
+
+	if len(stanzas) > 0 {
+		q.bounceFn(stanzas, reason)
+	}
+}
+
+func (q *pendingQueue) expire() {
+	q.mu.Lock()
+	reason := stanzaerror.RemoteServerTimeout
+	if q.lastErr != nil && !errors.Is(q.lastErr, errServerTimeout) {
+		reason = stanzaerror.RemoteServerNotFound
+	}
+	stanzas := q.takeExpired(time.Now())
+	q.mu.Unlock()
+
+	if len(stanzas) > 0 {
+		q.bounceFn(stanzas, reason)
+	}
+}
+
+// takeExpired removes the stanzas queued for longer than the queue timeout,
+// re-arming the expiration timer for the oldest remaining one.
+func (q *pendingQueue) takeExpired(now time.Time) []stravaganza.Stanza {
+	if q.tm != nil {
+		q.tm.Stop()
+		q.tm = nil
+	}
+	var i int
+	for ; i < len(q.elements); i++ {
+		if now.Sub(q.elements[i].queuedAt) < q.timeout {
+			break
+		}
+	}
+	expired := q.elements[:i]
+	q.elements = q.elements[i:]
+	if len(q.elements) > 0 {
+		q.tm = time.AfterFunc(q.timeout-now.Sub(q.elements[0].queuedAt), q.expire)
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	stanzas := make([]stravaganza.Stanza, 0, len(expired))
+	for _, e := range expired {
+		stanzas = append(stanzas, e.stanza)
+		reportPendingStanzaWait(pendingResultBounced, now.Sub(e.queuedAt).Seconds())
+	}
+	return stanzas
+}
+
+func (q *pendingQueue) takeAll(result string) []stravaganza.Stanza {
+	if q.tm != nil {
+		q.tm.Stop()
+		q.tm = nil
+	}
+	if len(q.elements) == 0 {
+		return nil
+	}
+	stanzas := make([]stravaganza.Stanza, 0, len(q.elements))
+	for _, e := range q.elements {
+		stanzas = append(stanzas, e.stanza)
+		reportPendingStanzaWait(result, time.Since(e.queuedAt).Seconds())
+	}
+	q.elements = nil
+	return stanzas
+}
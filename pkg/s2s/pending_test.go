@@ -0,0 +1,209 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/stravaganza"
+	stanzaerror "github.com/jackal-xmpp/stravaganza/errors/stanza"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingQueue_MaxSize(t *testing.T) {
+	// given
+	var bounced []stravaganza.Stanza
+	var bounceReason stanzaerror.Reason
+
+	pq := newPendingQueue(1, time.Minute, func(stanzas []stravaganza.Stanza, reason stanzaerror.Reason) {
+		bounced = append(bounced, stanzas...)
+		bounceReason = reason
+	})
+
+	// when
+	pq.push(testSMMessage("m1"))
+	pq.push(testSMMessage("m2"))
+
+	stanzas := pq.drain()
+
+	// then
+	require.Len(t, stanzas, 1)
+	require.Equal(t, "m1", stanzas[0].Attribute(stravaganza.ID))
+
+	require.Len(t, bounced, 1)
+	require.Equal(t, "m2", bounced[0].Attribute(stravaganza.ID))
+	require.Equal(t, stanzaerror.ResourceConstraint, bounceReason)
+}
+
+func TestPendingQueue_Expire(t *testing.T) {
+	var tcs = map[string]struct {
+		connErr        error
+		expectedReason stanzaerror.Reason
+	}{
+		"ServerTimeout": {
+			connErr:        errServerTimeout,
+			expectedReason: stanzaerror.RemoteServerTimeout,
+		},
+		"ServerNotFound": {
+			connErr:        errors.New("no such host"),
+			expectedReason: stanzaerror.RemoteServerNotFound,
+		},
+		"StillConnecting": {
+			expectedReason: stanzaerror.RemoteServerTimeout,
+		},
+	}
+	for tName, tCase := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			var mtx sync.Mutex
+			var bounced []stravaganza.Stanza
+			var bounceReason stanzaerror.Reason
+
+			pq := newPendingQueue(10, time.Millisecond*50, func(stanzas []stravaganza.Stanza, reason stanzaerror.Reason) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				bounced = append(bounced, stanzas...)
+				bounceReason = reason
+			})
+
+			// when
+			pq.push(testSMMessage("m1"))
+			pq.push(testSMMessage("m2"))
+			if tCase.connErr != nil {
+				pq.setConnectError(tCase.connErr, time.Second, time.Second)
+			}
+			time.Sleep(time.Millisecond * 150)
+
+			// then
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			require.Len(t, bounced, 2)
+			require.Equal(t, tCase.expectedReason, bounceReason)
+			require.Equal(t, 0, pq.len())
+		})
+	}
+}
+
+func TestPendingQueue_ExpireOldestOnly(t *testing.T) {
+	// given
+	var mtx sync.Mutex
+	var bounced []stravaganza.Stanza
+
+	pq := newPendingQueue(10, time.Millisecond*200, func(stanzas []stravaganza.Stanza, _ stanzaerror.Reason) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		bounced = append(bounced, stanzas...)
+	})
+	bouncedIDs := func() []string {
+		mtx.Lock()
+		defer mtx.Unlock()
+		var ids []string
+		for _, stanza := range bounced {
+			ids = append(ids, stanza.Attribute(stravaganza.ID))
+		}
+		return ids
+	}
+
+	// when
+	pq.push(testSMMessage("m1"))
+	time.Sleep(time.Millisecond * 100)
+	pq.push(testSMMessage("m2"))
+
+	time.Sleep(time.Millisecond * 130) // m1 timed out, m2 queued for ~130ms
+	ids0, len0 := bouncedIDs(), pq.len()
+
+	time.Sleep(time.Millisecond * 120)
+	ids1, len1 := bouncedIDs(), pq.len()
+
+	// then
+	require.Equal(t, []string{"m1"}, ids0)
+	require.Equal(t, 1, len0)
+
+	require.Equal(t, []string{"m1", "m2"}, ids1)
+	require.Equal(t, 0, len1)
+}
+
+func TestPendingQueue_Backoff(t *testing.T) {
+	// given
+	pq := newPendingQueue(10, time.Minute, nil)
+
+	// when
+	var backoffs []time.Duration
+	for i := 0; i < 5; i++ {
+		backoffs = append(backoffs, pq.setConnectError(errServerTimeout, time.Second, time.Second*5))
+	}
+	_ = pq.drain()
+	resetBackoff := pq.setConnectError(errServerTimeout, time.Second, time.Second*5)
+
+	// then
+	require.Equal(t, []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5}, backoffs)
+	require.Equal(t, time.Second, resetBackoff)
+}
+
+func TestOutProvider_RedialPending(t *testing.T) {
+	// given
+	pq := newPendingQueue(10, time.Minute, nil)
+	pq.push(testSMMessage("m1"))
+
+	cfg := OutConfig{}
+	cfg.PendingQueue.InitialBackoff = time.Millisecond * 10
+	cfg.PendingQueue.MaxBackoff = time.Millisecond * 10
+
+	op := &OutProvider{
+		cfg:        cfg,
+		outStreams: make(map[string]s2sOut),
+		pendingQs:  map[string]*pendingQueue{"jackal.im:jabber.org": pq},
+		smStates:   make(map[string]*outSMState),
+		logger:     kitlog.NewNopLogger(),
+	}
+	var mtx sync.Mutex
+	var outs []*s2sOutMock
+
+	op.newOutFn = func(sender, target string) s2sOut {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		dialErr := errServerTimeout
+		if len(outs) > 0 {
+			dialErr = nil
+		}
+		out := &s2sOutMock{}
+		out.dialFunc = func(ctx context.Context) error { return dialErr }
+		out.startFunc = func() error { return nil }
+		outs = append(outs, out)
+		return out
+	}
+
+	// when
+	_, err := op.GetOut(context.Background(), "jackal.im", "jabber.org")
+
+	time.Sleep(time.Millisecond * 250) // wait until redialed
+
+	// then
+	require.Nil(t, err)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	require.Len(t, outs, 2)
+	require.Len(t, outs[0].startCalls(), 0)
+	require.Len(t, outs[1].startCalls(), 1)
+}
@@ -0,0 +1,356 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ortuman/jackal/pkg/host"
+)
+
+// POSHPath is the well-known path POSH xmpp-server documents are served from.
+const POSHPath = "/.well-known/posh/xmpp-server.json"
+
+const (
+	poshMaxDocumentSize = 64 * 1024
+
+	poshDefaultExpires = time.Hour * 24
+
+	poshMaxCacheEntries = 4096
+	poshFailureExpires  = time.Minute * 5
+)
+
+var (
+	errPOSHMismatch = errors.New("s2s: peer certificate does not match POSH fingerprints")
+
+	// ErrPOSHPending is returned by PrefetchPOSH when domain document is still being retrieved.
+	ErrPOSHPending = errors.New("s2s: POSH document fetch in progress")
+)
+
+// POSHDocument represents a POSH (RFC 7711) xmpp-server document.
+type POSHDocument struct {
+	// Fingerprints contains the set of certificate fingerprints, keyed by hash algorithm name.
+	Fingerprints []map[string]string `json:"fingerprints,omitempty"`
+
+	// URL references the document containing the actual fingerprints.
+	URL string `json:"url,omitempty"`
+
+	// Expires is the number of seconds the document can be cached.
+	Expires int64 `json:"expires"`
+}
+
+// POSHFetcher retrieves remote domain POSH documents.
+type POSHFetcher interface {
+	// FetchPOSH returns domain POSH document, resolving any url reference.
+	FetchPOSH(ctx context.Context, domain string) (*POSHDocument, error)
+
+	// PrefetchPOSH returns domain POSH document without blocking.
+	// In case the document is not available yet, its retrieval is started in background
+	// and ErrPOSHPending is returned.
+	PrefetchPOSH(domain string) (*POSHDocument, error)
+}
+
+type cachedPOSHDocument struct {
+	doc       *POSHDocument
+	err       error
+	expiresAt time.Time
+}
+
+type poshFetch struct {
+	doneCh chan struct{}
+	doc    *POSHDocument
+	err    error
+}
+
+// HTTPPOSHFetcher is a POSHFetcher implementation that retrieves documents over HTTPS,
+// caching them according to their expiration value.
+// Failed fetches are cached as well, so that unreachable domains are not queried on every connection.
+type HTTPPOSHFetcher struct {
+	client     *http.Client
+	maxEntries int
+
+	mu       sync.Mutex
+	cache    map[string]cachedPOSHDocument
+	inFlight map[string]*poshFetch
+}
+
+// NewHTTPPOSHFetcher creates and initializes a new HTTPPOSHFetcher instance.
+func NewHTTPPOSHFetcher(timeout time.Duration) *HTTPPOSHFetcher {
+	return &HTTPPOSHFetcher{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+				return http.ErrUseLastResponse // redirects are not allowed (RFC 7711)
+			},
+		},
+		maxEntries: poshMaxCacheEntries,
+		cache:      make(map[string]cachedPOSHDocument),
+		inFlight:   make(map[string]*poshFetch),
+	}
+}
+
+// FetchPOSH satisfies POSHFetcher interface.
+func (f *HTTPPOSHFetcher) FetchPOSH(ctx context.Context, domain string) (*POSHDocument, error) {
+	f.mu.Lock()
+	if cached, ok := f.cachedDocument(domain); ok {
+		f.mu.Unlock()
+		return cached.doc, cached.err
+	}
+	ft := f.startFetch(domain)
+	f.mu.Unlock()
+
+	select {
+	case <-ft.doneCh:
+		return ft.doc, ft.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// PrefetchPOSH satisfies POSHFetcher interface.
+func (f *HTTPPOSHFetcher) PrefetchPOSH(domain string) (*POSHDocument, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if cached, ok := f.cachedDocument(domain); ok {
+		return cached.doc, cached.err
+	}
+	f.startFetch(domain)
+	return nil, ErrPOSHPending
+}
+
+// cachedDocument must be called with f.mu held.
+func (f *HTTPPOSHFetcher) cachedDocument(domain string) (cachedPOSHDocument, bool) {
+	cached, ok := f.cache[domain]
+	if !ok {
+		return cachedPOSHDocument{}, false
+	}
+	if !time.Now().Before(cached.expiresAt) {
+		delete(f.cache, domain)
+		return cachedPOSHDocument{}, false
+	}
+	return cached, true
+}
+
+// startFetch must be called with f.mu held.
+func (f *HTTPPOSHFetcher) startFetch(domain string) *poshFetch {
+	if ft, ok := f.inFlight[domain]; ok {
+		return ft
+	}
+	ft := &poshFetch{doneCh: make(chan struct{})}
+	f.inFlight[domain] = ft
+
+	go func() {
+		// fetch is shared among every waiter, hence it's only bound by client timeout
+		doc, err := f.fetchDocument(context.Background(), domain)
+
+		f.mu.Lock()
+		switch {
+		case err != nil:
+			f.store(domain, cachedPOSHDocument{err: err, expiresAt: time.Now().Add(poshFailureExpires)})
+		case doc.Expires > 0:
+			f.store(domain, cachedPOSHDocument{doc: doc, expiresAt: time.Now().Add(time.Duration(doc.Expires) * time.Second)})
+		}
+		delete(f.inFlight, domain)
+		f.mu.Unlock()
+
+		ft.doc, ft.err = doc, err
+		close(ft.doneCh)
+	}()
+	return ft
+}
+
+// store must be called with f.mu held.
+func (f *HTTPPOSHFetcher) store(domain string, cached cachedPOSHDocument) {
+	if _, ok := f.cache[domain]; !ok && len(f.cache) >= f.maxEntries {
+		f.evict()
+	}
+	f.cache[domain] = cached
+}
+
+// evict removes expired entries, or the one closest to expire in case there are none.
+func (f *HTTPPOSHFetcher) evict() {
+	now := time.Now()
+
+	var evictDomain string
+	var evictAt time.Time
+	for domain, cached := range f.cache {
+		if !now.Before(cached.expiresAt) {
+			delete(f.cache, domain)
+			continue
+		}
+		if len(evictDomain) == 0 || cached.expiresAt.Before(evictAt) {
+			evictDomain, evictAt = domain, cached.expiresAt
+		}
+	}
+	if len(f.cache) >= f.maxEntries {
+		delete(f.cache, evictDomain)
+	}
+}
+
+func (f *HTTPPOSHFetcher) fetchDocument(ctx context.Context, domain string) (*POSHDocument, error) {
+	doc, err := f.fetch(ctx, "https://"+domain+POSHPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Fingerprints) == 0 && len(doc.URL) > 0 {
+		if !strings.HasPrefix(doc.URL, "https://") {
+			return nil, fmt.Errorf("s2s: invalid POSH reference url: %s", doc.URL)
+		}
+		refDoc, err := f.fetch(ctx, doc.URL)
+		if err != nil {
+			return nil, err
+		}
+		if refDoc.Expires > doc.Expires {
+			refDoc.Expires = doc.Expires
+		}
+		doc = refDoc
+	}
+	return doc, nil
+}
+
+func (f *HTTPPOSHFetcher) fetch(ctx context.Context, url string) (*POSHDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("s2s: unexpected POSH response status: %d", resp.StatusCode)
+	}
+	var doc POSHDocument
+	if err := json.NewDecoder(io.LimitReader(resp.Body, poshMaxDocumentSize)).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// matchesPOSH tells whether cert public key matches any of the document fingerprints.
+func matchesPOSH(cert *x509.Certificate, doc *POSHDocument) bool {
+	sha256Sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	sha512Sum := sha512.Sum512(cert.RawSubjectPublicKeyInfo)
+
+	for _, fingerprints := range doc.Fingerprints {
+		for alg, fingerprint := range fingerprints {
+			b, err := base64.StdEncoding.DecodeString(fingerprint)
+			if err != nil {
+				continue
+			}
+			switch strings.ToLower(alg) {
+			case "sha-256":
+				if string(b) == string(sha256Sum[:]) {
+					return true
+				}
+			case "sha-512":
+				if string(b) == string(sha512Sum[:]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// POSHHandler serves local domains POSH documents.
+type POSHHandler struct {
+	hosts   *host.Hosts
+	configs poshConfigs
+}
+
+// NewPOSHHandler creates and initializes a new POSHHandler instance.
+// Publishing configuration of every local domain, including those defined at runtime, is taken from hostMng.
+func NewPOSHHandler(hosts *host.Hosts, hostMng *host.KVManager) *POSHHandler {
+	return &POSHHandler{
+		hosts:   hosts,
+		configs: hostMng,
+	}
+}
+
+// ServeHTTP satisfies http.Handler interface.
+func (h *POSHHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	domain := r.Host
+	if hst, _, err := net.SplitHostPort(domain); err == nil {
+		domain = hst
+	}
+	domain = strings.ToLower(domain)
+
+	doc, err := h.document(domain)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(doc)
+}
+
+func (h *POSHHandler) document(domain string) (*POSHDocument, error) {
+	cfg, ok := h.configs.POSHConfig(domain)
+	if !ok {
+		return nil, fmt.Errorf("s2s: unknown local domain: %s", domain)
+	}
+	cer, ok := h.hosts.Certificate(domain)
+	if !ok {
+		return nil, fmt.Errorf("s2s: unknown local domain: %s", domain)
+	}
+
+	expires := cfg.Expires
+	if expires == 0 {
+		expires = poshDefaultExpires
+	}
+	doc := &POSHDocument{Expires: int64(expires / time.Second)}
+
+	// delegated domain
+	if len(cfg.URL) > 0 {
+		doc.URL = cfg.URL
+		return doc, nil
+	}
+	if len(cer.Certificate) == 0 {
+		return nil, fmt.Errorf("s2s: no certificate found for domain: %s", domain)
+	}
+	leaf, err := x509.ParseCertificate(cer.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	sha256Sum := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+	sha512Sum := sha512.Sum512(leaf.RawSubjectPublicKeyInfo)
+
+	doc.Fingerprints = []map[string]string{
+		{"sha-256": base64.StdEncoding.EncodeToString(sha256Sum[:])},
+		{"sha-512": base64.StdEncoding.EncodeToString(sha512Sum[:])},
+	}
+	return doc, nil
+}
@@ -0,0 +1,285 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ortuman/jackal/pkg/host"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPOSH_Handler(t *testing.T) {
+	// given
+	caCert, caKey := testCACertificate(t)
+	leafCert := testLeafCertificate(t, caCert, caKey, "jackal.im")
+
+	hs, err := host.NewHosts(host.Configs{testHostConfig(t, "jackal.im")})
+	require.Nil(t, err)
+
+	hs.RegisterHost("jackal.im", tls.Certificate{Certificate: [][]byte{leafCert.Raw}})
+	hs.RegisterHost("jabber.org", tls.Certificate{Certificate: [][]byte{leafCert.Raw}})
+
+	hs.RegisterHost("konuro.net", tls.Certificate{Certificate: [][]byte{leafCert.Raw}}) // being removed
+
+	configs := map[string]host.POSHConfig{
+		"jackal.im":  {},
+		"jabber.org": {URL: "https://hosting.example.net/posh.json", Expires: time.Hour},
+	}
+	cfgMock := &poshConfigsMock{}
+	cfgMock.POSHConfigFunc = func(domain string) (host.POSHConfig, bool) {
+		cfg, ok := configs[domain]
+		return cfg, ok
+	}
+	h := &POSHHandler{hosts: hs, configs: cfgMock}
+
+	// when
+	rec0 := httptest.NewRecorder()
+	h.ServeHTTP(rec0, httptest.NewRequest(http.MethodGet, "https://jackal.im:443"+POSHPath, nil))
+
+	rec1 := httptest.NewRecorder()
+	h.ServeHTTP(rec1, httptest.NewRequest(http.MethodGet, "https://jabber.org"+POSHPath, nil))
+
+	rec2 := httptest.NewRecorder()
+	h.ServeHTTP(rec2, httptest.NewRequest(http.MethodGet, "https://konuro.net"+POSHPath, nil))
+
+	// then
+	require.Equal(t, http.StatusOK, rec0.Code)
+	require.Equal(t, "application/json", rec0.Header().Get("Content-Type"))
+
+	var doc0 POSHDocument
+	require.Nil(t, json.Unmarshal(rec0.Body.Bytes(), &doc0))
+	require.Equal(t, int64(86400), doc0.Expires)
+	require.Len(t, doc0.Fingerprints, 2)
+	require.True(t, matchesPOSH(leafCert, &doc0))
+
+	require.Equal(t, http.StatusOK, rec1.Code)
+	require.Equal(t, `{"url":"https://hosting.example.net/posh.json","expires":3600}`+"\n", rec1.Body.String())
+
+	require.Equal(t, http.StatusNotFound, rec2.Code)
+}
+
+func TestPOSH_HTTPFetcher(t *testing.T) {
+	// given
+	caCert, caKey := testCACertificate(t)
+	leafCert := testLeafCertificate(t, caCert, caKey, "hosting.example.net")
+	sum := sha256.Sum256(leafCert.RawSubjectPublicKeyInfo)
+
+	var reqCount int
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqCount++
+		switch r.URL.Path {
+		case POSHPath:
+			_, _ = fmt.Fprintf(w, `{"url":"https://example.com/delegated.json","expires":3600}`)
+		case "/delegated.json":
+			_, _ = fmt.Fprintf(w, `{"fingerprints":[{"sha-256":"%s"}],"expires":86400}`, base64.StdEncoding.EncodeToString(sum[:]))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	f := testPOSHFetcher(srv)
+
+	// when
+	doc, err := f.FetchPOSH(context.Background(), "example.com")
+	require.Nil(t, err)
+
+	cachedDoc, err := f.FetchPOSH(context.Background(), "example.com")
+	require.Nil(t, err)
+
+	// then
+	require.True(t, matchesPOSH(leafCert, doc))
+	require.Equal(t, int64(3600), doc.Expires)
+	require.Equal(t, doc, cachedDoc)
+	require.Equal(t, 2, reqCount) // cached on second fetch
+}
+
+func TestPOSH_HTTPFetcherCachesFailures(t *testing.T) {
+	// given
+	var reqCount int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reqCount, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	f := testPOSHFetcher(srv)
+
+	// when
+	_, err0 := f.FetchPOSH(context.Background(), "example.com")
+	_, err1 := f.FetchPOSH(context.Background(), "example.com")
+	_, err2 := f.PrefetchPOSH("example.com")
+
+	// then
+	require.NotNil(t, err0)
+	require.Equal(t, err0, err1)
+	require.Equal(t, err0, err2)
+	require.Equal(t, int32(1), atomic.LoadInt32(&reqCount))
+}
+
+func TestPOSH_HTTPFetcherPrefetch(t *testing.T) {
+	// given
+	releaseCh := make(chan struct{})
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-releaseCh
+		_, _ = fmt.Fprintf(w, `{"fingerprints":[{"sha-256":"AAAA"}],"expires":3600}`)
+	}))
+	defer srv.Close()
+
+	f := testPOSHFetcher(srv)
+
+	// when
+	_, err0 := f.PrefetchPOSH("example.com")
+	close(releaseCh)
+
+	doc, err1 := f.FetchPOSH(context.Background(), "example.com")
+
+	// then
+	require.Equal(t, ErrPOSHPending, err0)
+	require.Nil(t, err1)
+	require.Equal(t, int64(3600), doc.Expires)
+}
+
+func TestPOSH_HTTPFetcherBoundedCache(t *testing.T) {
+	// given
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"fingerprints":[{"sha-256":"AAAA"}],"expires":3600}`)
+	}))
+	defer srv.Close()
+
+	f := testPOSHFetcher(srv)
+	f.maxEntries = 2
+
+	// when
+	for _, domain := range []string{"jackal.im", "jabber.org", "example.com"} {
+		_, err := f.FetchPOSH(context.Background(), domain)
+		require.Nil(t, err)
+	}
+
+	// then
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	require.Len(t, f.cache, 2)
+	require.Contains(t, f.cache, "example.com")
+}
+
+func TestPeerAuthenticator_VerifyDelegatedCertificate(t *testing.T) {
+	// given
+	caCert, caKey := testCACertificate(t)
+	leafCert := testLeafCertificate(t, caCert, caKey, "hosting.example.net")
+	sum := sha256.Sum256(leafCert.RawSubjectPublicKeyInfo)
+
+	fetcherMock := &poshFetcherMock{}
+	fetcherMock.FetchPOSHFunc = func(ctx context.Context, domain string) (*POSHDocument, error) {
+		if domain != "jabber.org" {
+			return &POSHDocument{}, nil
+		}
+		return &POSHDocument{
+			Fingerprints: []map[string]string{{"sha-256": base64.StdEncoding.EncodeToString(sum[:])}},
+		}, nil
+	}
+	pa, err := NewPeerAuthenticator(AuthConfig{POSH: POSHConfig{Enabled: true}}, fetcherMock)
+	require.Nil(t, err)
+
+	pa.roots = x509.NewCertPool()
+	pa.roots.AddCert(caCert)
+
+	// when
+	err0 := pa.VerifyCertificates(context.Background(), []*x509.Certificate{leafCert}, "hosting.example.net")
+	err1 := pa.VerifyCertificates(context.Background(), []*x509.Certificate{leafCert}, "jabber.org")
+	err2 := pa.VerifyCertificates(context.Background(), []*x509.Certificate{leafCert}, "konuro.net")
+
+	// then
+	require.Nil(t, err0)
+	require.Nil(t, err1)
+	require.NotNil(t, err2)
+	require.Len(t, fetcherMock.FetchPOSHCalls(), 2)
+}
+
+func TestPeerAuthenticator_PrecheckDelegatedCertificate(t *testing.T) {
+	// given
+	caCert, caKey := testCACertificate(t)
+	leafCert := testLeafCertificate(t, caCert, caKey, "hosting.example.net")
+
+	fetcherMock := &poshFetcherMock{}
+	fetcherMock.PrefetchPOSHFunc = func(domain string) (*POSHDocument, error) {
+		return nil, ErrPOSHPending
+	}
+	pa, err := NewPeerAuthenticator(AuthConfig{POSH: POSHConfig{Enabled: true}}, fetcherMock)
+	require.Nil(t, err)
+
+	pa.roots = x509.NewCertPool()
+	pa.roots.AddCert(caCert)
+
+	// when
+	err0 := pa.PrecheckCertificates([]*x509.Certificate{leafCert}, "hosting.example.net")
+	err1 := pa.PrecheckCertificates([]*x509.Certificate{leafCert}, "jabber.org")
+
+	// then
+	require.Nil(t, err0)
+	require.Equal(t, ErrPOSHPending, err1)
+	require.Len(t, fetcherMock.PrefetchPOSHCalls(), 1)
+	require.Len(t, fetcherMock.FetchPOSHCalls(), 0)
+}
+
+func testPOSHFetcher(srv *httptest.Server) *HTTPPOSHFetcher {
+	// route every request to the test server
+	tr := srv.Client().Transport.(*http.Transport).Clone()
+	tr.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	tr.TLSClientConfig.ServerName = "example.com"
+
+	f := NewHTTPPOSHFetcher(time.Second)
+	f.client.Transport = tr
+	return f
+}
+
+func testHostConfig(t *testing.T, domain string) host.Config {
+	t.Helper()
+
+	caCert, caKey := testCACertificate(t)
+
+	var cfg host.Config
+	cfg.Domain = domain
+	cfg.TLS.CertFile = filepath.Join(t.TempDir(), "cert.pem")
+	cfg.TLS.PrivateKeyFile = filepath.Join(t.TempDir(), "key.pem")
+
+	keyB, err := x509.MarshalECPrivateKey(caKey)
+	require.Nil(t, err)
+
+	err = os.WriteFile(cfg.TLS.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}), 0600)
+	require.Nil(t, err)
+	err = os.WriteFile(cfg.TLS.PrivateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyB}), 0600)
+	require.Nil(t, err)
+	return cfg
+}
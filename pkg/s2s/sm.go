@@ -0,0 +1,333 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/google/uuid"
+	"github.com/jackal-xmpp/stravaganza"
+	streamerror "github.com/jackal-xmpp/stravaganza/errors/stream"
+	streamqueue "github.com/ortuman/jackal/pkg/module/xep0198/queue"
+)
+
+const (
+	smBadRequest        = "bad-request"
+	smUnexpectedRequest = "unexpected-request"
+	smItemNotFound      = "item-not-found"
+)
+
+// outSMState holds an outgoing domain pair stream management state (XEP-0198),
+// preserved across reconnections in order to resend unacknowledged stanzas.
+type outSMState struct {
+	mu      sync.Mutex
+	id      string
+	queue   *streamqueue.Queue
+	retries int
+}
+
+// enable starts tracking outgoing stanzas of a newly enabled stream management session.
+// Stanzas left unacknowledged by a previous session are returned in order to be sent again.
+func (sm *outSMState) enable(stm streamqueue.Stream, id string, reqAckInterval, waitForAckTimeout time.Duration) []stravaganza.Stanza {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	unacked := sm.unackedStanzas()
+	if sm.queue != nil {
+		sm.queue.CancelTimers()
+	}
+	sm.id = id
+	sm.queue = streamqueue.New(stm, nil, nil, 0, 0, reqAckInterval, waitForAckTimeout)
+	return unacked
+}
+
+// resume binds a previous stream management session to a new stream, returning the stanzas
+// not yet acknowledged by the remote server.
+func (sm *outSMState) resume(stm streamqueue.Stream, h uint32) []stravaganza.Stanza {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.queue.SetStream(stm)
+	sm.queue.Acknowledge(h)
+	sm.retries = 0
+	return sm.unackedStanzas()
+}
+
+// disable discards current stream management session, returning the stanzas not yet acknowledged.
+func (sm *outSMState) disable() []stravaganza.Stanza {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	unacked := sm.unackedStanzas()
+	if sm.queue != nil {
+		sm.queue.CancelTimers()
+	}
+	sm.id = ""
+	sm.queue = nil
+	return unacked
+}
+
+func (sm *outSMState) resumptionID() string {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.id
+}
+
+func (sm *outSMState) resetResumptionID() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.id = ""
+}
+
+func (sm *outSMState) isEnabled() bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.queue != nil
+}
+
+func (sm *outSMState) handleOut(stanza stravaganza.Stanza) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.queue != nil {
+		sm.queue.HandleOut(stanza)
+	}
+}
+
+func (sm *outSMState) acknowledge(h uint32) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.queue != nil {
+		sm.queue.Acknowledge(h)
+		sm.retries = 0
+	}
+}
+
+func (sm *outSMState) inboundH() uint32 {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.queue == nil {
+		return 0
+	}
+	return sm.queue.InboundH()
+}
+
+func (sm *outSMState) cancelTimers() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.queue != nil {
+		sm.queue.CancelTimers()
+	}
+}
+
+func (sm *outSMState) pendingCount() int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.queue == nil {
+		return 0
+	}
+	return sm.queue.Len()
+}
+
+func (sm *outSMState) unacked() []stravaganza.Stanza {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.unackedStanzas()
+}
+
+// incRetries increments reconnection attempts count, returning false if maxRetries was already reached.
+func (sm *outSMState) incRetries(maxRetries int) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.retries >= maxRetries {
+		return false
+	}
+	sm.retries++
+	return true
+}
+
+func (sm *outSMState) unackedStanzas() []stravaganza.Stanza {
+	if sm.queue == nil {
+		return nil
+	}
+	elements := sm.queue.Elements()
+	stanzas := make([]stravaganza.Stanza, 0, len(elements))
+	for _, e := range elements {
+		stanzas = append(stanzas, e.Stanza)
+	}
+	return stanzas
+}
+
+type inSMSession struct {
+	sender   string
+	target   string
+	h        uint32
+	attached bool
+	tm       *time.Timer
+}
+
+func (s *outS2S) requestStreamManagement(ctx context.Context) error {
+	if prevID := s.cfg.sm.resumptionID(); len(prevID) > 0 {
+		return s.sendElement(ctx, stravaganza.NewBuilder("resume").
+			WithAttribute(stravaganza.Namespace, smNamespace).
+			WithAttribute("previd", prevID).
+			WithAttribute("h", strconv.FormatUint(uint64(s.cfg.sm.inboundH()), 10)).
+			Build(),
+		)
+	}
+	return s.sendElement(ctx, stravaganza.NewBuilder("enable").
+		WithAttribute(stravaganza.Namespace, smNamespace).
+		WithAttribute("resume", "true").
+		Build(),
+	)
+}
+
+func (s *outS2S) handleNegotiatingSM(ctx context.Context, elem stravaganza.Element) error {
+	if elem.Attribute(stravaganza.Namespace) != smNamespace {
+		return s.disconnect(ctx, streamerror.E(streamerror.UnsupportedStanzaType))
+	}
+	switch elem.Name() {
+	case "enabled":
+		var id string
+		if elem.Attribute("resume") == "true" {
+			id = elem.Attribute(stravaganza.ID)
+		}
+		unacked := s.cfg.sm.enable(s, id, s.cfg.smReqAckInterval, s.cfg.smWaitForAckTimeout)
+		level.Info(s.logger).Log("msg", "S2S stream management enabled", "resumable", len(id) > 0)
+
+		return s.sendPendingElements(ctx, unacked)
+
+	case "resumed":
+		h, _ := strconv.ParseUint(elem.Attribute("h"), 10, 32)
+		unacked := s.cfg.sm.resume(s, uint32(h))
+		level.Info(s.logger).Log("msg", "S2S stream management session resumed", "unacked", len(unacked))
+
+		return s.sendPendingElements(ctx, unacked)
+
+	case "failed":
+		if len(s.cfg.sm.resumptionID()) > 0 {
+			// session could not be resumed... try to enable a new one
+			level.Info(s.logger).Log("msg", "failed to resume S2S stream management session")
+			s.cfg.sm.resetResumptionID()
+			return s.requestStreamManagement(ctx)
+		}
+		level.Info(s.logger).Log("msg", "failed to enable S2S stream management")
+		return s.sendPendingElements(ctx, s.cfg.sm.disable())
+
+	default:
+		return s.disconnect(ctx, streamerror.E(streamerror.UnsupportedStanzaType))
+	}
+}
+
+func (s *outS2S) handleStreamManagement(ctx context.Context, elem stravaganza.Element) error {
+	switch elem.Name() {
+	case "a":
+		h, _ := strconv.ParseUint(elem.Attribute("h"), 10, 32)
+		s.cfg.sm.acknowledge(uint32(h))
+		return nil
+
+	case "r":
+		return s.sendElement(ctx, smAckElement(s.cfg.sm.inboundH()))
+	}
+	return nil
+}
+
+func (s *inS2S) handleStreamManagement(ctx context.Context, elem stravaganza.Element) error {
+	switch elem.Name() {
+	case "enable":
+		if len(s.smID) > 0 {
+			return s.sendElement(ctx, smFailedElement(smUnexpectedRequest))
+		}
+		s.smID = uuid.New().String()
+		s.smH = 0
+
+		eb := stravaganza.NewBuilder("enabled").
+			WithAttribute(stravaganza.Namespace, smNamespace).
+			WithAttribute(stravaganza.ID, s.smID)
+		if elem.Attribute("resume") == "true" {
+			s.inHub.registerSM(s.smID, s.sender, s.target)
+			eb.WithAttribute("resume", "true")
+		}
+		return s.sendElement(ctx, eb.Build())
+
+	case "resume":
+		if len(s.smID) > 0 {
+			return s.sendElement(ctx, smFailedElement(smUnexpectedRequest))
+		}
+		prevID := elem.Attribute("previd")
+		h, ok := s.inHub.resumeSM(prevID, s.sender, s.target)
+		if !ok {
+			return s.sendElement(ctx, smFailedElement(smItemNotFound))
+		}
+		s.smID = prevID
+		s.smH = h
+
+		level.Info(s.logger).Log("msg", "resumed S2S stream management session", "sender", s.sender, "target", s.target)
+
+		return s.sendElement(ctx, stravaganza.NewBuilder("resumed").
+			WithAttribute(stravaganza.Namespace, smNamespace).
+			WithAttribute("previd", prevID).
+			WithAttribute("h", strconv.FormatUint(uint64(h), 10)).
+			Build(),
+		)
+
+	case "r":
+		if len(s.smID) == 0 {
+			return s.sendElement(ctx, smFailedElement(smUnexpectedRequest))
+		}
+		return s.sendElement(ctx, smAckElement(s.smH))
+
+	case "a":
+		return nil
+
+	default:
+		return s.sendElement(ctx, smFailedElement(smBadRequest))
+	}
+}
+
+func (s *inS2S) handleInStanza() {
+	if len(s.smID) > 0 {
+		s.smH = incSMH(s.smH)
+	}
+}
+
+func smAckElement(h uint32) stravaganza.Element {
+	return stravaganza.NewBuilder("a").
+		WithAttribute(stravaganza.Namespace, smNamespace).
+		WithAttribute("h", strconv.FormatUint(uint64(h), 10)).
+		Build()
+}
+
+func smFailedElement(reason string) stravaganza.Element {
+	return stravaganza.NewBuilder("failed").
+		WithAttribute(stravaganza.Namespace, smNamespace).
+		WithChild(
+			stravaganza.NewBuilder(reason).
+				WithAttribute(stravaganza.Namespace, stanzaErrorNamespace).
+				Build(),
+		).
+		Build()
+}
+
+func incSMH(h uint32) uint32 {
+	if h == math.MaxUint32-1 {
+		return 0
+	}
+	return h + 1
+}
@@ -0,0 +1,299 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s2s
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/jackal-xmpp/runqueue/v2"
+	"github.com/jackal-xmpp/stravaganza"
+	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/ortuman/jackal/pkg/hook"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutS2S_EnableStreamManagement(t *testing.T) {
+	// given
+	sessMock := &sessionMock{}
+
+	sendBuf := bytes.NewBuffer(nil)
+	sessMock.SendFunc = func(ctx context.Context, element stravaganza.Element) error {
+		_ = element.ToXML(sendBuf, true)
+		return nil
+	}
+	sm := &outSMState{}
+
+	s := &outS2S{
+		sender:  "jackal.im",
+		target:  "jabber.org",
+		state:   outNegotiatingSM,
+		session: sessMock,
+		cfg: outConfig{
+			sm:                  sm,
+			smReqAckInterval:    time.Minute,
+			smWaitForAckTimeout: time.Minute,
+		},
+		rq:     runqueue.New("out_s2s:test"),
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+	defer sm.cancelTimers()
+
+	pq := newPendingQueue(0, time.Minute, nil)
+	pq.push(testSMMessage("m1"))
+	pq.push(testSMMessage("m2"))
+	s.cfg.pending = pq
+
+	// when
+	err0 := s.handleElement(context.Background(), stravaganza.NewBuilder("enabled").
+		WithAttribute(stravaganza.Namespace, smNamespace).
+		WithAttribute(stravaganza.ID, "sm-1").
+		WithAttribute("resume", "true").
+		Build(),
+	)
+	pendingCount := sm.pendingCount()
+
+	err1 := s.handleElement(context.Background(), stravaganza.NewBuilder("a").
+		WithAttribute(stravaganza.Namespace, smNamespace).
+		WithAttribute("h", "1").
+		Build(),
+	)
+
+	// then
+	require.Nil(t, err0)
+	require.Nil(t, err1)
+
+	require.Equal(t, outAuthenticated, s.getState())
+	require.Equal(t, "sm-1", sm.resumptionID())
+	require.Equal(t, 2, pendingCount)
+
+	unacked := sm.unacked()
+	require.Len(t, unacked, 1)
+	require.Equal(t, "m2", unacked[0].Attribute(stravaganza.ID))
+
+	require.Equal(t, `<message id='m1' from='ortuman@jackal.im/yard' to='noelia@jabber.org/balcony'/><message id='m2' from='ortuman@jackal.im/yard' to='noelia@jabber.org/balcony'/>`, sendBuf.String())
+}
+
+func TestOutS2S_ResumeStreamManagement(t *testing.T) {
+	// given
+	sessMock := &sessionMock{}
+
+	sendBuf := bytes.NewBuffer(nil)
+	sessMock.SendFunc = func(ctx context.Context, element stravaganza.Element) error {
+		_ = element.ToXML(sendBuf, true)
+		return nil
+	}
+	sm := &outSMState{}
+	defer sm.cancelTimers()
+
+	_ = sm.enable(&outS2S{}, "sm-1", time.Minute, time.Minute)
+	sm.handleOut(testSMMessage("m1"))
+	sm.handleOut(testSMMessage("m2"))
+	sm.handleOut(testSMMessage("m3"))
+
+	s := &outS2S{
+		sender:  "jackal.im",
+		target:  "jabber.org",
+		state:   outNegotiatingSM,
+		session: sessMock,
+		cfg: outConfig{
+			sm:                  sm,
+			smReqAckInterval:    time.Minute,
+			smWaitForAckTimeout: time.Minute,
+		},
+		rq:     runqueue.New("out_s2s:test"),
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	err := s.handleElement(context.Background(), stravaganza.NewBuilder("resumed").
+		WithAttribute(stravaganza.Namespace, smNamespace).
+		WithAttribute("previd", "sm-1").
+		WithAttribute("h", "1").
+		Build(),
+	)
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, outAuthenticated, s.getState())
+	require.Equal(t, 2, sm.pendingCount())
+
+	require.Equal(t, `<message id='m2' from='ortuman@jackal.im/yard' to='noelia@jabber.org/balcony'/><message id='m3' from='ortuman@jackal.im/yard' to='noelia@jabber.org/balcony'/>`, sendBuf.String())
+}
+
+func TestOutS2S_FailedStreamManagementResumption(t *testing.T) {
+	// given
+	sessMock := &sessionMock{}
+
+	sendBuf := bytes.NewBuffer(nil)
+	sessMock.SendFunc = func(ctx context.Context, element stravaganza.Element) error {
+		_ = element.ToXML(sendBuf, true)
+		return nil
+	}
+	sm := &outSMState{}
+	defer sm.cancelTimers()
+
+	_ = sm.enable(&outS2S{}, "sm-1", time.Minute, time.Minute)
+	sm.handleOut(testSMMessage("m1"))
+
+	s := &outS2S{
+		sender:  "jackal.im",
+		target:  "jabber.org",
+		state:   outNegotiatingSM,
+		session: sessMock,
+		cfg: outConfig{
+			sm:                  sm,
+			smReqAckInterval:    time.Minute,
+			smWaitForAckTimeout: time.Minute,
+		},
+		rq:     runqueue.New("out_s2s:test"),
+		hk:     hook.NewHooks(),
+		logger: kitlog.NewNopLogger(),
+	}
+
+	// when
+	err0 := s.handleElement(context.Background(), smFailedElement(smItemNotFound))
+	err1 := s.handleElement(context.Background(), stravaganza.NewBuilder("enabled").
+		WithAttribute(stravaganza.Namespace, smNamespace).
+		WithAttribute(stravaganza.ID, "sm-2").
+		WithAttribute("resume", "true").
+		Build(),
+	)
+
+	// then
+	require.Nil(t, err0)
+	require.Nil(t, err1)
+	require.Equal(t, outAuthenticated, s.getState())
+	require.Equal(t, "sm-2", sm.resumptionID())
+	require.Equal(t, 1, sm.pendingCount())
+
+	require.Equal(t, `<enable xmlns='urn:xmpp:sm:3' resume='true'/><message id='m1' from='ortuman@jackal.im/yard' to='noelia@jabber.org/balcony'/>`, sendBuf.String())
+}
+
+func TestInS2S_StreamManagement(t *testing.T) {
+	// given
+	inHub := NewInHub(nil, kitlog.NewNopLogger())
+
+	sendBuf := bytes.NewBuffer(nil)
+	newStream := func() *inS2S {
+		sessMock := &sessionMock{}
+		sessMock.SendFunc = func(ctx context.Context, element stravaganza.Element) error {
+			_ = element.ToXML(sendBuf, true)
+			return nil
+		}
+		sessMock.CloseFunc = func(ctx context.Context) error { return nil }
+
+		trMock := &transportMock{}
+		trMock.CloseFunc = func() error { return nil }
+
+		return &inS2S{
+			cfg:     inConfig{resumeTimeout: time.Minute},
+			state:   inConnected,
+			flags:   flags{fs: fSecured | fAuthenticated},
+			sender:  "jabber.org",
+			target:  "jackal.im",
+			session: sessMock,
+			tr:      trMock,
+			inHub:   inHub,
+			doneCh:  make(chan struct{}),
+			hk:      hook.NewHooks(),
+			logger:  kitlog.NewNopLogger(),
+		}
+	}
+	s0 := newStream()
+
+	// when
+	_ = s0.handleStreamManagement(context.Background(), stravaganza.NewBuilder("enable").
+		WithAttribute(stravaganza.Namespace, smNamespace).
+		WithAttribute("resume", "true").
+		Build(),
+	)
+	s0.handleInStanza()
+	s0.handleInStanza()
+
+	_ = s0.handleStreamManagement(context.Background(), stravaganza.NewBuilder("r").
+		WithAttribute(stravaganza.Namespace, smNamespace).
+		Build(),
+	)
+	smID := s0.smID
+	_ = s0.close(context.Background())
+
+	sendBuf.Reset()
+
+	s1 := newStream()
+	err := s1.handleStreamManagement(context.Background(), stravaganza.NewBuilder("resume").
+		WithAttribute(stravaganza.Namespace, smNamespace).
+		WithAttribute("previd", smID).
+		WithAttribute("h", "0").
+		Build(),
+	)
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, uint32(2), s1.smH)
+	require.Equal(t, `<resumed xmlns='urn:xmpp:sm:3' previd='`+smID+`' h='2'/>`, sendBuf.String())
+
+	// session cannot be resumed twice
+	_, ok := inHub.resumeSM(smID, "jabber.org", "jackal.im")
+	require.False(t, ok)
+}
+
+func TestOutProvider_BounceUnacked(t *testing.T) {
+	// given
+	routerMock := &routerMock{}
+
+	output := bytes.NewBuffer(nil)
+	routerMock.RouteFunc = func(ctx context.Context, stanza stravaganza.Stanza) ([]jid.JID, error) {
+		_ = stanza.ToXML(output, true)
+		return nil, nil
+	}
+	sm := &outSMState{}
+	_ = sm.enable(&outS2S{}, "sm-1", time.Minute, time.Minute)
+	sm.handleOut(testSMMessage("m1"))
+
+	cfg := OutConfig{RequestTimeout: time.Second}
+	cfg.StreamManagement.MaxRetries = 0
+	cfg.StreamManagement.RetryInterval = time.Millisecond
+
+	op := &OutProvider{
+		cfg:        cfg,
+		router:     routerMock,
+		outStreams: make(map[string]s2sOut),
+		smStates:   map[string]*outSMState{"jackal.im:jabber.org": sm},
+		logger:     kitlog.NewNopLogger(),
+	}
+
+	// when
+	op.resumeOut("jackal.im", "jabber.org")
+
+	// then
+	require.Len(t, routerMock.RouteCalls(), 1)
+	require.Len(t, op.smStates, 0)
+	require.Equal(t, `<message id='m1' from='noelia@jabber.org/balcony' to='ortuman@jackal.im/yard' type='error'><error code='504' type='wait'><remote-server-timeout xmlns='urn:ietf:params:xml:ns:xmpp-stanzas'/></error></message>`, output.String())
+}
+
+func testSMMessage(id string) *stravaganza.Message {
+	msg, _ := stravaganza.NewMessageBuilder().
+		WithAttribute(stravaganza.ID, id).
+		WithAttribute(stravaganza.From, "ortuman@jackal.im/yard").
+		WithAttribute(stravaganza.To, "noelia@jabber.org/balcony").
+		BuildMessage()
+	return msg
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/ortuman/jackal/pkg/router"
 	"github.com/ortuman/jackal/pkg/shaper"
 	"github.com/ortuman/jackal/pkg/transport"
+	"github.com/ortuman/jackal/pkg/transport/proxyproto"
 )
 
 const (
@@ -48,9 +49,10 @@ type SocketListener struct {
 	comps         *component.Components
 	mods          *module.Modules
 	outProvider   *OutProvider
+	peerAuth      *PeerAuthenticator
 	inHUB         *InHub
 	kv            kv.KV
-	shapers       shaper.Shapers
+	shapers       *shaper.Registry
 	hk            *hook.Hooks
 	logger        kitlog.Logger
 	connHandlerFn func(conn net.Conn)
@@ -67,9 +69,10 @@ func NewListeners(
 	comps *component.Components,
 	mods *module.Modules,
 	outProvider *OutProvider,
+	peerAuth *PeerAuthenticator,
 	inHub *InHub,
 	kv kv.KV,
-	shapers shaper.Shapers,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) []*SocketListener {
@@ -82,6 +85,7 @@ func NewListeners(
 			comps,
 			mods,
 			outProvider,
+			peerAuth,
 			kv,
 			inHub,
 			shapers,
@@ -100,9 +104,10 @@ func newSocketListener(
 	comps *component.Components,
 	mods *module.Modules,
 	outProvider *OutProvider,
+	peerAuth *PeerAuthenticator,
 	kv kv.KV,
 	hub *InHub,
-	shapers shaper.Shapers,
+	shapers *shaper.Registry,
 	hk *hook.Hooks,
 	logger kitlog.Logger,
 ) *SocketListener {
@@ -113,6 +118,7 @@ func newSocketListener(
 		comps:       comps,
 		mods:        mods,
 		outProvider: outProvider,
+		peerAuth:    peerAuth,
 		kv:          kv,
 		inHUB:       hub,
 		shapers:     shapers,
@@ -120,6 +126,9 @@ func newSocketListener(
 		logger:      logger,
 	}
 	ln.connHandlerFn = ln.handleConn
+	if hub != nil {
+		hub.setBidiInFactory(ln.newBidiIn)
+	}
 	return ln
 }
 
@@ -135,6 +144,14 @@ func (l *SocketListener) Start(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if l.cfg.ProxyProtocol.Enabled {
+		pln, err := proxyproto.NewListener(ln, l.cfg.ProxyProtocol)
+		if err != nil {
+			_ = ln.Close()
+			return err
+		}
+		ln = pln
+	}
 	if l.cfg.DirectTLS {
 		ln = tls.NewListener(ln, l.getTLSConfig())
 	}
@@ -154,6 +171,7 @@ func (l *SocketListener) Start(ctx context.Context) error {
 	level.Info(l.logger).Log("msg", "accepting S2S socket connections",
 		"bind_addr", l.getAddress(),
 		"direct_tls", l.cfg.DirectTLS,
+		"proxy_protocol", l.cfg.ProxyProtocol.Enabled,
 	)
 	return nil
 }
@@ -168,7 +186,16 @@ func (l *SocketListener) Stop(ctx context.Context) error {
 	return nil
 }
 
+// HandleConn handles an incoming S2S connection whose TLS handshake has already been completed.
+func (l *SocketListener) HandleConn(conn net.Conn) {
+	l.serveConn(conn, true)
+}
+
 func (l *SocketListener) handleConn(conn net.Conn) {
+	l.serveConn(conn, l.cfg.DirectTLS)
+}
+
+func (l *SocketListener) serveConn(conn net.Conn, directTLS bool) {
 	tr := transport.NewSocketTransport(conn, l.cfg.ConnectTimeout, l.cfg.KeepAliveTimeout)
 	stm, err := newInS2S(
 		tr,
@@ -177,6 +204,7 @@ func (l *SocketListener) handleConn(conn net.Conn) {
 		l.comps,
 		l.mods,
 		l.outProvider,
+		l.peerAuth,
 		l.inHUB,
 		l.kv,
 		l.shapers,
@@ -185,8 +213,9 @@ func (l *SocketListener) handleConn(conn net.Conn) {
 		inConfig{
 			reqTimeout:    l.cfg.RequestTimeout,
 			maxStanzaSize: l.cfg.MaxStanzaSize,
-			directTLS:     l.cfg.DirectTLS,
+			directTLS:     directTLS,
 			tlsConfig:     l.getTLSConfig(),
+			resumeTimeout: l.cfg.ResumeTimeout,
 		},
 	)
 	if err != nil {
@@ -200,11 +229,32 @@ func (l *SocketListener) handleConn(conn net.Conn) {
 	}
 }
 
+func (l *SocketListener) newBidiIn(out *outS2S) *inS2S {
+	return newBidiInS2S(
+		out,
+		l.hosts,
+		l.router,
+		l.comps,
+		l.mods,
+		l.outProvider,
+		l.peerAuth,
+		l.inHUB,
+		l.kv,
+		l.shapers,
+		l.hk,
+		l.logger,
+		inConfig{
+			reqTimeout:    l.cfg.RequestTimeout,
+			maxStanzaSize: l.cfg.MaxStanzaSize,
+		},
+	)
+}
+
 func (l *SocketListener) getTLSConfig() *tls.Config {
 	return &tls.Config{
-		Certificates: l.hosts.Certificates(),
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-		MinVersion:   tls.VersionTLS12,
+		GetCertificate: l.hosts.GetCertificate,
+		ClientAuth:     tls.RequestClientCert,
+		MinVersion:     tls.VersionTLS12,
 	}
 }
 
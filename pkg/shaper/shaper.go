@@ -15,6 +15,8 @@
 package shaper
 
 import (
+	"sync"
+
 	"github.com/jackal-xmpp/stravaganza/jid"
 	"github.com/ortuman/jackal/pkg/util/stringmatcher"
 	"golang.org/x/time/rate"
@@ -47,6 +49,16 @@ func (ss Shapers) MatchingJID(j *jid.JID) *Shaper {
 	return &defaultC2SShaper
 }
 
+// ByName returns the shaper identified by name, or nil if not found.
+func (ss Shapers) ByName(name string) *Shaper {
+	for _, s := range ss {
+		if s.Name == name {
+			return &s
+		}
+	}
+	return nil
+}
+
 // DefaultC2S returns C2S default shaper.
 func (ss Shapers) DefaultC2S() *Shaper {
 	return &defaultC2SShaper
@@ -57,6 +69,55 @@ func (ss Shapers) DefaultS2S() *Shaper {
 	return &defaultS2SShaper
 }
 
+// Registry holds the configured shaper collection, allowing to replace it at runtime.
+// Already established connections keep their shaping constraints until they're closed.
+type Registry struct {
+	mu sync.RWMutex
+	ss Shapers
+}
+
+// NewRegistry returns a new Registry containing ss shapers.
+func NewRegistry(ss Shapers) *Registry {
+	return &Registry{ss: ss}
+}
+
+// Shapers returns current shaper collection.
+func (r *Registry) Shapers() Shapers {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ss
+}
+
+// Update replaces current shaper collection.
+func (r *Registry) Update(ss Shapers) {
+	r.mu.Lock()
+	r.ss = ss
+	r.mu.Unlock()
+}
+
+// MatchingJID returns the shaper that should be applied to a given JID.
+func (r *Registry) MatchingJID(j *jid.JID) *Shaper {
+	return r.Shapers().MatchingJID(j)
+}
+
+// ByName returns the shaper identified by name, or nil if not found.
+func (r *Registry) ByName(name string) *Shaper {
+	return r.Shapers().ByName(name)
+}
+
+// DefaultC2S returns C2S default shaper.
+func (r *Registry) DefaultC2S() *Shaper {
+	return r.Shapers().DefaultC2S()
+}
+
+// DefaultS2S returns S2S default shaper.
+func (r *Registry) DefaultS2S() *Shaper {
+	return r.Shapers().DefaultS2S()
+}
+
 // Shaper represents a connection traffic constraint set.
 type Shaper struct {
 	// Name is the shaper name.
@@ -63,6 +63,22 @@ func TestShapers_MatchingJID(t *testing.T) {
 	require.Equal(t, 1000, rLim.Burst())
 }
 
+func TestShapers_ByName(t *testing.T) {
+	// given
+	var ss Shapers
+	ss = append(ss, Shaper{Name: "foo", rateLimit: 2000, burst: 1000, jidMatcher: stringmatcher.Any})
+	ss = append(ss, Shaper{Name: "bar", rateLimit: 4000, burst: 2000, jidMatcher: stringmatcher.Any})
+
+	// when
+	s1 := ss.ByName("bar")
+	s2 := ss.ByName("baz")
+
+	// then
+	require.NotNil(t, s1)
+	require.Equal(t, rate.Limit(4000), s1.RateLimiter().Limit())
+	require.Nil(t, s2)
+}
+
 func TestShapers_Default(t *testing.T) {
 	// given
 	ss := new(Shapers)
@@ -75,3 +91,18 @@ func TestShapers_Default(t *testing.T) {
 	require.Equal(t, &defaultC2SShaper, s1)
 	require.Equal(t, &defaultS2SShaper, s2)
 }
+
+func TestRegistry_Update(t *testing.T) {
+	// given
+	r := NewRegistry(Shapers{{Name: "foo", rateLimit: 2000, burst: 1000, jidMatcher: stringmatcher.Any}})
+
+	// when
+	r.Update(Shapers{{Name: "bar", rateLimit: 4000, burst: 2000, jidMatcher: stringmatcher.Any}})
+
+	// then
+	require.Nil(t, r.ByName("foo"))
+	require.NotNil(t, r.ByName("bar"))
+
+	j, _ := jid.NewWithString("ortuman@jackal.im", true)
+	require.Equal(t, rate.Limit(4000), r.MatchingJID(j).RateLimiter().Limit())
+}
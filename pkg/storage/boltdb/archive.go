@@ -19,12 +19,17 @@ import (
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/jackal-xmpp/stravaganza"
 	"github.com/jackal-xmpp/stravaganza/jid"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	bolt "go.etcd.io/bbolt"
 )
 
-const archiveStampFormat = "2006-01-02T15:04:05Z"
+const (
+	archiveStampFormat = "2006-01-02T15:04:05Z"
+
+	archivePrefsKey = "prefs"
+)
 
 type boltDBArchiveRep struct {
 	tx *bolt.Tx
@@ -40,7 +45,11 @@ func (r *boltDBArchiveRep) InsertArchiveMessage(_ context.Context, message *arch
 		bucket: archiveBucket(message.ArchiveId),
 		obj:    message,
 	}
-	return op.do()
+	k, err := op.do()
+	if err != nil {
+		return err
+	}
+	return r.indexMessage(message.ArchiveId, k, message)
 }
 
 func (r *boltDBArchiveRep) FetchArchiveMetadata(_ context.Context, archiveID string) (metadata *archivemodel.Metadata, err error) {
@@ -90,7 +99,23 @@ func (r *boltDBArchiveRep) FetchArchiveMessages(_ context.Context, f *archivemod
 	if err := op.do(); err != nil {
 		return nil, err
 	}
-	return applyFilters(retVal, f)
+	retVal, err := applyFilters(retVal, f)
+	if err != nil {
+		return nil, err
+	}
+	// filtering by body text
+	if len(f.FullText) > 0 {
+		matchingIDs := r.searchMessageIDs(archiveID, f.FullText)
+
+		var filtered []*archivemodel.Message
+		for _, msg := range retVal {
+			if _, ok := matchingIDs[msg.Id]; ok {
+				filtered = append(filtered, msg)
+			}
+		}
+		retVal = filtered
+	}
+	return retVal, nil
 }
 
 func (r *boltDBArchiveRep) DeleteArchiveOldestMessages(_ context.Context, archiveID string, maxElements int) error {
@@ -112,17 +137,27 @@ func (r *boltDBArchiveRep) DeleteArchiveOldestMessages(_ context.Context, archiv
 	}
 	// store old value keys
 	var oldKeys [][]byte
+	var oldMessages []*archivemodel.Message
 
 	c = b.Cursor()
-	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+	for k, v := c.First(); k != nil; k, v = c.Next() {
 		if count <= maxElements {
 			break
 		}
 		count--
+
+		var msg archivemodel.Message
+		if err := proto.Unmarshal(v, &msg); err != nil {
+			return err
+		}
 		oldKeys = append(oldKeys, k)
+		oldMessages = append(oldMessages, &msg)
 	}
 	// delete old values
-	for _, k := range oldKeys {
+	for i, k := range oldKeys {
+		if err := r.unindexMessage(archiveID, k, oldMessages[i]); err != nil {
+			return err
+		}
 		if err := b.Delete(k); err != nil {
 			return err
 		}
@@ -131,6 +166,19 @@ func (r *boltDBArchiveRep) DeleteArchiveOldestMessages(_ context.Context, archiv
 }
 
 func (r *boltDBArchiveRep) DeleteArchive(_ context.Context, archiveID string) error {
+	idxExistsOp := bucketExistsOp{
+		tx:     r.tx,
+		bucket: archiveIndexBucket(archiveID),
+	}
+	if idxExistsOp.do() {
+		delIdxOp := delBucketOp{
+			tx:     r.tx,
+			bucket: archiveIndexBucket(archiveID),
+		}
+		if err := delIdxOp.do(); err != nil {
+			return err
+		}
+	}
 	op := delBucketOp{
 		tx:     r.tx,
 		bucket: archiveBucket(archiveID),
@@ -138,10 +186,128 @@ func (r *boltDBArchiveRep) DeleteArchive(_ context.Context, archiveID string) er
 	return op.do()
 }
 
+func (r *boltDBArchiveRep) UpdateArchiveMessage(_ context.Context, archiveID, fromJID, id string, message *stravaganza.PBElement) error {
+	k, msg, err := r.fetchMessageByRef(archiveID, fromJID, id)
+	if err != nil {
+		return err
+	}
+	if msg == nil || msg.Retracted {
+		return nil
+	}
+	return r.replaceMessage(archiveID, k, msg, &archivemodel.Message{
+		ArchiveId: msg.ArchiveId,
+		Id:        msg.Id,
+		OriginId:  msg.OriginId,
+		FromJid:   msg.FromJid,
+		ToJid:     msg.ToJid,
+		Message:   message,
+		Stamp:     msg.Stamp,
+	})
+}
+
+func (r *boltDBArchiveRep) TombstoneArchiveMessage(_ context.Context, archiveID, fromJID, id string, tombstone *stravaganza.PBElement) error {
+	k, msg, err := r.fetchMessageByRef(archiveID, fromJID, id)
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		return nil
+	}
+	return r.replaceMessage(archiveID, k, msg, &archivemodel.Message{
+		ArchiveId: msg.ArchiveId,
+		Id:        msg.Id,
+		OriginId:  msg.OriginId,
+		FromJid:   msg.FromJid,
+		ToJid:     msg.ToJid,
+		Message:   tombstone,
+		Stamp:     msg.Stamp,
+		Retracted: true,
+	})
+}
+
+func (r *boltDBArchiveRep) fetchMessageByRef(archiveID, fromJID, id string) ([]byte, *archivemodel.Message, error) {
+	b := r.tx.Bucket([]byte(archiveBucket(archiveID)))
+	if b == nil {
+		return nil, nil, nil
+	}
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var msg archivemodel.Message
+		if err := proto.Unmarshal(v, &msg); err != nil {
+			return nil, nil, err
+		}
+		if msg.Id != id && msg.OriginId != id {
+			continue
+		}
+		msgFromJID, err := jid.NewWithString(msg.FromJid, true)
+		if err != nil || msgFromJID.ToBareJID().String() != fromJID {
+			continue
+		}
+		return append([]byte(nil), k...), &msg, nil
+	}
+	return nil, nil, nil
+}
+
+func (r *boltDBArchiveRep) replaceMessage(archiveID string, k []byte, oldMsg, newMsg *archivemodel.Message) error {
+	if err := r.unindexMessage(archiveID, k, oldMsg); err != nil {
+		return err
+	}
+	b, err := proto.Marshal(newMsg)
+	if err != nil {
+		return err
+	}
+	if err := r.tx.Bucket([]byte(archiveBucket(archiveID))).Put(k, b); err != nil {
+		return err
+	}
+	return r.indexMessage(archiveID, k, newMsg)
+}
+
+func (r *boltDBArchiveRep) UpsertArchivePreferences(_ context.Context, prefs *archivemodel.Preferences) error {
+	op := upsertKeyOp{
+		tx:     r.tx,
+		bucket: archivePrefsBucket(prefs.ArchiveId),
+		key:    archivePrefsKey,
+		obj:    prefs,
+	}
+	return op.do()
+}
+
+func (r *boltDBArchiveRep) FetchArchivePreferences(_ context.Context, archiveID string) (*archivemodel.Preferences, error) {
+	op := fetchKeyOp{
+		tx:     r.tx,
+		bucket: archivePrefsBucket(archiveID),
+		key:    archivePrefsKey,
+		obj:    &archivemodel.Preferences{},
+	}
+	obj, err := op.do()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case obj != nil:
+		return obj.(*archivemodel.Preferences), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (r *boltDBArchiveRep) DeleteArchivePreferences(_ context.Context, archiveID string) error {
+	op := delKeyOp{
+		tx:     r.tx,
+		bucket: archivePrefsBucket(archiveID),
+		key:    archivePrefsKey,
+	}
+	return op.do()
+}
+
 func archiveBucket(archiveID string) string {
 	return fmt.Sprintf("archive:%s", archiveID)
 }
 
+func archivePrefsBucket(archiveID string) string {
+	return fmt.Sprintf("archive_prefs:%s", archiveID)
+}
+
 // InsertArchiveMessage inserts a new message element into an archive queue.
 func (r *Repository) InsertArchiveMessage(ctx context.Context, message *archivemodel.Message) error {
 	return r.db.Update(func(tx *bolt.Tx) error {
@@ -181,6 +347,43 @@ func (r *Repository) DeleteArchive(ctx context.Context, archiveID string) error
 	})
 }
 
+// UpdateArchiveMessage replaces the stanza of an archived message referenced by its archive or origin identifier.
+func (r *Repository) UpdateArchiveMessage(ctx context.Context, archiveID, fromJID, id string, message *stravaganza.PBElement) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newArchiveRep(tx).UpdateArchiveMessage(ctx, archiveID, fromJID, id, message)
+	})
+}
+
+// TombstoneArchiveMessage replaces an archived message referenced by its archive or origin identifier with a retraction tombstone.
+func (r *Repository) TombstoneArchiveMessage(ctx context.Context, archiveID, fromJID, id string, tombstone *stravaganza.PBElement) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newArchiveRep(tx).TombstoneArchiveMessage(ctx, archiveID, fromJID, id, tombstone)
+	})
+}
+
+// UpsertArchivePreferences inserts or updates archive preferences entity.
+func (r *Repository) UpsertArchivePreferences(ctx context.Context, prefs *archivemodel.Preferences) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newArchiveRep(tx).UpsertArchivePreferences(ctx, prefs)
+	})
+}
+
+// FetchArchivePreferences retrieves the preferences associated to an archive.
+func (r *Repository) FetchArchivePreferences(ctx context.Context, archiveID string) (prefs *archivemodel.Preferences, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		prefs, err = newArchiveRep(tx).FetchArchivePreferences(ctx, archiveID)
+		return err
+	})
+	return
+}
+
+// DeleteArchivePreferences removes the preferences associated to an archive.
+func (r *Repository) DeleteArchivePreferences(ctx context.Context, archiveID string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newArchiveRep(tx).DeleteArchivePreferences(ctx, archiveID)
+	})
+}
+
 func applyFilters(messages []*archivemodel.Message, f *archivemodel.Filters) ([]*archivemodel.Message, error) {
 	retVal := messages
 
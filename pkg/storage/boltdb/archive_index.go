@@ -0,0 +1,113 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boltdb
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
+)
+
+// archiveIndexSeparator splits token and message key within an inverted index entry key.
+const archiveIndexSeparator = 0x00
+
+func (r *boltDBArchiveRep) indexMessage(archiveID string, msgKey []byte, msg *archivemodel.Message) error {
+	tokens := tokenize(msg.BodyText())
+	if len(tokens) == 0 {
+		return nil
+	}
+	b, err := r.tx.CreateBucketIfNotExists([]byte(archiveIndexBucket(archiveID)))
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if err := b.Put(archiveIndexKey(token, msgKey), []byte(msg.Id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *boltDBArchiveRep) unindexMessage(archiveID string, msgKey []byte, msg *archivemodel.Message) error {
+	b := r.tx.Bucket([]byte(archiveIndexBucket(archiveID)))
+	if b == nil {
+		return nil
+	}
+	for _, token := range tokenize(msg.BodyText()) {
+		if err := b.Delete(archiveIndexKey(token, msgKey)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchMessageIDs returns the identifiers of all archive messages whose body contains every text token.
+func (r *boltDBArchiveRep) searchMessageIDs(archiveID, text string) map[string]struct{} {
+	retVal := make(map[string]struct{})
+
+	b := r.tx.Bucket([]byte(archiveIndexBucket(archiveID)))
+	if b == nil {
+		return retVal
+	}
+	for i, token := range tokenize(text) {
+		prefix := append([]byte(token), archiveIndexSeparator)
+
+		tokenIDs := make(map[string]struct{})
+		c := b.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			id := string(v)
+			if _, ok := retVal[id]; ok || i == 0 {
+				tokenIDs[id] = struct{}{}
+			}
+		}
+		retVal = tokenIDs
+		if len(retVal) == 0 {
+			break
+		}
+	}
+	return retVal
+}
+
+func archiveIndexBucket(archiveID string) string {
+	return fmt.Sprintf("archive_idx:%s", archiveID)
+}
+
+func archiveIndexKey(token string, msgKey []byte) []byte {
+	k := make([]byte, 0, len(token)+len(msgKey)+1)
+	k = append(k, token...)
+	k = append(k, archiveIndexSeparator)
+	return append(k, msgKey...)
+}
+
+// tokenize splits text into its set of lowercased words.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	seen := make(map[string]struct{}, len(words))
+
+	var retVal []string
+	for _, w := range words {
+		if _, ok := seen[w]; ok {
+			continue
+		}
+		seen[w] = struct{}{}
+		retVal = append(retVal, w)
+	}
+	return retVal
+}
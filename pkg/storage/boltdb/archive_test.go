@@ -16,6 +16,7 @@ package boltdb
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -290,3 +291,180 @@ func TestBoltDB_FetchArchiveMessages(t *testing.T) {
 		})
 	}
 }
+
+func TestBoltDB_UpsertAndFetchArchivePreferences(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		prefs, err := rep.FetchArchivePreferences(context.Background(), "ortuman@jackal.im")
+		require.NoError(t, err)
+		require.Nil(t, prefs)
+
+		err = rep.UpsertArchivePreferences(context.Background(), &archivemodel.Preferences{
+			ArchiveId:   "ortuman@jackal.im",
+			DefaultMode: archivemodel.RosterMode,
+			Never:       []string{"noelia@jackal.im"},
+		})
+		require.NoError(t, err)
+
+		prefs, err = rep.FetchArchivePreferences(context.Background(), "ortuman@jackal.im")
+		require.NoError(t, err)
+		require.NotNil(t, prefs)
+
+		require.Equal(t, archivemodel.RosterMode, prefs.DefaultMode)
+		require.Equal(t, []string{"noelia@jackal.im"}, prefs.Never)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteArchivePreferences(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		err := rep.UpsertArchivePreferences(context.Background(), &archivemodel.Preferences{
+			ArchiveId:   "ortuman@jackal.im",
+			DefaultMode: archivemodel.NeverMode,
+		})
+		require.NoError(t, err)
+
+		err = rep.DeleteArchivePreferences(context.Background(), "ortuman@jackal.im")
+		require.NoError(t, err)
+
+		prefs, err := rep.FetchArchivePreferences(context.Background(), "ortuman@jackal.im")
+		require.NoError(t, err)
+		require.Nil(t, prefs)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_FetchArchiveMessagesByFullText(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		bodies := []string{
+			"But, soft! What light through yonder window breaks?",
+			"It is the east, and Juliet is the sun.",
+			"Arise, fair sun, and kill the envious moon.",
+		}
+		for i, body := range bodies {
+			err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+				ArchiveId: "a1234",
+				Id:        fmt.Sprintf("m%d", i),
+				FromJid:   "noelia@jackal.im/yard",
+				ToJid:     "ortuman@jackal.im/chamber",
+				Stamp:     timestamppb.New(time.Date(2022, 01, i+1, 00, 00, 00, 00, time.UTC)),
+				Message:   testMessageStanzaWithParameters(body, "noelia@jackal.im/yard", "ortuman@jackal.im/chamber").Proto(),
+			})
+			require.NoError(t, err)
+		}
+
+		messages, err := rep.FetchArchiveMessages(context.Background(), &archivemodel.Filters{FullText: "SUN"}, "a1234")
+		require.NoError(t, err)
+		require.Len(t, messages, 2)
+		require.Equal(t, "m1", messages[0].Id)
+		require.Equal(t, "m2", messages[1].Id)
+
+		messages, err = rep.FetchArchiveMessages(context.Background(), &archivemodel.Filters{FullText: "fair sun"}, "a1234")
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.Equal(t, "m2", messages[0].Id)
+
+		messages, err = rep.FetchArchiveMessages(context.Background(), &archivemodel.Filters{FullText: "romeo"}, "a1234")
+		require.NoError(t, err)
+		require.Len(t, messages, 0)
+
+		// trimming archive should also remove indexed tokens
+		err = rep.DeleteArchiveOldestMessages(context.Background(), "a1234", 1)
+		require.NoError(t, err)
+
+		messages, err = rep.FetchArchiveMessages(context.Background(), &archivemodel.Filters{FullText: "sun"}, "a1234")
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.Equal(t, "m2", messages[0].Id)
+
+		require.Len(t, rep.searchMessageIDs("a1234", "juliet"), 0)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_UpdateAndTombstoneArchiveMessage(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBArchiveRep{tx: tx}
+
+		err := rep.InsertArchiveMessage(context.Background(), &archivemodel.Message{
+			ArchiveId: "a1234",
+			Id:        "m0",
+			OriginId:  "o0",
+			FromJid:   "noelia@jackal.im/yard",
+			ToJid:     "ortuman@jackal.im/chamber",
+			Stamp:     timestamppb.Now(),
+			Message:   testMessageStanzaWithParameters("It is the east", "noelia@jackal.im/yard", "ortuman@jackal.im/chamber").Proto(),
+		})
+		require.NoError(t, err)
+
+		// only sender messages can be updated
+		corrected := testMessageStanzaWithParameters("It is the west", "noelia@jackal.im/yard", "ortuman@jackal.im/chamber")
+
+		err = rep.UpdateArchiveMessage(context.Background(), "a1234", "ortuman@jackal.im", "o0", corrected.Proto())
+		require.NoError(t, err)
+
+		messages, err := rep.FetchArchiveMessages(context.Background(), &archivemodel.Filters{FullText: "east"}, "a1234")
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+
+		err = rep.UpdateArchiveMessage(context.Background(), "a1234", "noelia@jackal.im", "o0", corrected.Proto())
+		require.NoError(t, err)
+
+		messages, err = rep.FetchArchiveMessages(context.Background(), &archivemodel.Filters{FullText: "west"}, "a1234")
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.Equal(t, "m0", messages[0].Id)
+		require.Equal(t, "It is the west", messages[0].BodyText())
+		require.Len(t, rep.searchMessageIDs("a1234", "east"), 0)
+
+		// tombstone by archive identifier
+		tombstone := testMessageStanzaWithParameters("", "noelia@jackal.im/yard", "ortuman@jackal.im/chamber")
+
+		err = rep.TombstoneArchiveMessage(context.Background(), "a1234", "noelia@jackal.im", "m0", tombstone.Proto())
+		require.NoError(t, err)
+
+		messages, err = rep.FetchArchiveMessages(context.Background(), &archivemodel.Filters{}, "a1234")
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.True(t, messages[0].Retracted)
+		require.Len(t, rep.searchMessageIDs("a1234", "west"), 0)
+
+		// retracted messages can no longer be updated
+		err = rep.UpdateArchiveMessage(context.Background(), "a1234", "noelia@jackal.im", "o0", corrected.Proto())
+		require.NoError(t, err)
+
+		messages, err = rep.FetchArchiveMessages(context.Background(), &archivemodel.Filters{FullText: "west"}, "a1234")
+		require.NoError(t, err)
+		require.Len(t, messages, 0)
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -15,14 +15,20 @@
 package boltdb
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/jackal-xmpp/stravaganza"
+	offlinemodel "github.com/ortuman/jackal/pkg/model/offline"
 	bolt "go.etcd.io/bbolt"
 )
 
+const offlineExpiryBucket = "offline_expiry"
+
 type boltDBOfflineRep struct {
 	tx *bolt.Tx
 }
@@ -31,13 +37,34 @@ func newOfflineRep(tx *bolt.Tx) *boltDBOfflineRep {
 	return &boltDBOfflineRep{tx: tx}
 }
 
-func (r *boltDBOfflineRep) InsertOfflineMessage(_ context.Context, message *stravaganza.Message, username string) error {
+func (r *boltDBOfflineRep) InsertOfflineMessage(_ context.Context, message *stravaganza.Message, username string, expiresAt time.Time) error {
 	op := insertSeqOp{
 		tx:     r.tx,
 		bucket: offlineBucket(username),
 		obj:    message,
 	}
-	return op.do()
+	k, err := op.do()
+	if err != nil {
+		return err
+	}
+	if expiresAt.IsZero() {
+		return nil
+	}
+	// index message by expiration time
+	b, err := r.tx.CreateBucketIfNotExists([]byte(offlineExpiryBucket))
+	if err != nil {
+		return err
+	}
+	expiryKey := offlineExpiryKey(expiresAt, username, k)
+	if err := b.Put(expiryKey, nil); err != nil {
+		return err
+	}
+	// keep track of message expiry key, so that it can be removed along with the message
+	ib, err := r.tx.CreateBucketIfNotExists([]byte(offlineExpiryIdxBucket(username)))
+	if err != nil {
+		return err
+	}
+	return ib.Put(k, expiryKey)
 }
 
 func (r *boltDBOfflineRep) CountOfflineMessages(_ context.Context, username string) (int, error) {
@@ -73,22 +100,212 @@ func (r *boltDBOfflineRep) FetchOfflineMessages(_ context.Context, username stri
 	return retVal, nil
 }
 
+func (r *boltDBOfflineRep) FetchOfflineMessagesByID(_ context.Context, username string, ids []string) ([]*offlinemodel.Message, error) {
+	var retVal []*offlinemodel.Message
+
+	idsMap := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		idsMap[id] = struct{}{}
+	}
+	op := iterKeysOp{
+		tx:     r.tx,
+		bucket: offlineBucket(username),
+		iterFn: func(k, b []byte) error {
+			if _, ok := idsMap[string(k)]; len(idsMap) > 0 && !ok {
+				return nil
+			}
+			var elem stravaganza.PBElement
+			if err := proto.Unmarshal(b, &elem); err != nil {
+				return err
+			}
+			retVal = append(retVal, &offlinemodel.Message{
+				Id:      string(k),
+				Message: &elem,
+			})
+			return nil
+		},
+	}
+	if err := op.do(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+func (r *boltDBOfflineRep) FetchExpiredOfflineUsernames(_ context.Context, now time.Time) ([]string, error) {
+	b := r.tx.Bucket([]byte(offlineExpiryBucket))
+	if b == nil {
+		return nil, nil
+	}
+	var retVal []string
+
+	seen := make(map[string]struct{})
+	err := iterExpiredKeys(b, now, func(_ []byte, username, _ string) error {
+		if _, ok := seen[username]; ok {
+			return nil
+		}
+		seen[username] = struct{}{}
+		retVal = append(retVal, username)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+func (r *boltDBOfflineRep) DeleteExpiredOfflineMessages(_ context.Context, username string, now time.Time) ([]*offlinemodel.Message, error) {
+	b := r.tx.Bucket([]byte(offlineExpiryBucket))
+	if b == nil {
+		return nil, nil
+	}
+	var retVal []*offlinemodel.Message
+	var expiryKeys [][]byte
+
+	qb := r.tx.Bucket([]byte(offlineBucket(username)))
+	ib := r.tx.Bucket([]byte(offlineExpiryIdxBucket(username)))
+
+	err := iterExpiredKeys(b, now, func(k []byte, kUsername, msgKey string) error {
+		if kUsername != username {
+			return nil
+		}
+		expiryKeys = append(expiryKeys, append([]byte(nil), k...))
+
+		// make sure the queued message is the one that was indexed with this expiration time
+		if qb == nil || ib == nil || !bytes.Equal(ib.Get([]byte(msgKey)), k) {
+			return nil
+		}
+		v := qb.Get([]byte(msgKey))
+		if v == nil {
+			return nil // already delivered
+		}
+		var elem stravaganza.PBElement
+		if err := proto.Unmarshal(v, &elem); err != nil {
+			return err
+		}
+		retVal = append(retVal, &offlinemodel.Message{
+			Id:       msgKey,
+			Message:  &elem,
+			Username: username,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range expiryKeys {
+		if err := b.Delete(k); err != nil {
+			return nil, err
+		}
+	}
+	for _, msg := range retVal {
+		if err := qb.Delete([]byte(msg.Id)); err != nil {
+			return nil, err
+		}
+		if err := ib.Delete([]byte(msg.Id)); err != nil {
+			return nil, err
+		}
+	}
+	return retVal, nil
+}
+
+func (r *boltDBOfflineRep) DeleteOfflineMessagesByID(_ context.Context, username string, ids []string) error {
+	for _, id := range ids {
+		op := delKeyOp{
+			tx:     r.tx,
+			bucket: offlineBucket(username),
+			key:    id,
+		}
+		if err := op.do(); err != nil {
+			return err
+		}
+	}
+	ib := r.tx.Bucket([]byte(offlineExpiryIdxBucket(username)))
+	if ib == nil {
+		return nil
+	}
+	for _, id := range ids {
+		if err := r.deleteExpiryKey(ib, []byte(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *boltDBOfflineRep) DeleteOfflineMessages(_ context.Context, username string) error {
 	op := delBucketOp{
 		tx:     r.tx,
 		bucket: offlineBucket(username),
 	}
+	if err := op.do(); err != nil {
+		return err
+	}
+	ib := r.tx.Bucket([]byte(offlineExpiryIdxBucket(username)))
+	if ib == nil {
+		return nil
+	}
+	b := r.tx.Bucket([]byte(offlineExpiryBucket))
+	if b != nil {
+		err := ib.ForEach(func(_, expiryKey []byte) error {
+			return b.Delete(expiryKey)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	op = delBucketOp{
+		tx:     r.tx,
+		bucket: offlineExpiryIdxBucket(username),
+	}
 	return op.do()
 }
 
+func (r *boltDBOfflineRep) deleteExpiryKey(ib *bolt.Bucket, msgKey []byte) error {
+	expiryKey := ib.Get(msgKey)
+	if expiryKey == nil {
+		return nil
+	}
+	if b := r.tx.Bucket([]byte(offlineExpiryBucket)); b != nil {
+		if err := b.Delete(expiryKey); err != nil {
+			return err
+		}
+	}
+	return ib.Delete(msgKey)
+}
+
+// iterExpiredKeys calls fn for every expiry index key whose expiration time is not after now.
+func iterExpiredKeys(b *bolt.Bucket, now time.Time, fn func(k []byte, username, msgKey string) error) error {
+	nowPrefix := offlineExpiryKey(now, "", nil)
+	stampLen := strings.IndexByte(string(nowPrefix), ':')
+
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil && string(k[:stampLen]) <= string(nowPrefix[:stampLen]); k, _ = c.Next() {
+		keyParts := strings.SplitN(string(k), ":", 3)
+		if len(keyParts) != 3 {
+			continue
+		}
+		if err := fn(k, keyParts[1], keyParts[2]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func offlineBucket(username string) string {
 	return fmt.Sprintf("offline:%s", username)
 }
 
+func offlineExpiryIdxBucket(username string) string {
+	return fmt.Sprintf("offline_expiry_idx:%s", username)
+}
+
+func offlineExpiryKey(expiresAt time.Time, username string, msgKey []byte) []byte {
+	return []byte(fmt.Sprintf("%020d:%s:%s", expiresAt.UnixNano(), username, msgKey))
+}
+
 // InsertOfflineMessage satisfies repository.Offline interface.
-func (r *Repository) InsertOfflineMessage(ctx context.Context, message *stravaganza.Message, username string) error {
+func (r *Repository) InsertOfflineMessage(ctx context.Context, message *stravaganza.Message, username string, expiresAt time.Time) error {
 	return r.db.Update(func(tx *bolt.Tx) error {
-		return newOfflineRep(tx).InsertOfflineMessage(ctx, message, username)
+		return newOfflineRep(tx).InsertOfflineMessage(ctx, message, username, expiresAt)
 	})
 }
 
@@ -110,6 +327,40 @@ func (r *Repository) FetchOfflineMessages(ctx context.Context, username string)
 	return
 }
 
+// FetchOfflineMessagesByID satisfies repository.Offline interface.
+func (r *Repository) FetchOfflineMessagesByID(ctx context.Context, username string, ids []string) (msg []*offlinemodel.Message, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		msg, err = newOfflineRep(tx).FetchOfflineMessagesByID(ctx, username, ids)
+		return err
+	})
+	return
+}
+
+// FetchExpiredOfflineUsernames satisfies repository.Offline interface.
+func (r *Repository) FetchExpiredOfflineUsernames(ctx context.Context, now time.Time) (usernames []string, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		usernames, err = newOfflineRep(tx).FetchExpiredOfflineUsernames(ctx, now)
+		return err
+	})
+	return
+}
+
+// DeleteExpiredOfflineMessages satisfies repository.Offline interface.
+func (r *Repository) DeleteExpiredOfflineMessages(ctx context.Context, username string, now time.Time) (msg []*offlinemodel.Message, err error) {
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		msg, err = newOfflineRep(tx).DeleteExpiredOfflineMessages(ctx, username, now)
+		return err
+	})
+	return
+}
+
+// DeleteOfflineMessagesByID satisfies repository.Offline interface.
+func (r *Repository) DeleteOfflineMessagesByID(ctx context.Context, username string, ids []string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return newOfflineRep(tx).DeleteOfflineMessagesByID(ctx, username, ids)
+	})
+}
+
 // DeleteOfflineMessages satisfies repository.Offline interface.
 func (r *Repository) DeleteOfflineMessages(ctx context.Context, username string) error {
 	return r.db.Update(func(tx *bolt.Tx) error {
@@ -17,6 +17,7 @@ package boltdb
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	bolt "go.etcd.io/bbolt"
@@ -34,10 +35,10 @@ func TestBoltDB_InsertAndFetchOfflineMessages(t *testing.T) {
 		m0 := testMessageStanza()
 		m1 := testMessageStanza()
 
-		err := rep.InsertOfflineMessage(context.Background(), m0, "ortuman")
+		err := rep.InsertOfflineMessage(context.Background(), m0, "ortuman", time.Time{})
 		require.NoError(t, err)
 
-		err = rep.InsertOfflineMessage(context.Background(), m1, "ortuman")
+		err = rep.InsertOfflineMessage(context.Background(), m1, "ortuman", time.Time{})
 		require.NoError(t, err)
 
 		messages, err := rep.FetchOfflineMessages(context.Background(), "ortuman")
@@ -62,10 +63,10 @@ func TestBoltDB_CountOfflineMessages(t *testing.T) {
 		m0 := testMessageStanza()
 		m1 := testMessageStanza()
 
-		err := rep.InsertOfflineMessage(context.Background(), m0, "ortuman")
+		err := rep.InsertOfflineMessage(context.Background(), m0, "ortuman", time.Time{})
 		require.NoError(t, err)
 
-		err = rep.InsertOfflineMessage(context.Background(), m1, "ortuman")
+		err = rep.InsertOfflineMessage(context.Background(), m1, "ortuman", time.Time{})
 		require.NoError(t, err)
 
 		cnt, err := rep.CountOfflineMessages(context.Background(), "ortuman")
@@ -89,10 +90,10 @@ func TestBoltDB_DeleteOfflineMessages(t *testing.T) {
 		m0 := testMessageStanza()
 		m1 := testMessageStanza()
 
-		err := rep.InsertOfflineMessage(context.Background(), m0, "ortuman")
+		err := rep.InsertOfflineMessage(context.Background(), m0, "ortuman", time.Time{})
 		require.NoError(t, err)
 
-		err = rep.InsertOfflineMessage(context.Background(), m1, "ortuman")
+		err = rep.InsertOfflineMessage(context.Background(), m1, "ortuman", time.Time{})
 		require.NoError(t, err)
 
 		cnt, err := rep.CountOfflineMessages(context.Background(), "ortuman")
@@ -109,3 +110,148 @@ func TestBoltDB_DeleteOfflineMessages(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestBoltDB_FetchAndDeleteOfflineMessagesByID(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBOfflineRep{tx: tx}
+
+		for i := 0; i < 3; i++ {
+			err := rep.InsertOfflineMessage(context.Background(), testMessageStanza(), "ortuman", time.Time{})
+			require.NoError(t, err)
+		}
+
+		messages, err := rep.FetchOfflineMessagesByID(context.Background(), "ortuman", nil)
+		require.NoError(t, err)
+		require.Len(t, messages, 3)
+
+		messages, err = rep.FetchOfflineMessagesByID(context.Background(), "ortuman", []string{messages[1].Id, "foo"})
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+
+		err = rep.DeleteOfflineMessagesByID(context.Background(), "ortuman", []string{messages[0].Id})
+		require.NoError(t, err)
+
+		cnt, err := rep.CountOfflineMessages(context.Background(), "ortuman")
+		require.NoError(t, err)
+		require.Equal(t, 2, cnt)
+
+		messages, err = rep.FetchOfflineMessagesByID(context.Background(), "ortuman", []string{messages[0].Id})
+		require.NoError(t, err)
+		require.Len(t, messages, 0)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteExpiredOfflineMessages(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	now := time.Now()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBOfflineRep{tx: tx}
+
+		err := rep.InsertOfflineMessage(context.Background(), testMessageStanza(), "ortuman", now.Add(-time.Minute))
+		require.NoError(t, err)
+
+		err = rep.InsertOfflineMessage(context.Background(), testMessageStanza(), "noelia", now.Add(-time.Second))
+		require.NoError(t, err)
+
+		err = rep.InsertOfflineMessage(context.Background(), testMessageStanza(), "ortuman", now.Add(time.Hour))
+		require.NoError(t, err)
+
+		err = rep.InsertOfflineMessage(context.Background(), testMessageStanza(), "ortuman", time.Time{})
+		require.NoError(t, err)
+
+		usernames, err := rep.FetchExpiredOfflineUsernames(context.Background(), now)
+		require.NoError(t, err)
+		require.Equal(t, []string{"ortuman", "noelia"}, usernames)
+
+		messages, err := rep.DeleteExpiredOfflineMessages(context.Background(), "ortuman", now)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		require.Equal(t, "ortuman", messages[0].Username)
+
+		cnt, err := rep.CountOfflineMessages(context.Background(), "ortuman")
+		require.NoError(t, err)
+		require.Equal(t, 2, cnt)
+
+		cnt, err = rep.CountOfflineMessages(context.Background(), "noelia")
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+
+		usernames, err = rep.FetchExpiredOfflineUsernames(context.Background(), now)
+		require.NoError(t, err)
+		require.Equal(t, []string{"noelia"}, usernames)
+
+		messages, err = rep.DeleteExpiredOfflineMessages(context.Background(), "ortuman", now)
+		require.NoError(t, err)
+		require.Len(t, messages, 0)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestBoltDB_DeleteOfflineMessagesKeepsReusedKeys(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	now := time.Now()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBOfflineRep{tx: tx}
+
+		// expiring message delivered by clearing the whole queue
+		err := rep.InsertOfflineMessage(context.Background(), testMessageStanza(), "ortuman", now.Add(time.Second))
+		require.NoError(t, err)
+
+		err = rep.DeleteOfflineMessages(context.Background(), "ortuman")
+		require.NoError(t, err)
+
+		// expiring message acknowledged by identifier
+		err = rep.InsertOfflineMessage(context.Background(), testMessageStanza(), "noelia", now.Add(time.Second))
+		require.NoError(t, err)
+
+		messages, err := rep.FetchOfflineMessagesByID(context.Background(), "noelia", nil)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+
+		err = rep.DeleteOfflineMessagesByID(context.Background(), "noelia", []string{messages[0].Id})
+		require.NoError(t, err)
+
+		// non expiring messages reusing the same queue keys
+		err = rep.InsertOfflineMessage(context.Background(), testMessageStanza(), "ortuman", time.Time{})
+		require.NoError(t, err)
+
+		err = rep.InsertOfflineMessage(context.Background(), testMessageStanza(), "noelia", time.Time{})
+		require.NoError(t, err)
+
+		usernames, err := rep.FetchExpiredOfflineUsernames(context.Background(), now.Add(time.Minute))
+		require.NoError(t, err)
+		require.Len(t, usernames, 0)
+
+		for _, username := range []string{"ortuman", "noelia"} {
+			messages, err := rep.DeleteExpiredOfflineMessages(context.Background(), username, now.Add(time.Minute))
+			require.NoError(t, err)
+			require.Len(t, messages, 0)
+
+			cnt, err := rep.CountOfflineMessages(context.Background(), username)
+			require.NoError(t, err)
+			require.Equal(t, 1, cnt)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -47,20 +47,24 @@ type insertSeqOp struct {
 	obj    model.Codec
 }
 
-func (op insertSeqOp) do() error {
+func (op insertSeqOp) do() ([]byte, error) {
 	b, err := op.tx.CreateBucketIfNotExists([]byte(op.bucket))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	p, err := op.obj.MarshalBinary()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	seq, err := b.NextSequence()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	k := []byte(fmt.Sprintf("%d", seq))
+	if err := b.Put(k, p); err != nil {
+		return nil, err
 	}
-	return b.Put([]byte(fmt.Sprintf("%d", seq)), p)
+	return k, nil
 }
 
 type delBucketOp struct {
@@ -15,14 +15,17 @@
 package boltdb
 
 import (
+	"bytes"
 	"context"
-	"fmt"
 
 	usermodel "github.com/ortuman/jackal/pkg/model/user"
 	bolt "go.etcd.io/bbolt"
 )
 
-const userKey = "usr"
+const (
+	userKey          = "usr"
+	userBucketPrefix = "user:"
+)
 
 type boltDBUserRep struct {
 	tx *bolt.Tx
@@ -77,8 +80,31 @@ func (r *boltDBUserRep) UserExists(_ context.Context, username string) (bool, er
 	return op.do(), nil
 }
 
+func (r *boltDBUserRep) CountUsersByPepper(_ context.Context) (map[string]int, error) {
+	res := make(map[string]int)
+	err := r.tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !bytes.HasPrefix(name, []byte(userBucketPrefix)) {
+			return nil
+		}
+		data := b.Get([]byte(userKey))
+		if data == nil {
+			return nil
+		}
+		var usr usermodel.User
+		if err := usr.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		res[usr.GetScram().GetPepperId()]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func userBucketKey(username string) string {
-	return fmt.Sprintf("user:%s", username)
+	return userBucketPrefix + username
 }
 
 // UpsertUser satisfies repository.User interface.
@@ -112,3 +138,12 @@ func (r *Repository) UserExists(ctx context.Context, username string) (ok bool,
 	})
 	return
 }
+
+// CountUsersByPepper satisfies repository.User interface.
+func (r *Repository) CountUsersByPepper(ctx context.Context) (counts map[string]int, err error) {
+	err = r.db.View(func(tx *bolt.Tx) error {
+		counts, err = newUserRep(tx).CountUsersByPepper(ctx)
+		return err
+	})
+	return
+}
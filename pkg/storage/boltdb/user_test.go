@@ -94,3 +94,28 @@ func TestBoltDB_DeleteUser(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestBoltDB_CountUsersByPepper(t *testing.T) {
+	t.Parallel()
+
+	db := setupDB(t)
+	t.Cleanup(func() { cleanUp(db) })
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rep := boltDBUserRep{tx: tx}
+
+		for username, pepperID := range map[string]string{"ortuman": "v1", "noelia": "v2", "romeo": "v2"} {
+			err := rep.UpsertUser(context.Background(), &usermodel.User{
+				Username: username,
+				Scram:    &usermodel.Scram{PepperId: pepperID},
+			})
+			require.NoError(t, err)
+		}
+		counts, err := rep.CountUsersByPepper(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, map[string]int{"v1": 1, "v2": 2}, counts)
+		return nil
+	})
+	require.NoError(t, err)
+}
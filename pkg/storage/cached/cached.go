@@ -30,6 +30,14 @@ type Config struct {
 	Redis rediscache.Config
 }
 
+// Validate checks whether cache configuration values are supported.
+func (c Config) Validate() error {
+	if c.Type != rediscache.Type {
+		return fmt.Errorf("unrecognized repository cache type: %s", c.Type)
+	}
+	return nil
+}
+
 // Cache defines cache store interface.
 type Cache interface {
 	// Type identifies underlying cache store type.
@@ -79,8 +87,8 @@ type CachedRepository struct {
 
 // New returns a new initialized CachedRepository instance.
 func New(cfg Config, rep repository.Repository, logger kitlog.Logger) (repository.Repository, error) {
-	if cfg.Type != rediscache.Type {
-		return nil, fmt.Errorf("unrecognized repository cache type: %s", cfg.Type)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 	c := rediscache.New(cfg.Redis, logger)
 
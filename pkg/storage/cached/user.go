@@ -90,6 +90,10 @@ func (c *cachedUserRep) UserExists(ctx context.Context, username string) (bool,
 	return op.do(ctx)
 }
 
+func (c *cachedUserRep) CountUsersByPepper(ctx context.Context) (map[string]int, error) {
+	return c.rep.CountUsersByPepper(ctx)
+}
+
 func userNS(username string) string {
 	return fmt.Sprintf("usr:%s", username)
 }
@@ -151,3 +151,23 @@ func TestCachedUserRep_UserExists(t *testing.T) {
 
 	require.Len(t, repMock.UserExistsCalls(), 2)
 }
+
+func TestCachedUserRep_CountUsersByPepper(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.CountUsersByPepperFunc = func(ctx context.Context) (map[string]int, error) {
+		return map[string]int{"v1": 1, "v2": 4}, nil
+	}
+
+	// when
+	rep := cachedUserRep{
+		c:   &cacheMock{},
+		rep: repMock,
+	}
+	counts, err := rep.CountUsersByPepper(context.Background())
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"v1": 1, "v2": 4}, counts)
+	require.Len(t, repMock.CountUsersByPepperCalls(), 1)
+}
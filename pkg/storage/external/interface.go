@@ -0,0 +1,31 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalrepository
+
+import (
+	"context"
+
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+//go:generate moq -out checker.mock_test.go . userChecker
+type userChecker interface {
+	UserExists(ctx context.Context, username string) (bool, error)
+}
+
+//go:generate moq -out repository.mock_test.go . globalRepository:repositoryMock
+type globalRepository interface {
+	repository.Repository
+}
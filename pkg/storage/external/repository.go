@@ -0,0 +1,58 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalrepository
+
+import (
+	"context"
+
+	"github.com/ortuman/jackal/pkg/auth"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+// Repository is a repository.Repository implementation whose user existence checks
+// are also answered by a set of external authenticators.
+// Any other operation is delegated to the wrapped repository.
+type Repository struct {
+	repository.Repository
+	checkers []userChecker
+}
+
+// New returns a new initialized external authenticator backed repository.
+func New(extCls []*auth.ExternalClient, rep repository.Repository) *Repository {
+	r := &Repository{Repository: rep}
+	for _, extCl := range extCls {
+		r.checkers = append(r.checkers, extCl)
+	}
+	return r
+}
+
+// UserExists tells whether or not a user exists either within the wrapped repository
+// or in any of the external authenticators.
+func (r *Repository) UserExists(ctx context.Context, username string) (bool, error) {
+	exists, err := r.Repository.UserExists(ctx, username)
+	if err != nil || exists {
+		return exists, err
+	}
+	for _, checker := range r.checkers {
+		exists, err := checker.UserExists(ctx, username)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalrepository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_UserExists(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UserExistsFunc = func(ctx context.Context, username string) (bool, error) {
+		return username == "ortuman", nil
+	}
+	checkerMock := &userCheckerMock{}
+	checkerMock.UserExistsFunc = func(ctx context.Context, username string) (bool, error) {
+		switch username {
+		case "noelia":
+			return true, nil
+		case "broken":
+			return false, errors.New("external: unavailable")
+		}
+		return false, nil
+	}
+	rep := &Repository{Repository: repMock, checkers: []userChecker{checkerMock}}
+
+	// when
+	localExists, localErr := rep.UserExists(context.Background(), "ortuman")
+	extExists, extErr := rep.UserExists(context.Background(), "noelia")
+	notExists, notExistsErr := rep.UserExists(context.Background(), "mariana")
+	_, brokenErr := rep.UserExists(context.Background(), "broken")
+
+	// then
+	require.NoError(t, localErr)
+	require.True(t, localExists)
+
+	require.NoError(t, extErr)
+	require.True(t, extExists)
+
+	require.NoError(t, notExistsErr)
+	require.False(t, notExists)
+
+	require.Error(t, brokenErr)
+
+	require.Len(t, checkerMock.UserExistsCalls(), 3)
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldaprepository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ortuman/jackal/pkg/ldap"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"github.com/ortuman/jackal/pkg/storage/repository"
+)
+
+// ErrReadOnlyDirectory will be returned when trying to create or update a directory user.
+var ErrReadOnlyDirectory = errors.New("ldaprepository: user directory is read-only")
+
+// Repository is a repository.Repository implementation serving user entities from an LDAP directory.
+// Any other entity, as well as user deletions, is delegated to the wrapped repository.
+//
+// Since repository lookups aren't bound to a host, directory searches use global LDAP configuration.
+type Repository struct {
+	repository.Repository
+	dir userDirectory
+}
+
+// New returns a new initialized LDAP backed repository.
+func New(dir *ldap.Client, rep repository.Repository) *Repository {
+	return &Repository{
+		Repository: rep,
+		dir:        dir,
+	}
+}
+
+// UpsertUser returns ErrReadOnlyDirectory, as directory users can't be modified.
+func (r *Repository) UpsertUser(_ context.Context, _ *usermodel.User) error {
+	return ErrReadOnlyDirectory
+}
+
+// FetchUser retrieves a user entity from the directory.
+func (r *Repository) FetchUser(ctx context.Context, username string) (*usermodel.User, error) {
+	entry, err := r.dir.FindUser(ctx, "", username)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	return &usermodel.User{Username: entry.Username}, nil
+}
+
+// UserExists tells whether or not a user exists within the directory.
+func (r *Repository) UserExists(ctx context.Context, username string) (bool, error) {
+	entry, err := r.dir.FindUser(ctx, "", username)
+	if err != nil {
+		return false, err
+	}
+	return entry != nil, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldaprepository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ortuman/jackal/pkg/ldap"
+	usermodel "github.com/ortuman/jackal/pkg/model/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_FetchUser(t *testing.T) {
+	// given
+	dirMock := &userDirectoryMock{}
+	dirMock.FindUserFunc = func(ctx context.Context, domain, username string) (*ldap.Entry, error) {
+		if username == "ortuman" {
+			return &ldap.Entry{DN: "uid=ortuman,dc=jackal,dc=im", Username: "ortuman"}, nil
+		}
+		return nil, nil
+	}
+	rep := &Repository{Repository: &repositoryMock{}, dir: dirMock}
+
+	// when
+	usr, err := rep.FetchUser(context.Background(), "ortuman")
+	notFoundUsr, notFoundErr := rep.FetchUser(context.Background(), "noelia")
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, "ortuman", usr.Username)
+
+	require.NoError(t, notFoundErr)
+	require.Nil(t, notFoundUsr)
+}
+
+func TestRepository_UserExists(t *testing.T) {
+	// given
+	dirMock := &userDirectoryMock{}
+	dirMock.FindUserFunc = func(ctx context.Context, domain, username string) (*ldap.Entry, error) {
+		if username == "ortuman" {
+			return &ldap.Entry{DN: "uid=ortuman,dc=jackal,dc=im", Username: "ortuman"}, nil
+		}
+		return nil, nil
+	}
+	rep := &Repository{Repository: &repositoryMock{}, dir: dirMock}
+
+	// when
+	exists, err := rep.UserExists(context.Background(), "ortuman")
+	notExists, notExistsErr := rep.UserExists(context.Background(), "noelia")
+
+	// then
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	require.NoError(t, notExistsErr)
+	require.False(t, notExists)
+}
+
+func TestRepository_UpsertAndDeleteUser(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteUserFunc = func(ctx context.Context, username string) error { return nil }
+
+	rep := &Repository{Repository: repMock, dir: &userDirectoryMock{}}
+
+	// when
+	upsertErr := rep.UpsertUser(context.Background(), &usermodel.User{Username: "ortuman"})
+	deleteErr := rep.DeleteUser(context.Background(), "ortuman")
+
+	// then
+	require.Equal(t, ErrReadOnlyDirectory, upsertErr)
+	require.NoError(t, deleteErr)
+	require.Len(t, repMock.DeleteUserCalls(), 1)
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/jackal-xmpp/stravaganza"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	"github.com/ortuman/jackal/pkg/storage/repository"
 )
@@ -34,6 +35,20 @@ func (m *measuredArchiveRep) InsertArchiveMessage(ctx context.Context, message *
 	return err
 }
 
+func (m *measuredArchiveRep) UpdateArchiveMessage(ctx context.Context, archiveID, fromJID, id string, message *stravaganza.PBElement) error {
+	t0 := time.Now()
+	err := m.rep.UpdateArchiveMessage(ctx, archiveID, fromJID, id, message)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredArchiveRep) TombstoneArchiveMessage(ctx context.Context, archiveID, fromJID, id string, tombstone *stravaganza.PBElement) error {
+	t0 := time.Now()
+	err := m.rep.TombstoneArchiveMessage(ctx, archiveID, fromJID, id, tombstone)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
 func (m *measuredArchiveRep) FetchArchiveMetadata(ctx context.Context, archiveID string) (metadata *archivemodel.Metadata, err error) {
 	t0 := time.Now()
 	metadata, err = m.rep.FetchArchiveMetadata(ctx, archiveID)
@@ -61,3 +76,24 @@ func (m *measuredArchiveRep) DeleteArchive(ctx context.Context, archiveID string
 	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
 	return err
 }
+
+func (m *measuredArchiveRep) UpsertArchivePreferences(ctx context.Context, prefs *archivemodel.Preferences) error {
+	t0 := time.Now()
+	err := m.rep.UpsertArchivePreferences(ctx, prefs)
+	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
+
+func (m *measuredArchiveRep) FetchArchivePreferences(ctx context.Context, archiveID string) (prefs *archivemodel.Preferences, err error) {
+	t0 := time.Now()
+	prefs, err = m.rep.FetchArchivePreferences(ctx, archiveID)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
+
+func (m *measuredArchiveRep) DeleteArchivePreferences(ctx context.Context, archiveID string) error {
+	t0 := time.Now()
+	err := m.rep.DeleteArchivePreferences(ctx, archiveID)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
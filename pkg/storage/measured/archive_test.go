@@ -18,6 +18,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/jackal-xmpp/stravaganza"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	"github.com/stretchr/testify/require"
 )
@@ -37,6 +38,36 @@ func TestMeasuredArchiveRep_InsertArchiveMessage(t *testing.T) {
 	require.Len(t, repMock.InsertArchiveMessageCalls(), 1)
 }
 
+func TestMeasuredArchiveRep_UpdateArchiveMessage(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpdateArchiveMessageFunc = func(ctx context.Context, archiveID, fromJID, id string, message *stravaganza.PBElement) error {
+		return nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_ = m.UpdateArchiveMessage(context.Background(), "a1234", "ortuman@jackal.im", "id1234", &stravaganza.PBElement{})
+
+	// then
+	require.Len(t, repMock.UpdateArchiveMessageCalls(), 1)
+}
+
+func TestMeasuredArchiveRep_TombstoneArchiveMessage(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.TombstoneArchiveMessageFunc = func(ctx context.Context, archiveID, fromJID, id string, tombstone *stravaganza.PBElement) error {
+		return nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_ = m.TombstoneArchiveMessage(context.Background(), "a1234", "ortuman@jackal.im", "id1234", &stravaganza.PBElement{})
+
+	// then
+	require.Len(t, repMock.TombstoneArchiveMessageCalls(), 1)
+}
+
 func TestMeasuredArchiveRep_FetchArchiveMetadata(t *testing.T) {
 	// given
 	repMock := &repositoryMock{}
@@ -97,3 +128,51 @@ func TestMeasuredArchiveRep_DeleteArchive(t *testing.T) {
 	// then
 	require.Len(t, repMock.DeleteArchiveCalls(), 1)
 }
+
+func TestMeasuredArchiveRep_UpsertArchivePreferences(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.UpsertArchivePreferencesFunc = func(ctx context.Context, prefs *archivemodel.Preferences) error {
+		return nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	err := m.UpsertArchivePreferences(context.Background(), &archivemodel.Preferences{ArchiveId: "a1234"})
+
+	// then
+	require.Len(t, repMock.UpsertArchivePreferencesCalls(), 1)
+	require.NoError(t, err)
+}
+
+func TestMeasuredArchiveRep_FetchArchivePreferences(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchArchivePreferencesFunc = func(ctx context.Context, archiveID string) (*archivemodel.Preferences, error) {
+		return &archivemodel.Preferences{ArchiveId: archiveID}, nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	prefs, err := m.FetchArchivePreferences(context.Background(), "a1234")
+
+	// then
+	require.Len(t, repMock.FetchArchivePreferencesCalls(), 1)
+	require.NoError(t, err)
+	require.Equal(t, "a1234", prefs.ArchiveId)
+}
+
+func TestMeasuredArchiveRep_DeleteArchivePreferences(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteArchivePreferencesFunc = func(ctx context.Context, archiveID string) error {
+		return nil
+	}
+	m := &measuredArchiveRep{rep: repMock}
+
+	// when
+	_ = m.DeleteArchivePreferences(context.Background(), "a1234")
+
+	// then
+	require.Len(t, repMock.DeleteArchivePreferencesCalls(), 1)
+}
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/jackal-xmpp/stravaganza"
+	offlinemodel "github.com/ortuman/jackal/pkg/model/offline"
 	"github.com/ortuman/jackal/pkg/storage/repository"
 )
 
@@ -27,9 +28,9 @@ type measuredOfflineRep struct {
 	inTx bool
 }
 
-func (m *measuredOfflineRep) InsertOfflineMessage(ctx context.Context, message *stravaganza.Message, username string) error {
+func (m *measuredOfflineRep) InsertOfflineMessage(ctx context.Context, message *stravaganza.Message, username string, expiresAt time.Time) error {
 	t0 := time.Now()
-	err := m.rep.InsertOfflineMessage(ctx, message, username)
+	err := m.rep.InsertOfflineMessage(ctx, message, username, expiresAt)
 	reportOpMetric(upsertOp, time.Since(t0).Seconds(), err == nil, m.inTx)
 	return err
 }
@@ -54,3 +55,31 @@ func (m *measuredOfflineRep) DeleteOfflineMessages(ctx context.Context, username
 	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
 	return err
 }
+
+func (m *measuredOfflineRep) FetchExpiredOfflineUsernames(ctx context.Context, now time.Time) ([]string, error) {
+	t0 := time.Now()
+	usernames, err := m.rep.FetchExpiredOfflineUsernames(ctx, now)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return usernames, err
+}
+
+func (m *measuredOfflineRep) DeleteExpiredOfflineMessages(ctx context.Context, username string, now time.Time) ([]*offlinemodel.Message, error) {
+	t0 := time.Now()
+	ms, err := m.rep.DeleteExpiredOfflineMessages(ctx, username, now)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return ms, err
+}
+
+func (m *measuredOfflineRep) FetchOfflineMessagesByID(ctx context.Context, username string, ids []string) ([]*offlinemodel.Message, error) {
+	t0 := time.Now()
+	ms, err := m.rep.FetchOfflineMessagesByID(ctx, username, ids)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return ms, err
+}
+
+func (m *measuredOfflineRep) DeleteOfflineMessagesByID(ctx context.Context, username string, ids []string) error {
+	t0 := time.Now()
+	err := m.rep.DeleteOfflineMessagesByID(ctx, username, ids)
+	reportOpMetric(deleteOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return err
+}
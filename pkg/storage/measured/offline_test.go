@@ -17,21 +17,23 @@ package measuredrepository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/jackal-xmpp/stravaganza"
+	offlinemodel "github.com/ortuman/jackal/pkg/model/offline"
 	"github.com/stretchr/testify/require"
 )
 
 func TestMeasuredOfflineRep_InsertOfflineMessage(t *testing.T) {
 	// given
 	repMock := &repositoryMock{}
-	repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string) error {
+	repMock.InsertOfflineMessageFunc = func(ctx context.Context, message *stravaganza.Message, username string, expiresAt time.Time) error {
 		return nil
 	}
 	m := &measuredOfflineRep{rep: repMock}
 
 	// when
-	_ = m.InsertOfflineMessage(context.Background(), nil, "ortuman")
+	_ = m.InsertOfflineMessage(context.Background(), nil, "ortuman", time.Time{})
 
 	// then
 	require.Len(t, repMock.InsertOfflineMessageCalls(), 1)
@@ -82,3 +84,65 @@ func TestMeasuredOfflineRep_DeleteOfflineMessage(t *testing.T) {
 	// then
 	require.Len(t, repMock.DeleteOfflineMessagesCalls(), 1)
 }
+
+func TestMeasuredOfflineRep_FetchOfflineMessagesByID(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchOfflineMessagesByIDFunc = func(ctx context.Context, username string, ids []string) ([]*offlinemodel.Message, error) {
+		return nil, nil
+	}
+	m := &measuredOfflineRep{rep: repMock}
+
+	// when
+	_, _ = m.FetchOfflineMessagesByID(context.Background(), "ortuman", []string{"1"})
+
+	// then
+	require.Len(t, repMock.FetchOfflineMessagesByIDCalls(), 1)
+}
+
+func TestMeasuredOfflineRep_DeleteOfflineMessagesByID(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteOfflineMessagesByIDFunc = func(ctx context.Context, username string, ids []string) error {
+		return nil
+	}
+	m := &measuredOfflineRep{rep: repMock}
+
+	// when
+	_ = m.DeleteOfflineMessagesByID(context.Background(), "ortuman", []string{"1"})
+
+	// then
+	require.Len(t, repMock.DeleteOfflineMessagesByIDCalls(), 1)
+}
+
+func TestMeasuredOfflineRep_FetchExpiredOfflineUsernames(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.FetchExpiredOfflineUsernamesFunc = func(ctx context.Context, now time.Time) ([]string, error) {
+		return []string{"ortuman"}, nil
+	}
+	m := &measuredOfflineRep{rep: repMock}
+
+	// when
+	usernames, _ := m.FetchExpiredOfflineUsernames(context.Background(), time.Now())
+
+	// then
+	require.Len(t, repMock.FetchExpiredOfflineUsernamesCalls(), 1)
+	require.Equal(t, []string{"ortuman"}, usernames)
+}
+
+func TestMeasuredOfflineRep_DeleteExpiredOfflineMessages(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.DeleteExpiredOfflineMessagesFunc = func(ctx context.Context, username string, now time.Time) ([]*offlinemodel.Message, error) {
+		return []*offlinemodel.Message{{Id: "1", Username: "ortuman"}}, nil
+	}
+	m := &measuredOfflineRep{rep: repMock}
+
+	// when
+	ms, _ := m.DeleteExpiredOfflineMessages(context.Background(), "ortuman", time.Now())
+
+	// then
+	require.Len(t, repMock.DeleteExpiredOfflineMessagesCalls(), 1)
+	require.Len(t, ms, 1)
+}
@@ -55,3 +55,10 @@ func (m *measuredUserRep) UserExists(ctx context.Context, username string) (ok b
 	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
 	return
 }
+
+func (m *measuredUserRep) CountUsersByPepper(ctx context.Context) (counts map[string]int, err error) {
+	t0 := time.Now()
+	counts, err = m.rep.CountUsersByPepper(ctx)
+	reportOpMetric(fetchOp, time.Since(t0).Seconds(), err == nil, m.inTx)
+	return
+}
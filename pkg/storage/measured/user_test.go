@@ -83,3 +83,18 @@ func TestMeasuredUserRep_UserExists(t *testing.T) {
 	// then
 	require.Len(t, repMock.UserExistsCalls(), 1)
 }
+
+func TestMeasuredUserRep_CountUsersByPepper(t *testing.T) {
+	// given
+	repMock := &repositoryMock{}
+	repMock.CountUsersByPepperFunc = func(ctx context.Context) (map[string]int, error) {
+		return map[string]int{"v1": 1}, nil
+	}
+	m := New(repMock)
+
+	// when
+	_, _ = m.CountUsersByPepper(context.Background())
+
+	// then
+	require.Len(t, repMock.CountUsersByPepperCalls(), 1)
+}
@@ -26,12 +26,14 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/jackal-xmpp/stravaganza"
 	"github.com/jackal-xmpp/stravaganza/jid"
+	"github.com/lib/pq"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
-	archiveTableName = "archives"
+	archiveTableName      = "archives"
+	archivePrefsTableName = "archive_preferences"
 
 	archiveStampFormat = "2006-01-02T15:04:05Z"
 )
@@ -51,15 +53,17 @@ func (r *pgSQLArchiveRep) InsertArchiveMessage(ctx context.Context, message *arc
 
 	q := sq.Insert(archiveTableName).
 		Prefix(noLoadBalancePrefix).
-		Columns("archive_id", "id", `"from"`, "from_bare", `"to"`, "to_bare", "message").
+		Columns("archive_id", "id", "origin_id", `"from"`, "from_bare", `"to"`, "to_bare", "message", "body").
 		Values(
 			message.ArchiveId,
 			message.Id,
+			message.OriginId,
 			fromJID.String(),
 			fromJID.ToBareJID().String(),
 			toJID.String(),
 			toJID.ToBareJID().String(),
 			b,
+			message.BodyText(),
 		)
 
 	_, err = q.RunWith(r.conn).ExecContext(ctx)
@@ -138,6 +142,87 @@ func (r *pgSQLArchiveRep) DeleteArchive(ctx context.Context, archiveID string) e
 	return err
 }
 
+func (r *pgSQLArchiveRep) UpdateArchiveMessage(ctx context.Context, archiveID, fromJID, id string, message *stravaganza.PBElement) error {
+	b, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	archiveMsg := &archivemodel.Message{Message: message}
+
+	_, err = sq.Update(archiveTableName).
+		Prefix(noLoadBalancePrefix).
+		Set("message", b).
+		Set("body", archiveMsg.BodyText()).
+		Where(sq.And{
+			archiveMessageRefPred(archiveID, fromJID, id),
+			sq.Eq{"retracted": false},
+		}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLArchiveRep) TombstoneArchiveMessage(ctx context.Context, archiveID, fromJID, id string, tombstone *stravaganza.PBElement) error {
+	b, err := proto.Marshal(tombstone)
+	if err != nil {
+		return err
+	}
+	_, err = sq.Update(archiveTableName).
+		Prefix(noLoadBalancePrefix).
+		Set("message", b).
+		Set("body", "").
+		Set("retracted", true).
+		Where(archiveMessageRefPred(archiveID, fromJID, id)).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLArchiveRep) UpsertArchivePreferences(ctx context.Context, prefs *archivemodel.Preferences) error {
+	_, err := sq.Insert(archivePrefsTableName).
+		Prefix(noLoadBalancePrefix).
+		Columns("archive_id", "default_mode", "always", "never").
+		Values(prefs.ArchiveId, prefs.DefaultMode, pq.Array(prefs.Always), pq.Array(prefs.Never)).
+		Suffix("ON CONFLICT (archive_id) DO UPDATE SET default_mode = $2, always = $3, never = $4").
+		RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
+func (r *pgSQLArchiveRep) FetchArchivePreferences(ctx context.Context, archiveID string) (*archivemodel.Preferences, error) {
+	row := sq.Select("archive_id", "default_mode", "always", "never").
+		From(archivePrefsTableName).
+		Where(sq.Eq{"archive_id": archiveID}).
+		RunWith(r.conn).QueryRowContext(ctx)
+
+	var prefs archivemodel.Preferences
+	err := row.Scan(&prefs.ArchiveId, &prefs.DefaultMode, pq.Array(&prefs.Always), pq.Array(&prefs.Never))
+	switch err {
+	case nil:
+		return &prefs, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (r *pgSQLArchiveRep) DeleteArchivePreferences(ctx context.Context, archiveID string) error {
+	_, err := sq.Delete(archivePrefsTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.Eq{"archive_id": archiveID}).
+		RunWith(r.conn).
+		ExecContext(ctx)
+	return err
+}
+
+func archiveMessageRefPred(archiveID, fromJID, id string) sq.Sqlizer {
+	return sq.And{
+		sq.Eq{"archive_id": archiveID},
+		sq.Eq{"from_bare": fromJID},
+		sq.Or{sq.Eq{"id": id}, sq.Eq{"origin_id": id}},
+	}
+}
+
 func filtersToPred(f *archivemodel.Filters, archiveID string) (interface{}, error) {
 	pred := sq.And{
 		sq.Eq{"archive_id": archiveID},
@@ -169,6 +254,11 @@ func filtersToPred(f *archivemodel.Filters, archiveID string) (interface{}, erro
 		}
 	}
 
+	// filtering by body text
+	if len(f.FullText) > 0 {
+		pred = append(pred, sq.Expr(`to_tsvector('simple', body) @@ plainto_tsquery('simple', ?)`, f.FullText))
+	}
+
 	// filtering by timestamp
 	if f.Start != nil {
 		// due to higher precision of database timestamp we need to add an extra offset to discard first message.
@@ -23,6 +23,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/protobuf/proto"
 	"github.com/jackal-xmpp/stravaganza"
+	"github.com/lib/pq"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -43,6 +44,7 @@ func TestPgSQLArchive_InsertArchiveMessage(t *testing.T) {
 	aMsg := &archivemodel.Message{
 		ArchiveId: "ortuman",
 		Id:        "id1234",
+		OriginId:  "origin1234",
 		FromJid:   "ortuman@jackal.im/local",
 		ToJid:     "ortuman@jabber.org/remote",
 		Message:   msg.Proto(),
@@ -50,8 +52,8 @@ func TestPgSQLArchive_InsertArchiveMessage(t *testing.T) {
 	msgBytes, _ := proto.Marshal(aMsg.Message)
 
 	s, mock := newArchiveMock()
-	mock.ExpectExec(`INSERT INTO archives \(archive_id,id,"from",from_bare,"to",to_bare,message\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7\)`).
-		WithArgs("ortuman", "id1234", "ortuman@jackal.im/local", "ortuman@jackal.im", "ortuman@jabber.org/remote", "ortuman@jabber.org", msgBytes).
+	mock.ExpectExec(`INSERT INTO archives \(archive_id,id,origin_id,"from",from_bare,"to",to_bare,message,body\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9\)`).
+		WithArgs("ortuman", "id1234", "origin1234", "ortuman@jackal.im/local", "ortuman@jackal.im", "ortuman@jabber.org/remote", "ortuman@jabber.org", msgBytes, "I'll give thee a wind.").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// when
@@ -128,6 +130,11 @@ func TestPgSQLArchive_FetchArchiveMessages(t *testing.T) {
 			withArgs:    []driver.Value{"ortuman", "id1234", "ortuman", "id5678", "ortuman"},
 			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND \(serial < \(SELECT serial FROM archives WHERE "id" = \$2 AND archive_id = \$3\)\) AND \(serial > \(SELECT serial FROM archives WHERE "id" = \$4 AND archive_id = \$5\)\)\) ORDER BY created_at`,
 		},
+		"by full text": {
+			filters:     &archivemodel.Filters{FullText: "give wind"},
+			withArgs:    []driver.Value{"ortuman", "give wind"},
+			expectQuery: `SELECT id, "from", "to", message, created_at FROM archives WHERE \(archive_id = \$1 AND to_tsvector\('simple', body\) @@ plainto_tsquery\('simple', \$2\)\) ORDER BY created_at`,
+		},
 		"by start timestamp": {
 			filters:     &archivemodel.Filters{Start: timestamppb.New(starTm)},
 			withArgs:    []driver.Value{"ortuman", toEpoch(timestamppb.New(starTm)) + float64(time.Millisecond)},
@@ -211,6 +218,117 @@ func TestPgSQLArchive_DeleteArchive(t *testing.T) {
 	require.Nil(t, mock.ExpectationsWereMet())
 }
 
+func TestPgSQLArchive_UpdateArchiveMessage(t *testing.T) {
+	// given
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "noelia@jackal.im/yard")
+	b.WithAttribute("to", "ortuman@jackal.im/balcony")
+	b.WithChild(
+		stravaganza.NewBuilder("body").
+			WithText("I'll give thee a wind!").
+			Build(),
+	)
+	msg, _ := b.BuildMessage()
+	msgBytes, _ := proto.Marshal(msg.Proto())
+
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`UPDATE archives SET message = \$1, body = \$2 WHERE \(\(archive_id = \$3 AND from_bare = \$4 AND \(id = \$5 OR origin_id = \$6\)\) AND retracted = \$7\)`).
+		WithArgs(msgBytes, "I'll give thee a wind!", "ortuman@jackal.im", "noelia@jackal.im", "origin1234", "origin1234", false).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// when
+	err := s.UpdateArchiveMessage(context.Background(), "ortuman@jackal.im", "noelia@jackal.im", "origin1234", msg.Proto())
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLArchive_TombstoneArchiveMessage(t *testing.T) {
+	// given
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "noelia@jackal.im/yard")
+	b.WithAttribute("to", "ortuman@jackal.im/balcony")
+	b.WithChild(
+		stravaganza.NewBuilder("retracted").
+			WithAttribute(stravaganza.Namespace, "urn:xmpp:message-retract:1").
+			Build(),
+	)
+	msg, _ := b.BuildMessage()
+	msgBytes, _ := proto.Marshal(msg.Proto())
+
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`UPDATE archives SET message = \$1, body = \$2, retracted = \$3 WHERE \(archive_id = \$4 AND from_bare = \$5 AND \(id = \$6 OR origin_id = \$7\)\)`).
+		WithArgs(msgBytes, "", true, "ortuman@jackal.im", "noelia@jackal.im", "origin1234", "origin1234").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// when
+	err := s.TombstoneArchiveMessage(context.Background(), "ortuman@jackal.im", "noelia@jackal.im", "origin1234", msg.Proto())
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLArchive_UpsertArchivePreferences(t *testing.T) {
+	// given
+	prefs := &archivemodel.Preferences{
+		ArchiveId:   "ortuman@jackal.im",
+		DefaultMode: archivemodel.RosterMode,
+		Always:      []string{"noelia@jackal.im"},
+		Never:       []string{"romeo@montague.lit"},
+	}
+
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`INSERT INTO archive_preferences \(archive_id,default_mode,always,never\) VALUES \(\$1,\$2,\$3,\$4\) ON CONFLICT \(archive_id\) DO UPDATE SET default_mode = \$2, always = \$3, never = \$4`).
+		WithArgs("ortuman@jackal.im", "roster", pq.Array(prefs.Always), pq.Array(prefs.Never)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// when
+	err := s.UpsertArchivePreferences(context.Background(), prefs)
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLArchive_FetchArchivePreferences(t *testing.T) {
+	// given
+	s, mock := newArchiveMock()
+	mock.ExpectQuery(`SELECT archive_id, default_mode, always, never FROM archive_preferences WHERE archive_id = \$1`).
+		WithArgs("ortuman@jackal.im").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"archive_id", "default_mode", "always", "never"}).
+				AddRow("ortuman@jackal.im", "never", pq.Array([]string{"noelia@jackal.im"}), pq.Array([]string{})),
+		)
+
+	// when
+	prefs, err := s.FetchArchivePreferences(context.Background(), "ortuman@jackal.im")
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+
+	require.NotNil(t, prefs)
+	require.Equal(t, archivemodel.NeverMode, prefs.DefaultMode)
+	require.Equal(t, []string{"noelia@jackal.im"}, prefs.Always)
+}
+
+func TestPgSQLArchive_DeleteArchivePreferences(t *testing.T) {
+	// given
+	s, mock := newArchiveMock()
+	mock.ExpectExec(`DELETE FROM archive_preferences WHERE archive_id = \$1`).
+		WithArgs("ortuman@jackal.im").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// when
+	err := s.DeleteArchivePreferences(context.Background(), "ortuman@jackal.im")
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
 func newArchiveMock() (*pgSQLArchiveRep, sqlmock.Sqlmock) {
 	s, sqlMock := newPgSQLMock()
 	return &pgSQLArchiveRep{conn: s}, sqlMock
@@ -16,10 +16,13 @@ package pgsqlrepository
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	kitlog "github.com/go-kit/log"
 	"github.com/jackal-xmpp/stravaganza"
+	offlinemodel "github.com/ortuman/jackal/pkg/model/offline"
 )
 
 const offlineMessagesTableName = "offline_messages"
@@ -29,15 +32,19 @@ type pgSQLOfflineRep struct {
 	logger kitlog.Logger
 }
 
-func (r *pgSQLOfflineRep) InsertOfflineMessage(ctx context.Context, message *stravaganza.Message, username string) error {
+func (r *pgSQLOfflineRep) InsertOfflineMessage(ctx context.Context, message *stravaganza.Message, username string, expiresAt time.Time) error {
 	b, err := message.MarshalBinary()
 	if err != nil {
 		return err
 	}
+	var expiresAtVal interface{}
+	if !expiresAt.IsZero() {
+		expiresAtVal = expiresAt
+	}
 	q := sq.Insert(offlineMessagesTableName).
 		Prefix(noLoadBalancePrefix).
-		Columns("username", "message").
-		Values(username, b)
+		Columns("username", "message", "expires_at").
+		Values(username, b, expiresAtVal)
 
 	_, err = q.RunWith(r.conn).ExecContext(ctx)
 	return err
@@ -87,6 +94,45 @@ func (r *pgSQLOfflineRep) FetchOfflineMessages(ctx context.Context, username str
 	return ms, nil
 }
 
+func (r *pgSQLOfflineRep) FetchOfflineMessagesByID(ctx context.Context, username string, ids []string) ([]*offlinemodel.Message, error) {
+	pred := sq.And{sq.Eq{"username": username}}
+	if len(ids) > 0 {
+		pred = append(pred, sq.Eq{"id": offlineSerials(ids)})
+	}
+	q := sq.Select("id", "message").
+		From(offlineMessagesTableName).
+		Where(pred).
+		OrderBy("id")
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var ms []*offlinemodel.Message
+	for rows.Next() {
+		var id int64
+		var b []byte
+		if err := rows.Scan(&id, &b); err != nil {
+			return nil, err
+		}
+		sb, err := stravaganza.NewBuilderFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := sb.BuildMessage()
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, &offlinemodel.Message{
+			Id:      strconv.FormatInt(id, 10),
+			Message: msg.Proto(),
+		})
+	}
+	return ms, rows.Err()
+}
+
 func (r *pgSQLOfflineRep) DeleteOfflineMessages(ctx context.Context, username string) error {
 	q := sq.Delete(offlineMessagesTableName).
 		Prefix(noLoadBalancePrefix).
@@ -94,3 +140,95 @@ func (r *pgSQLOfflineRep) DeleteOfflineMessages(ctx context.Context, username st
 	_, err := q.RunWith(r.conn).ExecContext(ctx)
 	return err
 }
+
+func (r *pgSQLOfflineRep) FetchExpiredOfflineUsernames(ctx context.Context, now time.Time) ([]string, error) {
+	q := sq.Select("DISTINCT username").
+		From(offlineMessagesTableName).
+		Where(sq.And{
+			sq.NotEq{"expires_at": nil},
+			sq.LtOrEq{"expires_at": now},
+		})
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var usernames []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		usernames = append(usernames, username)
+	}
+	return usernames, rows.Err()
+}
+
+func (r *pgSQLOfflineRep) DeleteExpiredOfflineMessages(ctx context.Context, username string, now time.Time) ([]*offlinemodel.Message, error) {
+	query, args, err := sq.Delete(offlineMessagesTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.And{
+			sq.Eq{"username": username},
+			sq.NotEq{"expires_at": nil},
+			sq.LtOrEq{"expires_at": now},
+		}).
+		Suffix("RETURNING id, username, message").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(rows, r.logger)
+
+	var ms []*offlinemodel.Message
+	for rows.Next() {
+		var id int64
+		var username string
+		var b []byte
+		if err := rows.Scan(&id, &username, &b); err != nil {
+			return nil, err
+		}
+		sb, err := stravaganza.NewBuilderFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := sb.BuildMessage()
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, &offlinemodel.Message{
+			Id:       strconv.FormatInt(id, 10),
+			Message:  msg.Proto(),
+			Username: username,
+		})
+	}
+	return ms, rows.Err()
+}
+
+func (r *pgSQLOfflineRep) DeleteOfflineMessagesByID(ctx context.Context, username string, ids []string) error {
+	q := sq.Delete(offlineMessagesTableName).
+		Prefix(noLoadBalancePrefix).
+		Where(sq.And{
+			sq.Eq{"username": username},
+			sq.Eq{"id": offlineSerials(ids)},
+		})
+	_, err := q.RunWith(r.conn).ExecContext(ctx)
+	return err
+}
+
+func offlineSerials(ids []string) []int64 {
+	serials := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		serial, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue // not a valid identifier
+		}
+		serials = append(serials, serial)
+	}
+	return serials
+}
@@ -17,6 +17,7 @@ package pgsqlrepository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jackal-xmpp/stravaganza"
@@ -38,12 +39,35 @@ func TestPgSQLOffline_InsertOfflineMessage(t *testing.T) {
 	msgBytes, _ := msg.MarshalBinary()
 
 	s, mock := newOfflineMock()
-	mock.ExpectExec(`INSERT INTO offline_messages \(username,message\) VALUES \(\$1,\$2\)`).
-		WithArgs("ortuman", msgBytes).
+	mock.ExpectExec(`INSERT INTO offline_messages \(username,message,expires_at\) VALUES \(\$1,\$2,\$3\)`).
+		WithArgs("ortuman", msgBytes, nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// when
-	err := s.InsertOfflineMessage(context.Background(), msg, "ortuman")
+	err := s.InsertOfflineMessage(context.Background(), msg, "ortuman", time.Time{})
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLOffline_InsertExpiringOfflineMessage(t *testing.T) {
+	// given
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "noelia@jackal.im/yard")
+	b.WithAttribute("to", "ortuman@jackal.im/balcony")
+	msg, _ := b.BuildMessage()
+
+	msgBytes, _ := msg.MarshalBinary()
+	expiresAt := time.Date(2023, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	s, mock := newOfflineMock()
+	mock.ExpectExec(`INSERT INTO offline_messages \(username,message,expires_at\) VALUES \(\$1,\$2,\$3\)`).
+		WithArgs("ortuman", msgBytes, expiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// when
+	err := s.InsertOfflineMessage(context.Background(), msg, "ortuman", expiresAt)
 
 	// then
 	require.Nil(t, err)
@@ -115,6 +139,103 @@ func TestPgSQLOffline_DeleteOfflineMessage(t *testing.T) {
 	require.Nil(t, mock.ExpectationsWereMet())
 }
 
+func TestPgSQLOffline_FetchOfflineMessagesByID(t *testing.T) {
+	// given
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "noelia@jackal.im/yard")
+	b.WithAttribute("to", "ortuman@jackal.im/balcony")
+	b.WithChild(
+		stravaganza.NewBuilder("body").
+			WithText("I'll give thee a wind.").
+			Build(),
+	)
+	msg, _ := b.BuildMessage()
+
+	msgBytes, _ := msg.MarshalBinary()
+
+	s, mock := newOfflineMock()
+	mock.ExpectQuery(`SELECT id, message FROM offline_messages WHERE \(username = \$1 AND id IN \(\$2,\$3\)\) ORDER BY id`).
+		WithArgs("ortuman", int64(7), int64(9)).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "message"}).AddRow(7, msgBytes),
+		)
+
+	// when
+	ms, err := s.FetchOfflineMessagesByID(context.Background(), "ortuman", []string{"7", "9", "foo"})
+
+	// then
+	require.Nil(t, err)
+	require.Len(t, ms, 1)
+	require.Equal(t, "7", ms[0].Id)
+
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLOffline_DeleteOfflineMessagesByID(t *testing.T) {
+	// given
+	s, mock := newOfflineMock()
+	mock.ExpectExec(`DELETE FROM offline_messages WHERE \(username = \$1 AND id IN \(\$2\)\)`).
+		WithArgs("ortuman", int64(7)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// when
+	err := s.DeleteOfflineMessagesByID(context.Background(), "ortuman", []string{"7"})
+
+	// then
+	require.Nil(t, err)
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLOffline_DeleteExpiredOfflineMessages(t *testing.T) {
+	// given
+	b := stravaganza.NewMessageBuilder()
+	b.WithAttribute("from", "noelia@jackal.im/yard")
+	b.WithAttribute("to", "ortuman@jackal.im/balcony")
+	msg, _ := b.BuildMessage()
+
+	msgBytes, _ := msg.MarshalBinary()
+	now := time.Date(2023, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	s, mock := newOfflineMock()
+	mock.ExpectQuery(`DELETE FROM offline_messages WHERE \(username = \$1 AND expires_at IS NOT NULL AND expires_at <= \$2\) RETURNING id, username, message`).
+		WithArgs("ortuman", now).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "username", "message"}).AddRow(3, "ortuman", msgBytes),
+		)
+
+	// when
+	ms, err := s.DeleteExpiredOfflineMessages(context.Background(), "ortuman", now)
+
+	// then
+	require.Nil(t, err)
+	require.Len(t, ms, 1)
+	require.Equal(t, "3", ms[0].Id)
+	require.Equal(t, "ortuman", ms[0].Username)
+
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPgSQLOffline_FetchExpiredOfflineUsernames(t *testing.T) {
+	// given
+	now := time.Date(2023, 01, 01, 00, 00, 00, 00, time.UTC)
+
+	s, mock := newOfflineMock()
+	mock.ExpectQuery(`SELECT DISTINCT username FROM offline_messages WHERE \(expires_at IS NOT NULL AND expires_at <= \$1\)`).
+		WithArgs(now).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"username"}).AddRow("ortuman").AddRow("noelia"),
+		)
+
+	// when
+	usernames, err := s.FetchExpiredOfflineUsernames(context.Background(), now)
+
+	// then
+	require.Nil(t, err)
+	require.Equal(t, []string{"ortuman", "noelia"}, usernames)
+
+	require.Nil(t, mock.ExpectationsWereMet())
+}
+
 func newOfflineMock() (*pgSQLOfflineRep, sqlmock.Sqlmock) {
 	s, sqlMock := newPgSQLMock()
 	return &pgSQLOfflineRep{conn: s}, sqlMock
@@ -128,3 +128,26 @@ func (r *pgSQLUserRep) UserExists(ctx context.Context, username string) (bool, e
 		return false, err
 	}
 }
+
+func (r *pgSQLUserRep) CountUsersByPepper(ctx context.Context) (map[string]int, error) {
+	q := sq.Select("pepper_id", "COUNT(*)").
+		From(usersTableName).
+		GroupBy("pepper_id")
+
+	rows, err := q.RunWith(r.conn).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	res := make(map[string]int)
+	for rows.Next() {
+		var pepperID string
+		var count int
+		if err := rows.Scan(&pepperID, &count); err != nil {
+			return nil, err
+		}
+		res[pepperID] = count
+	}
+	return res, rows.Err()
+}
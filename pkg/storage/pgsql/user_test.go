@@ -106,6 +106,21 @@ func TestPgSQLUser_Exists(t *testing.T) {
 	require.True(t, ok)
 }
 
+func TestPgSQLUser_CountByPepper(t *testing.T) {
+	s, mock := newUserMock()
+	mock.ExpectQuery(`SELECT pepper_id, COUNT\(\*\) FROM users GROUP BY pepper_id`).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"pepper_id", "count"}).
+				AddRow("v1", 3).
+				AddRow("v2", 10),
+		)
+
+	counts, err := s.CountUsersByPepper(context.Background())
+	require.Nil(t, mock.ExpectationsWereMet())
+	require.Nil(t, err)
+	require.Equal(t, map[string]int{"v1": 3, "v2": 10}, counts)
+}
+
 func newUserMock() (*pgSQLUserRep, sqlmock.Sqlmock) {
 	s, sqlMock := newPgSQLMock()
 	return &pgSQLUserRep{conn: s}, sqlMock
@@ -17,6 +17,7 @@ package repository
 import (
 	"context"
 
+	"github.com/jackal-xmpp/stravaganza"
 	archivemodel "github.com/ortuman/jackal/pkg/model/archive"
 )
 
@@ -31,9 +32,26 @@ type Archive interface {
 	// FetchArchiveMessages fetches archive asscociated messages applying the passed f filters.
 	FetchArchiveMessages(ctx context.Context, f *archivemodel.Filters, archiveID string) ([]*archivemodel.Message, error)
 
+	// UpdateArchiveMessage replaces the stanza of a non retracted archived message sent by fromJID bare address.
+	// The message is referenced by id, which matches either its archive identifier or its origin-id.
+	UpdateArchiveMessage(ctx context.Context, archiveID, fromJID, id string, message *stravaganza.PBElement) error
+
+	// TombstoneArchiveMessage replaces an archived message sent by fromJID bare address with a retraction tombstone.
+	// The message is referenced by id, which matches either its archive identifier or its origin-id.
+	TombstoneArchiveMessage(ctx context.Context, archiveID, fromJID, id string, tombstone *stravaganza.PBElement) error
+
 	// DeleteArchiveOldestMessages trims archive oldest messages up to a maxElements total count.
 	DeleteArchiveOldestMessages(ctx context.Context, archiveID string, maxElements int) error
 
 	// DeleteArchive clears an archive queue.
 	DeleteArchive(ctx context.Context, archiveID string) error
+
+	// UpsertArchivePreferences inserts or updates archive preferences entity.
+	UpsertArchivePreferences(ctx context.Context, prefs *archivemodel.Preferences) error
+
+	// FetchArchivePreferences retrieves the preferences associated to an archive.
+	FetchArchivePreferences(ctx context.Context, archiveID string) (*archivemodel.Preferences, error)
+
+	// DeleteArchivePreferences removes the preferences associated to an archive.
+	DeleteArchivePreferences(ctx context.Context, archiveID string) error
 }
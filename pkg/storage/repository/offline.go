@@ -16,14 +16,17 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackal-xmpp/stravaganza"
+	offlinemodel "github.com/ortuman/jackal/pkg/model/offline"
 )
 
 // Offline defines user offline repository operations.
 type Offline interface {
 	// InsertOfflineMessage inserts a new message element into user's offline queue.
-	InsertOfflineMessage(ctx context.Context, message *stravaganza.Message, username string) error
+	// A zero expiresAt value means the message never expires.
+	InsertOfflineMessage(ctx context.Context, message *stravaganza.Message, username string, expiresAt time.Time) error
 
 	// CountOfflineMessages returns current length of user's offline queue.
 	CountOfflineMessages(ctx context.Context, username string) (int, error)
@@ -31,6 +34,19 @@ type Offline interface {
 	// FetchOfflineMessages retrieves from repository current user offline queue.
 	FetchOfflineMessages(ctx context.Context, username string) ([]*stravaganza.Message, error)
 
+	// FetchOfflineMessagesByID retrieves user offline queue messages along with their identifiers.
+	// Only messages whose identifier is contained in ids are returned, unless ids is empty.
+	FetchOfflineMessagesByID(ctx context.Context, username string, ids []string) ([]*offlinemodel.Message, error)
+
 	// DeleteOfflineMessages clears a user offline queue.
 	DeleteOfflineMessages(ctx context.Context, username string) error
+
+	// FetchExpiredOfflineUsernames returns the name of every user whose offline queue contains messages expired at now.
+	FetchExpiredOfflineUsernames(ctx context.Context, now time.Time) ([]string, error)
+
+	// DeleteExpiredOfflineMessages removes from user offline queue all messages expired at now, returning them.
+	DeleteExpiredOfflineMessages(ctx context.Context, username string, now time.Time) ([]*offlinemodel.Message, error)
+
+	// DeleteOfflineMessagesByID removes from user offline queue all messages whose identifier is contained in ids.
+	DeleteOfflineMessagesByID(ctx context.Context, username string, ids []string) error
 }
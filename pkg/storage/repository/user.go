@@ -33,4 +33,7 @@ type User interface {
 
 	// UserExists tells whether or not a user exists within repository.
 	UserExists(ctx context.Context, username string) (bool, error)
+
+	// CountUsersByPepper returns the number of users whose credentials were derived using each pepper key.
+	CountUsersByPepper(ctx context.Context) (map[string]int, error)
 }
@@ -62,3 +62,16 @@ func New(cfg Config, logger kitlog.Logger) (repository.Repository, error) {
 	}
 	return measuredrepository.New(rep), nil
 }
+
+// Validate checks whether storage configuration values are supported.
+func (c Config) Validate() error {
+	switch c.Type {
+	case pgSQLRepositoryType, boltDBRepositoryType:
+	default:
+		return fmt.Errorf("unrecognized repository type: %s", c.Type)
+	}
+	if len(c.Cache.Type) > 0 {
+		return c.Cache.Validate()
+	}
+	return nil
+}
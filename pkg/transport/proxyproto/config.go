@@ -0,0 +1,59 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyproto
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Config defines PROXY protocol listener configuration.
+type Config struct {
+	// Enabled tells whether PROXY protocol (v1/v2) headers should be accepted.
+	Enabled bool `fig:"enabled"`
+
+	// TrustedCIDRs defines the source networks allowed to send PROXY protocol headers.
+	// Connections originated from any other source are served using its direct address.
+	TrustedCIDRs []string `fig:"trusted_cidrs"`
+
+	// HeaderTimeout defines the maximum amount of time a trusted source may take to send the header.
+	HeaderTimeout time.Duration `fig:"header_timeout" default:"5s"`
+}
+
+// Validate checks whether PROXY protocol configuration values are supported.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	_, err := parseTrustedCIDRs(c.TrustedCIDRs)
+	return err
+}
+
+func parseTrustedCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	if len(cidrs) == 0 {
+		return nil, errors.New("proxyproto: trusted CIDRs must be specified")
+	}
+	var trusted []*net.IPNet
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("proxyproto: invalid trusted CIDR '%s': %v", cidr, err)
+		}
+		trusted = append(trusted, ipNet)
+	}
+	return trusted, nil
+}
@@ -0,0 +1,270 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	v1MaxHeaderLength = 107
+
+	v2HeaderLength = 16
+
+	v2CmdLocal = 0x0
+	v2CmdProxy = 0x1
+
+	v2FamilyTCPv4 = 0x11
+	v2FamilyTCPv6 = 0x21
+
+	readBufferSize = 256
+)
+
+var v2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+// ErrNoProxyHeader is returned when a trusted source does not send a PROXY protocol header.
+var ErrNoProxyHeader = errors.New("proxyproto: missing PROXY protocol header")
+
+// Conn represents a connection whose remote and local addresses are taken from
+// the PROXY protocol header sent by a trusted source.
+// Header is lazily read on first Read, RemoteAddr or LocalAddr call.
+type Conn struct {
+	net.Conn
+
+	trusted       bool
+	headerTimeout time.Duration
+
+	// read deadline requested by the connection user, restored once header has been read
+	deadlineMu   sync.Mutex
+	readDeadline time.Time
+
+	once    sync.Once
+	rd      *bufio.Reader
+	srcAddr net.Addr
+	dstAddr net.Addr
+	err     error
+}
+
+func newConn(conn net.Conn, trusted bool, headerTimeout time.Duration) *Conn {
+	return &Conn{
+		Conn:          conn,
+		trusted:       trusted,
+		headerTimeout: headerTimeout,
+	}
+}
+
+// Read reads data from the connection.
+func (c *Conn) Read(b []byte) (int, error) {
+	if err := c.readHeader(); err != nil {
+		return 0, err
+	}
+	if c.rd == nil {
+		return c.Conn.Read(b)
+	}
+	return c.rd.Read(b)
+}
+
+// RemoteAddr returns the original source address announced by the proxy.
+// In case no header was announced, the direct connection remote address is returned.
+func (c *Conn) RemoteAddr() net.Addr {
+	_ = c.readHeader()
+	if c.srcAddr != nil {
+		return c.srcAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// LocalAddr returns the original destination address announced by the proxy.
+// In case no header was announced, the direct connection local address is returned.
+func (c *Conn) LocalAddr() net.Addr {
+	_ = c.readHeader()
+	if c.dstAddr != nil {
+		return c.dstAddr
+	}
+	return c.Conn.LocalAddr()
+}
+
+// SetDeadline sets the read and write deadlines associated with the connection.
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	c.readDeadline = t
+	return c.Conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for future Read calls.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	c.readDeadline = t
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *Conn) readHeader() error {
+	c.once.Do(func() {
+		if !c.trusted {
+			return
+		}
+		c.rd = bufio.NewReaderSize(c.Conn, readBufferSize)
+
+		if c.headerTimeout > 0 {
+			c.setHeaderDeadline()
+			defer c.restoreReadDeadline()
+		}
+		c.err = c.parseHeader()
+	})
+	return c.err
+}
+
+func (c *Conn) setHeaderDeadline() {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+
+	deadline := time.Now().Add(c.headerTimeout)
+	if !c.readDeadline.IsZero() && c.readDeadline.Before(deadline) {
+		deadline = c.readDeadline
+	}
+	_ = c.Conn.SetReadDeadline(deadline)
+}
+
+func (c *Conn) restoreReadDeadline() {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	_ = c.Conn.SetReadDeadline(c.readDeadline)
+}
+
+func (c *Conn) parseHeader() error {
+	b, err := c.rd.Peek(1)
+	if err != nil {
+		return err
+	}
+	switch b[0] {
+	case 'P':
+		return c.parseV1Header()
+	case v2Signature[0]:
+		return c.parseV2Header()
+	default:
+		return ErrNoProxyHeader
+	}
+}
+
+func (c *Conn) parseV1Header() error {
+	line, err := c.rd.ReadSlice('\n')
+	if err != nil {
+		if err == bufio.ErrBufferFull {
+			return errors.New("proxyproto: v1 header too long")
+		}
+		return err
+	}
+	if len(line) > v1MaxHeaderLength || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return errors.New("proxyproto: malformed v1 header")
+	}
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) < 2 || fields[0] != "PROXY" {
+		return ErrNoProxyHeader
+	}
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil // keep direct connection addresses
+
+	case "TCP4", "TCP6":
+		if len(fields) != 6 {
+			return errors.New("proxyproto: malformed v1 header")
+		}
+		src, err := parseV1Addr(fields[1], fields[2], fields[4])
+		if err != nil {
+			return err
+		}
+		dst, err := parseV1Addr(fields[1], fields[3], fields[5])
+		if err != nil {
+			return err
+		}
+		c.srcAddr, c.dstAddr = src, dst
+		return nil
+
+	default:
+		return fmt.Errorf("proxyproto: unsupported v1 protocol: %s", fields[1])
+	}
+}
+
+func (c *Conn) parseV2Header() error {
+	hdr := make([]byte, v2HeaderLength)
+	if _, err := io.ReadFull(c.rd, hdr); err != nil {
+		return err
+	}
+	if !bytes.Equal(hdr[:len(v2Signature)], v2Signature) {
+		return ErrNoProxyHeader
+	}
+	if hdr[12]>>4 != 2 {
+		return fmt.Errorf("proxyproto: unsupported v2 version: %d", hdr[12]>>4)
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(hdr[14:16]))
+	if _, err := io.ReadFull(c.rd, payload); err != nil {
+		return err
+	}
+	switch hdr[12] & 0x0F {
+	case v2CmdLocal:
+		return nil // health checks and proxy originated connections
+
+	case v2CmdProxy:
+		break
+
+	default:
+		return fmt.Errorf("proxyproto: unsupported v2 command: %d", hdr[12]&0x0F)
+	}
+	var ipLen int
+	switch hdr[13] {
+	case v2FamilyTCPv4:
+		ipLen = net.IPv4len
+	case v2FamilyTCPv6:
+		ipLen = net.IPv6len
+	default:
+		return nil // unspecified, UDP or UNIX addresses: keep direct connection addresses
+	}
+	if len(payload) < 2*ipLen+4 {
+		return errors.New("proxyproto: malformed v2 address block")
+	}
+	c.srcAddr = &net.TCPAddr{
+		IP:   net.IP(payload[:ipLen]),
+		Port: int(binary.BigEndian.Uint16(payload[2*ipLen:])),
+	}
+	c.dstAddr = &net.TCPAddr{
+		IP:   net.IP(payload[ipLen : 2*ipLen]),
+		Port: int(binary.BigEndian.Uint16(payload[2*ipLen+2:])),
+	}
+	return nil
+}
+
+func parseV1Addr(proto, ipStr, portStr string) (*net.TCPAddr, error) {
+	ip := net.ParseIP(ipStr)
+	if ip == nil || (proto == "TCP4") != (ip.To4() != nil) {
+		return nil, fmt.Errorf("proxyproto: invalid v1 address: %s", ipStr)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("proxyproto: invalid v1 port: %s", portStr)
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyproto
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConn_Header(t *testing.T) {
+	var tcs = map[string]struct {
+		header         []byte
+		trusted        bool
+		expectedRemote string
+		expectedLocal  string
+		expectedErr    error
+	}{
+		"v1 TCP4": {
+			header:         []byte("PROXY TCP4 192.168.1.10 10.0.0.1 56324 5222\r\n"),
+			trusted:        true,
+			expectedRemote: "192.168.1.10:56324",
+			expectedLocal:  "10.0.0.1:5222",
+		},
+		"v1 TCP6": {
+			header:         []byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 5222\r\n"),
+			trusted:        true,
+			expectedRemote: "[2001:db8::1]:56324",
+			expectedLocal:  "[2001:db8::2]:5222",
+		},
+		"v1 UNKNOWN": {
+			header:         []byte("PROXY UNKNOWN\r\n"),
+			trusted:        true,
+			expectedRemote: "pipe",
+			expectedLocal:  "pipe",
+		},
+		"v2 TCP4": {
+			header:         testV2Header(v2CmdProxy, v2FamilyTCPv4, net.ParseIP("192.168.1.10").To4(), net.ParseIP("10.0.0.1").To4(), 56324, 5222),
+			trusted:        true,
+			expectedRemote: "192.168.1.10:56324",
+			expectedLocal:  "10.0.0.1:5222",
+		},
+		"v2 TCP6": {
+			header:         testV2Header(v2CmdProxy, v2FamilyTCPv6, net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2"), 56324, 5222),
+			trusted:        true,
+			expectedRemote: "[2001:db8::1]:56324",
+			expectedLocal:  "[2001:db8::2]:5222",
+		},
+		"v2 LOCAL": {
+			header:         testV2Header(v2CmdLocal, 0, nil, nil, 0, 0),
+			trusted:        true,
+			expectedRemote: "pipe",
+			expectedLocal:  "pipe",
+		},
+		"Untrusted": {
+			trusted:        false,
+			expectedRemote: "pipe",
+			expectedLocal:  "pipe",
+		},
+		"Missing header": {
+			trusted:     true,
+			expectedErr: ErrNoProxyHeader,
+		},
+	}
+	for tName, tc := range tcs {
+		t.Run(tName, func(t *testing.T) {
+			// given
+			srv, cl := net.Pipe()
+			defer func() { _ = cl.Close() }()
+
+			go func() {
+				_, _ = cl.Write(append(tc.header, []byte("<stream:stream>")...))
+			}()
+			conn := newConn(srv, tc.trusted, time.Second)
+
+			// when
+			b := make([]byte, 15)
+			_, err := io.ReadFull(conn, b)
+
+			// then
+			if tc.expectedErr != nil {
+				require.Equal(t, tc.expectedErr, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, "<stream:stream>", string(b))
+			require.Equal(t, tc.expectedRemote, conn.RemoteAddr().String())
+			require.Equal(t, tc.expectedLocal, conn.LocalAddr().String())
+		})
+	}
+}
+
+func TestConn_HeaderTimeout(t *testing.T) {
+	// given
+	srv, cl := net.Pipe()
+	defer func() { _ = cl.Close() }()
+
+	conn := newConn(srv, true, time.Millisecond*50)
+
+	// when
+	_, err := conn.Read(make([]byte, 1))
+
+	// then
+	require.NotNil(t, err)
+}
+
+func TestConn_RestoresReadDeadline(t *testing.T) {
+	// given
+	srv, cl := net.Pipe()
+	defer func() { _ = cl.Close() }()
+
+	conn := newConn(srv, true, time.Minute)
+	require.Nil(t, conn.SetReadDeadline(time.Now().Add(time.Millisecond*50)))
+
+	go func() {
+		_, _ = cl.Write([]byte("PROXY TCP4 192.168.1.10 10.0.0.1 56324 5222\r\n"))
+	}()
+	require.Equal(t, "192.168.1.10:56324", conn.RemoteAddr().String())
+
+	// when
+	_, err := conn.Read(make([]byte, 1))
+
+	// then
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	require.True(t, netErr.Timeout())
+}
+
+func testV2Header(cmd, family byte, src, dst net.IP, srcPort, dstPort uint16) []byte {
+	var payload []byte
+	payload = append(payload, src...)
+	payload = append(payload, dst...)
+	if len(src) > 0 {
+		payload = binary.BigEndian.AppendUint16(payload, srcPort)
+		payload = binary.BigEndian.AppendUint16(payload, dstPort)
+	}
+	hdr := append([]byte{}, v2Signature...)
+	hdr = append(hdr, 0x20|cmd, family)
+	hdr = binary.BigEndian.AppendUint16(hdr, uint16(len(payload)))
+	return append(hdr, payload...)
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyproto
+
+import (
+	"net"
+	"time"
+)
+
+type listener struct {
+	net.Listener
+	trusted       []*net.IPNet
+	headerTimeout time.Duration
+}
+
+// NewListener wraps ln to accept PROXY protocol headers sent by trusted sources.
+func NewListener(ln net.Listener, cfg Config) (net.Listener, error) {
+	trusted, err := parseTrustedCIDRs(cfg.TrustedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	return &listener{
+		Listener:      ln,
+		trusted:       trusted,
+		headerTimeout: cfg.HeaderTimeout,
+	}, nil
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newConn(conn, l.isTrusted(conn.RemoteAddr()), l.headerTimeout), nil
+}
+
+func (l *listener) isTrusted(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, ipNet := range l.trusted {
+		if ipNet.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyproto
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener_TrustedCIDRs(t *testing.T) {
+	// given
+	tcpLn, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	ln, err := NewListener(tcpLn, Config{TrustedCIDRs: []string{"127.0.0.0/8"}, HeaderTimeout: time.Second})
+	require.Nil(t, err)
+	defer func() { _ = ln.Close() }()
+
+	go func() {
+		cl, err := net.Dial("tcp", tcpLn.Addr().String())
+		if err != nil {
+			return
+		}
+		_, _ = cl.Write([]byte("PROXY TCP4 192.168.1.10 10.0.0.1 56324 5222\r\n"))
+	}()
+
+	// when
+	conn, err := ln.Accept()
+	require.Nil(t, err)
+	defer func() { _ = conn.Close() }()
+
+	// then
+	require.Equal(t, "192.168.1.10:56324", conn.RemoteAddr().String())
+}
+
+func TestListener_InvalidCIDR(t *testing.T) {
+	// when
+	_, err := NewListener(nil, Config{TrustedCIDRs: []string{"10.0.0.0/33"}})
+
+	// then
+	require.NotNil(t, err)
+}
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/ortuman/jackal/pkg/transport/compress"
+	"github.com/ortuman/jackal/pkg/transport/proxyproto"
 	"github.com/ortuman/jackal/pkg/util/ratelimiter"
 	"golang.org/x/time/rate"
 )
@@ -139,8 +140,10 @@ func (s *socketTransport) SetKeepAliveDeadlineHandler(hnd func()) {
 }
 
 func (s *socketTransport) StartTLS(cfg *tls.Config, asClient bool) {
-	_, ok := s.conn.underlyingConn().(*net.TCPConn)
-	if !ok {
+	switch s.conn.underlyingConn().(type) {
+	case *net.TCPConn, *proxyproto.Conn:
+		break
+	default:
 		return
 	}
 	var tlsConn *tls.Conn
@@ -196,6 +199,10 @@ func (s *socketTransport) ChannelBindingBytes(mechanism ChannelBindingMechanism)
 	return nil
 }
 
+func (s *socketTransport) RemoteAddr() net.Addr {
+	return s.conn.RemoteAddr()
+}
+
 func (s *socketTransport) PeerCertificates() []*x509.Certificate {
 	conn, ok := s.conn.underlyingConn().(tlsStateQueryable)
 	if !ok {
@@ -85,6 +85,8 @@ func TestSocket(t *testing.T) {
 	require.True(t, ok)
 	st.(*socketTransport).conn = newDeadlineConn(conn, time.Minute, time.Minute)
 
+	require.Equal(t, remoteAddr, st.RemoteAddr())
+
 	require.Nil(t, st2.ChannelBindingBytes(ChannelBindingMechanism(99)))
 	require.Nil(t, st2.ChannelBindingBytes(TLSUnique))
 	require.Nil(t, st2.ChannelBindingBytes(TLSExporter))
@@ -18,6 +18,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"io"
+	"net"
 	"time"
 
 	"github.com/ortuman/jackal/pkg/transport/compress"
@@ -91,6 +92,10 @@ type Transport interface {
 
 	// PeerCertificates returns the certificate chain presented by remote peer.
 	PeerCertificates() []*x509.Certificate
+
+	// RemoteAddr returns the remote peer network address.
+	// In case the connection was accepted through a trusted proxy, the original client address is returned.
+	RemoteAddr() net.Addr
 }
 
 type tlsStateQueryable interface {
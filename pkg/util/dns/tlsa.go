@@ -0,0 +1,319 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	typeTLSA = dnsmessage.Type(52)
+
+	maxUDPPayloadSize = 4096
+
+	resolvConfFile = "/etc/resolv.conf"
+)
+
+var errTruncatedResponse = errors.New("dns: truncated response")
+
+// TLSARecord represents a DNS TLSA resource record (RFC 6698).
+type TLSARecord struct {
+	// Usage represents the certificate usage field.
+	Usage uint8
+
+	// Selector specifies which part of the certificate is matched.
+	Selector uint8
+
+	// MatchingType specifies how certificate association data is presented.
+	MatchingType uint8
+
+	// Data contains the certificate association data.
+	Data []byte
+}
+
+// TLSAResolver resolves TLSA records, and the SRV records leading to the hosts they refer to,
+// relying on a DNSSEC validating recursive resolver.
+// Resolutions are reported as secure only if the resolver set the AD (authenticated data) bit.
+type TLSAResolver struct {
+	servers []string
+
+	exchangeFn func(ctx context.Context, network, server string, query []byte) ([]byte, error)
+}
+
+// NewTLSAResolver creates and initializes a new TLSAResolver instance.
+// If no servers are provided, system configured name servers will be used.
+func NewTLSAResolver(servers []string) *TLSAResolver {
+	if len(servers) == 0 {
+		servers = systemNameServers()
+	}
+	return &TLSAResolver{
+		servers:    servers,
+		exchangeFn: exchange,
+	}
+}
+
+// LookupTLSA returns the TLSA records associated to a host service port,
+// along with a flag indicating whether the resolution has been DNSSEC validated.
+func (r *TLSAResolver) LookupTLSA(ctx context.Context, port int, proto, host string) (records []TLSARecord, secure bool, err error) {
+	name := fmt.Sprintf("_%d._%s.%s", port, proto, strings.TrimSuffix(host, "."))
+
+	secure, err = r.resolve(ctx, name, typeTLSA, func(p *dnsmessage.Parser) error {
+		rr, err := p.UnknownResource()
+		if err != nil {
+			return err
+		}
+		if len(rr.Data) < 3 {
+			return errors.New("dns: bad TLSA record format")
+		}
+		records = append(records, TLSARecord{
+			Usage:        rr.Data[0],
+			Selector:     rr.Data[1],
+			MatchingType: rr.Data[2],
+			Data:         rr.Data[3:],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return records, secure, nil
+}
+
+// LookupSRV returns the SRV records associated to a domain service,
+// along with a flag indicating whether the resolution has been DNSSEC validated.
+func (r *TLSAResolver) LookupSRV(ctx context.Context, service, proto, name string) (addrs []*net.SRV, secure bool, err error) {
+	qName := fmt.Sprintf("_%s._%s.%s", service, proto, strings.TrimSuffix(name, "."))
+
+	secure, err = r.resolve(ctx, qName, dnsmessage.TypeSRV, func(p *dnsmessage.Parser) error {
+		rr, err := p.SRVResource()
+		if err != nil {
+			return err
+		}
+		addrs = append(addrs, &net.SRV{
+			Target:   rr.Target.String(),
+			Port:     rr.Port,
+			Priority: rr.Priority,
+			Weight:   rr.Weight,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return addrs, secure, nil
+}
+
+// resolve queries configured servers in order until one of them answers, calling answerFn
+// for every answer resource of type qType.
+func (r *TLSAResolver) resolve(ctx context.Context, name string, qType dnsmessage.Type, answerFn func(p *dnsmessage.Parser) error) (secure bool, err error) {
+	for _, server := range r.servers {
+		secure, err = r.lookup(ctx, server, name, qType, answerFn)
+		if err == nil {
+			return secure, nil
+		}
+	}
+	return false, err
+}
+
+func (r *TLSAResolver) lookup(ctx context.Context, server, name string, qType dnsmessage.Type, answerFn func(p *dnsmessage.Parser) error) (bool, error) {
+	id := uint16(rand.Intn(1 << 16))
+
+	q, err := buildQuery(id, name, qType)
+	if err != nil {
+		return false, err
+	}
+	resp, err := r.exchangeFn(ctx, "udp", server, q)
+	if err != nil {
+		return false, err
+	}
+	secure, err := parseResponse(id, resp, qType, answerFn)
+	if errors.Is(err, errTruncatedResponse) {
+		// retry over TCP
+		resp, err = r.exchangeFn(ctx, "tcp", server, q)
+		if err != nil {
+			return false, err
+		}
+		return parseResponse(id, resp, qType, answerFn)
+	}
+	return secure, err
+}
+
+func buildQuery(id uint16, name string, qType dnsmessage.Type) ([]byte, error) {
+	qName, err := dnsmessage.NewName(name + ".")
+	if err != nil {
+		return nil, err
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:               id,
+		RecursionDesired: true,
+		AuthenticData:    true,
+	})
+	b.EnableCompression()
+
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	err = b.Question(dnsmessage.Question{
+		Name:  qName,
+		Type:  qType,
+		Class: dnsmessage.ClassINET,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// request DNSSEC validation (DO bit)
+	if err := b.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	var optHdr dnsmessage.ResourceHeader
+	if err := optHdr.SetEDNS0(maxUDPPayloadSize, dnsmessage.RCodeSuccess, true); err != nil {
+		return nil, err
+	}
+	if err := b.OPTResource(optHdr, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+func parseResponse(id uint16, resp []byte, qType dnsmessage.Type, answerFn func(p *dnsmessage.Parser) error) (bool, error) {
+	var p dnsmessage.Parser
+
+	hdr, err := p.Start(resp)
+	if err != nil {
+		return false, err
+	}
+	if hdr.ID != id || !hdr.Response {
+		return false, errors.New("dns: unexpected response")
+	}
+	if hdr.Truncated {
+		return false, errTruncatedResponse
+	}
+	switch hdr.RCode {
+	case dnsmessage.RCodeSuccess:
+		break
+	case dnsmessage.RCodeNameError:
+		return hdr.AuthenticData, nil // no records
+	default:
+		return false, fmt.Errorf("dns: %s lookup failed: %s", qTypeName(qType), hdr.RCode)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return false, err
+	}
+	for {
+		h, err := p.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		if h.Type != qType {
+			if err := p.SkipAnswer(); err != nil {
+				return false, err
+			}
+			continue
+		}
+		if err := answerFn(&p); err != nil {
+			return false, err
+		}
+	}
+	return hdr.AuthenticData, nil
+}
+
+func qTypeName(qType dnsmessage.Type) string {
+	if qType == typeTLSA {
+		return "TLSA"
+	}
+	return strings.TrimPrefix(qType.String(), "Type")
+}
+
+func exchange(ctx context.Context, network, server string, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(resolveTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		b := make([]byte, maxUDPPayloadSize)
+		n, err := conn.Read(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+	// TCP messages are prefixed with a two byte length field
+	b := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(b, uint16(len(query)))
+	copy(b[2:], query)
+	if _, err := conn.Write(b); err != nil {
+		return nil, err
+	}
+	var l [2]byte
+	if _, err := io.ReadFull(conn, l[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(l[:]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func systemNameServers() []string {
+	var servers []string
+
+	f, err := os.Open(resolvConfFile)
+	if err == nil {
+		defer func() { _ = f.Close() }()
+
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			fields := strings.Fields(sc.Text())
+			if len(fields) < 2 || fields[0] != "nameserver" {
+				continue
+			}
+			servers = append(servers, net.JoinHostPort(fields[1], strconv.Itoa(53)))
+		}
+	}
+	if len(servers) == 0 {
+		servers = []string{"127.0.0.1:53"}
+	}
+	return servers
+}
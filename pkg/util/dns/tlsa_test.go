@@ -0,0 +1,238 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestTLSAResolver_LookupSecure(t *testing.T) {
+	// given
+	srv := newStubResolver(t, stubResponse{
+		records: []TLSARecord{{Usage: 3, Selector: 1, MatchingType: 1, Data: []byte{0xca, 0xfe}}},
+		secure:  true,
+	})
+	r := NewTLSAResolver([]string{srv.addr})
+
+	// when
+	records, secure, err := r.LookupTLSA(context.Background(), 5269, "tcp", "xmpp.jabber.org.")
+
+	// then
+	require.Nil(t, err)
+	require.True(t, secure)
+	require.Equal(t, []TLSARecord{{Usage: 3, Selector: 1, MatchingType: 1, Data: []byte{0xca, 0xfe}}}, records)
+	require.Equal(t, "_5269._tcp.xmpp.jabber.org.", srv.lastQuestion)
+}
+
+func TestTLSAResolver_LookupInsecure(t *testing.T) {
+	// given
+	srv := newStubResolver(t, stubResponse{
+		records: []TLSARecord{{Usage: 3, Selector: 1, MatchingType: 1, Data: []byte{0xca, 0xfe}}},
+	})
+	r := NewTLSAResolver([]string{srv.addr})
+
+	// when
+	records, secure, err := r.LookupTLSA(context.Background(), 5269, "tcp", "xmpp.jabber.org")
+
+	// then
+	require.Nil(t, err)
+	require.False(t, secure)
+	require.Len(t, records, 1)
+}
+
+func TestTLSAResolver_LookupNoRecords(t *testing.T) {
+	// given
+	srv := newStubResolver(t, stubResponse{rCode: dnsmessage.RCodeNameError, secure: true})
+	r := NewTLSAResolver([]string{srv.addr})
+
+	// when
+	records, secure, err := r.LookupTLSA(context.Background(), 5269, "tcp", "xmpp.jabber.org")
+
+	// then
+	require.Nil(t, err)
+	require.True(t, secure)
+	require.Len(t, records, 0)
+}
+
+func TestTLSAResolver_LookupServerFailure(t *testing.T) {
+	// given
+	srv := newStubResolver(t, stubResponse{rCode: dnsmessage.RCodeServerFailure})
+	r := NewTLSAResolver([]string{srv.addr})
+
+	// when
+	_, _, err := r.LookupTLSA(context.Background(), 5269, "tcp", "xmpp.jabber.org")
+
+	// then
+	require.NotNil(t, err)
+}
+
+func TestTLSAResolver_LookupTruncated(t *testing.T) {
+	// given
+	srv := newStubResolver(t, stubResponse{
+		records:   []TLSARecord{{Usage: 2, Selector: 0, MatchingType: 2, Data: []byte{0xbe, 0xef}}},
+		secure:    true,
+		truncated: true,
+	})
+	r := NewTLSAResolver([]string{srv.addr})
+
+	// when
+	records, secure, err := r.LookupTLSA(context.Background(), 5269, "tcp", "xmpp.jabber.org")
+
+	// then
+	require.Nil(t, err)
+	require.True(t, secure)
+	require.Len(t, records, 1)
+	require.Equal(t, 1, srv.tcpQueries)
+}
+
+func TestTLSAResolver_LookupSRV(t *testing.T) {
+	// given
+	srv := newStubResolver(t, stubResponse{
+		srvs:   []net.SRV{{Target: "xmpp.jabber.org.", Port: 5269, Priority: 10, Weight: 5}},
+		secure: true,
+	})
+	r := NewTLSAResolver([]string{srv.addr})
+
+	// when
+	addrs, secure, err := r.LookupSRV(context.Background(), "xmpp-server", "tcp", "jabber.org")
+
+	// then
+	require.Nil(t, err)
+	require.True(t, secure)
+	require.Equal(t, []*net.SRV{{Target: "xmpp.jabber.org.", Port: 5269, Priority: 10, Weight: 5}}, addrs)
+	require.Equal(t, "_xmpp-server._tcp.jabber.org.", srv.lastQuestion)
+}
+
+type stubResponse struct {
+	records   []TLSARecord
+	srvs      []net.SRV
+	secure    bool
+	truncated bool
+	rCode     dnsmessage.RCode
+}
+
+type stubResolver struct {
+	addr         string
+	resp         stubResponse
+	lastQuestion string
+	tcpQueries   int
+}
+
+// newStubResolver starts a local DNS resolver listening on both UDP and TCP that
+// answers every query with resp.
+func newStubResolver(t *testing.T, resp stubResponse) *stubResolver {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	require.Nil(t, err)
+
+	t.Cleanup(func() {
+		_ = pc.Close()
+		_ = ln.Close()
+	})
+	srv := &stubResolver{addr: pc.LocalAddr().String(), resp: resp}
+
+	go func() {
+		b := make([]byte, 4096)
+		for {
+			n, addr, err := pc.ReadFrom(b)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(srv.answer(t, b[:n], resp.truncated), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var l [2]byte
+			if _, err := io.ReadFull(conn, l[:]); err != nil {
+				return
+			}
+			q := make([]byte, binary.BigEndian.Uint16(l[:]))
+			if _, err := io.ReadFull(conn, q); err != nil {
+				return
+			}
+			srv.tcpQueries++
+
+			a := srv.answer(t, q, false)
+			out := make([]byte, 2+len(a))
+			binary.BigEndian.PutUint16(out, uint16(len(a)))
+			copy(out[2:], a)
+			_, _ = conn.Write(out)
+			_ = conn.Close()
+		}
+	}()
+	time.Sleep(time.Millisecond * 50) // wait until listening
+
+	return srv
+}
+
+func (s *stubResolver) answer(t *testing.T, query []byte, truncated bool) []byte {
+	var p dnsmessage.Parser
+	hdr, err := p.Start(query)
+	require.Nil(t, err)
+
+	q, err := p.Question()
+	require.Nil(t, err)
+	s.lastQuestion = q.Name.String()
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 hdr.ID,
+		Response:           true,
+		RecursionAvailable: true,
+		AuthenticData:      s.resp.secure,
+		Truncated:          truncated,
+		RCode:              s.resp.rCode,
+	})
+	require.Nil(t, b.StartQuestions())
+	require.Nil(t, b.Question(q))
+	require.Nil(t, b.StartAnswers())
+
+	if !truncated {
+		for _, r := range s.resp.records {
+			data := append([]byte{r.Usage, r.Selector, r.MatchingType}, r.Data...)
+			err := b.UnknownResource(
+				dnsmessage.ResourceHeader{Name: q.Name, Type: typeTLSA, Class: dnsmessage.ClassINET, TTL: 300},
+				dnsmessage.UnknownResource{Type: typeTLSA, Data: data},
+			)
+			require.Nil(t, err)
+		}
+		for _, addr := range s.resp.srvs {
+			err := b.SRVResource(
+				dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET, TTL: 300},
+				dnsmessage.SRVResource{Priority: addr.Priority, Weight: addr.Weight, Port: addr.Port, Target: dnsmessage.MustNewName(addr.Target)},
+			)
+			require.Nil(t, err)
+		}
+	}
+	msg, err := b.Finish()
+	require.Nil(t, err)
+	return msg
+}
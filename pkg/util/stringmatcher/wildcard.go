@@ -0,0 +1,44 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stringmatcher
+
+import (
+	"path"
+)
+
+// WildcardMatcher matches strings against a set of shell-like patterns (ie. '*.jabber.org').
+type WildcardMatcher struct {
+	patterns []string
+}
+
+// NewWildcardMatcher returns a new initialized WildcardMatcher.
+func NewWildcardMatcher(patterns []string) (*WildcardMatcher, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &WildcardMatcher{patterns: patterns}, nil
+}
+
+// Matches returns true if str matches any of the wm registered patterns.
+func (wm *WildcardMatcher) Matches(str string) bool {
+	for _, p := range wm.patterns {
+		if ok, _ := path.Match(p, str); ok {
+			return true
+		}
+	}
+	return false
+}
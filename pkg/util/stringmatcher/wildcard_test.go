@@ -0,0 +1,47 @@
+// Copyright 2022 The jackal Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stringmatcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher_Wildcard(t *testing.T) {
+	// given
+	m, err := NewWildcardMatcher([]string{"jackal.im", "*.jabber.org"})
+	require.Nil(t, err)
+
+	// when
+	r0 := m.Matches("jackal.im")
+	r1 := m.Matches("conference.jackal.im")
+	r2 := m.Matches("jabber.org")
+	r3 := m.Matches("muc.jabber.org")
+
+	// then
+	require.True(t, r0)
+	require.False(t, r1)
+	require.False(t, r2)
+	require.True(t, r3)
+}
+
+func TestMatcher_InvalidWildcard(t *testing.T) {
+	// when
+	_, err := NewWildcardMatcher([]string{"[jackal.im"})
+
+	// then
+	require.NotNil(t, err)
+}
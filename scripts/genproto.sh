@@ -13,14 +13,20 @@ go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.1
 
 FILES=(
   "admin/v1/users.proto"
+  "admin/v1/federation.proto"
+  "admin/v1/hosts.proto"
+  "admin/v1/config.proto"
+  "authenticator/v1/authenticator.proto"
   "c2s/v1/resourceinfo.proto"
   "cluster/v1/cluster.proto"
+  "host/v1/host.proto"
   "model/v1/archive.proto"
   "model/v1/user.proto"
   "model/v1/last.proto"
   "model/v1/blocklist.proto"
   "model/v1/caps.proto"
   "model/v1/roster.proto"
+  "model/v1/offline.proto"
 )
 
 for file in "${FILES[@]}"; do